@@ -0,0 +1,471 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/actions"
+	"github.com/SharanRP/gh-notif/internal/auth"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/spf13/cobra"
+)
+
+// actionFlags holds the flags shared by the action commands
+type actionFlags struct {
+	dryRun bool
+	format string
+}
+
+// register adds the shared action flags to a command
+func (f *actionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "show what would be done without changing anything")
+	cmd.Flags().StringVar(&f.format, "format", "text", "output format: text or json")
+}
+
+// validate checks the shared action flags
+func (f *actionFlags) validate() error {
+	if f.format != "text" && f.format != "json" {
+		return fmt.Errorf("unsupported format: %s (must be text or json)", f.format)
+	}
+	return nil
+}
+
+// batchRunner performs an action on a set of targets
+type batchRunner func(ctx context.Context, targets []string, opts *actions.BatchOptions) (*actions.BatchResult, error)
+
+// runBatchAction runs an action on all targets and prints the results
+func runBatchAction(cmd *cobra.Command, flags *actionFlags, template actions.Action, targets []string, repoTargets bool, run batchRunner) error {
+	if err := flags.validate(); err != nil {
+		return err
+	}
+
+	if flags.dryRun {
+		result := &actions.BatchResult{TotalCount: len(targets), SuccessCount: len(targets)}
+		for _, target := range targets {
+			action := template
+			action.Timestamp = time.Now()
+			action.Success = true
+			action.Metadata = map[string]interface{}{"dry_run": true}
+			for k, v := range template.Metadata {
+				action.Metadata[k] = v
+			}
+			if repoTargets {
+				action.RepositoryName = target
+			} else {
+				action.NotificationID = target
+			}
+			result.Results = append(result.Results, actions.ActionResult{Action: action, Success: true})
+		}
+		return printBatchResult(cmd.OutOrStdout(), flags.format, result)
+	}
+
+	result, err := run(commandContext(cmd), targets, actions.DefaultBatchOptions())
+	if err != nil {
+		return err
+	}
+
+	if err := printBatchResult(cmd.OutOrStdout(), flags.format, result); err != nil {
+		return err
+	}
+
+	return batchError(result)
+}
+
+// batchError converts failures in a batch result into an error with an exit code
+func batchError(result *actions.BatchResult) error {
+	if result.FailureCount == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("%d of %d operations failed", result.FailureCount, result.TotalCount)
+	if result.SuccessCount == 0 {
+		// Report authentication problems with the dedicated exit code
+		for _, e := range result.Errors {
+			if errors.Is(e, auth.ErrNotAuthenticated) {
+				return fmt.Errorf("%w: %v", auth.ErrNotAuthenticated, err)
+			}
+		}
+		return err
+	}
+
+	return withExitCode(exitPartial, err)
+}
+
+// jsonActionResult is the JSON representation of an action result
+type jsonActionResult struct {
+	ID             string `json:"id,omitempty"`
+	Type           string `json:"type"`
+	NotificationID string `json:"notification_id,omitempty"`
+	Repository     string `json:"repository,omitempty"`
+	Title          string `json:"title,omitempty"`
+	Success        bool   `json:"success"`
+	DryRun         bool   `json:"dry_run,omitempty"`
+	NoOp           bool   `json:"noop,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// printBatchResult prints the results of a batch action
+func printBatchResult(w io.Writer, format string, result *actions.BatchResult) error {
+	if format == "json" {
+		results := make([]jsonActionResult, 0, len(result.Results))
+		for _, r := range result.Results {
+			jr := jsonActionResult{
+				ID:             r.Action.ID,
+				Type:           string(r.Action.Type),
+				NotificationID: r.Action.NotificationID,
+				Repository:     r.Action.RepositoryName,
+				Title:          r.Action.Title,
+				Success:        r.Success,
+				DryRun:         r.Action.Metadata["dry_run"] == true,
+				NoOp:           r.Action.Metadata["noop"] == true,
+			}
+			if r.Error != nil {
+				jr.Error = r.Error.Error()
+			}
+			results = append(results, jr)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	for _, r := range result.Results {
+		target := r.Action.NotificationID
+		if target == "" {
+			target = r.Action.RepositoryName
+		}
+		if target == "" {
+			target = "all notifications"
+		}
+
+		if r.Action.Title != "" {
+			target = fmt.Sprintf("%s %q", target, r.Action.Title)
+		}
+
+		switch {
+		case r.Action.Metadata["dry_run"] == true:
+			fmt.Fprintf(w, "Would %s %s\n", describeAction(r.Action), target)
+		case r.Action.Metadata["noop"] == true:
+			fmt.Fprintf(w, "✓ %s %s: already in the previous state, nothing changed\n", describeAction(r.Action), target)
+		case r.Success:
+			fmt.Fprintf(w, "✓ %s %s\n", describeAction(r.Action), target)
+		default:
+			fmt.Fprintf(w, "✗ %s %s: %v\n", describeAction(r.Action), target, r.Error)
+		}
+	}
+
+	if result.TotalCount > 1 && result.Duration > 0 {
+		fmt.Fprintf(w, "%d succeeded, %d failed in %s\n",
+			result.SuccessCount, result.FailureCount, actions.FormatDuration(result.Duration))
+	}
+
+	return nil
+}
+
+// describeAction returns the verb used to describe an action
+func describeAction(action actions.Action) string {
+	if action.Metadata["undo"] == true {
+		undone := action
+		undone.Metadata = map[string]interface{}{"unmute": action.Metadata["unmute"]}
+		return "undo " + describeAction(undone)
+	}
+
+	switch action.Type {
+	case actions.ActionMarkAsRead, actions.ActionMarkAllAsRead:
+		return "mark as read"
+	case actions.ActionMarkAsUnread:
+		return "mark as unread"
+	case actions.ActionArchive:
+		return "archive"
+	case actions.ActionUnarchive:
+		return "unarchive"
+	case actions.ActionSubscribe:
+		return "subscribe to"
+	case actions.ActionUnsubscribe:
+		return "unsubscribe from"
+	case actions.ActionSnooze:
+		return "snooze"
+	case actions.ActionUnsnooze:
+		return "unsnooze"
+	case actions.ActionMute:
+		if action.Metadata["unmute"] == true {
+			return "unmute"
+		}
+		return "mute"
+	default:
+		return string(action.Type)
+	}
+}
+
+// singleResult wraps a single action result into a batch result
+func singleResult(result *actions.ActionResult, err error) (*actions.BatchResult, error) {
+	if result == nil {
+		return nil, err
+	}
+
+	batch := &actions.BatchResult{
+		TotalCount: 1,
+		Results:    []actions.ActionResult{*result},
+	}
+	if result.Success {
+		batch.SuccessCount = 1
+	} else {
+		batch.FailureCount = 1
+		batch.Errors = []error{result.Error}
+	}
+	return batch, nil
+}
+
+// commandContext returns the command context or a background context
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+var (
+	readFlags actionFlags
+	readAll   bool
+	readRepo  string
+)
+
+var readCmd = &cobra.Command{
+	Use:   "read [notification-id...]",
+	Short: "Mark notifications as read",
+	Long: `Mark one or more notifications as read.
+
+Use --all to mark every notification as read, or --repo to mark all
+notifications of a repository as read.`,
+	Example: `  gh-notif read 123456789
+  gh-notif read 123456789 987654321
+  gh-notif read --repo owner/repo
+  gh-notif read --all --dry-run`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case readAll:
+			if len(args) > 0 || readRepo != "" {
+				return withExitCode(exitUsage, fmt.Errorf("--all cannot be combined with notification IDs or --repo"))
+			}
+			return runBatchAction(cmd, &readFlags, actions.Action{Type: actions.ActionMarkAllAsRead}, []string{""}, false,
+				func(ctx context.Context, _ []string, _ *actions.BatchOptions) (*actions.BatchResult, error) {
+					return singleResult(actions.MarkAllAsRead(ctx))
+				})
+		case readRepo != "":
+			if len(args) > 0 {
+				return withExitCode(exitUsage, fmt.Errorf("--repo cannot be combined with notification IDs"))
+			}
+			owner, repo, ok := strings.Cut(readRepo, "/")
+			if !ok || owner == "" || repo == "" {
+				return withExitCode(exitUsage, fmt.Errorf("invalid repository name %q, expected owner/repo", readRepo))
+			}
+			return runBatchAction(cmd, &readFlags, actions.Action{Type: actions.ActionMarkAllAsRead}, []string{readRepo}, true,
+				func(ctx context.Context, _ []string, _ *actions.BatchOptions) (*actions.BatchResult, error) {
+					return singleResult(actions.MarkRepositoryNotificationsAsRead(ctx, owner, repo))
+				})
+		case len(args) == 0:
+			return withExitCode(exitUsage, fmt.Errorf("requires at least one notification ID, --repo or --all"))
+		}
+
+		return runBatchAction(cmd, &readFlags, actions.Action{Type: actions.ActionMarkAsRead}, args, false, actions.MarkMultipleAsRead)
+	},
+}
+
+var archiveFlags actionFlags
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <notification-id...>",
+	Short: "Archive notifications",
+	Long: `Archive one or more notifications by marking them as read and
+ignoring future updates to their threads.`,
+	Example: `  gh-notif archive 123456789
+  gh-notif archive 123456789 987654321 --format json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatchAction(cmd, &archiveFlags, actions.Action{Type: actions.ActionArchive}, args, false, actions.ArchiveMultipleNotifications)
+	},
+}
+
+var muteFlags actionFlags
+
+var muteCmd = &cobra.Command{
+	Use:   "mute <owner/repo...>",
+	Short: "Mute repositories",
+	Long: `Mute one or more repositories. All their notifications are marked as
+read and the repository subscription is set to ignore.`,
+	Example: `  gh-notif mute owner/noisy-repo
+  gh-notif mute owner/repo1 owner/repo2 --dry-run`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatchAction(cmd, &muteFlags, actions.Action{Type: actions.ActionMute}, args, true, actions.MuteMultipleRepositories)
+	},
+}
+
+var unmuteFlags actionFlags
+
+var unmuteCmd = &cobra.Command{
+	Use:     "unmute <owner/repo...>",
+	Short:   "Unmute repositories",
+	Long:    `Unmute one or more repositories by removing their ignore subscription.`,
+	Example: `  gh-notif unmute owner/repo`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatchAction(cmd, &unmuteFlags, actions.Action{
+			Type:     actions.ActionMute,
+			Metadata: map[string]interface{}{"unmute": true},
+		}, args, true,
+			func(ctx context.Context, repos []string, opts *actions.BatchOptions) (*actions.BatchResult, error) {
+				processor := actions.NewBatchProcessor(ctx, opts)
+				for _, repo := range repos {
+					processor.AddTask(func() (actions.Action, error) {
+						result, err := actions.UnmuteRepository(ctx, repo)
+						if err != nil {
+							return actions.Action{
+								Type:           actions.ActionMute,
+								RepositoryName: repo,
+								Timestamp:      time.Now(),
+								Error:          err,
+								Metadata:       map[string]interface{}{"unmute": true},
+							}, err
+						}
+						return result.Action, nil
+					})
+				}
+				return processor.Process(), nil
+			})
+	},
+}
+
+var subscribeFlags actionFlags
+
+var subscribeCmd = &cobra.Command{
+	Use:   "subscribe <notification-id...>",
+	Short: "Subscribe to notification threads",
+	Long:  `Subscribe to the threads of one or more notifications to receive future updates.`,
+	Example: `  gh-notif subscribe 123456789
+  gh-notif subscribe 123456789 --dry-run`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatchAction(cmd, &subscribeFlags, actions.Action{Type: actions.ActionSubscribe}, args, false, actions.SubscribeToMultipleThreads)
+	},
+}
+
+var unsubscribeFlags actionFlags
+
+var unsubscribeCmd = &cobra.Command{
+	Use:     "unsubscribe <notification-id...>",
+	Short:   "Unsubscribe from notification threads",
+	Long:    `Unsubscribe from the threads of one or more notifications.`,
+	Example: `  gh-notif unsubscribe 123456789`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatchAction(cmd, &unsubscribeFlags, actions.Action{Type: actions.ActionUnsubscribe}, args, false, actions.UnsubscribeFromMultipleThreads)
+	},
+}
+
+var (
+	undoFlags actionFlags
+	undoCount int
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last actions",
+	Long: `Undo the most recent actions performed on notifications that were not
+undone yet. Actions from earlier sessions can be undone too; use
+"gh-notif history" to list them and undo a specific one.`,
+	Example: `  gh-notif undo
+  gh-notif undo --count 3`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := undoFlags.validate(); err != nil {
+			return err
+		}
+		if undoCount <= 0 {
+			return withExitCode(exitUsage, fmt.Errorf("--count must be positive"))
+		}
+
+		history := actions.GetActionHistory().GetUndoable(undoCount)
+		if len(history) == 0 {
+			return fmt.Errorf("no actions to undo")
+		}
+
+		if undoFlags.dryRun {
+			return printBatchResult(cmd.OutOrStdout(), undoFlags.format, dryRunUndoResult(history))
+		}
+
+		result, err := actions.UndoLastNActions(commandContext(cmd), undoCount, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := printBatchResult(cmd.OutOrStdout(), undoFlags.format, result); err != nil {
+			return err
+		}
+		return batchError(result)
+	},
+}
+
+var openDryRun bool
+
+var openCmd = &cobra.Command{
+	Use:   "open <notification-id>",
+	Short: "Open a notification in the browser",
+	Long:  `Open the issue, pull request or other subject of a notification in the browser.`,
+	Example: `  gh-notif open 123456789
+  gh-notif open 123456789 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := commandContext(cmd)
+		client, err := githubclient.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+
+		notification, _, err := client.GetThread(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get notification: %w", err)
+		}
+
+		apiURL := notification.GetSubject().GetURL()
+		if apiURL == "" {
+			apiURL = notification.GetRepository().GetURL()
+		}
+		webURL, err := githubclient.ConvertAPIURLToWebURL(apiURL)
+		if err != nil {
+			return fmt.Errorf("failed to build URL: %w", err)
+		}
+
+		if openDryRun {
+			fmt.Fprintln(cmd.OutOrStdout(), webURL)
+			return nil
+		}
+
+		if err := auth.OpenBrowser(webURL); err != nil {
+			return fmt.Errorf("failed to open browser: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	readCmd.Flags().BoolVarP(&readAll, "all", "a", false, "mark all notifications as read")
+	readCmd.Flags().StringVarP(&readRepo, "repo", "r", "", "mark all notifications of a repository as read")
+	readFlags.register(readCmd)
+	archiveFlags.register(archiveCmd)
+	muteFlags.register(muteCmd)
+	unmuteFlags.register(unmuteCmd)
+	subscribeFlags.register(subscribeCmd)
+	unsubscribeFlags.register(unsubscribeCmd)
+	undoCmd.Flags().IntVarP(&undoCount, "count", "n", 1, "number of actions to undo")
+	undoFlags.register(undoCmd)
+	openCmd.Flags().BoolVar(&openDryRun, "dry-run", false, "print the URL instead of opening it")
+
+	rootCmd.AddCommand(readCmd, archiveCmd, muteCmd, unmuteCmd, subscribeCmd, unsubscribeCmd, undoCmd, openCmd)
+}
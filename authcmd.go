@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/auth"
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authToken        string
+	authStatusFormat string
+)
+
+// selectedHost returns the name of the host the auth commands act on
+func selectedHost() string {
+	if configManager == nil || configManager.GetConfig() == nil {
+		return config.DefaultHost
+	}
+	return configManager.GetConfig().ResolveHost(config.Host()).Host
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage GitHub authentication",
+	Long: `Log in to GitHub, check the authentication status or log out.
+
+By default login uses the OAuth device flow. A personal access token can be
+supplied with --token or the GH_NOTIF_TOKEN environment variable instead.
+
+Each host has its own token. Use --host to log in to a GitHub Enterprise
+Server host, whose OAuth client ID is set in the hosts section of the config.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Authenticate with GitHub",
+	Example: `  gh-notif auth login
+  gh-notif auth login --token ghp_xxx
+  gh-notif --host ghe.corp.example auth login`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token := authToken
+		if token == "" {
+			token = os.Getenv("GH_NOTIF_TOKEN")
+		}
+
+		if token != "" {
+			if err := auth.LoginWithToken(token); err != nil {
+				return fmt.Errorf("failed to log in: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %s with personal access token.\n", selectedHost())
+			return nil
+		}
+
+		if err := auth.Login(commandContext(cmd)); err != nil {
+			return fmt.Errorf("failed to log in: %w", err)
+		}
+		return nil
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove stored GitHub credentials",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := auth.Logout(); err != nil {
+			return fmt.Errorf("failed to log out: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Logged out of %s.\n", selectedHost())
+		return nil
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the authentication status",
+	Long: `Show whether gh-notif is authenticated with GitHub.
+
+The command exits with status 3 when not authenticated.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if authStatusFormat != "text" && authStatusFormat != "json" {
+			return fmt.Errorf("unsupported format: %s (must be text or json)", authStatusFormat)
+		}
+
+		authenticated, token, err := auth.Status()
+		if err != nil {
+			return fmt.Errorf("failed to check authentication status: %w", err)
+		}
+
+		var expiry time.Time
+		if token != nil {
+			expiry = token.Expiry
+		}
+
+		w := cmd.OutOrStdout()
+		if authStatusFormat == "json" {
+			status := struct {
+				Host          string     `json:"host"`
+				Authenticated bool       `json:"authenticated"`
+				Expiry        *time.Time `json:"expiry,omitempty"`
+			}{Host: selectedHost(), Authenticated: authenticated}
+			if !expiry.IsZero() {
+				status.Expiry = &expiry
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(status); err != nil {
+				return err
+			}
+		} else if authenticated {
+			fmt.Fprintf(w, "Authenticated with %s.\n", selectedHost())
+			if !expiry.IsZero() {
+				fmt.Fprintf(w, "Token expires: %s\n", expiry.Local().Format(time.RFC1123))
+			}
+		} else if token != nil {
+			fmt.Fprintln(w, "Token expired.")
+		}
+
+		if !authenticated {
+			return auth.ErrNotAuthenticated
+		}
+		return nil
+	},
+}
+
+var authRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refresh the stored OAuth token",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := auth.RefreshToken(commandContext(cmd)); err != nil {
+			return fmt.Errorf("failed to refresh token: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "Token refreshed.")
+		return nil
+	},
+}
+
+func init() {
+	authLoginCmd.Flags().StringVar(&authToken, "token", "", "log in with a personal access token")
+	authStatusCmd.Flags().StringVar(&authStatusFormat, "format", "text", "output format: text or json")
+
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authRefreshCmd)
+
+	rootCmd.AddCommand(authCmd)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configExportFormat string
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage gh-notif configuration",
+	Long: `View and change gh-notif configuration.
+
+Keys use the section.key form, for example display.theme or
+notifications.default_filter.`,
+	Example: `  gh-notif config list
+  gh-notif config get display.theme
+  gh-notif config set display.theme light
+  gh-notif config export settings.yaml`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all configuration values",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := loadConfigManager()
+		if err != nil {
+			return err
+		}
+
+		out, err := cm.ListConfig()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "# %s\n%s", cm.GetConfigFile(), out)
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Get a configuration value",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := loadConfigManager()
+		if err != nil {
+			return err
+		}
+
+		value, err := cm.GetValue(args[0])
+		if err != nil {
+			return err
+		}
+
+		switch v := value.(type) {
+		case string:
+			fmt.Fprintln(cmd.OutOrStdout(), v)
+		case []interface{}, []string, map[string]interface{}:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("failed to format value: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		default:
+			fmt.Fprintf(cmd.OutOrStdout(), "%v\n", v)
+		}
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a configuration value",
+	Long: `Set a configuration value and save it to the configuration file.
+
+The value is converted to the type of the current value, so integers,
+booleans and comma-separated lists are accepted where expected.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := loadConfigManager()
+		if err != nil {
+			return err
+		}
+
+		key := args[0]
+		current, _ := cm.GetValue(key)
+		value, err := parseConfigValue(current, args[1])
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+
+		if err := cm.SetValue(key, value); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Set %s to %v\n", key, value)
+		return nil
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the configuration file in an editor",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := loadConfigManager()
+		if err != nil {
+			return err
+		}
+
+		return cm.EditConfig()
+	},
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Export the configuration to a file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := loadConfigManager()
+		if err != nil {
+			return err
+		}
+
+		format := configExportFormat
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+			if format == "yml" {
+				format = "yaml"
+			}
+		}
+
+		if err := cm.ExportConfig(format, args[0]); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Exported configuration to %s\n", args[0])
+		return nil
+	},
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import configuration from a file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := loadConfigManager()
+		if err != nil {
+			return err
+		}
+
+		if err := cm.ImportConfig(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported configuration from %s\n", args[0])
+		return nil
+	},
+}
+
+// loadConfigManager loads the configuration for the config subcommands
+func loadConfigManager() (*config.ConfigManager, error) {
+	cm := config.NewConfigManager()
+	if err := cm.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	configManager = cm
+	return cm, nil
+}
+
+// parseConfigValue converts a command line value to the type of the current value
+func parseConfigValue(current interface{}, raw string) (interface{}, error) {
+	switch current.(type) {
+	case int, int64:
+		return strconv.Atoi(raw)
+	case float64:
+		return strconv.ParseFloat(raw, 64)
+	case bool:
+		return strconv.ParseBool(raw)
+	case []interface{}, []string:
+		if raw == "" {
+			return []string{}, nil
+		}
+		parts := strings.Split(raw, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts, nil
+	default:
+		return raw, nil
+	}
+}
+
+func init() {
+	configExportCmd.Flags().StringVar(&configExportFormat, "format", "", "export format: yaml or json (default from file extension)")
+
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configExportCmd)
+	configCmd.AddCommand(configImportCmd)
+
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/digest"
+	"github.com/SharanRP/gh-notif/internal/grouping"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/SharanRP/gh-notif/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+// digestConfig returns the digest settings of the active profile
+func digestConfig() config.DigestConfig {
+	if configManager == nil || configManager.GetConfig() == nil {
+		return config.DefaultConfig().Digest
+	}
+	return configManager.GetConfig().Digest
+}
+
+// hasDigestSubscriptions reports whether any active subscription is
+// delivered as a digest
+func hasDigestSubscriptions(evaluator *subscriptions.Evaluator) bool {
+	if evaluator == nil {
+		return false
+	}
+	for _, f := range evaluator.Filters() {
+		if f.Subscription.Config.Frequency != subscriptions.FrequencyRealTime {
+			return true
+		}
+	}
+	return false
+}
+
+// newDigestScheduler creates the scheduler of the digests of hourly and
+// daily subscriptions, delivering them as configured in the digest section.
+// stdout delivers the digests of the stdout delivery.
+func newDigestScheduler(evaluator *subscriptions.Evaluator, stdout digest.Deliverer) (*digest.Scheduler, error) {
+	cfg := digestConfig()
+
+	hour, minute, err := cfg.DailyTime()
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := newDigestDelivery(cfg, stdout)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := digest.Open(filepath.Join(cacheDirectory(configManager.GetConfig()), digest.FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := digest.Schedule{Hour: hour, Minute: minute}
+	renderer := digest.NewRenderer(grouping.GroupType(cfg.GroupBy))
+	return digest.NewScheduler(queue, evaluator, schedule, renderer, delivery), nil
+}
+
+// newDigestDelivery creates the delivery selected by the digest settings
+func newDigestDelivery(cfg config.DigestConfig, stdout digest.Deliverer) (digest.Deliverer, error) {
+	switch cfg.Delivery {
+	case "", "stdout":
+		return stdout, nil
+	case "file":
+		return digest.NewFileDelivery(cfg.DigestFile()), nil
+	case "desktop":
+		return digest.DeliverFunc(deliverDigestToDesktop), nil
+	case "email":
+		return &digest.EmailDelivery{
+			Address:  cfg.SMTP.Address(),
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Secret(),
+			From:     cfg.SMTP.From,
+			To:       cfg.SMTP.To,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown digest delivery: %s", cfg.Delivery)
+	}
+}
+
+// deliverDigestToDesktop shows a digest as a desktop notification listing
+// its groups
+func deliverDigestToDesktop(ctx context.Context, d *digest.Digest) error {
+	notifier, err := watch.NewNotifier(watch.DefaultWatchOptions())
+	if err != nil {
+		return err
+	}
+	defer notifier.Close()
+
+	var lines []string
+	for _, g := range d.Groups {
+		lines = append(lines, fmt.Sprintf("%s: %d", g.Name, g.Count))
+	}
+	notification := watch.DesktopNotification{
+		Title:   d.Subject,
+		Body:    strings.Join(lines, "\n"),
+		Urgency: watch.UrgencyNormal,
+		Count:   d.Count,
+	}
+	if d.Count == 1 {
+		notification = watch.NewDesktopNotification(d.Notifications[0], watch.EventNew, nil)
+	}
+	return notifier.Notify(ctx, notification)
+}
+
+// openDigestScheduler creates the digest scheduler of the active
+// subscriptions, failing when no subscription is hourly or daily
+func openDigestScheduler(w io.Writer) (*digest.Scheduler, error) {
+	evaluator, err := newSubscriptionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	if !hasDigestSubscriptions(evaluator) {
+		return nil, fmt.Errorf("no hourly or daily subscriptions; add one with: gh-notif subscriptions add <owner/repo> --frequency daily")
+	}
+	return newDigestScheduler(evaluator, digest.NewWriterDelivery(w))
+}
+
+// jsonDigestBatch is the JSON representation of a pending digest
+type jsonDigestBatch struct {
+	Subscription  string     `json:"subscription"`
+	Frequency     string     `json:"frequency"`
+	Queued        int        `json:"queued"`
+	Next          *time.Time `json:"next,omitempty"`
+	LastDelivered *time.Time `json:"last_delivered,omitempty"`
+}
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Show and deliver the digests of hourly and daily subscriptions",
+	Long: `Subscriptions with an hourly or daily frequency do not report their
+notifications as they arrive. watch queues them instead and delivers a
+grouped summary at the top of every hour, or every day at digest.daily_at.
+A subscription's template, if set, renders its summary; templates are
+executed with the digest and can use the webURL and details functions.
+
+Digests are delivered as configured in the digest section of the
+configuration: to stdout, appended to digest.file, as a desktop
+notification, or by email through digest.smtp. The SMTP password can be
+set with the GH_NOTIF_DIGEST_SMTP_PASSWORD environment variable.
+
+Without watch running, "digest send" fetches notifications, queues them and
+delivers the digests that are due, which suits a cron job.`,
+}
+
+var digestShowFormat string
+
+var digestShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the pending digests and when they are due",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(digestShowFormat); err != nil {
+			return err
+		}
+
+		scheduler, err := openDigestScheduler(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		batches := scheduler.Queue.Batches()
+
+		w := cmd.OutOrStdout()
+		if digestShowFormat == "json" {
+			out := make([]jsonDigestBatch, 0, len(batches))
+			for _, b := range batches {
+				jb := jsonDigestBatch{Subscription: b.Subscription, Frequency: b.Frequency.String(), Queued: len(b.Entries)}
+				if len(b.Entries) > 0 {
+					next := scheduler.Next(b)
+					jb.Next = &next
+				}
+				if !b.LastDelivered.IsZero() {
+					last := b.LastDelivered
+					jb.LastDelivered = &last
+				}
+				out = append(out, jb)
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(out)
+		}
+
+		if len(batches) == 0 {
+			fmt.Fprintln(w, "No pending digests")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SUBSCRIPTION\tFREQUENCY\tQUEUED\tNEXT\tLAST DELIVERED")
+		for _, b := range batches {
+			next, last := "-", "never"
+			if len(b.Entries) > 0 {
+				next = scheduler.Next(b).Local().Format("2006-01-02 15:04")
+			}
+			if !b.LastDelivered.IsZero() {
+				last = b.LastDelivered.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", b.Subscription, b.Frequency, len(b.Entries), next, last)
+		}
+		return tw.Flush()
+	},
+}
+
+var digestSendNow bool
+
+var digestSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Queue new notifications and deliver the digests that are due",
+	Args:  cobra.NoArgs,
+	Example: `  gh-notif digest send
+  gh-notif digest send --now`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := commandContext(cmd)
+		scheduler, err := openDigestScheduler(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+
+		notifications, err := fetchNotifications(ctx, &listOptions{})
+		if err != nil {
+			return err
+		}
+		if _, err := scheduler.Enqueue(notifications); err != nil {
+			return err
+		}
+
+		delivered, err := scheduler.Deliver(ctx, digestSendNow)
+		if len(delivered) > 0 {
+			fmt.Fprintf(cmd.ErrOrStderr(), "✓ Delivered %d digests\n", len(delivered))
+		} else if err == nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "No digests are due")
+		}
+		if err != nil && len(delivered) > 0 {
+			return withExitCode(exitPartial, err)
+		}
+		return err
+	},
+}
+
+var digestPreviewCmd = &cobra.Command{
+	Use:   "preview [owner/repo|owner/*]",
+	Short: "Print the pending digests without delivering them",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scheduler, err := openDigestScheduler(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		preview := digest.NewWriterDelivery(w)
+		printed := 0
+		for _, b := range scheduler.Queue.Batches() {
+			if len(b.Entries) == 0 || (len(args) > 0 && b.Subscription != args[0]) {
+				continue
+			}
+
+			var text string
+			if f := scheduler.Subscriptions.Find(b.Subscription); f != nil {
+				text = f.Subscription.Config.Template
+			}
+			d, err := scheduler.Renderer.Render(commandContext(cmd), b, text, time.Now())
+			if err != nil {
+				return err
+			}
+			if printed > 0 {
+				fmt.Fprintln(w)
+			}
+			if err := preview.Deliver(commandContext(cmd), d); err != nil {
+				return err
+			}
+			printed++
+		}
+
+		if printed == 0 {
+			fmt.Fprintln(w, "No pending digests")
+		}
+		return nil
+	},
+}
+
+var digestClearCmd = &cobra.Command{
+	Use:   "clear [owner/repo|owner/*]",
+	Short: "Drop the queued notifications of one or every digest",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		queue, err := digest.Open(filepath.Join(cacheDirectory(configManager.GetConfig()), digest.FileName))
+		if err != nil {
+			return err
+		}
+
+		var subscription string
+		if len(args) > 0 {
+			subscription = args[0]
+		}
+		count, err := queue.Clear(subscription)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Dropped %d queued notifications\n", count)
+		return nil
+	},
+}
+
+func init() {
+	digestShowCmd.Flags().StringVar(&digestShowFormat, "format", "text", "output format: text or json")
+	digestSendCmd.Flags().BoolVar(&digestSendNow, "now", false, "deliver every pending digest, even if it is not due yet")
+
+	digestCmd.AddCommand(digestShowCmd, digestSendCmd, digestPreviewCmd, digestClearCmd)
+	rootCmd.AddCommand(digestCmd)
+}
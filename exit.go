@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SharanRP/gh-notif/internal/auth"
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/filter/persistent"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes returned by gh-notif
+const (
+	// exitOK means the command succeeded
+	exitOK = 0
+	// exitError means the command failed
+	exitError = 1
+	// exitUsage means the command line could not be parsed
+	exitUsage = 2
+	// exitAuth means the user is not authenticated
+	exitAuth = 3
+	// exitPartial means some items of a batch operation failed
+	exitPartial = 4
+)
+
+// exitCodeError attaches an exit code to an error
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode wraps an error so that the process exits with the given code
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitCodeError{code: code, err: err}
+}
+
+// exitCode determines the exit code for an error returned by a command
+func exitCode(cmd *cobra.Command, err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var codeErr *exitCodeError
+	if errors.As(err, &codeErr) {
+		return codeErr.code
+	}
+
+	if errors.Is(err, auth.ErrNotAuthenticated) {
+		return exitAuth
+	}
+
+	// Usage is only silenced once flags and arguments were accepted
+	if cmd != nil && !cmd.SilenceUsage {
+		return exitUsage
+	}
+
+	return exitError
+}
+
+// printError prints an error with a hint for common failures
+func printError(w io.Writer, err error) {
+	fmt.Fprintf(w, "Error: %v\n", err)
+
+	// Point at the error in a filter expression
+	var syntaxErr *persistent.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		for _, line := range strings.Split(syntaxErr.Pointer(), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	if errors.Is(err, auth.ErrNotAuthenticated) {
+		if host := config.Host(); host != "" {
+			fmt.Fprintf(w, "Run 'gh-notif --host %s auth login' to authenticate.\n", host)
+		} else {
+			fmt.Fprintln(w, "Run 'gh-notif auth login' to authenticate.")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/spf13/cobra"
+)
+
+var filterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Work with filter expressions",
+	Long: `Work with the filter expressions taken by --filter.
+
+Expressions follow the GitHub search syntax. Terms next to each other must
+all match, OR matches either side, and NOT or a leading - negates a term or
+parentheses:
+
+  repo:owner/* type:PullRequest -reason:subscribed
+  (type:Issue OR type:PullRequest) AND is:unread
+
+Qualifiers:
+  is:read, is:unread, is:open, is:closed, is:merged, is:draft
+  repo:owner/name, org:owner       glob patterns such as owner/*
+  type:PullRequest, reason:mention
+  updated:2026-01-01..2026-02-01   also >2026-01-01, <=2026-01-31 and >24h
+  since:7d, since:2026-01-01       updated at or after a time
+  score:>70, score:50..75
+  title:regexp
+  in:title,repository,type,reason  where to search for the text
+  state:, author:, assignee:, reviewer:, label:, review:, ci:
+  author:@me                       @me is the authenticated user
+  @preset                          a saved filter or shortcut
+
+Values and text with spaces can be quoted, as in "login bug".`,
+}
+
+var filterExplainCmd = &cobra.Command{
+	Use:   "explain <expression>",
+	Short: "Show how a filter expression is understood",
+	Long: `Parse a filter expression and show the resulting filter as a tree, without
+fetching notifications. Errors point to the column of the expression where
+they are.`,
+	Example: `  gh-notif filter explain "repo:owner/* -type:Release"
+  gh-notif filter explain "@high OR review:changes_requested"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		parser, err := newFilterParser()
+		if err != nil {
+			return err
+		}
+
+		f, err := parser.Parse(args[0])
+		if err != nil {
+			return withExitCode(exitUsage, fmt.Errorf("invalid filter %q: %w", args[0], err))
+		}
+
+		fmt.Fprint(cmd.OutOrStdout(), filter.DescribeTree(f))
+		return nil
+	},
+}
+
+func init() {
+	filterCmd.AddCommand(filterExplainCmd)
+	rootCmd.AddCommand(filterCmd)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var groupOpts = &listOptions{sort: "updated:desc"}
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Group GitHub notifications",
+	Long: `Group GitHub notifications by repository, owner, type, reason, thread,
+time, score, or related content (smart grouping).
+
+Groups are ordered by size. Groups beyond --limit are merged into an
+"Other" group.`,
+	Example: `  gh-notif group --by repository
+  gh-notif group --by repository --secondary-by type
+  gh-notif group --filter "is:unread" --by smart
+  gh-notif group --by reason --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		groupOpts.scopeSet = scopeFlagsChanged(cmd)
+		return runList(cmd.Context(), groupOpts, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	flags := groupCmd.Flags()
+	flags.StringVarP(&groupOpts.groupBy, "by", "b", "repository", "group by repository, owner, type, reason, thread, time, score or smart")
+	flags.StringVar(&groupOpts.secondaryBy, "secondary-by", "", "secondary grouping within each group")
+	flags.BoolVarP(&groupOpts.all, "all", "a", false, "include read notifications")
+	flags.StringVarP(&groupOpts.repo, "repo", "r", "", "only group notifications for a repository (owner/repo)")
+	flags.StringVarP(&groupOpts.org, "org", "o", "", "only group notifications for an organization")
+	flags.StringVarP(&groupOpts.filter, "filter", "f", "", "filter expression or @preset")
+	flags.IntVarP(&groupOpts.maxGroups, "limit", "l", 10, "maximum number of groups to show")
+	flags.StringVar(&groupOpts.format, "format", "text", "output format: text, json, csv or template")
+	flags.StringVar(&groupOpts.template, "template", "", "Go template used with --format template")
+	flags.StringVar(&groupOpts.outputFile, "output", "", "write output to a file instead of stdout")
+	flags.BoolVar(&groupOpts.noColor, "no-color", false, "disable color output")
+
+	rootCmd.AddCommand(groupCmd)
+}
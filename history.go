@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/actions"
+	"github.com/spf13/cobra"
+)
+
+// jsonHistoryEntry is the JSON representation of a history entry
+type jsonHistoryEntry struct {
+	ID             string                 `json:"id"`
+	Type           string                 `json:"type"`
+	NotificationID string                 `json:"notification_id,omitempty"`
+	Repository     string                 `json:"repository,omitempty"`
+	Title          string                 `json:"title,omitempty"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Undone         bool                   `json:"undone"`
+	UndoneAt       *time.Time             `json:"undone_at,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// newJSONHistoryEntry converts a history entry for JSON output
+func newJSONHistoryEntry(entry actions.HistoryEntry) jsonHistoryEntry {
+	return jsonHistoryEntry{
+		ID:             entry.ID,
+		Type:           string(entry.Type),
+		NotificationID: entry.NotificationID,
+		Repository:     entry.RepositoryName,
+		Title:          entry.Title,
+		Timestamp:      entry.Timestamp,
+		Undone:         entry.UndoneAt != nil,
+		UndoneAt:       entry.UndoneAt,
+		Metadata:       entry.Metadata,
+	}
+}
+
+// printHistoryEntries prints history entries as a table or JSON
+func printHistoryEntries(w io.Writer, format string, entries []actions.HistoryEntry) error {
+	if format == "json" {
+		out := make([]jsonHistoryEntry, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, newJSONHistoryEntry(entry))
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No actions in history")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tWHEN\tACTION\tTARGET\tTITLE\tSTATUS")
+	for _, entry := range entries {
+		status := ""
+		if entry.UndoneAt != nil {
+			status = "undone"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.ID,
+			entry.Timestamp.Local().Format("2006-01-02 15:04"),
+			describeAction(entry.Action),
+			historyTarget(entry.Action),
+			entry.Title,
+			status)
+	}
+	return tw.Flush()
+}
+
+// printHistoryEntry prints the details of a history entry
+func printHistoryEntry(w io.Writer, format string, entry actions.HistoryEntry) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newJSONHistoryEntry(entry))
+	}
+
+	fmt.Fprintf(w, "ID:           %s\n", entry.ID)
+	fmt.Fprintf(w, "Action:       %s\n", describeAction(entry.Action))
+	if entry.NotificationID != "" {
+		fmt.Fprintf(w, "Notification: %s\n", entry.NotificationID)
+	}
+	if entry.RepositoryName != "" {
+		fmt.Fprintf(w, "Repository:   %s\n", entry.RepositoryName)
+	}
+	if entry.Title != "" {
+		fmt.Fprintf(w, "Title:        %s\n", entry.Title)
+	}
+	fmt.Fprintf(w, "Performed:    %s\n", entry.Timestamp.Local().Format(time.RFC1123))
+	if entry.UndoneAt != nil {
+		fmt.Fprintf(w, "Undone:       %s\n", entry.UndoneAt.Local().Format(time.RFC1123))
+	}
+	return nil
+}
+
+// historyTarget returns the notification or repository an action applied to
+func historyTarget(action actions.Action) string {
+	switch {
+	case action.NotificationID != "" && action.RepositoryName != "":
+		return fmt.Sprintf("%s (%s)", action.NotificationID, action.RepositoryName)
+	case action.NotificationID != "":
+		return action.NotificationID
+	case action.RepositoryName != "":
+		return action.RepositoryName
+	default:
+		return "all notifications"
+	}
+}
+
+// lookupHistoryEntry returns a history entry or a usage error for unknown IDs
+func lookupHistoryEntry(id string) (actions.HistoryEntry, error) {
+	entry, ok := actions.GetActionHistory().Entry(id)
+	if !ok {
+		return actions.HistoryEntry{}, withExitCode(exitUsage, fmt.Errorf("action %s: %w", id, actions.ErrActionNotFound))
+	}
+	return entry, nil
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show and undo past actions",
+	Long: `Show and undo actions performed on notifications.
+
+Actions are kept in a journal in the cache directory, so they can be undone
+from a later session. The journal keeps the actions of the last
+advanced.history_max_age days, up to advanced.history_max_entries actions.`,
+}
+
+var (
+	historyListFormat string
+	historyListLimit  int
+)
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List past actions",
+	Long:  `List past actions, most recent first.`,
+	Example: `  gh-notif history list
+  gh-notif history list --limit 50 --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(historyListFormat); err != nil {
+			return err
+		}
+		if historyListLimit < 0 {
+			return withExitCode(exitUsage, fmt.Errorf("--limit must not be negative"))
+		}
+
+		entries := actions.GetActionHistory().Entries(historyListLimit)
+		return printHistoryEntries(cmd.OutOrStdout(), historyListFormat, entries)
+	},
+}
+
+var historyShowFormat string
+
+var historyShowCmd = &cobra.Command{
+	Use:     "show <id>",
+	Short:   "Show a past action",
+	Long:    `Show the details of a past action.`,
+	Example: `  gh-notif history show 42`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(historyShowFormat); err != nil {
+			return err
+		}
+
+		entry, err := lookupHistoryEntry(args[0])
+		if err != nil {
+			return err
+		}
+		return printHistoryEntry(cmd.OutOrStdout(), historyShowFormat, entry)
+	},
+}
+
+var historyUndoFlags actionFlags
+
+var historyUndoCmd = &cobra.Command{
+	Use:   "undo <id>",
+	Short: "Undo a past action",
+	Long: `Undo a past action by its history ID.
+
+Nothing is changed when the notification or repository is already back in
+the state it was in before the action.`,
+	Example: `  gh-notif history undo 42
+  gh-notif history undo 42 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := historyUndoFlags.validate(); err != nil {
+			return err
+		}
+
+		entry, err := lookupHistoryEntry(args[0])
+		if err != nil {
+			return err
+		}
+		if entry.UndoneAt != nil {
+			return fmt.Errorf("action %s: %w", entry.ID, actions.ErrAlreadyUndone)
+		}
+
+		if historyUndoFlags.dryRun {
+			return printBatchResult(cmd.OutOrStdout(), historyUndoFlags.format, dryRunUndoResult([]actions.Action{entry.Action}))
+		}
+
+		result, err := actions.UndoActionByID(commandContext(cmd), entry.ID)
+		if result == nil {
+			return err
+		}
+
+		batch := undoBatchResult(entry.Action, result)
+		if err := printBatchResult(cmd.OutOrStdout(), historyUndoFlags.format, batch); err != nil {
+			return err
+		}
+		return batchError(batch)
+	},
+}
+
+// undoBatchResult wraps the result of undoing original into a batch result
+func undoBatchResult(original actions.Action, result *actions.UndoResult) *actions.BatchResult {
+	action := result.UndoAction
+	if !result.Success {
+		action = original
+		action.Metadata = map[string]interface{}{"undo": true, "unmute": original.Metadata["unmute"]}
+	}
+
+	batch, _ := singleResult(&actions.ActionResult{
+		Action:  action,
+		Success: result.Success,
+		Error:   result.Error,
+	}, nil)
+	return batch
+}
+
+// dryRunUndoResult describes the undo of actions without performing it
+func dryRunUndoResult(history []actions.Action) *actions.BatchResult {
+	result := &actions.BatchResult{TotalCount: len(history), SuccessCount: len(history)}
+	for _, action := range history {
+		metadata := map[string]interface{}{"dry_run": true, "undo": true}
+		for k, v := range action.Metadata {
+			metadata[k] = v
+		}
+		action.Metadata = metadata
+		result.Results = append(result.Results, actions.ActionResult{Action: action, Success: true})
+	}
+	return result
+}
+
+// validateHistoryFormat checks the --format flag of the history commands
+func validateHistoryFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format: %s (must be text or json)", format)
+	}
+	return nil
+}
+
+func init() {
+	historyListCmd.Flags().IntVarP(&historyListLimit, "limit", "l", 20, "maximum number of actions to show, 0 for all")
+	historyListCmd.Flags().StringVar(&historyListFormat, "format", "text", "output format: text or json")
+	historyShowCmd.Flags().StringVar(&historyShowFormat, "format", "text", "output format: text or json")
+	historyUndoFlags.register(historyUndoCmd)
+
+	historyCmd.AddCommand(historyListCmd, historyShowCmd, historyUndoCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -3,11 +3,13 @@ package actions
 import (
 	"context"
 	"errors"
-	"sync"
 	"time"
 
 	"github.com/SharanRP/gh-notif/internal/common"
+	"github.com/SharanRP/gh-notif/internal/config"
 	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/google/go-github/v60/github"
 )
 
 // Common errors
@@ -17,12 +19,17 @@ var (
 	ErrPartialFailure    = errors.New("some operations failed")
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
 	ErrNotAuthenticated  = errors.New("not authenticated")
+	ErrActionNotFound    = errors.New("action not found in history")
+	ErrAlreadyUndone     = errors.New("action was already undone")
+	ErrNotSnoozed        = errors.New("notification is not snoozed")
 )
 
 // Action types
 const (
 	// ActionMarkAsRead represents marking a notification as read
 	ActionMarkAsRead = common.ActionMarkAsRead
+	// ActionMarkAsUnread represents keeping a read notification unread locally
+	ActionMarkAsUnread = common.ActionMarkAsUnread
 	// ActionMarkAllAsRead represents marking all notifications as read
 	ActionMarkAllAsRead = common.ActionMarkAllAsRead
 	// ActionArchive represents archiving a notification
@@ -35,6 +42,10 @@ const (
 	ActionUnsubscribe = common.ActionUnsubscribe
 	// ActionMute represents muting a repository
 	ActionMute = common.ActionMute
+	// ActionSnooze represents hiding a notification until a time or new activity
+	ActionSnooze = common.ActionSnooze
+	// ActionUnsnooze represents ending the snooze of a notification
+	ActionUnsnooze = common.ActionUnsnooze
 )
 
 // Action represents an action performed on a notification
@@ -71,77 +82,138 @@ type ActionResult = common.ActionResult
 // BatchResult represents the result of a batch operation
 type BatchResult = common.BatchResult
 
-// ActionHistory tracks actions for potential undo
-type ActionHistory struct {
-	// Actions is a list of actions performed
-	Actions []Action
-	// mu protects the actions list
-	mu sync.RWMutex
-	// maxSize is the maximum number of actions to store
-	maxSize int
+// GetClient is a function that returns a GitHub client for performing actions
+var GetClient = func(ctx context.Context) (*githubclient.Client, error) {
+	return githubclient.NewClient(ctx, githubclient.WithHost(HostFromContext(ctx)))
 }
 
-// NewActionHistory creates a new action history
-func NewActionHistory(maxSize int) *ActionHistory {
-	if maxSize <= 0 {
-		maxSize = 100
-	}
-	return &ActionHistory{
-		Actions: make([]Action, 0, maxSize),
-		maxSize: maxSize,
+// hostKey carries the host actions are performed on
+type hostKey struct{}
+
+// WithHost returns a context whose actions are performed on a GitHub
+// Enterprise Server host. An empty host keeps the selected host.
+func WithHost(ctx context.Context, host string) context.Context {
+	if host == "" {
+		return ctx
 	}
+	return context.WithValue(ctx, hostKey{}, host)
 }
 
-// Add adds an action to the history
-func (h *ActionHistory) Add(action Action) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
+// HostFromContext returns the host set with WithHost, empty for the selected host
+func HostFromContext(ctx context.Context) string {
+	host, _ := ctx.Value(hostKey{}).(string)
+	return host
+}
 
-	// Add the action to the beginning of the list
-	h.Actions = append([]Action{action}, h.Actions...)
+// ruleKey carries the name of the rule that performs actions
+type ruleKey struct{}
 
-	// Trim the list if it exceeds the maximum size
-	if len(h.Actions) > h.maxSize {
-		h.Actions = h.Actions[:h.maxSize]
-	}
-}
+// MetadataRule is the action metadata naming the rule that performed an
+// action, absent for actions the user took
+const MetadataRule = "rule"
 
-// GetLast returns the last n actions
-func (h *ActionHistory) GetLast(n int) []Action {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+// WithRule returns a context whose actions are performed by a triage rule.
+// They are recorded in the history with the rule's name.
+func WithRule(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ruleKey{}, name)
+}
 
-	if n <= 0 || n > len(h.Actions) {
-		n = len(h.Actions)
+// notificationsKey carries the notifications actions are performed on, by ID
+type notificationsKey struct{}
+
+// WithNotifications returns a context whose actions are performed on
+// notifications the caller already has. The history describes them from
+// these instead of fetching their threads again.
+func WithNotifications(ctx context.Context, notifications ...*github.Notification) context.Context {
+	known := make(map[string]*github.Notification, len(notifications))
+	if outer, ok := ctx.Value(notificationsKey{}).(map[string]*github.Notification); ok {
+		for id, n := range outer {
+			known[id] = n
+		}
 	}
+	for _, n := range notifications {
+		if n != nil {
+			known[n.GetID()] = n
+		}
+	}
+	return context.WithValue(ctx, notificationsKey{}, known)
+}
 
-	result := make([]Action, n)
-	copy(result, h.Actions[:n])
-	return result
+// knownNotification returns the notification set with WithNotifications, or
+// nil when the caller does not have it
+func knownNotification(ctx context.Context, id string) *github.Notification {
+	known, _ := ctx.Value(notificationsKey{}).(map[string]*github.Notification)
+	return known[id]
 }
 
-// Clear clears the action history
-func (h *ActionHistory) Clear() {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	h.Actions = make([]Action, 0, h.maxSize)
+// historyOptOutKey marks contexts whose actions are not recorded in the history
+type historyOptOutKey struct{}
+
+// withoutHistory returns a context whose actions are not recorded in the history
+func withoutHistory(ctx context.Context) context.Context {
+	return context.WithValue(ctx, historyOptOutKey{}, true)
 }
 
-// Singleton instance of ActionHistory
-var (
-	actionHistory     *ActionHistory
-	actionHistoryOnce sync.Once
-)
+// recordAction adds a successful action to the history and sets its ID
+func recordAction(ctx context.Context, action *Action) {
+	if ctx.Value(historyOptOutKey{}) != nil {
+		return
+	}
 
-// GetActionHistory returns the singleton instance of ActionHistory
-func GetActionHistory() *ActionHistory {
-	actionHistoryOnce.Do(func() {
-		actionHistory = NewActionHistory(100)
-	})
-	return actionHistory
+	history := GetActionHistory()
+	if history == nil {
+		return
+	}
+
+	// Undo has to go to the host the action was performed on
+	if action.Host == "" {
+		action.Host = HostFromContext(ctx)
+		if action.Host == "" {
+			action.Host = config.Host()
+		}
+	}
+
+	if rule, ok := ctx.Value(ruleKey{}).(string); ok {
+		if action.Metadata == nil {
+			action.Metadata = make(map[string]interface{})
+		}
+		action.Metadata[MetadataRule] = rule
+	}
+
+	// A journal failure must not fail an action that already happened
+	recorded, _ := history.Record(*action)
+	action.ID = recorded.ID
 }
 
-// GetClient is a function that returns a GitHub client for performing actions
-var GetClient = func(ctx context.Context) (*githubclient.Client, error) {
-	return githubclient.NewClient(ctx)
+// describeThread fills in the title and repository of a notification action
+// for the history. The thread is only fetched when the caller does not have
+// the notification already. Lookup failures are ignored since they only
+// affect how the action is shown later.
+func describeThread(ctx context.Context, client *githubclient.Client, action *Action) {
+	if ctx.Value(historyOptOutKey{}) != nil {
+		return
+	}
+
+	thread := knownNotification(ctx, action.NotificationID)
+	if thread == nil {
+		var err error
+		thread, _, err = client.GetThread(action.NotificationID)
+		if err != nil || thread == nil {
+			return
+		}
+	}
+
+	action.Title = thread.GetSubject().GetTitle()
+	if action.RepositoryName == "" {
+		action.RepositoryName = thread.GetRepository().GetFullName()
+	}
+
+	// The state of the thread before the action is what score training
+	// learns from
+	if action.Metadata == nil {
+		action.Metadata = make(map[string]interface{})
+	}
+	action.Metadata[metadataUnread] = thread.GetUnread()
+	action.Metadata[metadataUpdatedAt] = thread.GetUpdatedAt().Format(time.RFC3339)
+	action.Metadata[metadataFeatures] = scoring.NewScorer(nil).Features(thread)
 }
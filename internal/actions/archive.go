@@ -23,6 +23,9 @@ func ArchiveNotification(ctx context.Context, notificationID string) (*ActionRes
 		Timestamp:      time.Now(),
 	}
 
+	// Look up the title shown in the history
+	describeThread(ctx, client, &action)
+
 	// First, mark the notification as read (required for archiving)
 	resp, err := client.MarkThreadRead(notificationID)
 	if err != nil {
@@ -55,6 +58,7 @@ func ArchiveNotification(ctx context.Context, notificationID string) (*ActionRes
 		Ignored: github.Bool(true),
 	}
 	_, resp, err = client.GetRawClient().Activity.SetThreadSubscription(ctx, notificationID, sub)
+	client.RecordWrite("SetThreadSubscription", notificationID)
 
 	if err != nil {
 		action.Success = false
@@ -82,9 +86,7 @@ func ArchiveNotification(ctx context.Context, notificationID string) (*ActionRes
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
@@ -107,11 +109,15 @@ func UnarchiveNotification(ctx context.Context, notificationID string) (*ActionR
 		Timestamp:      time.Now(),
 	}
 
+	// Look up the title shown in the history
+	describeThread(ctx, client, &action)
+
 	// Update the subscription to unignore the thread
 	sub := &github.Subscription{
 		Ignored: github.Bool(false),
 	}
 	_, resp, err := client.GetRawClient().Activity.SetThreadSubscription(ctx, notificationID, sub)
+	client.RecordWrite("SetThreadSubscription", notificationID)
 
 	if err != nil {
 		action.Success = false
@@ -139,9 +145,7 @@ func UnarchiveNotification(ctx context.Context, notificationID string) (*ActionR
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
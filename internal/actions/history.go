@@ -0,0 +1,503 @@
+package actions
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/common"
+	"github.com/SharanRP/gh-notif/internal/filelock"
+)
+
+// HistoryFileName is the name of the action journal in the cache directory
+const HistoryFileName = "action_history.jsonl"
+
+// HistoryRetention controls which actions are kept in the history
+type HistoryRetention struct {
+	// MaxEntries is the maximum number of actions to keep, 0 for no limit
+	MaxEntries int
+	// MaxAge is the maximum age of actions to keep, 0 for no limit
+	MaxAge time.Duration
+}
+
+// HistoryEntry is an action in the history together with its undo state
+type HistoryEntry struct {
+	Action
+	// UndoneAt is when the action was undone, nil if it was not undone
+	UndoneAt *time.Time
+}
+
+// journalEntry is a line of the action journal. The journal is append-only
+// between compactions; a later line with the same ID replaces an earlier one. Several processes
+// may share the journal, so it is written under its file lock and re-read
+// before IDs are given out.
+type journalEntry struct {
+	ID             string                 `json:"id"`
+	Type           common.ActionType      `json:"type"`
+	NotificationID string                 `json:"notification_id,omitempty"`
+	Repository     string                 `json:"repository,omitempty"`
+	Host           string                 `json:"host,omitempty"`
+	Title          string                 `json:"title,omitempty"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	UndoneAt       *time.Time             `json:"undone_at,omitempty"`
+
+	// NextID is only set on the journalMark line
+	NextID int `json:"next_id,omitempty"`
+}
+
+// journalMark is the first line of a compacted journal. It records the ID
+// given to the next action, so IDs are not reused once the actions that
+// had the highest ones are dropped.
+type journalMark struct {
+	NextID int `json:"next_id"`
+}
+
+// ActionHistory tracks actions for potential undo
+type ActionHistory struct {
+	// Actions is a list of actions performed, most recent first
+	Actions []Action
+	// mu protects the actions list
+	mu sync.RWMutex
+	// maxSize is the maximum number of actions to store, 0 for no limit
+	maxSize int
+	// maxAge is the maximum age of stored actions, 0 for no limit
+	maxAge time.Duration
+	// path is the journal file, empty for an in-memory history
+	path string
+	// nextID is the ID given to the next recorded action
+	nextID int
+	// undone maps the IDs of undone actions to when they were undone
+	undone map[string]time.Time
+}
+
+// NewActionHistory creates a new in-memory action history
+func NewActionHistory(maxSize int) *ActionHistory {
+	if maxSize <= 0 {
+		maxSize = 100
+	}
+	return &ActionHistory{
+		Actions: make([]Action, 0, maxSize),
+		maxSize: maxSize,
+		nextID:  1,
+		undone:  make(map[string]time.Time),
+	}
+}
+
+// OpenActionHistory loads the action history from a journal file, creating
+// it if needed. Actions outside the retention limits are dropped and the
+// journal is compacted.
+func OpenActionHistory(path string, retention HistoryRetention) (*ActionHistory, error) {
+	h := &ActionHistory{
+		maxSize: retention.MaxEntries,
+		maxAge:  retention.MaxAge,
+		path:    path,
+		nextID:  1,
+		undone:  make(map[string]time.Time),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	lock, err := filelock.Acquire(path)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	lines, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	h.prune()
+
+	// Rewrite the journal when it holds replaced or dropped lines
+	if lines != len(h.Actions) {
+		if err := h.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// load reads the journal, replacing the actions in memory, and returns the
+// number of action lines it contained
+func (h *ActionHistory) load() (int, error) {
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open history journal: %w", err)
+	}
+	defer file.Close()
+
+	entries := make(map[string]journalEntry)
+	var order []string
+	lines := 0
+	h.undone = make(map[string]time.Time)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry journalEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err == nil && entry.ID == "" && entry.NextID > 0 {
+			if entry.NextID > h.nextID {
+				h.nextID = entry.NextID
+			}
+			continue
+		}
+		lines++
+
+		// Skip lines that cannot be parsed, such as a write torn by a crash
+		if err != nil || entry.ID == "" {
+			continue
+		}
+
+		if _, ok := entries[entry.ID]; !ok {
+			order = append(order, entry.ID)
+		}
+		entries[entry.ID] = entry
+
+		if id, err := strconv.Atoi(entry.ID); err == nil && id >= h.nextID {
+			h.nextID = id + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read history journal: %w", err)
+	}
+
+	// The journal is chronological, the history is most recent first
+	h.Actions = make([]Action, 0, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		entry := entries[order[i]]
+		h.Actions = append(h.Actions, entry.action())
+		if entry.UndoneAt != nil {
+			h.undone[entry.ID] = *entry.UndoneAt
+		}
+	}
+
+	return lines, nil
+}
+
+// Add adds an action to the history
+func (h *ActionHistory) Add(action Action) {
+	// Journal failures are not fatal; the action is still kept in memory
+	_, _ = h.Record(action)
+}
+
+// Record adds an action to the history and its journal, and returns the
+// action with the ID it was given
+func (h *ActionHistory) Record(action Action) (Action, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Pick up the actions other processes recorded, so IDs are not reused;
+	// without the journal the action is still kept in memory
+	unlock, lockErr := h.lockJournal()
+
+	action.ID = strconv.Itoa(h.nextID)
+	h.nextID++
+
+	// Add the action to the beginning of the list
+	h.Actions = append([]Action{action}, h.Actions...)
+	h.prune()
+
+	if lockErr != nil {
+		return action, lockErr
+	}
+	defer unlock()
+	return action, h.appendEntry(newJournalEntry(action, nil))
+}
+
+// MarkUndone records that an action was undone
+func (h *ActionHistory) MarkUndone(id string, at time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	unlock, err := h.lockJournal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, action := range h.Actions {
+		if action.ID == id {
+			h.undone[id] = at
+			return h.appendEntry(newJournalEntry(action, &at))
+		}
+	}
+
+	return fmt.Errorf("action %s: %w", id, ErrActionNotFound)
+}
+
+// GetLast returns the last n actions
+func (h *ActionHistory) GetLast(n int) []Action {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if n <= 0 || n > len(h.Actions) {
+		n = len(h.Actions)
+	}
+
+	result := make([]Action, n)
+	copy(result, h.Actions[:n])
+	return result
+}
+
+// GetUndoable returns the last n actions that can still be undone
+func (h *ActionHistory) GetUndoable(n int) []Action {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var result []Action
+	for _, action := range h.Actions {
+		if n > 0 && len(result) == n {
+			break
+		}
+		if _, undone := h.undone[action.ID]; undone || action.Type == ActionMarkAllAsRead {
+			continue
+		}
+		result = append(result, action)
+	}
+	return result
+}
+
+// Entries returns the last n actions with their undo state
+func (h *ActionHistory) Entries(n int) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if n <= 0 || n > len(h.Actions) {
+		n = len(h.Actions)
+	}
+
+	result := make([]HistoryEntry, 0, n)
+	for _, action := range h.Actions[:n] {
+		result = append(result, h.entry(action))
+	}
+	return result
+}
+
+// Entry returns the action with the given ID
+func (h *ActionHistory) Entry(id string) (HistoryEntry, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, action := range h.Actions {
+		if action.ID == id {
+			return h.entry(action), true
+		}
+	}
+	return HistoryEntry{}, false
+}
+
+// Clear clears the action history and its journal
+func (h *ActionHistory) Clear() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.path != "" {
+		lock, err := filelock.Acquire(h.path)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock()
+	}
+
+	h.Actions = make([]Action, 0, h.maxSize)
+	h.undone = make(map[string]time.Time)
+
+	return h.compact()
+}
+
+// entry builds the history entry of an action
+func (h *ActionHistory) entry(action Action) HistoryEntry {
+	entry := HistoryEntry{Action: action}
+	if at, ok := h.undone[action.ID]; ok {
+		entry.UndoneAt = &at
+	}
+	return entry
+}
+
+// prune drops actions outside the retention limits
+func (h *ActionHistory) prune() {
+	if h.maxAge > 0 {
+		cutoff := time.Now().Add(-h.maxAge)
+		kept := h.Actions[:0]
+		for _, action := range h.Actions {
+			if action.Timestamp.After(cutoff) {
+				kept = append(kept, action)
+			} else {
+				delete(h.undone, action.ID)
+			}
+		}
+		h.Actions = kept
+	}
+
+	// Trim the list if it exceeds the maximum size
+	if h.maxSize > 0 && len(h.Actions) > h.maxSize {
+		for _, action := range h.Actions[h.maxSize:] {
+			delete(h.undone, action.ID)
+		}
+		h.Actions = h.Actions[:h.maxSize]
+	}
+}
+
+// lockJournal takes the journal's file lock and reloads the journal, which
+// other processes may have appended to. It returns the function releasing
+// the lock.
+func (h *ActionHistory) lockJournal() (func(), error) {
+	if h.path == "" {
+		return func() {}, nil
+	}
+
+	lock, err := filelock.Acquire(h.path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.load(); err != nil {
+		lock.Unlock()
+		return nil, err
+	}
+	h.prune()
+
+	return func() { lock.Unlock() }, nil
+}
+
+// appendEntry appends an entry to the journal
+func (h *ActionHistory) appendEntry(entry journalEntry) error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history journal: %w", err)
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write history journal: %w", err)
+	}
+
+	return file.Close()
+}
+
+// compact rewrites the journal with its mark and one line per retained action
+func (h *ActionHistory) compact() error {
+	if h.path == "" {
+		return nil
+	}
+
+	tmpPath := h.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create history journal: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	if err := encoder.Encode(journalMark{NextID: h.nextID}); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write history journal: %w", err)
+	}
+	for i := len(h.Actions) - 1; i >= 0; i-- {
+		action := h.Actions[i]
+		var undoneAt *time.Time
+		if at, ok := h.undone[action.ID]; ok {
+			undoneAt = &at
+		}
+		if err := encoder.Encode(newJournalEntry(action, undoneAt)); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write history journal: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write history journal: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write history journal: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return fmt.Errorf("failed to replace history journal: %w", err)
+	}
+
+	return nil
+}
+
+// newJournalEntry converts an action into a journal entry
+func newJournalEntry(action Action, undoneAt *time.Time) journalEntry {
+	return journalEntry{
+		ID:             action.ID,
+		Type:           action.Type,
+		NotificationID: action.NotificationID,
+		Repository:     action.RepositoryName,
+		Host:           action.Host,
+		Title:          action.Title,
+		Timestamp:      action.Timestamp,
+		Metadata:       action.Metadata,
+		UndoneAt:       undoneAt,
+	}
+}
+
+// action converts a journal entry back into a successful action
+func (e journalEntry) action() Action {
+	return Action{
+		ID:             e.ID,
+		Type:           e.Type,
+		NotificationID: e.NotificationID,
+		RepositoryName: e.Repository,
+		Host:           e.Host,
+		Title:          e.Title,
+		Timestamp:      e.Timestamp,
+		Success:        true,
+		Metadata:       e.Metadata,
+	}
+}
+
+// Singleton instance of ActionHistory
+var (
+	actionHistory     *ActionHistory
+	actionHistoryOnce sync.Once
+)
+
+// GetActionHistory returns the singleton instance of ActionHistory
+func GetActionHistory() *ActionHistory {
+	actionHistoryOnce.Do(func() {
+		actionHistory = NewActionHistory(100)
+	})
+	return actionHistory
+}
+
+// SetActionHistory replaces the history returned by GetActionHistory,
+// typically with one opened from a journal
+func SetActionHistory(history *ActionHistory) {
+	actionHistoryOnce.Do(func() {})
+	actionHistory = history
+}
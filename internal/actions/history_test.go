@@ -1,6 +1,10 @@
 package actions
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -134,3 +138,200 @@ func TestFormatProgress(t *testing.T) {
 		}
 	}
 }
+
+func TestOpenActionHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", HistoryFileName)
+
+	history, err := OpenActionHistory(path, HistoryRetention{MaxEntries: 10})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+
+	archived, err := history.Record(Action{
+		Type:           ActionArchive,
+		NotificationID: "111",
+		RepositoryName: "owner/repo",
+		Title:          "Fix the flaky test",
+		Timestamp:      time.Now(),
+		Success:        true,
+	})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	history.Add(Action{
+		Type:           ActionMute,
+		RepositoryName: "owner/noisy",
+		Timestamp:      time.Now(),
+		Success:        true,
+		Metadata:       map[string]interface{}{"unmute": true},
+	})
+	if err := history.MarkUndone(archived.ID, time.Now()); err != nil {
+		t.Fatalf("MarkUndone() error = %v", err)
+	}
+
+	// A new session sees the same history
+	reopened, err := OpenActionHistory(path, HistoryRetention{MaxEntries: 10})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+
+	entries := reopened.Entries(0)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Type != ActionMute || entries[0].Metadata["unmute"] != true || entries[0].UndoneAt != nil {
+		t.Errorf("unexpected latest entry: %+v", entries[0])
+	}
+
+	entry, ok := reopened.Entry(archived.ID)
+	if !ok {
+		t.Fatalf("Entry(%s) not found", archived.ID)
+	}
+	if entry.Title != "Fix the flaky test" || entry.RepositoryName != "owner/repo" || !entry.Success || entry.UndoneAt == nil {
+		t.Errorf("unexpected reloaded entry: %+v", entry)
+	}
+
+	// Undone actions are skipped when undoing the latest actions
+	undoable := reopened.GetUndoable(0)
+	if len(undoable) != 1 || undoable[0].Type != ActionMute {
+		t.Errorf("unexpected undoable actions: %+v", undoable)
+	}
+
+	// IDs keep increasing across sessions
+	next, _ := reopened.Record(Action{Type: ActionSubscribe, NotificationID: "222", Timestamp: time.Now(), Success: true})
+	if next.ID != "3" {
+		t.Errorf("next ID = %s, want 3", next.ID)
+	}
+}
+
+func TestOpenActionHistoryRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+	now := time.Now().UTC()
+
+	var lines []string
+	for i, age := range []time.Duration{72 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour} {
+		lines = append(lines, fmt.Sprintf(`{"id":"%d","type":"archive","notification_id":"%d","timestamp":%q}`,
+			i+1, i+1, now.Add(-age).Format(time.RFC3339)))
+	}
+	// A torn write at the end of the journal is ignored
+	lines = append(lines, `{"id":"5","type":`)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := OpenActionHistory(path, HistoryRetention{MaxEntries: 2, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+
+	actions := history.GetLast(0)
+	if len(actions) != 2 || actions[0].ID != "4" || actions[1].ID != "3" {
+		t.Fatalf("unexpected retained actions: %+v", actions)
+	}
+
+	// The journal is compacted to the next ID and the retained actions,
+	// oldest first
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(journal) != 3 || journal[0] != `{"next_id":5}` ||
+		!strings.Contains(journal[1], `"id":"3"`) || !strings.Contains(journal[2], `"id":"4"`) {
+		t.Errorf("unexpected compacted journal:\n%s", data)
+	}
+}
+
+func TestActionHistoryIDsAreNotReused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+	record := func(history *ActionHistory) Action {
+		t.Helper()
+		action, err := history.Record(Action{Type: ActionArchive, NotificationID: "1", Timestamp: time.Now(), Success: true})
+		if err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		return action
+	}
+
+	history, err := OpenActionHistory(path, HistoryRetention{})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+	record(history)
+	record(history)
+
+	// Clearing drops the actions with the highest IDs
+	if err := history.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if next := record(history); next.ID != "3" {
+		t.Errorf("next ID after Clear() = %s, want 3", next.ID)
+	}
+	if err := history.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	// A new session continues after them too
+	reopened, err := OpenActionHistory(path, HistoryRetention{})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+	if next := record(reopened); next.ID != "4" {
+		t.Errorf("next ID in a new session = %s, want 4", next.ID)
+	}
+
+	// So does a session whose retention drops every action
+	expired, err := OpenActionHistory(path, HistoryRetention{MaxAge: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+	if len(expired.GetLast(0)) != 0 {
+		t.Fatalf("expected every action to expire")
+	}
+	if next := record(expired); next.ID != "5" {
+		t.Errorf("next ID after retention = %s, want 5", next.ID)
+	}
+}
+
+func TestActionHistorySharedJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+
+	// Two processes, e.g. a running watch and a command in another shell
+	watch, err := OpenActionHistory(path, HistoryRetention{})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+	shell, err := OpenActionHistory(path, HistoryRetention{})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+
+	first, err := watch.Record(Action{Type: ActionArchive, NotificationID: "1", Timestamp: time.Now(), Success: true})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	second, err := shell.Record(Action{Type: ActionMute, RepositoryName: "owner/noisy", Timestamp: time.Now(), Success: true})
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("both processes recorded action %s", first.ID)
+	}
+
+	// Undoing in one process keeps the other's actions
+	if err := watch.MarkUndone(first.ID, time.Now()); err != nil {
+		t.Fatalf("MarkUndone() error = %v", err)
+	}
+
+	reopened, err := OpenActionHistory(path, HistoryRetention{})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+	entries := reopened.Entries(0)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].ID != second.ID || entries[0].UndoneAt != nil || entries[1].ID != first.ID || entries[1].UndoneAt == nil {
+		t.Errorf("unexpected shared history: %+v", entries)
+	}
+}
@@ -0,0 +1,86 @@
+package actions
+
+import (
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/scoring"
+)
+
+// Metadata recorded by describeThread about the thread before an action
+const (
+	metadataUnread    = "unread"
+	metadataUpdatedAt = "updated_at"
+	metadataFeatures  = "score_features"
+)
+
+// QuickRead is how soon after its update reading a notification counts as
+// engaging with it
+const QuickRead = 24 * time.Hour
+
+// TrainingData turns the action history into examples for learning score
+// weights. Notifications read within QuickRead of their update are engaged
+// with; notifications archived or unsubscribed from while unread are
+// dismissed. Undone actions and actions performed by rules are left out,
+// and muting a repository makes it score lowest.
+func TrainingData(entries []HistoryEntry) scoring.TrainingData {
+	data := scoring.TrainingData{Muted: make(map[string]bool)}
+
+	// Entries are most recent first, so the latest mute of a repository wins
+	for _, entry := range entries {
+		// Rules act on what the user already decided, so their actions say
+		// nothing new about what the user engages with
+		if _, byRule := entry.Metadata[MetadataRule]; byRule || entry.UndoneAt != nil || entry.Metadata["undo"] == true {
+			continue
+		}
+
+		if entry.Type == ActionMute {
+			if _, seen := data.Muted[entry.RepositoryName]; !seen && entry.RepositoryName != "" {
+				data.Muted[entry.RepositoryName] = entry.Metadata["unmute"] != true
+			}
+			continue
+		}
+
+		features := entryFeatures(entry.Action)
+		unread, _ := entry.Metadata[metadataUnread].(bool)
+		if features == nil || !unread {
+			continue
+		}
+
+		switch entry.Type {
+		case ActionMarkAsRead:
+			updatedAt, err := time.Parse(time.RFC3339, stringValue(entry.Metadata[metadataUpdatedAt]))
+			if err == nil && entry.Timestamp.Sub(updatedAt) <= QuickRead {
+				data.Examples = append(data.Examples, scoring.Example{Features: features, Engaged: true})
+			}
+		case ActionArchive, ActionUnsubscribe:
+			data.Examples = append(data.Examples, scoring.Example{Features: features, Engaged: false})
+		}
+	}
+
+	return data
+}
+
+// entryFeatures returns the score features recorded with an action. Actions
+// loaded from the journal have them decoded from JSON.
+func entryFeatures(action Action) map[string]float64 {
+	switch features := action.Metadata[metadataFeatures].(type) {
+	case map[string]float64:
+		return features
+	case map[string]interface{}:
+		result := make(map[string]float64, len(features))
+		for factor, value := range features {
+			if v, ok := value.(float64); ok {
+				result[factor] = v
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// stringValue returns a metadata value as a string
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,188 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestTrainingData(t *testing.T) {
+	now := time.Now()
+	features := map[string]float64{"age": 1, "reason": 0.8}
+	metadata := func(unread bool, updatedAt time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			metadataUnread:    unread,
+			metadataUpdatedAt: updatedAt.Format(time.RFC3339),
+			metadataFeatures:  features,
+		}
+	}
+
+	history, err := OpenActionHistory(filepath.Join(t.TempDir(), HistoryFileName), HistoryRetention{})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+
+	record := func(action Action) Action {
+		action.Success = true
+		recorded, err := history.Record(action)
+		if err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		return recorded
+	}
+
+	// Read soon after the update
+	record(Action{Type: ActionMarkAsRead, NotificationID: "1", Timestamp: now, Metadata: metadata(true, now.Add(-time.Hour))})
+	// Read days later, which says little about its importance
+	record(Action{Type: ActionMarkAsRead, NotificationID: "2", Timestamp: now, Metadata: metadata(true, now.Add(-72*time.Hour))})
+	// Archived unread
+	record(Action{Type: ActionArchive, NotificationID: "3", Timestamp: now, Metadata: metadata(true, now)})
+	// Archived after reading
+	record(Action{Type: ActionArchive, NotificationID: "4", Timestamp: now, Metadata: metadata(false, now)})
+	// Unsubscribed unread, then undone
+	undone := record(Action{Type: ActionUnsubscribe, NotificationID: "5", Timestamp: now, Metadata: metadata(true, now)})
+	if err := history.MarkUndone(undone.ID, now); err != nil {
+		t.Fatalf("MarkUndone() error = %v", err)
+	}
+	// Recorded without the state of the thread
+	record(Action{Type: ActionArchive, NotificationID: "6", Timestamp: now})
+	// Muted, unmuted and muted again
+	record(Action{Type: ActionMute, RepositoryName: "org/noisy", Timestamp: now})
+	record(Action{Type: ActionMute, RepositoryName: "org/noisy", Timestamp: now, Metadata: map[string]interface{}{"unmute": true}})
+	record(Action{Type: ActionMute, RepositoryName: "org/quiet", Timestamp: now})
+	record(Action{Type: ActionMute, RepositoryName: "org/quiet", Timestamp: now, Metadata: map[string]interface{}{"unmute": true}})
+	record(Action{Type: ActionMute, RepositoryName: "org/noisy", Timestamp: now})
+
+	// Archived unread and muted by a rule, which records its name
+	originalHistory := GetActionHistory()
+	SetActionHistory(history)
+	defer SetActionHistory(originalHistory)
+	ruleCtx := WithRule(context.Background(), "bots")
+	for _, action := range []Action{
+		{Type: ActionArchive, NotificationID: "7", Timestamp: now, Success: true, Metadata: metadata(true, now)},
+		{Type: ActionMute, RepositoryName: "org/bots", Timestamp: now, Success: true},
+	} {
+		recordAction(ruleCtx, &action)
+		if action.Metadata[MetadataRule] != "bots" {
+			t.Errorf("recorded %s action metadata = %v, want the rule", action.Type, action.Metadata)
+		}
+	}
+
+	// The features survive the journal
+	reopened, err := OpenActionHistory(history.path, HistoryRetention{})
+	if err != nil {
+		t.Fatalf("OpenActionHistory() error = %v", err)
+	}
+
+	data := TrainingData(reopened.Entries(0))
+	if len(data.Examples) != 2 {
+		t.Fatalf("got %d examples, want 2: %+v", len(data.Examples), data.Examples)
+	}
+	// Most recent first
+	if data.Examples[0].Engaged || !data.Examples[1].Engaged {
+		t.Errorf("got examples %+v, want the archived one dismissed and the quickly read one engaged", data.Examples)
+	}
+	if data.Examples[1].Features["reason"] != 0.8 {
+		t.Errorf("got features %v, want %v", data.Examples[1].Features, features)
+	}
+	if _, ok := data.Muted["org/bots"]; ok {
+		t.Errorf("got muted %v, want the rule's mute left out", data.Muted)
+	}
+	if !data.Muted["org/noisy"] || data.Muted["org/quiet"] {
+		t.Errorf("got muted %v, want org/noisy muted and org/quiet unmuted", data.Muted)
+	}
+}
+
+func TestMarkMultipleAsReadIsEngaged(t *testing.T) {
+	// Threads are unread until they are marked read
+	var mu sync.Mutex
+	read := make(map[string]bool)
+	history := useStandIn(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var id string
+		if _, err := fmt.Sscanf(r.URL.Path, "/notifications/threads/%s", &id); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPatch:
+			read[id] = true
+			w.WriteHeader(http.StatusResetContent)
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id": %q, "unread": %t, "reason": "review_requested", "updated_at": %q,
+				"repository": {"full_name": "owner/repo"}, "subject": {"title": "Fix it", "type": "PullRequest"}}`,
+				id, !read[id], time.Now().Add(-time.Hour).Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	result, err := MarkMultipleAsRead(context.Background(), []string{"1", "2"}, nil)
+	if err != nil {
+		t.Fatalf("MarkMultipleAsRead() error = %v", err)
+	}
+	if result.SuccessCount != 2 {
+		t.Fatalf("MarkMultipleAsRead() succeeded for %d threads, want 2", result.SuccessCount)
+	}
+
+	data := TrainingData(history.Entries(0))
+	if len(data.Examples) != 2 {
+		t.Fatalf("got %d training examples, want 2", len(data.Examples))
+	}
+	for _, example := range data.Examples {
+		if !example.Engaged {
+			t.Errorf("expected a quick batch read to be engaged, got %+v", example)
+		}
+	}
+}
+
+func TestKnownNotificationsAreNotFetched(t *testing.T) {
+	// Only the write reaches GitHub
+	var mu sync.Mutex
+	var requests []string
+	history := useStandIn(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method != http.MethodPatch {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusResetContent)
+	}))
+
+	notification := &github.Notification{
+		ID:         github.String("1"),
+		Unread:     github.Bool(true),
+		Reason:     github.String("mention"),
+		UpdatedAt:  &github.Timestamp{Time: time.Now().Add(-time.Hour)},
+		Repository: &github.Repository{FullName: github.String("owner/repo")},
+		Subject:    &github.NotificationSubject{Title: github.String("Fix it"), Type: github.String("Issue")},
+	}
+
+	ctx := WithNotifications(context.Background(), notification)
+	result, err := MarkAsRead(ctx, "1")
+	if err != nil {
+		t.Fatalf("MarkAsRead() error = %v", err)
+	}
+	if result.Action.Title != "Fix it" || result.Action.RepositoryName != "owner/repo" {
+		t.Errorf("action not described from the known notification: %+v", result.Action)
+	}
+	if len(requests) != 1 || requests[0] != "PATCH /notifications/threads/1" {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+
+	if data := TrainingData(history.Entries(0)); len(data.Examples) != 1 || !data.Examples[0].Engaged {
+		t.Errorf("expected one engaged example, got %+v", data.Examples)
+	}
+}
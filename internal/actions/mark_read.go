@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/SharanRP/gh-notif/internal/readstate"
 )
 
 // MarkAsRead marks a notification as read
@@ -21,6 +23,9 @@ func MarkAsRead(ctx context.Context, notificationID string) (*ActionResult, erro
 		Timestamp:      time.Now(),
 	}
 
+	// Look up the title shown in the history
+	describeThread(ctx, client, &action)
+
 	// Mark the notification as read
 	resp, err := client.MarkThreadRead(notificationID)
 	if err != nil {
@@ -49,10 +54,65 @@ func MarkAsRead(ctx context.Context, notificationID string) (*ActionResult, erro
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
+	recordAction(ctx, &action)
+
+	return &ActionResult{
+		Action:  action,
+		Success: true,
+	}, nil
+}
+
+// MarkAsUnread keeps a notification unread locally. GitHub cannot mark a
+// thread unread, so the thread is added to the read state overlay until it
+// is updated or read again.
+func MarkAsUnread(ctx context.Context, notificationID string) (*ActionResult, error) {
+	// Create a client
+	client, err := GetClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+
+	// Create the action
+	action := Action{
+		Type:           ActionMarkAsUnread,
+		NotificationID: notificationID,
+		Timestamp:      time.Now(),
+	}
+
+	// The overlay needs the current update time of the thread
+	thread, _, err := client.GetThread(notificationID)
+	if err != nil {
+		action.Success = false
+		action.Error = err
+		return &ActionResult{
+			Action:  action,
+			Success: false,
+			Error:   err,
+		}, fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	action.Title = thread.GetSubject().GetTitle()
+	action.RepositoryName = thread.GetRepository().GetFullName()
+
+	// Threads that are still unread on GitHub need no overlay entry
+	if !thread.GetUnread() {
+		if err := readstate.Default().MarkUnread(thread); err != nil {
+			action.Success = false
+			action.Error = err
+			return &ActionResult{
+				Action:  action,
+				Success: false,
+				Error:   err,
+			}, fmt.Errorf("failed to mark notification as unread: %w", err)
+		}
 	}
 
+	// Record the successful action
+	action.Success = true
+
+	// Add to history if available
+	recordAction(ctx, &action)
+
 	return &ActionResult{
 		Action:  action,
 		Success: true,
@@ -88,9 +148,6 @@ func MarkMultipleAsRead(ctx context.Context, notificationIDs []string, opts *Bat
 				}, err
 			}
 
-			// Mark the notification as read
-			resp, err := client.MarkThreadRead(id)
-
 			// Create the action
 			action := Action{
 				Type:           ActionMarkAsRead,
@@ -98,6 +155,12 @@ func MarkMultipleAsRead(ctx context.Context, notificationIDs []string, opts *Bat
 				Timestamp:      time.Now(),
 			}
 
+			// Look up the title shown in the history while the thread is
+			// still unread
+			describeThread(ctx, client, &action)
+
+			// Mark the notification as read
+			resp, err := client.MarkThreadRead(id)
 			if err != nil {
 				action.Success = false
 				action.Error = err
@@ -113,6 +176,7 @@ func MarkMultipleAsRead(ctx context.Context, notificationIDs []string, opts *Bat
 			}
 
 			action.Success = true
+
 			return action, nil
 		})
 	}
@@ -121,11 +185,9 @@ func MarkMultipleAsRead(ctx context.Context, notificationIDs []string, opts *Bat
 	result := processor.Process()
 
 	// Add successful actions to history
-	if history := GetActionHistory(); history != nil {
-		for _, r := range result.Results {
-			if r.Success {
-				history.Add(r.Action)
-			}
+	for i := range result.Results {
+		if result.Results[i].Success {
+			recordAction(ctx, &result.Results[i].Action)
 		}
 	}
 
@@ -162,9 +224,7 @@ func MarkAllAsRead(ctx context.Context) (*ActionResult, error) {
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
@@ -203,9 +263,7 @@ func MarkRepositoryNotificationsAsRead(ctx context.Context, owner, repo string)
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
@@ -49,6 +49,7 @@ func MuteRepository(ctx context.Context, repoFullName string) (*ActionResult, er
 		Ignored:    github.Bool(true),
 	}
 	_, resp, err := client.GetRawClient().Activity.SetRepositorySubscription(ctx, owner, repo, sub)
+	client.RecordWrite("SetRepositorySubscription", owner+"/"+repo)
 
 	if err != nil {
 		action.Success = false
@@ -76,9 +77,7 @@ func MuteRepository(ctx context.Context, repoFullName string) (*ActionResult, er
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
@@ -117,6 +116,7 @@ func UnmuteRepository(ctx context.Context, repoFullName string) (*ActionResult,
 		Ignored:    github.Bool(false),
 	}
 	_, resp, err := client.GetRawClient().Activity.SetRepositorySubscription(ctx, owner, repo, sub)
+	client.RecordWrite("SetRepositorySubscription", owner+"/"+repo)
 
 	if err != nil {
 		action.Success = false
@@ -144,9 +144,7 @@ func UnmuteRepository(ctx context.Context, repoFullName string) (*ActionResult,
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
@@ -0,0 +1,200 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/snooze"
+)
+
+// SnoozeNotification hides a notification until the given time or until its
+// thread is updated. A zero until waits for new activity only. Snoozing is
+// local; the notification is not changed on GitHub.
+func SnoozeNotification(ctx context.Context, notificationID string, until time.Time) (*ActionResult, error) {
+	// Create a client
+	client, err := GetClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+
+	// Create the action
+	action := Action{
+		Type:           ActionSnooze,
+		NotificationID: notificationID,
+		Timestamp:      time.Now(),
+		Metadata:       map[string]interface{}{"until": formatSnoozeUntil(until)},
+	}
+
+	// The snooze ends when the thread is updated after its current update time
+	thread := knownNotification(ctx, notificationID)
+	if thread == nil {
+		thread, _, err = client.GetThread(notificationID)
+		if err != nil {
+			action.Success = false
+			action.Error = err
+			return &ActionResult{
+				Action:  action,
+				Success: false,
+				Error:   err,
+			}, fmt.Errorf("failed to get notification: %w", err)
+		}
+	}
+
+	action.Title = thread.GetSubject().GetTitle()
+	action.RepositoryName = thread.GetRepository().GetFullName()
+
+	if _, err := snooze.Default().Snooze(thread, until); err != nil {
+		action.Success = false
+		action.Error = err
+		return &ActionResult{
+			Action:  action,
+			Success: false,
+			Error:   err,
+		}, fmt.Errorf("failed to snooze notification: %w", err)
+	}
+
+	// Record the successful action
+	action.Success = true
+
+	// Add to history if available
+	recordAction(ctx, &action)
+
+	return &ActionResult{
+		Action:  action,
+		Success: true,
+	}, nil
+}
+
+// UnsnoozeNotification ends the snooze of a notification
+func UnsnoozeNotification(ctx context.Context, notificationID string) (*ActionResult, error) {
+	// Create the action
+	action := Action{
+		Type:           ActionUnsnooze,
+		NotificationID: notificationID,
+		Timestamp:      time.Now(),
+	}
+
+	entry, ok, err := snooze.Default().Unsnooze(notificationID)
+	if err == nil && !ok {
+		err = ErrNotSnoozed
+	}
+	if err != nil {
+		action.Success = false
+		action.Error = err
+		return &ActionResult{
+			Action:  action,
+			Success: false,
+			Error:   err,
+		}, fmt.Errorf("failed to unsnooze notification: %w", err)
+	}
+
+	// Keep the snooze time so the unsnooze can be undone
+	action.Title = entry.Title
+	action.RepositoryName = entry.Repository
+	action.Metadata = map[string]interface{}{"until": formatSnoozeUntil(entry.Until)}
+
+	// Record the successful action
+	action.Success = true
+
+	// Add to history if available
+	recordAction(ctx, &action)
+
+	return &ActionResult{
+		Action:  action,
+		Success: true,
+	}, nil
+}
+
+// SnoozeMultipleNotifications snoozes multiple notifications until the same time
+func SnoozeMultipleNotifications(ctx context.Context, notificationIDs []string, until time.Time, opts *BatchOptions) (*BatchResult, error) {
+	if opts == nil {
+		opts = DefaultBatchOptions()
+	}
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	// Create a batch processor
+	processor := NewBatchProcessor(ctx, opts)
+
+	// Add tasks to the processor
+	for _, id := range notificationIDs {
+		// Capture for closure
+		processor.AddTask(func() (Action, error) {
+			result, err := SnoozeNotification(ctx, id, until)
+			if err != nil {
+				return Action{
+					Type:           ActionSnooze,
+					NotificationID: id,
+					Timestamp:      time.Now(),
+					Success:        false,
+					Error:          err,
+					Metadata:       map[string]interface{}{"until": formatSnoozeUntil(until)},
+				}, err
+			}
+			return result.Action, nil
+		})
+	}
+
+	// Process the tasks
+	return processor.Process(), nil
+}
+
+// UnsnoozeMultipleNotifications ends the snooze of multiple notifications
+func UnsnoozeMultipleNotifications(ctx context.Context, notificationIDs []string, opts *BatchOptions) (*BatchResult, error) {
+	if opts == nil {
+		opts = DefaultBatchOptions()
+	}
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	// Create a batch processor
+	processor := NewBatchProcessor(ctx, opts)
+
+	// Add tasks to the processor
+	for _, id := range notificationIDs {
+		// Capture for closure
+		processor.AddTask(func() (Action, error) {
+			result, err := UnsnoozeNotification(ctx, id)
+			if err != nil {
+				return Action{
+					Type:           ActionUnsnooze,
+					NotificationID: id,
+					Timestamp:      time.Now(),
+					Success:        false,
+					Error:          err,
+				}, err
+			}
+			return result.Action, nil
+		})
+	}
+
+	// Process the tasks
+	return processor.Process(), nil
+}
+
+// formatSnoozeUntil formats a snooze time for the action metadata
+func formatSnoozeUntil(until time.Time) string {
+	if until.IsZero() {
+		return snooze.NextActivity
+	}
+	return until.Format(time.RFC3339)
+}
+
+// snoozeUntil returns the snooze time recorded in the metadata of an action
+func snoozeUntil(action Action) (time.Time, error) {
+	value, _ := action.Metadata["until"].(string)
+	if value == "" || value == snooze.NextActivity {
+		return time.Time{}, nil
+	}
+
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snooze time %q: %w", value, err)
+	}
+	return until, nil
+}
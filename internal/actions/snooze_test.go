@@ -0,0 +1,81 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestSnoozeAndUndo(t *testing.T) {
+	history := useStandIn(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/notifications/threads/111" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "111", "unread": true, "updated_at": "2024-01-02T00:00:00Z",
+			"repository": {"full_name": "owner/repo"}, "subject": {"title": "Fix the flaky test"}}`))
+	}))
+
+	originalStore := snooze.Default()
+	store := snooze.NewStore()
+	snooze.SetDefault(store)
+	t.Cleanup(func() { snooze.SetDefault(originalStore) })
+
+	until := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	result, err := SnoozeNotification(context.Background(), "111", until)
+	if err != nil {
+		t.Fatalf("SnoozeNotification() error = %v", err)
+	}
+	if result.Action.ID == "" || result.Action.Title != "Fix the flaky test" {
+		t.Errorf("snooze action not recorded with its title: %+v", result.Action)
+	}
+	if entry, ok := store.Entry("111"); !ok || !entry.Until.Equal(until) {
+		t.Fatalf("snooze entry = %+v, %v", entry, ok)
+	}
+
+	// Undoing the snooze ends it
+	if _, err := UndoActionByID(context.Background(), result.Action.ID); err != nil {
+		t.Fatalf("UndoActionByID() error = %v", err)
+	}
+	if _, ok := store.Entry("111"); ok {
+		t.Errorf("thread is still snoozed after undo")
+	}
+
+	// Undoing an unsnooze restores the original snooze time
+	store.Snooze(&github.Notification{ID: github.String("111")}, until)
+	unsnoozed, err := UnsnoozeNotification(context.Background(), "111")
+	if err != nil {
+		t.Fatalf("UnsnoozeNotification() error = %v", err)
+	}
+	if _, err := UndoActionByID(context.Background(), unsnoozed.Action.ID); err != nil {
+		t.Fatalf("UndoActionByID() error = %v", err)
+	}
+	if entry, ok := store.Entry("111"); !ok || !entry.Until.Equal(until) {
+		t.Errorf("snooze after undoing unsnooze = %+v, %v", entry, ok)
+	}
+
+	if entries := history.Entries(0); len(entries) != 2 || entries[0].UndoneAt == nil || entries[1].UndoneAt == nil {
+		t.Errorf("history does not show both actions as undone: %+v", entries)
+	}
+}
+
+func TestUnsnoozeNotSnoozed(t *testing.T) {
+	originalStore := snooze.Default()
+	snooze.SetDefault(snooze.NewStore())
+	t.Cleanup(func() { snooze.SetDefault(originalStore) })
+
+	result, err := UnsnoozeNotification(context.Background(), "111")
+	if err == nil || result.Success {
+		t.Fatalf("UnsnoozeNotification() succeeded for a thread that is not snoozed")
+	}
+	if !errors.Is(result.Error, ErrNotSnoozed) {
+		t.Errorf("error = %v, want ErrNotSnoozed", result.Error)
+	}
+}
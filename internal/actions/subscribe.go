@@ -23,12 +23,16 @@ func SubscribeToThread(ctx context.Context, notificationID string) (*ActionResul
 		Timestamp:      time.Now(),
 	}
 
+	// Look up the title shown in the history
+	describeThread(ctx, client, &action)
+
 	// Update the subscription to subscribe to the thread
 	sub := &github.Subscription{
 		Subscribed: github.Bool(true),
 		Ignored:    github.Bool(false),
 	}
 	_, resp, err := client.GetRawClient().Activity.SetThreadSubscription(ctx, notificationID, sub)
+	client.RecordWrite("SetThreadSubscription", notificationID)
 
 	if err != nil {
 		action.Success = false
@@ -56,9 +60,7 @@ func SubscribeToThread(ctx context.Context, notificationID string) (*ActionResul
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
@@ -81,11 +83,15 @@ func UnsubscribeFromThread(ctx context.Context, notificationID string) (*ActionR
 		Timestamp:      time.Now(),
 	}
 
+	// Look up the title shown in the history
+	describeThread(ctx, client, &action)
+
 	// Update the subscription to unsubscribe from the thread
 	sub := &github.Subscription{
 		Subscribed: github.Bool(false),
 	}
 	_, resp, err := client.GetRawClient().Activity.SetThreadSubscription(ctx, notificationID, sub)
+	client.RecordWrite("SetThreadSubscription", notificationID)
 
 	if err != nil {
 		action.Success = false
@@ -113,9 +119,7 @@ func UnsubscribeFromThread(ctx context.Context, notificationID string) (*ActionR
 	action.Success = true
 
 	// Add to history if available
-	if history := GetActionHistory(); history != nil {
-		history.Add(action)
-	}
+	recordAction(ctx, &action)
 
 	return &ActionResult{
 		Action:  action,
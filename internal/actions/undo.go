@@ -3,15 +3,21 @@ package actions
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/SharanRP/gh-notif/internal/common"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/readstate"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/google/go-github/v60/github"
 )
 
 // UndoResult represents the result of an undo operation
 type UndoResult = common.UndoResult
 
-// UndoLastAction undoes the last action
+// UndoLastAction undoes the last action that was not undone yet
 func UndoLastAction(ctx context.Context) (*UndoResult, error) {
 	// Get the action history
 	history := GetActionHistory()
@@ -20,7 +26,7 @@ func UndoLastAction(ctx context.Context) (*UndoResult, error) {
 	}
 
 	// Get the last action
-	actions := history.GetLast(1)
+	actions := history.GetUndoable(1)
 	if len(actions) == 0 {
 		return nil, fmt.Errorf("no actions to undo")
 	}
@@ -29,7 +35,23 @@ func UndoLastAction(ctx context.Context) (*UndoResult, error) {
 	return UndoAction(ctx, lastAction)
 }
 
-// UndoAction undoes a specific action
+// UndoActionByID undoes the action with the given history ID
+func UndoActionByID(ctx context.Context, id string) (*UndoResult, error) {
+	history := GetActionHistory()
+	if history == nil {
+		return nil, fmt.Errorf("action history not available")
+	}
+
+	entry, ok := history.Entry(id)
+	if !ok {
+		return nil, fmt.Errorf("action %s: %w", id, ErrActionNotFound)
+	}
+
+	return UndoAction(ctx, entry.Action)
+}
+
+// UndoAction undoes a specific action. Nothing is changed when the remote
+// state already matches the state before the action.
 func UndoAction(ctx context.Context, action Action) (*UndoResult, error) {
 	// Create a result with the original action
 	result := &UndoResult{
@@ -41,14 +63,53 @@ func UndoAction(ctx context.Context, action Action) (*UndoResult, error) {
 		return nil, fmt.Errorf("cannot undo a failed action")
 	}
 
+	if action.Type == ActionMarkAllAsRead {
+		// Cannot directly undo marking all as read
+		return nil, fmt.Errorf("cannot undo marking all notifications as read")
+	}
+
+	history := GetActionHistory()
+	if history != nil && action.ID != "" {
+		if entry, ok := history.Entry(action.ID); ok && entry.UndoneAt != nil {
+			return nil, fmt.Errorf("action %s: %w", action.ID, ErrAlreadyUndone)
+		}
+	}
+
+	// The undo itself is recorded by marking the original action as undone
+	ctx = withoutHistory(ctx)
+
+	// The action is undone on the host it was performed on
+	ctx = WithHost(ctx, action.Host)
+
+	client, err := GetClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+
+	// A failed check is not fatal; the undo below reports real problems
+	if matches, err := remoteStateMatches(ctx, client, action); err == nil && matches {
+		result.UndoAction = Action{
+			Type:           action.Type,
+			NotificationID: action.NotificationID,
+			RepositoryName: action.RepositoryName,
+			Title:          action.Title,
+			Timestamp:      time.Now(),
+			Success:        true,
+			Metadata:       noopUndoMetadata(action),
+		}
+		result.Success = true
+		result.NoOp = true
+		markUndone(history, action)
+		return result, nil
+	}
+
 	var undoAction Action
 
 	// Perform the appropriate undo action based on the action type
 	switch action.Type {
 	case ActionMarkAsRead:
-		// Cannot directly mark as unread, but we can update the subscription
-		// to make it appear in the unread list again
-		undoResult, err := SubscribeToThread(ctx, action.NotificationID)
+		// GitHub cannot mark a thread unread, so it is kept unread locally
+		undoResult, err := MarkAsUnread(ctx, action.NotificationID)
 		if err != nil {
 			result.Success = false
 			result.Error = err
@@ -56,9 +117,15 @@ func UndoAction(ctx context.Context, action Action) (*UndoResult, error) {
 		}
 		undoAction = undoResult.Action
 
-	case ActionMarkAllAsRead:
-		// Cannot directly undo marking all as read
-		return nil, fmt.Errorf("cannot undo marking all notifications as read")
+	case ActionMarkAsUnread:
+		// Undo marking as unread by marking as read
+		undoResult, err := MarkAsRead(ctx, action.NotificationID)
+		if err != nil {
+			result.Success = false
+			result.Error = err
+			return result, fmt.Errorf("failed to undo mark as unread: %w", err)
+		}
+		undoAction = undoResult.Action
 
 	case ActionArchive:
 		// Undo archiving by unarchiving
@@ -122,17 +189,135 @@ func UndoAction(ctx context.Context, action Action) (*UndoResult, error) {
 			undoAction = undoResult.Action
 		}
 
+	case ActionSnooze:
+		// Undo snoozing by unsnoozing
+		undoResult, err := UnsnoozeNotification(ctx, action.NotificationID)
+		if err != nil {
+			result.Success = false
+			result.Error = err
+			return result, fmt.Errorf("failed to undo snooze: %w", err)
+		}
+		undoAction = undoResult.Action
+
+	case ActionUnsnooze:
+		// Undo unsnoozing by snoozing until the original time
+		until, err := snoozeUntil(action)
+		if err != nil {
+			result.Success = false
+			result.Error = err
+			return result, fmt.Errorf("failed to undo unsnooze: %w", err)
+		}
+		undoResult, err := SnoozeNotification(ctx, action.NotificationID, until)
+		if err != nil {
+			result.Success = false
+			result.Error = err
+			return result, fmt.Errorf("failed to undo unsnooze: %w", err)
+		}
+		undoAction = undoResult.Action
+
 	default:
 		return nil, fmt.Errorf("unknown action type: %s", action.Type)
 	}
 
 	// Set the undo action in the result
+	undoAction.Title = action.Title
+	if undoAction.RepositoryName == "" {
+		undoAction.RepositoryName = action.RepositoryName
+	}
 	result.UndoAction = undoAction
 	result.Success = true
+	markUndone(history, action)
 
 	return result, nil
 }
 
+// remoteStateMatches reports whether the remote state already matches the
+// state from before the action, which makes undoing it a no-op
+func remoteStateMatches(ctx context.Context, client *githubclient.Client, action Action) (bool, error) {
+	switch action.Type {
+	case ActionMarkAsRead, ActionMarkAsUnread:
+		thread, _, err := client.GetThread(action.NotificationID)
+		if err != nil {
+			return false, err
+		}
+		unread := readstate.IsUnread(thread)
+		if action.Type == ActionMarkAsUnread {
+			return !unread, nil
+		}
+		return unread, nil
+
+	case ActionArchive, ActionUnarchive, ActionSubscribe, ActionUnsubscribe:
+		sub, resp, err := client.GetRawClient().Activity.GetThreadSubscription(ctx, action.NotificationID)
+		if err != nil {
+			// Threads without a subscription report not found
+			if resp == nil || resp.StatusCode != http.StatusNotFound {
+				return false, err
+			}
+			sub = &github.Subscription{}
+		}
+
+		switch action.Type {
+		case ActionArchive:
+			return !sub.GetIgnored(), nil
+		case ActionUnarchive:
+			return sub.GetIgnored(), nil
+		case ActionSubscribe:
+			return !sub.GetSubscribed(), nil
+		default:
+			return sub.GetSubscribed(), nil
+		}
+
+	case ActionMute:
+		owner, repo, ok := strings.Cut(action.RepositoryName, "/")
+		if !ok {
+			return false, fmt.Errorf("invalid repository name: %s", action.RepositoryName)
+		}
+
+		sub, resp, err := client.GetRawClient().Activity.GetRepositorySubscription(ctx, owner, repo)
+		if err != nil {
+			if resp == nil || resp.StatusCode != http.StatusNotFound {
+				return false, err
+			}
+			sub = &github.Subscription{}
+		}
+
+		if action.Metadata["unmute"] == true {
+			return sub.GetIgnored(), nil
+		}
+		return !sub.GetIgnored(), nil
+
+	case ActionSnooze, ActionUnsnooze:
+		// Snoozes are local, so the snooze store is the state to compare
+		_, snoozed := snooze.Default().Entry(action.NotificationID)
+		if action.Type == ActionSnooze {
+			return !snoozed, nil
+		}
+		return snoozed, nil
+	}
+
+	return false, nil
+}
+
+// noopUndoMetadata returns the metadata of an undo that changed nothing
+func noopUndoMetadata(action Action) map[string]interface{} {
+	metadata := map[string]interface{}{"undo": true, "noop": true}
+	if action.Metadata["unmute"] == true {
+		metadata["unmute"] = true
+	}
+	return metadata
+}
+
+// markUndone records in the history that action was undone
+func markUndone(history *ActionHistory, action Action) {
+	if history == nil || action.ID == "" {
+		return
+	}
+
+	// The undo already happened, so a journal failure is not reported; undoing
+	// the action again is a no-op since the remote state matches
+	_ = history.MarkUndone(action.ID, time.Now())
+}
+
 // UndoMultipleActions undoes multiple actions
 func UndoMultipleActions(ctx context.Context, actions []Action, opts *BatchOptions) (*BatchResult, error) {
 	if opts == nil {
@@ -180,8 +365,8 @@ func UndoLastNActions(ctx context.Context, n int, opts *BatchOptions) (*BatchRes
 		return nil, fmt.Errorf("action history not available")
 	}
 
-	// Get the last N actions
-	actions := history.GetLast(n)
+	// Get the last N actions that were not undone yet
+	actions := history.GetUndoable(n)
 	if len(actions) == 0 {
 		return nil, fmt.Errorf("no actions to undo")
 	}
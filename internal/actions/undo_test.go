@@ -0,0 +1,184 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/readstate"
+)
+
+// subscriptionStandIn serves a single thread subscription and records writes
+type subscriptionStandIn struct {
+	mu      sync.Mutex
+	ignored bool
+	writes  []string
+}
+
+func (s *subscriptionStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != "/notifications/threads/111/subscription" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		s.writes = append(s.writes, r.Method)
+		s.ignored = false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if s.ignored {
+		w.Write([]byte(`{"subscribed": false, "ignored": true}`))
+	} else {
+		w.Write([]byte(`{"subscribed": true, "ignored": false}`))
+	}
+}
+
+// useStandIn points GetClient and the action history at test doubles
+func useStandIn(t *testing.T, handler http.Handler) *ActionHistory {
+	client, server, err := githubclient.NewTestClient(context.Background(), handler)
+	if err != nil {
+		t.Fatalf("failed to create test client: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	originalGetClient := GetClient
+	GetClient = func(ctx context.Context) (*githubclient.Client, error) {
+		return client, nil
+	}
+
+	originalHistory := GetActionHistory()
+	history := NewActionHistory(10)
+	SetActionHistory(history)
+
+	t.Cleanup(func() {
+		GetClient = originalGetClient
+		SetActionHistory(originalHistory)
+	})
+
+	return history
+}
+
+func TestUndoActionByID(t *testing.T) {
+	testCases := []struct {
+		name       string
+		ignored    bool
+		wantNoOp   bool
+		wantWrites int
+	}{
+		{name: "still archived", ignored: true, wantNoOp: false, wantWrites: 1},
+		{name: "already unarchived", ignored: false, wantNoOp: true, wantWrites: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standIn := &subscriptionStandIn{ignored: tc.ignored}
+			history := useStandIn(t, standIn)
+
+			archived, _ := history.Record(Action{
+				Type:           ActionArchive,
+				NotificationID: "111",
+				Title:          "Fix the flaky test",
+				Timestamp:      time.Now(),
+				Success:        true,
+			})
+
+			result, err := UndoActionByID(context.Background(), archived.ID)
+			if err != nil {
+				t.Fatalf("UndoActionByID() error = %v", err)
+			}
+			if !result.Success || result.NoOp != tc.wantNoOp {
+				t.Errorf("success = %v, noop = %v, want true, %v", result.Success, result.NoOp, tc.wantNoOp)
+			}
+			if result.UndoAction.Title != "Fix the flaky test" {
+				t.Errorf("undo action title = %q", result.UndoAction.Title)
+			}
+			if len(standIn.writes) != tc.wantWrites {
+				t.Errorf("got %d subscription writes, want %d", len(standIn.writes), tc.wantWrites)
+			}
+
+			// The undo marks the original action instead of adding a new one
+			if actions := history.GetLast(0); len(actions) != 1 {
+				t.Errorf("history has %d actions, want 1", len(actions))
+			}
+			if entry, _ := history.Entry(archived.ID); entry.UndoneAt == nil {
+				t.Errorf("action was not marked as undone")
+			}
+
+			if _, err := UndoActionByID(context.Background(), archived.ID); !errors.Is(err, ErrAlreadyUndone) {
+				t.Errorf("second undo error = %v, want ErrAlreadyUndone", err)
+			}
+		})
+	}
+}
+
+func TestUndoActionByIDNotFound(t *testing.T) {
+	useStandIn(t, &subscriptionStandIn{})
+
+	if _, err := UndoActionByID(context.Background(), "42"); !errors.Is(err, ErrActionNotFound) {
+		t.Errorf("UndoActionByID() error = %v, want ErrActionNotFound", err)
+	}
+}
+
+func TestUndoMarkAsReadKeepsThreadUnread(t *testing.T) {
+	testCases := []struct {
+		name         string
+		remoteUnread bool
+		wantNoOp     bool
+		wantEntries  int
+	}{
+		{name: "read on GitHub", remoteUnread: false, wantNoOp: false, wantEntries: 1},
+		{name: "already unread", remoteUnread: true, wantNoOp: true, wantEntries: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			history := useStandIn(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/notifications/threads/111" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"id": "111", "unread": %t, "updated_at": "2024-01-02T00:00:00Z",
+					"repository": {"full_name": "owner/repo"}, "subject": {"title": "Fix the flaky test"}}`, tc.remoteUnread)
+			}))
+
+			originalOverlay := readstate.Default()
+			overlay := readstate.NewOverlay()
+			readstate.SetDefault(overlay)
+			t.Cleanup(func() { readstate.SetDefault(originalOverlay) })
+
+			read, _ := history.Record(Action{
+				Type:           ActionMarkAsRead,
+				NotificationID: "111",
+				Timestamp:      time.Now(),
+				Success:        true,
+			})
+
+			result, err := UndoActionByID(context.Background(), read.ID)
+			if err != nil {
+				t.Fatalf("UndoActionByID() error = %v", err)
+			}
+			if result.NoOp != tc.wantNoOp {
+				t.Errorf("noop = %v, want %v", result.NoOp, tc.wantNoOp)
+			}
+
+			entries := overlay.Entries()
+			if len(entries) != tc.wantEntries {
+				t.Fatalf("got %d overlay entries, want %d", len(entries), tc.wantEntries)
+			}
+			if tc.wantEntries > 0 && (entries[0].ThreadID != "111" || entries[0].Repository != "owner/repo") {
+				t.Errorf("unexpected overlay entry: %+v", entries[0])
+			}
+		})
+	}
+}
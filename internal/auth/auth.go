@@ -23,21 +23,13 @@ var (
 	ErrNotAuthenticated = errors.New("not authenticated")
 )
 
-// init initializes the auth package
-func init() {
-	var err error
-	// Create storage based on configuration
-	storage, err = CreateStorage()
-	if err != nil {
-		// If we can't create storage, we'll initialize it when needed
-		return
-	}
-
-	// Try to load the token
-	token, err := storage.LoadToken()
-	if err == nil && token.Valid() {
-		TokenSource = oauth2.StaticTokenSource(token)
-	}
+// Reload discards the current storage and token, so they are loaded again
+// when next needed, e.g. after a different config file or profile was
+// selected. The token is loaded lazily rather than in init, so the config
+// it depends on is read only after the command line flags are parsed.
+func Reload() {
+	storage = nil
+	TokenSource = nil
 }
 
 // Login performs the GitHub OAuth2 device flow authentication
@@ -63,8 +55,9 @@ func Login(ctx context.Context) error {
 		}
 	}
 
-	// Start the device flow
-	deviceResp, err := InitiateDeviceFlow(clientID, scopes)
+	// Start the device flow on the selected host
+	endpoint := EndpointFor(GetWebURL())
+	deviceResp, err := InitiateDeviceFlowAt(endpoint, clientID, scopes)
 	if err != nil {
 		return fmt.Errorf("failed to initiate device flow: %w", err)
 	}
@@ -86,7 +79,7 @@ func Login(ctx context.Context) error {
 	defer cancel()
 
 	// Poll for the token
-	token, err := PollForToken(timeoutCtx, clientID, deviceResp.DeviceCode, deviceResp.Interval)
+	token, err := PollForTokenAt(timeoutCtx, endpoint, clientID, deviceResp.DeviceCode, deviceResp.Interval)
 	if err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
@@ -102,6 +95,37 @@ func Login(ctx context.Context) error {
 	return nil
 }
 
+// LoginWithToken stores a personal access token instead of using the device flow
+func LoginWithToken(accessToken string) error {
+	if accessToken == "" {
+		return errors.New("token must not be empty")
+	}
+
+	// Initialize storage if needed
+	if storage == nil {
+		var err error
+		storage, err = CreateStorage()
+		if err != nil {
+			return fmt.Errorf("failed to initialize token storage: %w", err)
+		}
+	}
+
+	token := &oauth2.Token{
+		AccessToken: accessToken,
+		TokenType:   "bearer",
+	}
+
+	// Save the token
+	if err := storage.SaveToken(token); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	// Set the token source
+	TokenSource = oauth2.StaticTokenSource(token)
+
+	return nil
+}
+
 // Logout removes the stored credentials
 func Logout() error {
 	TokenSource = nil
@@ -180,9 +204,14 @@ func RefreshToken(ctx context.Context) error {
 	}
 
 	// Create a token source with the refresh token
+	// Enterprise Server hosts have their own token endpoint
+	endpoint := github.Endpoint
+	if webURL := GetWebURL(); webURL != "" && webURL != defaultWebURL {
+		endpoint = EndpointFor(webURL).OAuth2()
+	}
 	config := &oauth2.Config{
 		ClientID: clientID,
-		Endpoint: github.Endpoint,
+		Endpoint: endpoint,
 	}
 
 	// Create a new token source with the refresh token
@@ -243,6 +272,47 @@ func GetClient(ctx context.Context) (*http.Client, error) {
 	return oauth2.NewClient(ctx, TokenSource), nil
 }
 
+// HostStatus checks the authentication status of a host without changing the
+// selected host. An empty host is the host configured by api.base_url.
+func HostStatus(host string) (bool, *oauth2.Token, error) {
+	if host == GetHost() {
+		return Status()
+	}
+
+	hostStorage, err := CreateHostStorage(host)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to initialize token storage: %w", err)
+	}
+
+	token, err := hostStorage.LoadToken()
+	if err != nil {
+		if errors.Is(err, ErrNoToken) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	return token.Valid(), token, nil
+}
+
+// GetHostClient returns an HTTP client with the OAuth2 token of a host, so
+// several hosts can be used at once
+func GetHostClient(ctx context.Context, host string) (*http.Client, error) {
+	if host == GetHost() {
+		return GetClient(ctx)
+	}
+
+	authenticated, token, err := HostStatus(host)
+	if err != nil {
+		return nil, err
+	}
+	if !authenticated {
+		return nil, fmt.Errorf("%s: %w", host, ErrNotAuthenticated)
+	}
+
+	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)), nil
+}
+
 // GetClientOrExit returns an HTTP client or exits if not authenticated
 func GetClientOrExit(ctx context.Context) *http.Client {
 	client, err := GetClient(ctx)
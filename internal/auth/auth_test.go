@@ -212,6 +212,63 @@ func TestLogout(t *testing.T) {
 	}
 }
 
+func TestLoginWithToken(t *testing.T) {
+	// Save original values to restore after test
+	originalTokenSource := TokenSource
+	originalStorage := storage
+	defer func() {
+		TokenSource = originalTokenSource
+		storage = originalStorage
+	}()
+
+	var saved *oauth2.Token
+	storage = &MockStorage{
+		saveTokenFunc: func(token *oauth2.Token) error {
+			saved = token
+			return nil
+		},
+	}
+	TokenSource = nil
+
+	if err := LoginWithToken(""); err == nil {
+		t.Errorf("LoginWithToken() with empty token should fail")
+	}
+
+	if err := LoginWithToken("ghp_test"); err != nil {
+		t.Fatalf("LoginWithToken() error = %v", err)
+	}
+	if saved == nil || saved.AccessToken != "ghp_test" {
+		t.Errorf("LoginWithToken() saved token = %v, want access token ghp_test", saved)
+	}
+	if TokenSource == nil {
+		t.Fatalf("LoginWithToken() did not set TokenSource")
+	}
+	token, err := TokenSource.Token()
+	if err != nil || !token.Valid() {
+		t.Errorf("LoginWithToken() token source returned %v, %v", token, err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	// Save original values to restore after test
+	originalTokenSource := TokenSource
+	originalStorage := storage
+	defer func() {
+		TokenSource = originalTokenSource
+		storage = originalStorage
+	}()
+
+	storage = &MockStorage{}
+	TokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "old"})
+
+	// Reloading only discards the token and storage; the config is read
+	// and the token loaded again on the next use
+	Reload()
+	if TokenSource != nil || storage != nil {
+		t.Errorf("Reload() kept the token source or storage")
+	}
+}
+
 func TestGetClient(t *testing.T) {
 	// Save original values to restore after test
 	originalTokenSource := TokenSource
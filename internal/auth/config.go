@@ -18,6 +18,13 @@ type AuthConfig struct {
 	// TokenStorage defines how to store the OAuth token
 	// Options: "keyring", "file", "auto"
 	TokenStorage string
+
+	// Host is the selected GitHub Enterprise Server host, empty for the host
+	// configured by api.base_url
+	Host string
+
+	// WebURL is the web URL of the selected host, which serves the device flow
+	WebURL string
 }
 
 // GetAuthConfigFunc is the function type for GetAuthConfig
@@ -38,13 +45,20 @@ var GetAuthConfig GetAuthConfigFunc = func() AuthConfig {
 	// Get the config
 	cfg := cm.GetConfig()
 
-	// Return the auth config
-	return AuthConfig{
-		ClientID:     cfg.Auth.ClientID,
+	// Each host has its own OAuth app and token
+	host := cfg.ResolveHost(config.Host())
+	authConfig := AuthConfig{
+		ClientID:     host.ClientID,
 		ClientSecret: cfg.Auth.ClientSecret,
-		Scopes:       cfg.Auth.Scopes,
+		Scopes:       host.Scopes,
 		TokenStorage: cfg.Auth.TokenStorage,
+		WebURL:       host.WebURL(),
+	}
+	if !host.Default {
+		authConfig.Host = host.Host
 	}
+
+	return authConfig
 }
 
 // SaveAuthConfigFunc is the function type for SaveAuthConfig
@@ -103,26 +117,48 @@ var GetTokenStorage GetTokenStorageFunc = func() string {
 	return GetAuthConfig().TokenStorage
 }
 
+// GetHostFunc is the function type for GetHost
+type GetHostFunc func() string
+
+// GetHost returns the selected host, empty for the host configured by api.base_url
+var GetHost GetHostFunc = func() string {
+	return GetAuthConfig().Host
+}
+
+// GetWebURLFunc is the function type for GetWebURL
+type GetWebURLFunc func() string
+
+// GetWebURL returns the web URL of the selected host
+var GetWebURL GetWebURLFunc = func() string {
+	return GetAuthConfig().WebURL
+}
+
 // CreateStorage creates a storage implementation based on the configuration
 func CreateStorage() (Storage, error) {
+	return CreateHostStorage(GetHost())
+}
+
+// CreateHostStorage creates a storage implementation for the token of a host.
+// An empty host selects the token of the host configured by api.base_url.
+func CreateHostStorage(host string) (Storage, error) {
 	tokenStorage := GetTokenStorage()
 
 	switch tokenStorage {
 	case "keyring":
-		return &KeyringStorage{}, nil
+		return &KeyringStorage{profile: config.Profile(), host: host}, nil
 	case "file":
-		return NewFileStorage()
+		return NewHostFileStorage(host)
 	case "auto":
 		// Try keyring first
-		keyringStorage := &KeyringStorage{}
+		keyringStorage := &KeyringStorage{profile: config.Profile(), host: host}
 		if _, err := keyringStorage.LoadToken(); err == nil {
 			return keyringStorage, nil
 		}
 
 		// Fall back to file storage
-		return NewFileStorage()
+		return NewHostFileStorage(host)
 	default:
 		// Default to file storage
-		return NewFileStorage()
+		return NewHostFileStorage(host)
 	}
 }
@@ -15,11 +15,38 @@ import (
 	"golang.org/x/oauth2"
 )
 
-const (
-	// GitHub Device Flow endpoints
-	deviceCodeURL = "https://github.com/login/device/code"
-	tokenURL      = "https://github.com/login/oauth/access_token"
-)
+// defaultWebURL is the web URL of github.com
+const defaultWebURL = "https://github.com"
+
+// Endpoint holds the OAuth endpoints of a GitHub host
+type Endpoint struct {
+	// DeviceCodeURL starts the device flow
+	DeviceCodeURL string
+	// TokenURL exchanges device codes and refresh tokens for access tokens
+	TokenURL string
+	// AuthURL is the authorization URL of the host
+	AuthURL string
+}
+
+// EndpointFor returns the OAuth endpoints of a host given its web URL, such
+// as https://ghe.corp.example. An empty URL selects github.com.
+func EndpointFor(webURL string) Endpoint {
+	if webURL == "" {
+		webURL = defaultWebURL
+	}
+	webURL = strings.TrimSuffix(webURL, "/")
+
+	return Endpoint{
+		DeviceCodeURL: webURL + "/login/device/code",
+		TokenURL:      webURL + "/login/oauth/access_token",
+		AuthURL:       webURL + "/login/oauth/authorize",
+	}
+}
+
+// OAuth2 returns the endpoint in the form used by the oauth2 package
+func (e Endpoint) OAuth2() oauth2.Endpoint {
+	return oauth2.Endpoint{AuthURL: e.AuthURL, TokenURL: e.TokenURL}
+}
 
 // DeviceFlowResponse represents the response from the device flow initiation
 type DeviceFlowResponse struct {
@@ -36,15 +63,20 @@ type DeviceFlowError struct {
 	ErrorDescription string `json:"error_description"`
 }
 
-// InitiateDeviceFlow starts the GitHub OAuth device flow
+// InitiateDeviceFlow starts the GitHub OAuth device flow on github.com
 func InitiateDeviceFlow(clientID string, scopes []string) (*DeviceFlowResponse, error) {
+	return InitiateDeviceFlowAt(EndpointFor(""), clientID, scopes)
+}
+
+// InitiateDeviceFlowAt starts the OAuth device flow on a GitHub host
+func InitiateDeviceFlowAt(endpoint Endpoint, clientID string, scopes []string) (*DeviceFlowResponse, error) {
 	// Prepare request data
 	data := url.Values{}
 	data.Set("client_id", clientID)
 	data.Set("scope", strings.Join(scopes, " "))
 
 	// Create request
-	req, err := http.NewRequest("POST", deviceCodeURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequest("POST", endpoint.DeviceCodeURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -83,8 +115,13 @@ func InitiateDeviceFlow(clientID string, scopes []string) (*DeviceFlowResponse,
 	return &deviceResp, nil
 }
 
-// PollForToken polls GitHub for an access token using the device code
+// PollForToken polls github.com for an access token using the device code
 func PollForToken(ctx context.Context, clientID, deviceCode string, interval int) (*oauth2.Token, error) {
+	return PollForTokenAt(ctx, EndpointFor(""), clientID, deviceCode, interval)
+}
+
+// PollForTokenAt polls a GitHub host for an access token using the device code
+func PollForTokenAt(ctx context.Context, endpoint Endpoint, clientID, deviceCode string, interval int) (*oauth2.Token, error) {
 	// Prepare request data
 	data := url.Values{}
 	data.Set("client_id", clientID)
@@ -100,7 +137,7 @@ func PollForToken(ctx context.Context, clientID, deviceCode string, interval int
 			return nil, ctx.Err()
 		case <-ticker.C:
 			// Create request
-			req, err := http.NewRequest("POST", tokenURL, strings.NewReader(data.Encode()))
+			req, err := http.NewRequest("POST", endpoint.TokenURL, strings.NewReader(data.Encode()))
 			if err != nil {
 				return nil, fmt.Errorf("failed to create request: %w", err)
 			}
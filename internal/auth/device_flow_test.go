@@ -431,3 +431,44 @@ func TestLogin(t *testing.T) {
 func contains(s, substr string) bool {
 	return s != "" && s != substr && len(s) >= len(substr) && s[0:len(substr)] == substr
 }
+
+func TestDeviceFlowAtHost(t *testing.T) {
+	if got := EndpointFor(""); got.DeviceCodeURL != "https://github.com/login/device/code" {
+		t.Errorf("EndpointFor(\"\").DeviceCodeURL = %q", got.DeviceCodeURL)
+	}
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login/device/code":
+			json.NewEncoder(w).Encode(DeviceFlowResponse{DeviceCode: "device", UserCode: "ABCD-1234", Interval: 1, ExpiresIn: 60})
+		case "/login/oauth/access_token":
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "ghe-token", "token_type": "bearer"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := EndpointFor(server.URL + "/")
+	resp, err := InitiateDeviceFlowAt(endpoint, "ghe-client", []string{"notifications"})
+	if err != nil {
+		t.Fatalf("InitiateDeviceFlowAt() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	token, err := PollForTokenAt(ctx, endpoint, "ghe-client", resp.DeviceCode, resp.Interval)
+	if err != nil {
+		t.Fatalf("PollForTokenAt() error = %v", err)
+	}
+	if token.AccessToken != "ghe-token" {
+		t.Errorf("AccessToken = %q, want ghe-token", token.AccessToken)
+	}
+
+	if strings.Join(paths, ",") != "/login/device/code,/login/oauth/access_token" {
+		t.Errorf("requests = %v, want the device flow endpoints of the host", paths)
+	}
+}
@@ -2,13 +2,16 @@ package auth
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/SharanRP/gh-notif/internal/config"
 	"github.com/zalando/go-keyring"
 	"golang.org/x/crypto/nacl/secretbox"
 	"golang.org/x/oauth2"
@@ -21,8 +24,12 @@ const (
 	username = "github-user"
 	// File name for encrypted token
 	encryptedTokenFile = ".gh-notif-token.enc"
+	// Base name of the encrypted token file, before the profile suffix
+	encryptedTokenBase = ".gh-notif-token"
 	// Key file name
 	keyFile = ".gh-notif-key"
+	// Username of the cache encryption key in the keyring
+	cacheKeyAccount = "cache-key"
 )
 
 var (
@@ -40,7 +47,27 @@ type Storage interface {
 }
 
 // KeyringStorage implements Storage using the system keyring
-type KeyringStorage struct{}
+type KeyringStorage struct {
+	// profile selects the keyring entry, empty for the default profile
+	profile string
+	// host selects the keyring entry, empty for the default host
+	host string
+}
+
+// account returns the keyring user name for the storage profile and host
+func (s *KeyringStorage) account() string {
+	return config.ProfileFileName(username, s.profile) + hostSuffix(s.host)
+}
+
+// hostSuffix returns the suffix that keeps the tokens of hosts apart, empty
+// for the default host so its token keeps its original name
+func hostSuffix(host string) string {
+	if host == "" {
+		return ""
+	}
+	// Ports are separated with a colon, which file names cannot contain everywhere
+	return "@" + strings.ReplaceAll(host, ":", "_")
+}
 
 // SaveToken saves the token to the system keyring
 func (s *KeyringStorage) SaveToken(token *oauth2.Token) error {
@@ -49,7 +76,7 @@ func (s *KeyringStorage) SaveToken(token *oauth2.Token) error {
 		return fmt.Errorf("failed to marshal token: %w", err)
 	}
 
-	err = keyring.Set(serviceName, username, string(data))
+	err = keyring.Set(serviceName, s.account(), string(data))
 	if err != nil {
 		return fmt.Errorf("failed to save token to keyring: %w", err)
 	}
@@ -59,7 +86,7 @@ func (s *KeyringStorage) SaveToken(token *oauth2.Token) error {
 
 // LoadToken loads the token from the system keyring
 func (s *KeyringStorage) LoadToken() (*oauth2.Token, error) {
-	data, err := keyring.Get(serviceName, username)
+	data, err := keyring.Get(serviceName, s.account())
 	if err != nil {
 		if err == keyring.ErrNotFound {
 			return nil, ErrNoToken
@@ -77,7 +104,7 @@ func (s *KeyringStorage) LoadToken() (*oauth2.Token, error) {
 
 // DeleteToken deletes the token from the system keyring
 func (s *KeyringStorage) DeleteToken() error {
-	err := keyring.Delete(serviceName, username)
+	err := keyring.Delete(serviceName, s.account())
 	if err != nil && err != keyring.ErrNotFound {
 		return fmt.Errorf("failed to delete token from keyring: %w", err)
 	}
@@ -92,6 +119,12 @@ type FileStorage struct {
 
 // NewFileStorage creates a new FileStorage
 func NewFileStorage() (*FileStorage, error) {
+	return NewHostFileStorage("")
+}
+
+// NewHostFileStorage creates a FileStorage for the token of a host, empty for
+// the default host
+func NewHostFileStorage(host string) (*FileStorage, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
@@ -99,10 +132,15 @@ func NewFileStorage() (*FileStorage, error) {
 
 	return &FileStorage{
 		keyPath:  filepath.Join(home, keyFile),
-		filePath: filepath.Join(home, encryptedTokenFile),
+		filePath: filepath.Join(home, tokenFileName(config.Profile(), host)),
 	}, nil
 }
 
+// tokenFileName returns the encrypted token file name for a profile and host
+func tokenFileName(profile, host string) string {
+	return config.ProfileFileName(encryptedTokenBase, profile) + hostSuffix(host) + ".enc"
+}
+
 // SaveToken saves the token to an encrypted file
 func (s *FileStorage) SaveToken(token *oauth2.Token) error {
 	// Marshal token to JSON
@@ -232,6 +270,52 @@ func (s *FileStorage) getKey() ([32]byte, error) {
 	return key, nil
 }
 
+// CacheKey returns the key that encrypts the cache at rest, creating it on
+// first use. It is kept where the token is: in the system keyring, or in the
+// key file that also encrypts the token file.
+func CacheKey() ([32]byte, error) {
+	switch GetTokenStorage() {
+	case "keyring":
+		return keyringCacheKey()
+	case "auto":
+		if key, err := keyringCacheKey(); err == nil {
+			return key, nil
+		}
+	}
+
+	storage, err := NewFileStorage()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return storage.getOrCreateKey()
+}
+
+// keyringCacheKey gets the cache key from the system keyring or creates it
+func keyringCacheKey() ([32]byte, error) {
+	var key [32]byte
+
+	encoded, err := keyring.Get(serviceName, cacheKeyAccount)
+	if err == nil {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(decoded) != len(key) {
+			return key, fmt.Errorf("invalid cache key in keyring")
+		}
+		copy(key[:], decoded)
+		return key, nil
+	}
+	if err != keyring.ErrNotFound {
+		return key, fmt.Errorf("failed to load cache key from keyring: %w", err)
+	}
+
+	if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+		return key, fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := keyring.Set(serviceName, cacheKeyAccount, base64.StdEncoding.EncodeToString(key[:])); err != nil {
+		return key, fmt.Errorf("failed to save cache key to keyring: %w", err)
+	}
+	return key, nil
+}
+
 // encrypt encrypts data using NaCl secretbox
 func encrypt(data []byte, key [32]byte) ([]byte, error) {
 	var nonce [24]byte
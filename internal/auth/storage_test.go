@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/SharanRP/gh-notif/internal/testutil"
+	"github.com/zalando/go-keyring"
 	"golang.org/x/oauth2"
 )
 
@@ -224,3 +225,83 @@ func getType(v interface{}) string {
 		return "unknown"
 	}
 }
+
+func TestStorageProfiles(t *testing.T) {
+	if got := tokenFileName("", ""); got != encryptedTokenFile {
+		t.Errorf("tokenFileName(\"\", \"\") = %q, want %q", got, encryptedTokenFile)
+	}
+
+	if got := tokenFileName("work", ""); got != ".gh-notif-token-work.enc" {
+		t.Errorf("tokenFileName(\"work\", \"\") = %q, want %q", got, ".gh-notif-token-work.enc")
+	}
+
+	if got := (&KeyringStorage{}).account(); got != username {
+		t.Errorf("account() = %q, want %q", got, username)
+	}
+
+	if got := (&KeyringStorage{profile: "work"}).account(); got != username+"-work" {
+		t.Errorf("account() = %q, want %q", got, username+"-work")
+	}
+}
+
+func TestStorageHosts(t *testing.T) {
+	if got := tokenFileName("", "ghe.corp.example"); got != ".gh-notif-token@ghe.corp.example.enc" {
+		t.Errorf("tokenFileName(\"\", host) = %q, want %q", got, ".gh-notif-token@ghe.corp.example.enc")
+	}
+
+	if got := tokenFileName("work", "ghe.corp.example:8443"); got != ".gh-notif-token-work@ghe.corp.example_8443.enc" {
+		t.Errorf("tokenFileName(\"work\", host) = %q, want %q", got, ".gh-notif-token-work@ghe.corp.example_8443.enc")
+	}
+
+	if got := (&KeyringStorage{profile: "work", host: "ghe.corp.example"}).account(); got != username+"-work@ghe.corp.example" {
+		t.Errorf("account() = %q, want %q", got, username+"-work@ghe.corp.example")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	originalStorage := GetTokenStorage
+	defer func() { GetTokenStorage = originalStorage }()
+
+	t.Run("file", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		GetTokenStorage = func() string { return "file" }
+
+		key, err := CacheKey()
+		if err != nil {
+			t.Fatalf("CacheKey() error = %v", err)
+		}
+		if key == [32]byte{} {
+			t.Error("CacheKey() returned an empty key")
+		}
+
+		// The key is shared with the token file and stable across calls
+		again, err := CacheKey()
+		if err != nil {
+			t.Fatalf("CacheKey() error = %v", err)
+		}
+		if again != key {
+			t.Error("CacheKey() returned a different key on the second call")
+		}
+		data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), keyFile))
+		if err != nil || string(data) != string(key[:]) {
+			t.Errorf("key file does not hold the cache key: %v", err)
+		}
+	})
+
+	t.Run("keyring", func(t *testing.T) {
+		keyring.MockInit()
+		GetTokenStorage = func() string { return "keyring" }
+
+		key, err := CacheKey()
+		if err != nil {
+			t.Fatalf("CacheKey() error = %v", err)
+		}
+		again, err := CacheKey()
+		if err != nil {
+			t.Fatalf("CacheKey() error = %v", err)
+		}
+		if again != key || key == [32]byte{} {
+			t.Error("CacheKey() should return the same non-empty key from the keyring")
+		}
+	})
+}
@@ -2,9 +2,9 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,6 +20,8 @@ type badgerCache struct {
 	mu           sync.RWMutex
 	options      *Options
 	metrics      *Metrics
+	sealer       *sealer
+	lru          *lruIndex
 	ctx          context.Context
 	cancel       context.CancelFunc
 }
@@ -48,8 +50,18 @@ func NewBadgerCache(opts *Options) (Cache, error) {
 		badgerOpts.MemTableSize = int64(opts.MemoryLimit / 4)
 	}
 
-	// Compression settings
+	// Compression settings; values sealed by the cache itself are compressed
+	// or encrypted already, so compressing the blocks again gains nothing
 	badgerOpts.Compression = options.Snappy
+	if opts.EnableCompression || opts.EnableEncryption {
+		badgerOpts.Compression = options.None
+	}
+
+	metrics := NewMetrics()
+	sealer, err := newSealer(opts, metrics)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set appropriate values based on expected usage
 	if opts.ReadOnly {
@@ -59,6 +71,7 @@ func NewBadgerCache(opts *Options) (Cache, error) {
 	// Open the database
 	db, err := badger.Open(badgerOpts)
 	if err != nil {
+		sealer.close()
 		return nil, fmt.Errorf("failed to open BadgerDB: %w", err)
 	}
 
@@ -70,11 +83,20 @@ func NewBadgerCache(opts *Options) (Cache, error) {
 		db:           db,
 		prefetchChan: make(chan string, 100),
 		options:      opts,
-		metrics:      NewMetrics(),
+		metrics:      metrics,
+		sealer:       sealer,
+		lru:          newLRUIndex(),
 		ctx:          ctx,
 		cancel:       cancel,
 	}
 
+	if err := cache.loadIndex(); err != nil {
+		cancel()
+		db.Close()
+		sealer.close()
+		return nil, err
+	}
+
 	// Start background processes
 	if !opts.ReadOnly {
 		go cache.runGC(ctx)
@@ -88,9 +110,69 @@ func NewBadgerCache(opts *Options) (Cache, error) {
 func (c *badgerCache) Close() error {
 	c.cancel()
 	c.prefetchWg.Wait()
+	c.sealer.close()
 	return c.db.Close()
 }
 
+// loadIndex builds the LRU index from the stored items. Access times are not
+// stored, so the items expiring first are considered least recently used.
+func (c *badgerCache) loadIndex() error {
+	type stored struct {
+		key       string
+		size      int64
+		expiresAt uint64
+	}
+	var items []stored
+
+	err := c.db.View(func(txn *badger.Txn) error {
+		itOpts := badger.DefaultIteratorOptions
+		itOpts.PrefetchValues = false
+		it := txn.NewIterator(itOpts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := item.KeyCopy(nil)
+			items = append(items, stored{
+				key:       string(key),
+				size:      int64(len(key)) + item.ValueSize(),
+				expiresAt: item.ExpiresAt(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index BadgerDB: %w", err)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].expiresAt < items[j].expiresAt })
+	for _, item := range items {
+		c.lru.add(item.key, item.size)
+	}
+	c.lru.report(c.metrics)
+	return nil
+}
+
+// evict removes the least recently used items beyond the maximum size
+func (c *badgerCache) evict() {
+	evicted := c.lru.evict(c.options.MaxSize)
+	if len(evicted) > 0 {
+		err := c.db.Update(func(txn *badger.Txn) error {
+			for _, key := range evicted {
+				if err := txn.Delete([]byte(key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.metrics.Inc(&c.metrics.Errors)
+		}
+		c.metrics.Add(&c.metrics.Evictions, int64(len(evicted)))
+	}
+	c.lru.report(c.metrics)
+}
+
 // Get retrieves a value from the cache
 func (c *badgerCache) Get(key string) (interface{}, bool) {
 	c.metrics.Inc(&c.metrics.Gets)
@@ -107,17 +189,29 @@ func (c *badgerCache) Get(key string) (interface{}, bool) {
 	})
 
 	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			// The item may have expired
+			c.lru.remove(key)
+		}
 		c.metrics.Inc(&c.metrics.Misses)
 		return nil, false
 	}
 
-	// Unmarshal the value
-	var result interface{}
-	if err := json.Unmarshal(value, &result); err != nil {
+	// Decrypt and decompress the value
+	value, err = c.sealer.open(value)
+	if err != nil {
+		c.metrics.Inc(&c.metrics.Errors)
+		return nil, false
+	}
+
+	// Decode the value into its original type
+	result, err := decodeValue(value)
+	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 		return nil, false
 	}
 
+	c.lru.touch(key)
 	c.metrics.Inc(&c.metrics.Hits)
 	return result, true
 }
@@ -126,8 +220,11 @@ func (c *badgerCache) Get(key string) (interface{}, bool) {
 func (c *badgerCache) Set(key string, value interface{}, ttl time.Duration) {
 	c.metrics.Inc(&c.metrics.Sets)
 
-	// Marshal the value
-	data, err := json.Marshal(value)
+	// Encode the value with its type, then compress and encrypt it
+	data, err := encodeValue(value)
+	if err == nil {
+		data, err = c.sealer.seal(data)
+	}
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 		return
@@ -141,7 +238,11 @@ func (c *badgerCache) Set(key string, value interface{}, ttl time.Duration) {
 
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
+		return
 	}
+
+	c.lru.add(key, int64(len(key)+len(data)))
+	c.evict()
 }
 
 // Delete removes a value from the cache
@@ -155,6 +256,9 @@ func (c *badgerCache) Delete(key string) {
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 	}
+
+	c.lru.remove(key)
+	c.lru.report(c.metrics)
 }
 
 // Clear removes all items from the cache
@@ -166,6 +270,14 @@ func (c *badgerCache) Clear() {
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 	}
+
+	c.lru.clear()
+	c.lru.report(c.metrics)
+}
+
+// Keys returns the keys of the cached items
+func (c *badgerCache) Keys() []string {
+	return c.lru.keys()
 }
 
 // Prefetch queues a key for background prefetching
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -21,10 +22,21 @@ type boltCache struct {
 	mu           sync.RWMutex
 	options      *Options
 	metrics      *Metrics
+	sealer       *sealer
+	lru          *lruIndex
 	ctx          context.Context
 	cancel       context.CancelFunc
 }
 
+// boltItem is a value stored in BoltDB with its expiration
+type boltItem struct {
+	// Data is the sealed value, see sealer
+	Data []byte `json:"d,omitempty"`
+	// Value is the plain JSON value of items written before values were sealed
+	Value      json.RawMessage `json:"v,omitempty"`
+	Expiration int64           `json:"e"`
+}
+
 // NewBoltCache creates a new BoltDB-backed cache
 func NewBoltCache(opts *Options) (Cache, error) {
 	if opts == nil {
@@ -44,10 +56,17 @@ func NewBoltCache(opts *Options) (Cache, error) {
 		FreelistType: bbolt.FreelistMapType,
 	}
 
+	metrics := NewMetrics()
+	sealer, err := newSealer(opts, metrics)
+	if err != nil {
+		return nil, err
+	}
+
 	// Open the database
 	dbPath := filepath.Join(opts.CacheDir, "bolt-cache.db")
 	db, err := bbolt.Open(dbPath, 0600, boltOpts)
 	if err != nil {
+		sealer.close()
 		return nil, fmt.Errorf("failed to open BoltDB: %w", err)
 	}
 
@@ -60,6 +79,7 @@ func NewBoltCache(opts *Options) (Cache, error) {
 		})
 		if err != nil {
 			db.Close()
+			sealer.close()
 			return nil, fmt.Errorf("failed to create bucket: %w", err)
 		}
 	}
@@ -73,11 +93,20 @@ func NewBoltCache(opts *Options) (Cache, error) {
 		bucketName:   bucketName,
 		prefetchChan: make(chan string, 100),
 		options:      opts,
-		metrics:      NewMetrics(),
+		metrics:      metrics,
+		sealer:       sealer,
+		lru:          newLRUIndex(),
 		ctx:          ctx,
 		cancel:       cancel,
 	}
 
+	if err := cache.loadIndex(); err != nil {
+		cancel()
+		db.Close()
+		sealer.close()
+		return nil, err
+	}
+
 	// Start background processes
 	if !opts.ReadOnly {
 		go cache.processPrefetchQueue(ctx)
@@ -90,9 +119,75 @@ func NewBoltCache(opts *Options) (Cache, error) {
 func (c *boltCache) Close() error {
 	c.cancel()
 	c.prefetchWg.Wait()
+	c.sealer.close()
 	return c.db.Close()
 }
 
+// loadIndex builds the LRU index from the stored items. Access times are not
+// stored, so the items expiring first are considered least recently used.
+func (c *boltCache) loadIndex() error {
+	type stored struct {
+		key        string
+		size       int64
+		expiration int64
+	}
+	var items []stored
+
+	err := c.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(c.bucketName)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var item struct {
+				Expiration int64 `json:"e"`
+			}
+			// Items that cannot be parsed are indexed as expiring first
+			_ = json.Unmarshal(v, &item)
+			items = append(items, stored{
+				key:        string(k),
+				size:       int64(len(k) + len(v)),
+				expiration: item.Expiration,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index BoltDB: %w", err)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].expiration < items[j].expiration })
+	for _, item := range items {
+		c.lru.add(item.key, item.size)
+	}
+	c.lru.report(c.metrics)
+	return nil
+}
+
+// evict removes the least recently used items beyond the maximum size
+func (c *boltCache) evict() {
+	evicted := c.lru.evict(c.options.MaxSize)
+	if len(evicted) > 0 {
+		err := c.db.Update(func(tx *bbolt.Tx) error {
+			bucket := tx.Bucket(c.bucketName)
+			if bucket == nil {
+				return fmt.Errorf("bucket not found")
+			}
+			for _, key := range evicted {
+				if err := bucket.Delete([]byte(key)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.metrics.Inc(&c.metrics.Errors)
+		}
+		c.metrics.Add(&c.metrics.Evictions, int64(len(evicted)))
+	}
+	c.lru.report(c.metrics)
+}
+
 // Get retrieves a value from the cache
 func (c *boltCache) Get(key string) (interface{}, bool) {
 	c.metrics.Inc(&c.metrics.Gets)
@@ -120,11 +215,8 @@ func (c *boltCache) Get(key string) (interface{}, bool) {
 		return nil, false
 	}
 
-	// Unmarshal the value
-	var item struct {
-		Value      interface{} `json:"v"`
-		Expiration int64       `json:"e"`
-	}
+	// Unmarshal the item
+	var item boltItem
 	if err := json.Unmarshal(value, &item); err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 		return nil, false
@@ -137,8 +229,27 @@ func (c *boltCache) Get(key string) (interface{}, bool) {
 		return nil, false
 	}
 
+	// Decrypt and decompress the value
+	sealed := item.Data
+	if sealed == nil {
+		sealed = item.Value
+	}
+	data, err := c.sealer.open(sealed)
+	if err != nil {
+		c.metrics.Inc(&c.metrics.Errors)
+		return nil, false
+	}
+
+	// Decode the value into its original type
+	result, err := decodeValue(data)
+	if err != nil {
+		c.metrics.Inc(&c.metrics.Errors)
+		return nil, false
+	}
+
+	c.lru.touch(key)
 	c.metrics.Inc(&c.metrics.Hits)
-	return item.Value, true
+	return result, true
 }
 
 // Set adds a value to the cache with the given TTL
@@ -151,16 +262,18 @@ func (c *boltCache) Set(key string, value interface{}, ttl time.Duration) {
 		expiration = time.Now().Add(ttl).Unix()
 	}
 
-	// Marshal the value with expiration
-	item := struct {
-		Value      interface{} `json:"v"`
-		Expiration int64       `json:"e"`
-	}{
-		Value:      value,
-		Expiration: expiration,
+	// Encode the value with its type, then compress and encrypt it
+	encoded, err := encodeValue(value)
+	if err == nil {
+		encoded, err = c.sealer.seal(encoded)
+	}
+	if err != nil {
+		c.metrics.Inc(&c.metrics.Errors)
+		return
 	}
 
-	data, err := json.Marshal(item)
+	// Marshal the value with expiration
+	data, err := json.Marshal(boltItem{Data: encoded, Expiration: expiration})
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 		return
@@ -177,7 +290,11 @@ func (c *boltCache) Set(key string, value interface{}, ttl time.Duration) {
 
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
+		return
 	}
+
+	c.lru.add(key, int64(len(key)+len(data)))
+	c.evict()
 }
 
 // Delete removes a value from the cache
@@ -195,6 +312,9 @@ func (c *boltCache) Delete(key string) {
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 	}
+
+	c.lru.remove(key)
+	c.lru.report(c.metrics)
 }
 
 // Clear removes all items from the cache
@@ -212,6 +332,14 @@ func (c *boltCache) Clear() {
 	if err != nil {
 		c.metrics.Inc(&c.metrics.Errors)
 	}
+
+	c.lru.clear()
+	c.lru.report(c.metrics)
+}
+
+// Keys returns the keys of the cached items
+func (c *boltCache) Keys() []string {
+	return c.lru.keys()
 }
 
 // Prefetch queues a key for background prefetching
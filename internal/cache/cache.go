@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -19,6 +21,9 @@ type Cache interface {
 	// Clear removes all items from the cache
 	Clear()
 
+	// Keys returns the keys of the cached items
+	Keys() []string
+
 	// Prefetch queues a key for background prefetching
 	Prefetch(key string)
 
@@ -90,6 +95,11 @@ func (c *nullCache) Clear() {
 	// No-op
 }
 
+// Keys returns no keys
+func (c *nullCache) Keys() []string {
+	return nil
+}
+
 // Prefetch does nothing
 func (c *nullCache) Prefetch(key string) {
 	// No-op
@@ -107,7 +117,9 @@ func (c *nullCache) GetMetrics() *Metrics {
 
 // memoryCache is an in-memory cache implementation
 type memoryCache struct {
+	mu      sync.Mutex
 	items   map[string]*cacheItem
+	lru     *lruIndex
 	options *Options
 	metrics *Metrics
 }
@@ -127,6 +139,7 @@ func NewMemoryCache(opts *Options) Cache {
 
 	return &memoryCache{
 		items:   make(map[string]*cacheItem),
+		lru:     newLRUIndex(),
 		options: opts,
 		metrics: NewMetrics(),
 	}
@@ -136,6 +149,9 @@ func NewMemoryCache(opts *Options) Cache {
 func (c *memoryCache) Get(key string) (interface{}, bool) {
 	c.metrics.Inc(&c.metrics.Gets)
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	item, found := c.items[key]
 	if !found {
 		c.metrics.Inc(&c.metrics.Misses)
@@ -144,11 +160,12 @@ func (c *memoryCache) Get(key string) (interface{}, bool) {
 
 	// Check if the item has expired
 	if time.Now().After(item.Expiration) {
-		delete(c.items, key)
+		c.deleteItem(key)
 		c.metrics.Inc(&c.metrics.Misses)
 		return nil, false
 	}
 
+	c.lru.touch(key)
 	c.metrics.Inc(&c.metrics.Hits)
 	return item.Value, true
 }
@@ -157,22 +174,61 @@ func (c *memoryCache) Get(key string) (interface{}, bool) {
 func (c *memoryCache) Set(key string, value interface{}, ttl time.Duration) {
 	c.metrics.Inc(&c.metrics.Sets)
 
+	size := estimateSize(key, value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.items[key] = &cacheItem{
 		Value:      value,
 		Expiration: time.Now().Add(ttl),
+		Size:       size,
+	}
+	c.lru.add(key, size)
+
+	// Evict the least recently used items beyond the memory limit
+	limit := c.options.MemoryLimit
+	if limit <= 0 {
+		limit = c.options.MaxSize
+	}
+	evicted := c.lru.evict(limit)
+	for _, k := range evicted {
+		delete(c.items, k)
 	}
+	c.metrics.Add(&c.metrics.Evictions, int64(len(evicted)))
+	c.lru.report(c.metrics)
 }
 
 // Delete removes a value from the cache
 func (c *memoryCache) Delete(key string) {
 	c.metrics.Inc(&c.metrics.Deletes)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteItem(key)
+}
+
+// deleteItem removes an item, with the lock held
+func (c *memoryCache) deleteItem(key string) {
 	delete(c.items, key)
+	c.lru.remove(key)
+	c.lru.report(c.metrics)
 }
 
 // Clear removes all items from the cache
 func (c *memoryCache) Clear() {
 	c.metrics.Inc(&c.metrics.Clears)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.items = make(map[string]*cacheItem)
+	c.lru.clear()
+	c.lru.report(c.metrics)
+}
+
+// Keys returns the keys of the cached items
+func (c *memoryCache) Keys() []string {
+	return c.lru.keys()
 }
 
 // Prefetch does nothing for memory cache
@@ -189,3 +245,13 @@ func (c *memoryCache) Close() error {
 func (c *memoryCache) GetMetrics() *Metrics {
 	return c.metrics
 }
+
+// estimateSize estimates the memory used by a cached item from the size of
+// its JSON encoding
+func estimateSize(key string, value interface{}) int64 {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return int64(len(key))
+	}
+	return int64(len(key) + len(data))
+}
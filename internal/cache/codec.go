@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// codecTypes maps type names to the types registered with Register
+var (
+	codecMu    sync.RWMutex
+	codecTypes = make(map[string]reflect.Type)
+)
+
+// Register registers a value type with the codec of the persistent caches,
+// so values of that type are returned as the same type after they were
+// stored on disk. Values of unregistered types come back as the generic
+// result of decoding JSON.
+func Register[T any]() {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecTypes[typeName(t)] = t
+}
+
+// typeName returns a name identifying a type across runs, qualifying named
+// types with their package path
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeName(t.Elem()))
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	default:
+		return t.String()
+	}
+}
+
+// envelope is the encoding of a cached value, which records its type
+type envelope struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// encodeValue encodes a value for a persistent cache
+func encodeValue(value interface{}) ([]byte, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cache value: %w", err)
+	}
+
+	name := ""
+	if value != nil {
+		name = typeName(reflect.TypeOf(value))
+	}
+
+	return json.Marshal(envelope{Type: name, Value: raw})
+}
+
+// decodeValue decodes a value encoded by encodeValue into its registered
+// type. Values of unregistered types and values written before types were
+// recorded are decoded generically.
+func decodeValue(data []byte) (interface{}, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Value == nil {
+		var result interface{}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode cache value: %w", err)
+		}
+		return result, nil
+	}
+
+	codecMu.RLock()
+	t, ok := codecTypes[env.Type]
+	codecMu.RUnlock()
+
+	if !ok {
+		var result interface{}
+		if err := json.Unmarshal(env.Value, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode cache value: %w", err)
+		}
+		return result, nil
+	}
+
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(env.Value, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode cache value of type %s: %w", env.Type, err)
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// Store is the part of a cache used to read and write values. It is
+// implemented by every Cache and by Manager.
+type Store interface {
+	// Get retrieves a value
+	Get(key string) (interface{}, bool)
+
+	// Set adds a value with the given TTL
+	Set(key string, value interface{}, ttl time.Duration)
+
+	// Delete removes a value
+	Delete(key string)
+}
+
+// Typed is a view of a cache holding values of type T
+type Typed[T any] struct {
+	store Store
+}
+
+// NewTyped creates a typed view of a cache, registering T with the codec
+func NewTyped[T any](store Store) *Typed[T] {
+	Register[T]()
+	return &Typed[T]{store: store}
+}
+
+// Get retrieves a value, reporting a miss when the cached value is not a T
+func (t *Typed[T]) Get(key string) (T, bool) {
+	var zero T
+
+	value, found := t.store.Get(key)
+	if !found {
+		return zero, false
+	}
+
+	typed, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return typed, true
+}
+
+// Set adds a value with the given TTL
+func (t *Typed[T]) Set(key string, value T, ttl time.Duration) {
+	t.store.Set(key, value, ttl)
+}
+
+// Delete removes a value
+func (t *Typed[T]) Delete(key string) {
+	t.store.Delete(key)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestTypeName(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{[]*github.Notification{}, "[]*github.com/google/go-github/v60/github.Notification"},
+		{map[string]int{}, "map[string]int"},
+		{"text", "string"},
+		{[2]bool{}, "[2]bool"},
+	}
+
+	for _, tt := range tests {
+		if got := typeName(reflect.TypeOf(tt.value)); got != tt.want {
+			t.Errorf("typeName(%T) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	// Values written before types were recorded are still readable
+	legacy, err := decodeValue([]byte(`[{"id":"1"}]`))
+	if err != nil {
+		t.Fatalf("decodeValue() error = %v", err)
+	}
+	if items, ok := legacy.([]interface{}); !ok || len(items) != 1 {
+		t.Errorf("decodeValue() = %#v, want a generic slice", legacy)
+	}
+
+	// Unregistered types decode generically
+	type unregistered struct{ Name string }
+	data, err := encodeValue(unregistered{Name: "x"})
+	if err != nil {
+		t.Fatalf("encodeValue() error = %v", err)
+	}
+	value, err := decodeValue(data)
+	if err != nil {
+		t.Fatalf("decodeValue() error = %v", err)
+	}
+	if m, ok := value.(map[string]interface{}); !ok || m["Name"] != "x" {
+		t.Errorf("decodeValue() = %#v, want a generic map", value)
+	}
+}
+
+func TestTypedRoundTrip(t *testing.T) {
+	backends := map[string]func(dir string) (Cache, error){
+		"memory": func(dir string) (Cache, error) { return NewMemoryCache(nil), nil },
+		"badger": func(dir string) (Cache, error) { return NewBadgerCache(&Options{CacheDir: dir}) },
+		"bolt":   func(dir string) (Cache, error) { return NewBoltCache(&Options{CacheDir: dir}) },
+	}
+
+	updatedAt := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	want := []*github.Notification{
+		{
+			ID:         github.String("1"),
+			Unread:     github.Bool(true),
+			Reason:     github.String("mention"),
+			UpdatedAt:  &github.Timestamp{Time: updatedAt},
+			Repository: &github.Repository{FullName: github.String("org/repo")},
+			Subject:    &github.NotificationSubject{Title: github.String("Hello"), Type: github.String("Issue")},
+		},
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			c, err := open(dir)
+			if err != nil {
+				t.Fatalf("failed to open cache: %v", err)
+			}
+			NewTyped[[]*github.Notification](c).Set("notifications", want, time.Hour)
+
+			// Persistent caches are reopened, as after a restart
+			if name != "memory" {
+				if err := c.Close(); err != nil {
+					t.Fatalf("Close() error = %v", err)
+				}
+				if c, err = open(dir); err != nil {
+					t.Fatalf("failed to reopen cache: %v", err)
+				}
+			}
+			defer c.Close()
+
+			// The untyped API returns the original type too
+			if value, found := c.Get("notifications"); !found {
+				t.Fatal("Get() found nothing")
+			} else if _, ok := value.([]*github.Notification); !ok {
+				t.Fatalf("Get() = %T, want []*github.Notification", value)
+			}
+
+			got, found := NewTyped[[]*github.Notification](c).Get("notifications")
+			if !found {
+				t.Fatal("Typed.Get() found nothing")
+			}
+			if len(got) != 1 {
+				t.Fatalf("Typed.Get() returned %d notifications, want 1", len(got))
+			}
+			n := got[0]
+			if n.GetID() != "1" || !n.GetUnread() || n.GetReason() != "mention" ||
+				!n.GetUpdatedAt().Time.Equal(updatedAt) ||
+				n.GetRepository().GetFullName() != "org/repo" || n.GetSubject().GetTitle() != "Hello" {
+				t.Errorf("Typed.Get() = %+v, want %+v", n, want[0])
+			}
+
+			// Values of another type are a miss
+			if _, found := NewTyped[map[string]int](c).Get("notifications"); found {
+				t.Error("Typed.Get() of another type should miss")
+			}
+		})
+	}
+}
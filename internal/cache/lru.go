@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruIndex tracks the keys and sizes of cached items in least recently used
+// order, so the caches can evict items when they exceed their size limit
+type lruIndex struct {
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	size    int64
+}
+
+// lruEntry is an item tracked by an lruIndex
+type lruEntry struct {
+	key  string
+	size int64
+}
+
+// newLRUIndex creates an empty LRU index
+func newLRUIndex() *lruIndex {
+	return &lruIndex{
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// add records an item as the most recently used one
+func (l *lruIndex) add(key string, size int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		l.size += size - entry.size
+		entry.size = size
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, size: size})
+	l.size += size
+}
+
+// touch marks an item as the most recently used one
+func (l *lruIndex) touch(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		l.order.MoveToFront(elem)
+	}
+}
+
+// remove forgets an item
+func (l *lruIndex) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		l.size -= elem.Value.(*lruEntry).size
+		l.order.Remove(elem)
+		delete(l.entries, key)
+	}
+}
+
+// clear forgets all items
+func (l *lruIndex) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.order.Init()
+	l.entries = make(map[string]*list.Element)
+	l.size = 0
+}
+
+// keys returns the keys of all items, most recently used first
+func (l *lruIndex) keys() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := make([]string, 0, l.order.Len())
+	for elem := l.order.Front(); elem != nil; elem = elem.Next() {
+		keys = append(keys, elem.Value.(*lruEntry).key)
+	}
+	return keys
+}
+
+// evict forgets the least recently used items until the total size is within
+// limit and returns their keys. The most recently used item is always kept.
+func (l *lruIndex) evict(limit int64) []string {
+	if limit <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var evicted []string
+	for l.size > limit && l.order.Len() > 1 {
+		elem := l.order.Back()
+		entry := elem.Value.(*lruEntry)
+		l.size -= entry.size
+		l.order.Remove(elem)
+		delete(l.entries, entry.key)
+		evicted = append(evicted, entry.key)
+	}
+	return evicted
+}
+
+// report records the size and number of items in the metrics
+func (l *lruIndex) report(metrics *Metrics) {
+	l.mu.Lock()
+	size, items := l.size, int64(l.order.Len())
+	l.mu.Unlock()
+
+	metrics.Set(&metrics.Size, size)
+	metrics.Set(&metrics.Items, items)
+}
@@ -2,8 +2,12 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"regexp"
 	"sync"
 	"time"
+
+	"github.com/gobwas/glob"
 )
 
 // Manager manages the cache with advanced features
@@ -17,8 +21,14 @@ type Manager struct {
 	// prefetchQueue is the queue for prefetch operations
 	prefetchQueue chan PrefetchRequest
 
+	// mu protects the invalidation patterns and refresh callbacks
+	mu sync.RWMutex
+
 	// invalidationPatterns are patterns for cache invalidation
-	invalidationPatterns []InvalidationPattern
+	invalidationPatterns []*compiledPattern
+
+	// callbacks are the callbacks of prefetched keys, used to refresh them
+	callbacks map[string]func(ctx context.Context) (interface{}, error)
 
 	// ctx is the context for background operations
 	ctx context.Context
@@ -76,18 +86,66 @@ type PrefetchRequest struct {
 
 	// Callback is called to fetch the item if not in cache
 	Callback func(ctx context.Context) (interface{}, error)
+
+	// refresh fetches the item even if it is in cache
+	refresh bool
 }
 
 // InvalidationPattern represents a pattern for cache invalidation
 type InvalidationPattern struct {
-	// Pattern is the pattern to match cache keys
+	// Pattern is the glob matching the keys whose reads or writes trigger
+	// the invalidation, such as "write:MarkThreadRead:*". OnTime patterns
+	// match the keys to invalidate.
 	Pattern string
 
+	// Targets is the glob matching the keys to invalidate, such as
+	// "notifications_*". It defaults to the key that triggered the
+	// invalidation.
+	Targets string
+
+	// Regexp makes Pattern and Targets regular expressions instead of globs
+	Regexp bool
+
 	// Action is the action to take when the pattern matches
 	Action InvalidationAction
 
 	// Condition is the condition for invalidation
 	Condition InvalidationCondition
+
+	// Interval is how often OnTime patterns sweep the cache, defaulting to
+	// a minute
+	Interval time.Duration
+
+	// Grace is how long expired items remain readable. Items expire
+	// immediately without it.
+	Grace time.Duration
+}
+
+// compiledPattern is an invalidation pattern with its matchers compiled
+type compiledPattern struct {
+	InvalidationPattern
+	trigger matcher
+	targets matcher
+}
+
+// matcher matches cache keys
+type matcher func(key string) bool
+
+// compileMatcher compiles a glob or, if regex is set, a regular expression
+func compileMatcher(pattern string, regex bool) (matcher, error) {
+	if regex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	g, err := glob.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return g.Match, nil
 }
 
 // InvalidationAction is the action to take when invalidating cache items
@@ -118,6 +176,9 @@ const (
 	OnTime InvalidationCondition = "time"
 )
 
+// defaultSweepInterval is how often OnTime patterns sweep the cache by default
+const defaultSweepInterval = time.Minute
+
 // NewManager creates a new cache manager
 func NewManager(cache Cache, opts *ManagerOptions) *Manager {
 	if opts == nil {
@@ -127,10 +188,11 @@ func NewManager(cache Cache, opts *ManagerOptions) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	manager := &Manager{
-		Cache:   cache,
-		Options: opts,
-		ctx:     ctx,
-		cancel:  cancel,
+		Cache:     cache,
+		Options:   opts,
+		callbacks: make(map[string]func(ctx context.Context) (interface{}, error)),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 
 	if opts.EnablePrefetching {
@@ -160,6 +222,11 @@ func (m *Manager) Get(key string) (interface{}, bool) {
 		m.Cache.Prefetch(key)
 	}
 
+	// Apply invalidation patterns
+	if found && m.Options.EnableInvalidation {
+		m.applyInvalidationPatterns(key, OnRead)
+	}
+
 	return value, found
 }
 
@@ -182,8 +249,34 @@ func (m *Manager) Delete(key string) {
 	m.Cache.Delete(key)
 }
 
-// Prefetch queues a key for background prefetching
+// RecordWrite applies the OnWrite invalidation patterns for a write made
+// outside the cache, such as an API call changing data that is cached
+func (m *Manager) RecordWrite(key string) {
+	if m.Options.EnableInvalidation {
+		m.applyInvalidationPatterns(key, OnWrite)
+	}
+}
+
+// Invalidate applies an action to the cached items whose keys match a glob
+func (m *Manager) Invalidate(pattern string, action InvalidationAction) error {
+	targets, err := compileMatcher(pattern, false)
+	if err != nil {
+		return fmt.Errorf("failed to compile invalidation pattern %q: %w", pattern, err)
+	}
+
+	m.invalidate(InvalidationPattern{Action: action}, targets, "")
+	return nil
+}
+
+// Prefetch queues a key for background prefetching. Its callback is kept
+// to refresh the key when it is invalidated.
 func (m *Manager) Prefetch(req PrefetchRequest) {
+	if req.Callback != nil {
+		m.mu.Lock()
+		m.callbacks[req.Key] = req.Callback
+		m.mu.Unlock()
+	}
+
 	if !m.Options.EnablePrefetching {
 		return
 	}
@@ -205,8 +298,8 @@ func (m *Manager) prefetchWorker(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case req := <-m.prefetchQueue:
-			// Skip if already in cache
-			if _, found := m.Cache.Get(req.Key); found {
+			// Skip if already in cache, unless it is refreshed
+			if _, found := m.Cache.Get(req.Key); found && !req.refresh {
 				continue
 			}
 
@@ -221,29 +314,140 @@ func (m *Manager) prefetchWorker(ctx context.Context) {
 	}
 }
 
-// applyInvalidationPatterns applies invalidation patterns
+// applyInvalidationPatterns applies the patterns of a condition matching a
+// key that was read or written
 func (m *Manager) applyInvalidationPatterns(key string, condition InvalidationCondition) {
+	m.mu.RLock()
+	var matched []*compiledPattern
 	for _, pattern := range m.invalidationPatterns {
-		if pattern.Condition == condition {
-			// TODO: Implement pattern matching
-			// For now, just a simple exact match
-			if pattern.Pattern == key {
-				switch pattern.Action {
-				case InvalidateDelete:
-					m.Cache.Delete(key)
-				case InvalidateRefresh:
-					// TODO: Implement refresh
-				case InvalidateExpire:
-					// TODO: Implement expire
-				}
-			}
+		if pattern.Condition == condition && pattern.trigger(key) {
+			matched = append(matched, pattern)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, pattern := range matched {
+		if pattern.targets == nil {
+			m.applyAction(pattern.InvalidationPattern, key)
+			continue
+		}
+		m.invalidate(pattern.InvalidationPattern, pattern.targets, key)
+	}
+}
+
+// invalidate applies the action of a pattern to the cached items matching
+// targets, except the key that triggered it
+func (m *Manager) invalidate(pattern InvalidationPattern, targets matcher, trigger string) {
+	for _, key := range m.Cache.Keys() {
+		if key != trigger && targets(key) {
+			m.applyAction(pattern, key)
 		}
 	}
 }
 
-// AddInvalidationPattern adds an invalidation pattern
-func (m *Manager) AddInvalidationPattern(pattern InvalidationPattern) {
-	m.invalidationPatterns = append(m.invalidationPatterns, pattern)
+// applyAction applies the action of a pattern to a cached item
+func (m *Manager) applyAction(pattern InvalidationPattern, key string) {
+	switch pattern.Action {
+	case InvalidateDelete:
+		m.Cache.Delete(key)
+	case InvalidateRefresh:
+		m.refresh(key)
+	case InvalidateExpire:
+		m.expire(key, pattern.Grace)
+	}
+}
+
+// refresh fetches an item again with the callback it was prefetched with,
+// or the RefreshCallback option. Items that cannot be fetched again are
+// deleted, so they are not read stale.
+func (m *Manager) refresh(key string) {
+	m.mu.RLock()
+	callback := m.callbacks[key]
+	m.mu.RUnlock()
+
+	if callback == nil && m.Options.RefreshCallback != nil {
+		callback = func(ctx context.Context) (interface{}, error) {
+			return m.Options.RefreshCallback(ctx, key)
+		}
+	}
+	if callback == nil || !m.Options.EnablePrefetching {
+		m.Cache.Delete(key)
+		return
+	}
+
+	select {
+	case m.prefetchQueue <- PrefetchRequest{Key: key, Callback: callback, refresh: true}:
+	default:
+		// Queue is full, so the item is fetched again when it is next read
+		m.Cache.Delete(key)
+	}
+}
+
+// expire makes an item expire after a grace period
+func (m *Manager) expire(key string, grace time.Duration) {
+	if grace <= 0 {
+		m.Cache.Delete(key)
+		return
+	}
+
+	if value, found := m.Cache.Get(key); found {
+		m.Cache.Set(key, value, grace)
+	}
+}
+
+// sweep applies an OnTime pattern at its interval until the manager is closed
+func (m *Manager) sweep(pattern *compiledPattern) {
+	defer m.wg.Done()
+
+	interval := pattern.Interval
+	if interval <= 0 {
+		interval = defaultSweepInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.invalidate(pattern.InvalidationPattern, pattern.targets, "")
+		}
+	}
+}
+
+// AddInvalidationPattern adds an invalidation pattern. OnTime patterns start
+// sweeping the cache at once.
+func (m *Manager) AddInvalidationPattern(pattern InvalidationPattern) error {
+	compiled := &compiledPattern{InvalidationPattern: pattern}
+
+	trigger, err := compileMatcher(pattern.Pattern, pattern.Regexp)
+	if err != nil {
+		return fmt.Errorf("failed to compile invalidation pattern %q: %w", pattern.Pattern, err)
+	}
+	compiled.trigger = trigger
+
+	if pattern.Targets != "" {
+		targets, err := compileMatcher(pattern.Targets, pattern.Regexp)
+		if err != nil {
+			return fmt.Errorf("failed to compile invalidation targets %q: %w", pattern.Targets, err)
+		}
+		compiled.targets = targets
+	} else if pattern.Condition == OnTime {
+		compiled.targets = trigger
+	}
+
+	m.mu.Lock()
+	m.invalidationPatterns = append(m.invalidationPatterns, compiled)
+	m.mu.Unlock()
+
+	if pattern.Condition == OnTime && m.Options.EnableInvalidation {
+		m.wg.Add(1)
+		go m.sweep(compiled)
+	}
+
+	return nil
 }
 
 // GetMetrics returns cache metrics
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	opts := DefaultManagerOptions()
+	opts.PrefetchConcurrency = 1
+	m := NewManager(NewMemoryCache(nil), opts)
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestManagerInvalidatesOnWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern InvalidationPattern
+	}{
+		{"glob", InvalidationPattern{Pattern: "write:MarkThreadRead:*", Targets: "notifications:*"}},
+		{"regexp", InvalidationPattern{Pattern: `^write:MarkThreadRead:\d+$`, Targets: `^notifications:`, Regexp: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			tt.pattern.Action = InvalidateDelete
+			tt.pattern.Condition = OnWrite
+			if err := m.AddInvalidationPattern(tt.pattern); err != nil {
+				t.Fatalf("AddInvalidationPattern() error = %v", err)
+			}
+
+			m.Set("notifications:all", "list", time.Hour)
+			m.Set("notifications:unread", "list", time.Hour)
+			m.Set("issue:1", "issue", time.Hour)
+
+			// Writes that do not match leave the cache alone
+			m.RecordWrite("write:Subscribe:1")
+			if _, found := m.Get("notifications:all"); !found {
+				t.Fatal("an unrelated write should not invalidate the lists")
+			}
+
+			m.RecordWrite("write:MarkThreadRead:42")
+			for _, key := range []string{"notifications:all", "notifications:unread"} {
+				if _, found := m.Get(key); found {
+					t.Errorf("%s should be invalidated", key)
+				}
+			}
+			if _, found := m.Get("issue:1"); !found {
+				t.Error("issue:1 should be kept")
+			}
+		})
+	}
+}
+
+func TestManagerRefresh(t *testing.T) {
+	m := newTestManager(t)
+	err := m.AddInvalidationPattern(InvalidationPattern{
+		Pattern:   "write:*",
+		Targets:   "notifications:*",
+		Action:    InvalidateRefresh,
+		Condition: OnWrite,
+	})
+	if err != nil {
+		t.Fatalf("AddInvalidationPattern() error = %v", err)
+	}
+
+	var calls atomic.Int32
+	m.Set("notifications:all", "stale", time.Hour)
+	m.Set("notifications:other", "stale", time.Hour)
+	m.Prefetch(PrefetchRequest{
+		Key: "notifications:all",
+		Callback: func(ctx context.Context) (interface{}, error) {
+			calls.Add(1)
+			return "fresh", nil
+		},
+	})
+
+	m.RecordWrite("write:MarkThreadRead:1")
+
+	// The registered callback fetches the item again
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if value, _ := m.Get("notifications:all"); value == "fresh" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("notifications:all was not refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("callback called %d times, want 1", calls.Load())
+	}
+
+	// Items without a callback cannot be refreshed, so they are deleted
+	if _, found := m.Get("notifications:other"); found {
+		t.Error("notifications:other should be deleted")
+	}
+}
+
+func TestManagerExpire(t *testing.T) {
+	m := newTestManager(t)
+	err := m.AddInvalidationPattern(InvalidationPattern{
+		Pattern:   "notifications:*",
+		Action:    InvalidateExpire,
+		Condition: OnRead,
+		Grace:     50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("AddInvalidationPattern() error = %v", err)
+	}
+
+	m.Set("notifications:all", "list", time.Hour)
+
+	// The first read still returns the item, which then expires
+	if _, found := m.Get("notifications:all"); !found {
+		t.Fatal("the item should be readable during the grace period")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, found := m.Get("notifications:all"); found {
+		t.Error("the item should expire after the grace period")
+	}
+}
+
+func TestManagerSweep(t *testing.T) {
+	m := newTestManager(t)
+	err := m.AddInvalidationPattern(InvalidationPattern{
+		Pattern:   "notifications:*",
+		Action:    InvalidateDelete,
+		Condition: OnTime,
+		Interval:  20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("AddInvalidationPattern() error = %v", err)
+	}
+
+	m.Set("notifications:all", "list", time.Hour)
+	m.Set("issue:1", "issue", time.Hour)
+
+	time.Sleep(100 * time.Millisecond)
+	if _, found := m.Get("notifications:all"); found {
+		t.Error("the sweep should delete notifications:all")
+	}
+	if _, found := m.Get("issue:1"); !found {
+		t.Error("the sweep should keep issue:1")
+	}
+}
+
+func TestAddInvalidationPatternInvalid(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.AddInvalidationPattern(InvalidationPattern{Pattern: "(", Regexp: true}); err == nil {
+		t.Error("an invalid regexp should be rejected")
+	}
+	if err := m.AddInvalidationPattern(InvalidationPattern{Pattern: "*", Targets: "[a"}); err == nil {
+		t.Error("an invalid glob should be rejected")
+	}
+}
@@ -15,10 +15,12 @@ type Options struct {
 	// DefaultTTL is the default time-to-live for cached items
 	DefaultTTL time.Duration
 
-	// MaxSize is the maximum size of the cache in bytes (0 = unlimited)
+	// MaxSize is the maximum size of the cache in bytes (0 = unlimited).
+	// Persistent caches evict the least recently used items beyond it.
 	MaxSize int64
 
-	// MemoryLimit is the maximum memory usage in bytes (0 = unlimited)
+	// MemoryLimit is the maximum memory usage in bytes (0 = unlimited). The
+	// memory cache evicts the least recently used items beyond it.
 	MemoryLimit int64
 
 	// ReadOnly opens the cache in read-only mode
@@ -30,13 +32,16 @@ type Options struct {
 	// PrefetchConcurrency is the number of concurrent prefetch operations
 	PrefetchConcurrency int
 
-	// EnableCompression enables compression for cached values
+	// EnableCompression enables compression for values of persistent caches
 	EnableCompression bool
 
-	// EnableEncryption enables encryption for cached values
+	// Compression is the compression algorithm, zstd when empty
+	Compression Compression
+
+	// EnableEncryption enables encryption at rest for values of persistent caches
 	EnableEncryption bool
 
-	// EncryptionKey is the key used for encryption
+	// EncryptionKey is the 32 byte key used for encryption
 	EncryptionKey []byte
 
 	// EnableMetrics enables collection of cache metrics
@@ -60,6 +65,7 @@ func DefaultOptions() *Options {
 		EnablePrefetching:   true,
 		PrefetchConcurrency: 2,
 		EnableCompression:   true,
+		Compression:         CompressionZstd,
 		EnableEncryption:    false,
 		EnableMetrics:       true,
 	}
@@ -97,6 +103,24 @@ type Metrics struct {
 	// Size is the current size of the cache in bytes
 	Size int64
 
+	// Items is the current number of items in the cache
+	Items int64
+
+	// Evictions is the number of items evicted to stay within the size limits
+	Evictions int64
+
+	// UncompressedBytes is the size of the values written, before compression
+	UncompressedBytes int64
+
+	// CompressedBytes is the size of the values written, after compression
+	CompressedBytes int64
+
+	// Encryptions is the number of values encrypted
+	Encryptions int64
+
+	// DecryptionFailures is the number of values that could not be decrypted
+	DecryptionFailures int64
+
 	// mu protects the metrics
 	mu sync.Mutex
 }
@@ -120,6 +144,13 @@ func (m *Metrics) Add(metric *int64, value int64) {
 	*metric += value
 }
 
+// Set sets a metric to a value
+func (m *Metrics) Set(metric *int64, value int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	*metric = value
+}
+
 // Get gets a metric value
 func (m *Metrics) Get(metric *int64) int64 {
 	m.mu.Lock()
@@ -141,6 +172,12 @@ func (m *Metrics) Reset() {
 	m.Prefetches = 0
 	m.PrefetchesProcessed = 0
 	m.Size = 0
+	m.Items = 0
+	m.Evictions = 0
+	m.UncompressedBytes = 0
+	m.CompressedBytes = 0
+	m.Encryptions = 0
+	m.DecryptionFailures = 0
 }
 
 // HitRatio returns the cache hit ratio
@@ -158,3 +195,16 @@ func (m *Metrics) HitRatio() float64 {
 
 	return float64(hits) / float64(total)
 }
+
+// CompressionRatio returns the size of the values written after compression
+// relative to their size before, or 0 if nothing was compressed
+func (m *Metrics) CompressionRatio() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.UncompressedBytes == 0 {
+		return 0
+	}
+
+	return float64(m.CompressedBytes) / float64(m.UncompressedBytes)
+}
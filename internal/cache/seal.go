@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Compression is a compression algorithm for cached values
+type Compression string
+
+const (
+	// CompressionZstd compresses values with zstd
+	CompressionZstd Compression = "zstd"
+
+	// CompressionGzip compresses values with gzip
+	CompressionGzip Compression = "gzip"
+)
+
+// sealMagic starts every value written by a sealer, which tells it apart
+// from plain JSON written before values were sealed
+const sealMagic byte = 0xC5
+
+// Flags of a sealed value
+const (
+	sealZstd      byte = 1 << 0
+	sealGzip      byte = 1 << 1
+	sealEncrypted byte = 1 << 2
+)
+
+// ErrNotEncrypted is returned for values stored without encryption by a cache
+// that encrypts its values
+var ErrNotEncrypted = errors.New("cached value is not encrypted")
+
+// sealer compresses and encrypts the values of a persistent cache
+type sealer struct {
+	compression Compression
+	key         *[32]byte
+	metrics     *Metrics
+
+	encoder     *zstd.Encoder
+	decoder     *zstd.Decoder
+	decoderErr  error
+	decoderOnce sync.Once
+}
+
+// newSealer creates the sealer configured by the cache options
+func newSealer(opts *Options, metrics *Metrics) (*sealer, error) {
+	s := &sealer{metrics: metrics}
+
+	if opts.EnableCompression {
+		s.compression = opts.Compression
+		if s.compression == "" {
+			s.compression = CompressionZstd
+		}
+
+		switch s.compression {
+		case CompressionZstd:
+			encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+			}
+			s.encoder = encoder
+		case CompressionGzip:
+		default:
+			return nil, fmt.Errorf("unsupported cache compression: %s", s.compression)
+		}
+	}
+
+	if opts.EnableEncryption {
+		if len(opts.EncryptionKey) != 32 {
+			return nil, fmt.Errorf("invalid cache encryption key length: %d, want 32", len(opts.EncryptionKey))
+		}
+		s.key = new([32]byte)
+		copy(s.key[:], opts.EncryptionKey)
+	}
+
+	return s, nil
+}
+
+// seal compresses and encrypts a value as configured
+func (s *sealer) seal(data []byte) ([]byte, error) {
+	flags := byte(0)
+	payload := data
+
+	if s.compression != "" {
+		compressed, flag, err := s.compress(data)
+		if err != nil {
+			return nil, err
+		}
+		// Values too small to benefit from compression are stored as they are
+		if len(compressed) < len(data) {
+			payload = compressed
+			flags |= flag
+		}
+		s.metrics.Add(&s.metrics.UncompressedBytes, int64(len(data)))
+		s.metrics.Add(&s.metrics.CompressedBytes, int64(len(payload)))
+	}
+
+	if s.key != nil {
+		var nonce [24]byte
+		if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		payload = secretbox.Seal(nonce[:], payload, &nonce, s.key)
+		flags |= sealEncrypted
+		s.metrics.Inc(&s.metrics.Encryptions)
+	}
+
+	sealed := make([]byte, 0, len(payload)+2)
+	sealed = append(sealed, sealMagic, flags)
+	return append(sealed, payload...), nil
+}
+
+// open decrypts and decompresses a value written by seal. Values written
+// before values were sealed are returned unchanged, unless the cache
+// encrypts its values.
+func (s *sealer) open(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != sealMagic {
+		if s.key != nil {
+			return nil, ErrNotEncrypted
+		}
+		return data, nil
+	}
+
+	flags, payload := data[1], data[2:]
+
+	if flags&sealEncrypted != 0 {
+		if s.key == nil {
+			return nil, errors.New("cached value is encrypted, but cache encryption is disabled")
+		}
+		if len(payload) < 24 {
+			s.metrics.Inc(&s.metrics.DecryptionFailures)
+			return nil, errors.New("encrypted cache value too short")
+		}
+		var nonce [24]byte
+		copy(nonce[:], payload[:24])
+		opened, ok := secretbox.Open(nil, payload[24:], &nonce, s.key)
+		if !ok {
+			s.metrics.Inc(&s.metrics.DecryptionFailures)
+			return nil, errors.New("failed to decrypt cache value")
+		}
+		payload = opened
+	} else if s.key != nil {
+		return nil, ErrNotEncrypted
+	}
+
+	switch {
+	case flags&sealZstd != 0:
+		decoder, err := s.zstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := decoder.DecodeAll(payload, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress cache value: %w", err)
+		}
+		return decoded, nil
+	case flags&sealGzip != 0:
+		reader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress cache value: %w", err)
+		}
+		defer reader.Close()
+		decoded, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress cache value: %w", err)
+		}
+		return decoded, nil
+	default:
+		return payload, nil
+	}
+}
+
+// compress compresses data with the configured algorithm and returns the
+// flag recording it
+func (s *sealer) compress(data []byte) ([]byte, byte, error) {
+	if s.compression == CompressionGzip {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(data); err != nil {
+			return nil, 0, fmt.Errorf("failed to compress cache value: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, 0, fmt.Errorf("failed to compress cache value: %w", err)
+		}
+		return buf.Bytes(), sealGzip, nil
+	}
+
+	return s.encoder.EncodeAll(data, nil), sealZstd, nil
+}
+
+// zstdDecoder returns the zstd decoder, created on first use since values
+// compressed with zstd may be read whatever compression is configured now
+func (s *sealer) zstdDecoder() (*zstd.Decoder, error) {
+	s.decoderOnce.Do(func() {
+		s.decoder, s.decoderErr = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		if s.decoderErr != nil {
+			s.decoderErr = fmt.Errorf("failed to create zstd decoder: %w", s.decoderErr)
+		}
+	})
+	return s.decoder, s.decoderErr
+}
+
+// close releases the compression resources
+func (s *sealer) close() {
+	if s.encoder != nil {
+		s.encoder.Close()
+	}
+	s.decoderOnce.Do(func() {})
+	if s.decoder != nil {
+		s.decoder.Close()
+	}
+}
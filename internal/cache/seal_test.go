@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSealerRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	data := []byte(strings.Repeat(`{"title":"Fix the private repository"}`, 50))
+
+	tests := []struct {
+		name string
+		opts *Options
+	}{
+		{"plain", &Options{}},
+		{"zstd", &Options{EnableCompression: true, Compression: CompressionZstd}},
+		{"gzip", &Options{EnableCompression: true, Compression: CompressionGzip}},
+		{"encrypted", &Options{EnableEncryption: true, EncryptionKey: key}},
+		{"zstd encrypted", &Options{EnableCompression: true, EnableEncryption: true, EncryptionKey: key}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &Metrics{}
+			s, err := newSealer(tt.opts, metrics)
+			if err != nil {
+				t.Fatalf("newSealer() error = %v", err)
+			}
+			defer s.close()
+
+			sealed, err := s.seal(data)
+			if err != nil {
+				t.Fatalf("seal() error = %v", err)
+			}
+			if tt.opts.EnableCompression && len(sealed) >= len(data) {
+				t.Errorf("sealed %d bytes to %d, want them compressed", len(data), len(sealed))
+			}
+			if tt.opts.EnableEncryption && bytes.Contains(sealed, []byte("private")) {
+				t.Error("encrypted value contains plain text")
+			}
+
+			opened, err := s.open(sealed)
+			if err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+			if !bytes.Equal(opened, data) {
+				t.Errorf("open() = %q, want %q", opened, data)
+			}
+		})
+	}
+}
+
+func TestSealerRejects(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	if _, err := newSealer(&Options{EnableEncryption: true, EncryptionKey: []byte("short")}, &Metrics{}); err == nil {
+		t.Error("newSealer() with a short key should fail")
+	}
+	if _, err := newSealer(&Options{EnableCompression: true, Compression: "lz4"}, &Metrics{}); err == nil {
+		t.Error("newSealer() with an unknown compression should fail")
+	}
+
+	metrics := &Metrics{}
+	s, err := newSealer(&Options{EnableEncryption: true, EncryptionKey: key}, metrics)
+	if err != nil {
+		t.Fatalf("newSealer() error = %v", err)
+	}
+
+	// Values written before encryption was enabled are not trusted
+	if _, err := s.open([]byte(`{"type":"string","value":"x"}`)); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("open() of a plain value error = %v, want ErrNotEncrypted", err)
+	}
+
+	other, err := newSealer(&Options{EnableEncryption: true, EncryptionKey: bytes.Repeat([]byte{8}, 32)}, &Metrics{})
+	if err != nil {
+		t.Fatalf("newSealer() error = %v", err)
+	}
+	sealed, err := other.seal([]byte("secret"))
+	if err != nil {
+		t.Fatalf("seal() error = %v", err)
+	}
+	if _, err := s.open(sealed); err == nil {
+		t.Error("open() with the wrong key should fail")
+	}
+	if metrics.Get(&metrics.DecryptionFailures) != 1 {
+		t.Errorf("DecryptionFailures = %d, want 1", metrics.Get(&metrics.DecryptionFailures))
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	backends := map[string]func(opts *Options) (Cache, error){
+		"memory": func(opts *Options) (Cache, error) { return NewMemoryCache(opts), nil },
+		"badger": func(opts *Options) (Cache, error) { return NewBadgerCache(opts) },
+		"bolt":   func(opts *Options) (Cache, error) { return NewBoltCache(opts) },
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			// Incompressible values of about 500 bytes each
+			value := func(i int) string {
+				var b strings.Builder
+				for j := 0; b.Len() < 500; j++ {
+					fmt.Fprintf(&b, "%x", (i*7919+j)*2654435761)
+				}
+				return b.String()
+			}
+
+			opts := DefaultOptions()
+			opts.CacheDir = t.TempDir()
+			opts.EnableEncryption = true
+			opts.EncryptionKey = bytes.Repeat([]byte{7}, 32)
+			if name == "memory" {
+				opts.MemoryLimit = 0
+			}
+
+			// Each backend accounts for the stored size of an item, so the
+			// limit is set to hold three and a half items
+			probe, err := open(&Options{CacheDir: t.TempDir(), EnableEncryption: true, EncryptionKey: opts.EncryptionKey})
+			if err != nil {
+				t.Fatalf("failed to open cache: %v", err)
+			}
+			probe.Set("key0", value(0), time.Hour)
+			itemSize := probe.GetMetrics().Get(&probe.GetMetrics().Size)
+			probe.Close()
+			opts.MaxSize = itemSize*3 + itemSize/2
+
+			c, err := open(opts)
+			if err != nil {
+				t.Fatalf("failed to open cache: %v", err)
+			}
+			defer c.Close()
+
+			c.Set("key0", value(0), time.Hour)
+			c.Set("key1", value(1), time.Hour)
+			c.Set("key2", value(2), time.Hour)
+
+			// Reading key0 makes key1 the least recently used item
+			if _, found := c.Get("key0"); !found {
+				t.Fatal("Get(key0) found nothing")
+			}
+			for i := 3; i < 6; i++ {
+				c.Set(fmt.Sprintf("key%d", i), value(i), time.Hour)
+			}
+
+			if _, found := c.Get("key1"); found {
+				t.Error("the least recently used item should be evicted")
+			}
+			if _, found := c.Get("key5"); !found {
+				t.Error("the most recent item should be kept")
+			}
+
+			metrics := c.GetMetrics()
+			if metrics.Get(&metrics.Evictions) == 0 {
+				t.Error("Evictions = 0, want evicted items counted")
+			}
+			if size := metrics.Get(&metrics.Size); size == 0 || size > opts.MaxSize {
+				t.Errorf("Size = %d, want within %d", size, opts.MaxSize)
+			}
+			if items := metrics.Get(&metrics.Items); items == 0 || items >= 6 {
+				t.Errorf("Items = %d, want some items evicted", items)
+			}
+		})
+	}
+}
+
+func TestEncryptedCacheOnDisk(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	for name, open := range map[string]func(opts *Options) (Cache, error){
+		"badger": func(opts *Options) (Cache, error) { return NewBadgerCache(opts) },
+		"bolt":   func(opts *Options) (Cache, error) { return NewBoltCache(opts) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := &Options{CacheDir: dir, EnableCompression: true, EnableEncryption: true, EncryptionKey: key}
+
+			c, err := open(opts)
+			if err != nil {
+				t.Fatalf("failed to open cache: %v", err)
+			}
+			c.Set("title", "Fix the private repository", time.Hour)
+			if err := c.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				if bytes.Contains(data, []byte("private repository")) {
+					t.Errorf("%s contains the value in plain text", path)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("failed to read cache directory: %v", err)
+			}
+
+			// The value is readable again after a restart with the same key
+			if c, err = open(opts); err != nil {
+				t.Fatalf("failed to reopen cache: %v", err)
+			}
+			defer c.Close()
+			if value, found := c.Get("title"); !found || value != "Fix the private repository" {
+				t.Errorf("Get() = %v, %v, want the stored value", value, found)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ type ActionType string
 const (
 	// ActionMarkAsRead represents marking a notification as read
 	ActionMarkAsRead ActionType = "mark_as_read"
+	// ActionMarkAsUnread represents keeping a read notification unread locally
+	ActionMarkAsUnread ActionType = "mark_as_unread"
 	// ActionMarkAllAsRead represents marking all notifications as read
 	ActionMarkAllAsRead ActionType = "mark_all_as_read"
 	// ActionArchive represents archiving a notification
@@ -23,16 +25,27 @@ const (
 	ActionUnsubscribe ActionType = "unsubscribe"
 	// ActionMute represents muting a repository
 	ActionMute ActionType = "mute"
+	// ActionSnooze represents hiding a notification until a time or new activity
+	ActionSnooze ActionType = "snooze"
+	// ActionUnsnooze represents ending the snooze of a notification
+	ActionUnsnooze ActionType = "unsnooze"
 )
 
 // Action represents an action performed on a notification
 type Action struct {
+	// ID identifies the action in the action history
+	ID string
 	// Type is the type of action
 	Type ActionType
 	// NotificationID is the ID of the notification
 	NotificationID string
 	// RepositoryName is the name of the repository
 	RepositoryName string
+	// Host is the GitHub host the action was performed on, empty for the
+	// host configured by api.base_url
+	Host string
+	// Title is the title of the notification subject, if known
+	Title string
 	// Timestamp is when the action was performed
 	Timestamp time.Time
 	// Success indicates whether the action was successful
@@ -118,6 +131,8 @@ type UndoResult struct {
 	UndoAction Action
 	// Success indicates whether the undo was successful
 	Success bool
+	// NoOp indicates that the remote state already matched and nothing was changed
+	NoOp bool
 	// Error is the error that occurred, if any
 	Error error
 }
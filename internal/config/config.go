@@ -28,6 +28,44 @@ type Config struct {
 
 	// Advanced settings
 	Advanced AdvancedConfig `mapstructure:"advanced"`
+
+	// Rules triage notifications automatically after they are fetched
+	Rules []RuleConfig `mapstructure:"rules"`
+
+	// Hosts are additional GitHub Enterprise Server hosts whose notifications
+	// are merged with those of the host configured by api.base_url
+	Hosts []HostConfig `mapstructure:"hosts"`
+
+	// Digest settings of hourly and daily repository subscriptions
+	Digest DigestConfig `mapstructure:"digest"`
+
+	// Webhooks receive watch events as signed JSON
+	Webhooks []WebhookConfig `mapstructure:"webhooks"`
+}
+
+// RuleActions are the actions a rule can perform
+var RuleActions = []string{"read", "archive", "unsubscribe", "mute", "snooze"}
+
+// RuleConfig is a rule that acts on matching notifications after every fetch
+type RuleConfig struct {
+	// Name identifies the rule in reports and the audit log
+	Name string `mapstructure:"name"`
+
+	// When is the filter expression notifications must match
+	When string `mapstructure:"when"`
+
+	// OlderThan only matches notifications not updated for this long, e.g. "1d"
+	OlderThan string `mapstructure:"older_than"`
+
+	// Then is the action to perform
+	// Options: "read", "archive", "unsubscribe", "mute", "snooze"
+	Then string `mapstructure:"then"`
+
+	// Until is when snoozed notifications resurface, e.g. "1d" or "next-activity"
+	Until string `mapstructure:"until"`
+
+	// Disabled turns the rule off without removing it
+	Disabled bool `mapstructure:"disabled"`
 }
 
 // AuthConfig holds authentication-related configuration
@@ -133,8 +171,41 @@ type AdvancedConfig struct {
 	// CacheDir is the directory to store cached data
 	CacheDir string `mapstructure:"cache_dir"`
 
+	// CacheMaxSize is the maximum size in bytes of the cache on disk, 0 for no limit
+	CacheMaxSize int64 `mapstructure:"cache_max_size"`
+
+	// CacheMemoryLimit is the maximum size in bytes of in-memory caches, 0 for no limit
+	CacheMemoryLimit int64 `mapstructure:"cache_memory_limit"`
+
+	// CacheCompression compresses cached data: zstd, gzip or none
+	CacheCompression string `mapstructure:"cache_compression"`
+
+	// CacheEncryption encrypts cached data at rest with a key kept next to the token
+	CacheEncryption bool `mapstructure:"cache_encryption"`
+
 	// Editor is the preferred editor for editing configuration
 	Editor string `mapstructure:"editor"`
+
+	// HistoryMaxEntries is the number of actions kept in the history journal, 0 for no limit
+	HistoryMaxEntries int `mapstructure:"history_max_entries"`
+
+	// HistoryMaxAge is the number of days actions are kept in the history journal, 0 for no limit
+	HistoryMaxAge int `mapstructure:"history_max_age"`
+
+	// SubscriptionsFile is a plaintext YAML file declaring repository
+	// subscriptions in addition to the encrypted ones, empty for none
+	SubscriptionsFile string `mapstructure:"subscriptions_file"`
+}
+
+// DeclaredSubscriptionsFile returns the declarative subscriptions file,
+// expanding a leading ~
+func (a AdvancedConfig) DeclaredSubscriptionsFile() string {
+	if strings.HasPrefix(a.SubscriptionsFile, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + a.SubscriptionsFile[1:]
+		}
+	}
+	return a.SubscriptionsFile
 }
 
 // ConfigManager manages the application configuration
@@ -150,12 +221,20 @@ type ConfigManager struct {
 
 	// configDir is the directory containing the configuration file
 	configDir string
+
+	// explicitFile is a configuration file that overrides the default lookup
+	explicitFile string
+
+	// profile is the name of the active profile, empty for the default one
+	profile string
 }
 
-// NewConfigManager creates a new ConfigManager
+// NewConfigManager creates a new ConfigManager for the selected config file and profile
 func NewConfigManager() *ConfigManager {
 	return &ConfigManager{
-		v: viper.New(),
+		v:            viper.New(),
+		explicitFile: ConfigFile(),
+		profile:      Profile(),
 	}
 }
 
@@ -196,8 +275,24 @@ func DefaultConfig() *Config {
 			Debug:         false,
 			MaxConcurrent: 5,
 			CacheTTL:      3600,
-			CacheDir:      filepath.Join(home, ".gh-notif-cache"),
+			CacheDir:      filepath.Join(home, ProfileFileName(".gh-notif-cache", Profile())),
 			Editor:        getDefaultEditor(),
+
+			CacheMaxSize:     1024 * 1024 * 1024, // 1GB
+			CacheMemoryLimit: 100 * 1024 * 1024,  // 100MB
+			CacheCompression: "zstd",
+			CacheEncryption:  true,
+
+			HistoryMaxEntries: 500,
+			HistoryMaxAge:     30,
+		},
+		Digest: DigestConfig{
+			Delivery: "stdout",
+			DailyAt:  "09:00",
+			GroupBy:  "repository",
+			SMTP: SMTPConfig{
+				Port: 587,
+			},
 		},
 	}
 }
@@ -248,13 +343,17 @@ func (cm *ConfigManager) Load() error {
 	// Read config file
 	if err := cm.v.ReadInConfig(); err != nil {
 		// Create default config if it doesn't exist
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) || errors.Is(err, os.ErrNotExist) {
 			if err := cm.createDefaultConfig(); err != nil {
 				return fmt.Errorf("failed to create default config: %w", err)
 			}
 		} else {
 			return fmt.Errorf("failed to read config: %w", err)
 		}
+	} else {
+		cm.configFile = cm.v.ConfigFileUsed()
+		cm.configDir = filepath.Dir(cm.configFile)
 	}
 
 	// Unmarshal config
@@ -303,7 +402,25 @@ func (cm *ConfigManager) setDefaults(config *Config) {
 	cm.v.SetDefault("advanced.max_concurrent", config.Advanced.MaxConcurrent)
 	cm.v.SetDefault("advanced.cache_ttl", config.Advanced.CacheTTL)
 	cm.v.SetDefault("advanced.cache_dir", config.Advanced.CacheDir)
+	cm.v.SetDefault("advanced.cache_max_size", config.Advanced.CacheMaxSize)
+	cm.v.SetDefault("advanced.cache_memory_limit", config.Advanced.CacheMemoryLimit)
+	cm.v.SetDefault("advanced.cache_compression", config.Advanced.CacheCompression)
+	cm.v.SetDefault("advanced.cache_encryption", config.Advanced.CacheEncryption)
 	cm.v.SetDefault("advanced.editor", config.Advanced.Editor)
+	cm.v.SetDefault("advanced.history_max_entries", config.Advanced.HistoryMaxEntries)
+	cm.v.SetDefault("advanced.history_max_age", config.Advanced.HistoryMaxAge)
+	cm.v.SetDefault("advanced.subscriptions_file", config.Advanced.SubscriptionsFile)
+
+	// Digest defaults
+	cm.v.SetDefault("digest.delivery", config.Digest.Delivery)
+	cm.v.SetDefault("digest.file", config.Digest.File)
+	cm.v.SetDefault("digest.daily_at", config.Digest.DailyAt)
+	cm.v.SetDefault("digest.group_by", config.Digest.GroupBy)
+	cm.v.SetDefault("digest.smtp.host", config.Digest.SMTP.Host)
+	cm.v.SetDefault("digest.smtp.port", config.Digest.SMTP.Port)
+	cm.v.SetDefault("digest.smtp.username", config.Digest.SMTP.Username)
+	cm.v.SetDefault("digest.smtp.from", config.Digest.SMTP.From)
+	cm.v.SetDefault("digest.smtp.to", config.Digest.SMTP.To)
 }
 
 // setupConfigLocations sets up the configuration file locations
@@ -314,8 +431,18 @@ func (cm *ConfigManager) setupConfigLocations() error {
 		return fmt.Errorf("failed to find home directory: %w", err)
 	}
 
-	// Set up config file name and type
-	cm.v.SetConfigName(".gh-notif")
+	// An explicit config file replaces the lookup entirely
+	if cm.explicitFile != "" {
+		cm.v.SetConfigFile(cm.explicitFile)
+		cm.v.SetConfigType("yaml")
+		cm.configFile = cm.explicitFile
+		cm.configDir = filepath.Dir(cm.explicitFile)
+		return nil
+	}
+
+	// Set up config file name and type, one file per profile
+	name := ProfileFileName(".gh-notif", cm.profile)
+	cm.v.SetConfigName(name)
 	cm.v.SetConfigType("yaml")
 
 	// Add config paths in order of precedence
@@ -349,7 +476,7 @@ func (cm *ConfigManager) setupConfigLocations() error {
 	} else {
 		// Default to home directory
 		cm.configDir = home
-		cm.configFile = filepath.Join(home, ".gh-notif.yaml")
+		cm.configFile = filepath.Join(home, name+".yaml")
 	}
 
 	return nil
@@ -416,6 +543,52 @@ func (cm *ConfigManager) validateConfig(config *Config) error {
 		return errors.New("invalid cache TTL: must be non-negative")
 	}
 
+	if config.Advanced.CacheMaxSize < 0 {
+		return errors.New("invalid cache max size: must be non-negative")
+	}
+
+	if config.Advanced.CacheMemoryLimit < 0 {
+		return errors.New("invalid cache memory limit: must be non-negative")
+	}
+
+	if !contains([]string{"", "zstd", "gzip", "none"}, config.Advanced.CacheCompression) {
+		return fmt.Errorf("invalid cache compression: %s", config.Advanced.CacheCompression)
+	}
+
+	if config.Advanced.HistoryMaxEntries < 0 {
+		return errors.New("invalid history max entries: must be non-negative")
+	}
+
+	if config.Advanced.HistoryMaxAge < 0 {
+		return errors.New("invalid history max age: must be non-negative")
+	}
+
+	if err := validateHosts(config.Hosts); err != nil {
+		return err
+	}
+
+	if err := validateDigest(config.Digest); err != nil {
+		return err
+	}
+
+	if err := validateWebhooks(config.Webhooks); err != nil {
+		return err
+	}
+
+	// Validate rules; their expressions are checked when they are compiled
+	for i, rule := range config.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if strings.TrimSpace(rule.When) == "" {
+			return fmt.Errorf("invalid rule %s: when must not be empty", name)
+		}
+		if !contains(RuleActions, rule.Then) {
+			return fmt.Errorf("invalid rule %s: then must be one of %s", name, strings.Join(RuleActions, ", "))
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+)
+
+// DigestDeliveries are the ways digests can be delivered
+var DigestDeliveries = []string{"stdout", "file", "desktop", "email"}
+
+// DigestGroupings are the groupings digests can use
+var DigestGroupings = []string{"repository", "owner", "type", "reason", "thread", "time", "smart"}
+
+// DigestConfig holds the settings of the digests of hourly and daily
+// repository subscriptions
+type DigestConfig struct {
+	// Delivery is where digests are delivered: stdout, file, desktop or email
+	Delivery string `mapstructure:"delivery"`
+
+	// File is the file digests are appended to by the file delivery
+	File string `mapstructure:"file"`
+
+	// DailyAt is the local time daily digests are delivered, as HH:MM
+	DailyAt string `mapstructure:"daily_at"`
+
+	// GroupBy groups the notifications of a digest: repository, owner, type,
+	// reason, thread, time or smart
+	GroupBy string `mapstructure:"group_by"`
+
+	// SMTP is the mail server used by the email delivery
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig holds the settings of the mail server digests are sent through
+type SMTPConfig struct {
+	// Host is the host name of the mail server
+	Host string `mapstructure:"host"`
+
+	// Port is the port of the mail server, 587 for STARTTLS or 465 for TLS
+	Port int `mapstructure:"port"`
+
+	// Username authenticates with the mail server, empty to send without authentication
+	Username string `mapstructure:"username"`
+
+	// Password authenticates with the mail server. Prefer setting
+	// SMTPPasswordEnv over storing it in the config file.
+	Password string `mapstructure:"password"`
+
+	// From is the sender address
+	From string `mapstructure:"from"`
+
+	// To are the recipient addresses
+	To []string `mapstructure:"to"`
+}
+
+// SMTPPasswordEnv is the environment variable holding the SMTP password. It
+// is read directly so that saving the configuration never writes it to disk.
+const SMTPPasswordEnv = "GH_NOTIF_DIGEST_SMTP_PASSWORD"
+
+// Secret returns the SMTP password, preferring SMTPPasswordEnv
+func (s SMTPConfig) Secret() string {
+	if password := os.Getenv(SMTPPasswordEnv); password != "" {
+		return password
+	}
+	return s.Password
+}
+
+// Address returns the host:port address of the mail server
+func (s SMTPConfig) Address() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// DailyTime returns the hour and minute daily digests are delivered at
+func (d DigestConfig) DailyTime() (hour, minute int, err error) {
+	t, err := time.Parse("15:04", d.DailyAt)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid digest daily_at %q: use HH:MM", d.DailyAt)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// DigestFile returns the file digests are appended to, expanding a leading ~
+func (d DigestConfig) DigestFile() string {
+	if strings.HasPrefix(d.File, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + d.File[1:]
+		}
+	}
+	return d.File
+}
+
+// validateDigest checks the digest settings. The settings of a delivery are
+// only required when it is selected.
+func validateDigest(d DigestConfig) error {
+	if !contains(DigestDeliveries, d.Delivery) {
+		return fmt.Errorf("invalid digest delivery: %s (must be %s)", d.Delivery, strings.Join(DigestDeliveries, ", "))
+	}
+	if d.GroupBy != "" && !contains(DigestGroupings, d.GroupBy) {
+		return fmt.Errorf("invalid digest group_by: %s (must be %s)", d.GroupBy, strings.Join(DigestGroupings, ", "))
+	}
+	if _, _, err := d.DailyTime(); err != nil {
+		return err
+	}
+
+	switch d.Delivery {
+	case "file":
+		if d.File == "" {
+			return fmt.Errorf("invalid digest: file must be set for the file delivery")
+		}
+	case "email":
+		if d.SMTP.Host == "" {
+			return fmt.Errorf("invalid digest: smtp.host must be set for the email delivery")
+		}
+		if d.SMTP.Port <= 0 || d.SMTP.Port > 65535 {
+			return fmt.Errorf("invalid digest smtp.port: %d", d.SMTP.Port)
+		}
+		if _, err := mail.ParseAddress(d.SMTP.From); err != nil {
+			return fmt.Errorf("invalid digest smtp.from %q: %w", d.SMTP.From, err)
+		}
+		if len(d.SMTP.To) == 0 {
+			return fmt.Errorf("invalid digest: smtp.to must list at least one recipient")
+		}
+		for _, to := range d.SMTP.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("invalid digest smtp.to %q: %w", to, err)
+			}
+		}
+	}
+	return nil
+}
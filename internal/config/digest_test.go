@@ -0,0 +1,45 @@
+package config
+
+import "testing"
+
+func TestValidateDigest(t *testing.T) {
+	email := DefaultConfig().Digest
+	email.Delivery = "email"
+	email.SMTP = SMTPConfig{Host: "smtp.example.com", Port: 587, From: "gh-notif <gh-notif@example.com>", To: []string{"dev@example.com"}}
+
+	with := func(change func(d *DigestConfig)) DigestConfig {
+		d := email
+		change(&d)
+		return d
+	}
+
+	tests := []struct {
+		name    string
+		digest  DigestConfig
+		wantErr bool
+	}{
+		{name: "default", digest: DefaultConfig().Digest},
+		{name: "email", digest: email},
+		{name: "unknown delivery", digest: with(func(d *DigestConfig) { d.Delivery = "pager" }), wantErr: true},
+		{name: "unknown grouping", digest: with(func(d *DigestConfig) { d.GroupBy = "size" }), wantErr: true},
+		{name: "invalid time", digest: with(func(d *DigestConfig) { d.DailyAt = "9am" }), wantErr: true},
+		{name: "file without path", digest: with(func(d *DigestConfig) { d.Delivery = "file" }), wantErr: true},
+		{name: "email without host", digest: with(func(d *DigestConfig) { d.SMTP.Host = "" }), wantErr: true},
+		{name: "email without recipients", digest: with(func(d *DigestConfig) { d.SMTP.To = nil }), wantErr: true},
+		{name: "invalid recipient", digest: with(func(d *DigestConfig) { d.SMTP.To = []string{"dev"} }), wantErr: true},
+		{name: "SMTP settings are ignored by other deliveries", digest: with(func(d *DigestConfig) { d.Delivery = "stdout"; d.SMTP.Port = 0 })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDigest(tt.digest); (err != nil) != tt.wantErr {
+				t.Errorf("validateDigest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Setenv(SMTPPasswordEnv, "from-env")
+	if got := email.SMTP.Secret(); got != "from-env" {
+		t.Errorf("Secret() = %q, want the environment variable", got)
+	}
+}
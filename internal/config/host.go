@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DefaultHost is the host of github.com
+const DefaultHost = "github.com"
+
+// defaultAPIURL is the API URL of github.com
+const defaultAPIURL = "https://api.github.com"
+
+// selectedHost is the host chosen with SetHost
+var selectedHost string
+
+// HostConfig holds the settings of a GitHub or GitHub Enterprise Server host
+type HostConfig struct {
+	// Host is the host name, e.g. ghe.corp.example
+	Host string `mapstructure:"host"`
+
+	// APIURL is the REST API URL of the host
+	// Default: https://<host>/api/v3, or https://api.github.com for github.com
+	APIURL string `mapstructure:"api_url"`
+
+	// UploadURL is the upload URL of the host
+	// Default: https://<host>/api/uploads, or https://uploads.github.com for github.com
+	UploadURL string `mapstructure:"upload_url"`
+
+	// ClientID is the OAuth client ID registered on the host
+	// Default: auth.client_id
+	ClientID string `mapstructure:"client_id"`
+
+	// Scopes are the OAuth scopes to request on the host
+	// Default: auth.scopes
+	Scopes []string `mapstructure:"scopes"`
+
+	// Default is set for the host configured by api.base_url, whose token is
+	// stored without a host suffix
+	Default bool `mapstructure:"-"`
+}
+
+// WebURL returns the web URL of the host, which serves the device flow
+func (h HostConfig) WebURL() string {
+	scheme := "https"
+	if u, err := url.Parse(h.APIURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	return scheme + "://" + h.Host
+}
+
+// SetHost selects the host used by new clients. An empty name selects the
+// host configured by api.base_url.
+func SetHost(name string) {
+	selectionMu.Lock()
+	defer selectionMu.Unlock()
+	selectedHost = NormalizeHost(name)
+}
+
+// Host returns the selected host, falling back to the GH_NOTIF_HOST
+// environment variable. The default host is the empty string.
+func Host() string {
+	selectionMu.RLock()
+	defer selectionMu.RUnlock()
+	if selectedHost != "" {
+		return selectedHost
+	}
+	return NormalizeHost(os.Getenv("GH_NOTIF_HOST"))
+}
+
+// NormalizeHost turns a host name or URL into a lower-case host name
+func NormalizeHost(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if strings.Contains(name, "://") {
+		if u, err := url.Parse(name); err == nil {
+			name = u.Host
+		}
+	}
+	return strings.TrimSuffix(name, "/")
+}
+
+// HostFromAPIURL returns the host of an API URL, mapping api.github.com to
+// github.com
+func HostFromAPIURL(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.ToLower(u.Host)
+	if host == "api.github.com" {
+		return DefaultHost
+	}
+	return host
+}
+
+// DefaultHostName returns the name of the host configured by api.base_url
+func (c *Config) DefaultHostName() string {
+	if host := HostFromAPIURL(c.API.BaseURL); host != "" {
+		return host
+	}
+	return DefaultHost
+}
+
+// ResolveHost returns the settings of a host with defaults filled in. An
+// empty name selects the host configured by api.base_url. Hosts that are
+// not listed in hosts use the default endpoints of GitHub Enterprise Server.
+func (c *Config) ResolveHost(name string) HostConfig {
+	name = NormalizeHost(name)
+
+	defaultName := c.DefaultHostName()
+	if name == "" {
+		name = defaultName
+	}
+
+	resolved := HostConfig{Host: name}
+	for _, h := range c.Hosts {
+		host := NormalizeHost(h.Host)
+		if host == name || (h.APIURL != "" && HostFromAPIURL(h.APIURL) == name) {
+			resolved = h
+			resolved.Host = host
+			break
+		}
+	}
+
+	if resolved.Host == defaultName {
+		resolved.Default = true
+		if resolved.APIURL == "" {
+			resolved.APIURL = c.API.BaseURL
+		}
+		if resolved.UploadURL == "" {
+			resolved.UploadURL = c.API.UploadURL
+		}
+	}
+
+	if resolved.APIURL == "" {
+		if resolved.Host == DefaultHost {
+			resolved.APIURL = defaultAPIURL
+		} else {
+			resolved.APIURL = "https://" + resolved.Host + "/api/v3"
+		}
+	}
+	if resolved.UploadURL == "" {
+		if resolved.Host == DefaultHost {
+			resolved.UploadURL = "https://uploads.github.com"
+		} else {
+			resolved.UploadURL = "https://" + resolved.Host + "/api/uploads"
+		}
+	}
+	if resolved.ClientID == "" {
+		resolved.ClientID = c.Auth.ClientID
+	}
+	if len(resolved.Scopes) == 0 {
+		resolved.Scopes = c.Auth.Scopes
+	}
+
+	return resolved
+}
+
+// HostNames returns the default host followed by the other configured hosts
+func (c *Config) HostNames() []string {
+	names := []string{c.DefaultHostName()}
+	for _, h := range c.Hosts {
+		host := c.ResolveHost(h.Host).Host
+		if !contains(names, host) {
+			names = append(names, host)
+		}
+	}
+	return names
+}
+
+// validateHosts checks the configured hosts
+func validateHosts(hosts []HostConfig) error {
+	seen := make(map[string]bool, len(hosts))
+	for i, h := range hosts {
+		host := NormalizeHost(h.Host)
+		if host == "" {
+			return fmt.Errorf("invalid host #%d: host must not be empty", i+1)
+		}
+		if strings.ContainsAny(host, "/ ") {
+			return fmt.Errorf("invalid host %q: use a host name such as ghe.example.com", h.Host)
+		}
+		if seen[host] {
+			return fmt.Errorf("invalid host %q: configured more than once", h.Host)
+		}
+		seen[host] = true
+
+		for _, u := range []string{h.APIURL, h.UploadURL} {
+			if u == "" {
+				continue
+			}
+			if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return fmt.Errorf("invalid host %q: %q is not an absolute URL", h.Host, u)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveHost(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Hosts = []HostConfig{
+		{Host: "GHE.corp.example", ClientID: "ghe-client"},
+		{Host: "code.example", APIURL: "https://api.code.example/", Scopes: []string{"notifications"}},
+	}
+
+	def := cfg.ResolveHost("")
+	if def.Host != DefaultHost || !def.Default || def.APIURL != cfg.API.BaseURL || def.ClientID != cfg.Auth.ClientID {
+		t.Errorf("ResolveHost(\"\") = %+v", def)
+	}
+	if def.WebURL() != "https://github.com" {
+		t.Errorf("WebURL() = %q, want https://github.com", def.WebURL())
+	}
+	if got := cfg.ResolveHost("github.com"); !got.Default {
+		t.Errorf("ResolveHost(github.com) is not the default host: %+v", got)
+	}
+
+	ghe := cfg.ResolveHost("https://ghe.corp.example/")
+	want := HostConfig{
+		Host:      "ghe.corp.example",
+		APIURL:    "https://ghe.corp.example/api/v3",
+		UploadURL: "https://ghe.corp.example/api/uploads",
+		ClientID:  "ghe-client",
+		Scopes:    cfg.Auth.Scopes,
+	}
+	if !reflect.DeepEqual(ghe, want) {
+		t.Errorf("ResolveHost(ghe) = %+v, want %+v", ghe, want)
+	}
+
+	// Hosts can also be selected by the host of their API URL
+	if got := cfg.ResolveHost("api.code.example"); got.Host != "code.example" || got.ClientID != cfg.Auth.ClientID {
+		t.Errorf("ResolveHost(api.code.example) = %+v", got)
+	}
+
+	if got, want := cfg.HostNames(), []string{"github.com", "ghe.corp.example", "code.example"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HostNames() = %v, want %v", got, want)
+	}
+
+	// An Enterprise Server configured by api.base_url is the default host
+	cfg.API.BaseURL = "https://ghe.corp.example/api/v3"
+	if got := cfg.ResolveHost(""); got.Host != "ghe.corp.example" || !got.Default {
+		t.Errorf("ResolveHost(\"\") with an enterprise base URL = %+v", got)
+	}
+}
+
+func TestValidateHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosts   []HostConfig
+		wantErr bool
+	}{
+		{name: "valid", hosts: []HostConfig{{Host: "ghe.corp.example"}, {Host: "other.example", APIURL: "https://other.example/api/v3"}}},
+		{name: "empty host", hosts: []HostConfig{{APIURL: "https://ghe.corp.example/api/v3"}}, wantErr: true},
+		{name: "path", hosts: []HostConfig{{Host: "ghe.corp.example/api"}}, wantErr: true},
+		{name: "duplicate", hosts: []HostConfig{{Host: "ghe.corp.example"}, {Host: "GHE.corp.example"}}, wantErr: true},
+		{name: "relative URL", hosts: []HostConfig{{Host: "ghe.corp.example", APIURL: "/api/v3"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateHosts(tt.hosts); (err != nil) != tt.wantErr {
+				t.Errorf("validateHosts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -23,6 +23,11 @@ func (cm *ConfigManager) GetConfigFile() string {
 	return cm.configFile
 }
 
+// GetProfile returns the name of the active profile, empty for the default one
+func (cm *ConfigManager) GetProfile() string {
+	return cm.profile
+}
+
 // Save saves the current configuration to file
 func (cm *ConfigManager) Save() error {
 	// Set all values in viper
@@ -70,7 +75,28 @@ func (cm *ConfigManager) setConfigValues(config *Config) {
 	cm.v.Set("advanced.max_concurrent", config.Advanced.MaxConcurrent)
 	cm.v.Set("advanced.cache_ttl", config.Advanced.CacheTTL)
 	cm.v.Set("advanced.cache_dir", config.Advanced.CacheDir)
+	cm.v.Set("advanced.cache_max_size", config.Advanced.CacheMaxSize)
+	cm.v.Set("advanced.cache_memory_limit", config.Advanced.CacheMemoryLimit)
+	cm.v.Set("advanced.cache_compression", config.Advanced.CacheCompression)
+	cm.v.Set("advanced.cache_encryption", config.Advanced.CacheEncryption)
 	cm.v.Set("advanced.editor", config.Advanced.Editor)
+	cm.v.Set("advanced.history_max_entries", config.Advanced.HistoryMaxEntries)
+	cm.v.Set("advanced.history_max_age", config.Advanced.HistoryMaxAge)
+	cm.v.Set("advanced.subscriptions_file", config.Advanced.SubscriptionsFile)
+
+	// Digest settings
+	cm.v.Set("digest.delivery", config.Digest.Delivery)
+	cm.v.Set("digest.file", config.Digest.File)
+	cm.v.Set("digest.daily_at", config.Digest.DailyAt)
+	cm.v.Set("digest.group_by", config.Digest.GroupBy)
+	cm.v.Set("digest.smtp.host", config.Digest.SMTP.Host)
+	cm.v.Set("digest.smtp.port", config.Digest.SMTP.Port)
+	cm.v.Set("digest.smtp.username", config.Digest.SMTP.Username)
+	if config.Digest.SMTP.Password != "" {
+		cm.v.Set("digest.smtp.password", config.Digest.SMTP.Password)
+	}
+	cm.v.Set("digest.smtp.from", config.Digest.SMTP.From)
+	cm.v.Set("digest.smtp.to", config.Digest.SMTP.To)
 }
 
 // GetValue gets a configuration value by key
@@ -118,7 +144,7 @@ func (cm *ConfigManager) validateKey(key string) error {
 
 	// Check if the section exists
 	section := parts[0]
-	validSections := []string{"auth", "display", "notifications", "api", "advanced"}
+	validSections := []string{"auth", "display", "notifications", "api", "advanced", "digest"}
 	if !contains(validSections, section) {
 		return fmt.Errorf("invalid configuration section: %s (valid sections: %s)", section, strings.Join(validSections, ", "))
 	}
@@ -135,15 +161,15 @@ func (cm *ConfigManager) validateKey(key string) error {
 
 	// Check if the key exists in the section
 	keyName := parts[1]
-	keyValue := sectionValue.FieldByNameFunc(func(name string) bool {
-		return strings.EqualFold(name, keyName)
-	})
-
-	if !keyValue.IsValid() {
-		return fmt.Errorf("invalid configuration key: %s", key)
+	sectionType := sectionValue.Type()
+	for i := 0; i < sectionType.NumField(); i++ {
+		field := sectionType.Field(i)
+		if field.Tag.Get("mapstructure") == keyName || strings.EqualFold(field.Name, keyName) {
+			return nil
+		}
 	}
 
-	return nil
+	return fmt.Errorf("invalid configuration key: %s", key)
 }
 
 // validateValue validates a configuration value
@@ -255,6 +281,64 @@ func (cm *ConfigManager) validateValue(key string, value interface{}) error {
 		} else {
 			return errors.New("cache TTL must be an integer")
 		}
+	case "advanced.cache_max_size", "advanced.cache_memory_limit":
+		if num, ok := value.(int); ok {
+			if num < 0 {
+				return errors.New("cache size limits must be non-negative")
+			}
+		} else {
+			return errors.New("cache size limits must be integers")
+		}
+	case "advanced.cache_compression":
+		if str, ok := value.(string); ok {
+			if !contains([]string{"zstd", "gzip", "none"}, str) {
+				return errors.New("invalid cache compression: must be 'zstd', 'gzip', or 'none'")
+			}
+		} else {
+			return errors.New("cache compression must be a string")
+		}
+	case "advanced.history_max_entries":
+		if num, ok := value.(int); ok {
+			if num < 0 {
+				return errors.New("history max entries must be non-negative")
+			}
+		} else {
+			return errors.New("history max entries must be an integer")
+		}
+	case "advanced.history_max_age":
+		if num, ok := value.(int); ok {
+			if num < 0 {
+				return errors.New("history max age must be non-negative")
+			}
+		} else {
+			return errors.New("history max age must be an integer")
+		}
+
+	// Digest settings
+	case "digest.delivery":
+		if str, ok := value.(string); ok {
+			if !contains(DigestDeliveries, str) {
+				return fmt.Errorf("invalid digest delivery: must be one of %s", strings.Join(DigestDeliveries, ", "))
+			}
+		} else {
+			return errors.New("digest delivery must be a string")
+		}
+	case "digest.group_by":
+		if str, ok := value.(string); ok {
+			if !contains(DigestGroupings, str) {
+				return fmt.Errorf("invalid digest grouping: must be one of %s", strings.Join(DigestGroupings, ", "))
+			}
+		} else {
+			return errors.New("digest grouping must be a string")
+		}
+	case "digest.daily_at":
+		if str, ok := value.(string); ok {
+			if _, _, err := (DigestConfig{DailyAt: str}).DailyTime(); err != nil {
+				return err
+			}
+		} else {
+			return errors.New("digest daily_at must be a string")
+		}
 	}
 
 	return nil
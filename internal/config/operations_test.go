@@ -316,6 +316,11 @@ func TestConfigManagerValidateKey(t *testing.T) {
 			key:     "display.theme",
 			wantErr: false,
 		},
+		{
+			name:    "Valid snake case key",
+			key:     "display.date_format",
+			wantErr: false,
+		},
 		{
 			name:    "Invalid section",
 			key:     "invalid.theme",
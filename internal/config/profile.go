@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+)
+
+var (
+	// selectedConfigFile is the configuration file chosen with SetConfigFile
+	selectedConfigFile string
+
+	// selectedProfile is the profile chosen with SetProfile
+	selectedProfile string
+
+	// selectionMu protects the selected config file and profile
+	selectionMu sync.RWMutex
+
+	// profileNamePattern matches valid profile names
+	profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+)
+
+// SetConfigFile selects the configuration file used by new ConfigManagers.
+// An empty path restores the default lookup.
+func SetConfigFile(path string) {
+	selectionMu.Lock()
+	defer selectionMu.Unlock()
+	selectedConfigFile = path
+}
+
+// ConfigFile returns the selected configuration file, falling back to the
+// GH_NOTIF_CONFIG environment variable
+func ConfigFile() string {
+	selectionMu.RLock()
+	defer selectionMu.RUnlock()
+	if selectedConfigFile != "" {
+		return selectedConfigFile
+	}
+	return os.Getenv("GH_NOTIF_CONFIG")
+}
+
+// SetProfile selects the named profile used by new ConfigManagers.
+// An empty name selects the default profile.
+func SetProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	selectionMu.Lock()
+	defer selectionMu.Unlock()
+	selectedProfile = name
+	return nil
+}
+
+// Profile returns the selected profile, falling back to the GH_NOTIF_PROFILE
+// environment variable. The default profile is the empty string.
+func Profile() string {
+	selectionMu.RLock()
+	defer selectionMu.RUnlock()
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	return os.Getenv("GH_NOTIF_PROFILE")
+}
+
+// ValidateProfileName checks that a profile name can be used in file names
+func ValidateProfileName(name string) error {
+	if name == "" || profileNamePattern.MatchString(name) {
+		return nil
+	}
+	return fmt.Errorf("invalid profile name %q: use letters, digits, '-' and '_'", name)
+}
+
+// ProfileFileName returns the file name for a profile, e.g. ".gh-notif" becomes
+// ".gh-notif-work" for the "work" profile
+func ProfileFileName(base, profile string) string {
+	if profile == "" {
+		return base
+	}
+	return base + "-" + profile
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SharanRP/gh-notif/internal/testutil"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "", wantErr: false},
+		{name: "work", wantErr: false},
+		{name: "personal_2-gh", wantErr: false},
+		{name: "-work", wantErr: true},
+		{name: "a/b", wantErr: true},
+		{name: "../work", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProfileName(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProfileName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigFileSelection(t *testing.T) {
+	tempDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	defer SetConfigFile("")
+
+	// The environment variable is used when no file was selected
+	envFile := filepath.Join(tempDir, "env.yaml")
+	t.Setenv("GH_NOTIF_CONFIG", envFile)
+	if got := ConfigFile(); got != envFile {
+		t.Errorf("ConfigFile() = %q, want %q", got, envFile)
+	}
+
+	// An explicitly selected file wins over the environment
+	flagFile := filepath.Join(tempDir, "nested", "flag.yaml")
+	SetConfigFile(flagFile)
+	if got := ConfigFile(); got != flagFile {
+		t.Errorf("ConfigFile() = %q, want %q", got, flagFile)
+	}
+
+	cm := NewConfigManager()
+	if err := cm.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cm.GetConfigFile() != flagFile {
+		t.Errorf("GetConfigFile() = %q, want %q", cm.GetConfigFile(), flagFile)
+	}
+	if _, err := os.Stat(flagFile); err != nil {
+		t.Errorf("Load() did not create the selected config file: %v", err)
+	}
+
+	// Values are saved to and read back from the selected file
+	if err := cm.SetValue("display.theme", "light"); err != nil {
+		t.Fatalf("SetValue() error = %v", err)
+	}
+
+	reloaded := NewConfigManager()
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := reloaded.GetConfig().Display.Theme; got != "light" {
+		t.Errorf("Display.Theme = %q, want %q", got, "light")
+	}
+}
+
+func TestProfileSelection(t *testing.T) {
+	tempDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	t.Setenv("HOME", tempDir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("GH_NOTIF_CONFIG", "")
+	t.Setenv("GH_NOTIF_PROFILE", "")
+
+	// Run from the temporary directory so the current directory lookup finds nothing
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+
+	if err := SetProfile("a/b"); err == nil {
+		t.Errorf("SetProfile() with invalid name did not return error")
+	}
+
+	if err := SetProfile("work"); err != nil {
+		t.Fatalf("SetProfile() error = %v", err)
+	}
+	defer SetProfile("")
+
+	work := NewConfigManager()
+	if err := work.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if work.GetProfile() != "work" {
+		t.Errorf("GetProfile() = %q, want %q", work.GetProfile(), "work")
+	}
+	if want := filepath.Join(tempDir, ".gh-notif-work.yaml"); work.GetConfigFile() != want {
+		t.Errorf("GetConfigFile() = %q, want %q", work.GetConfigFile(), want)
+	}
+	if want := filepath.Join(tempDir, ".gh-notif-cache-work"); work.GetConfig().Advanced.CacheDir != want {
+		t.Errorf("Advanced.CacheDir = %q, want %q", work.GetConfig().Advanced.CacheDir, want)
+	}
+
+	if err := work.SetValue("display.theme", "light"); err != nil {
+		t.Fatalf("SetValue() error = %v", err)
+	}
+
+	// The default profile keeps its own configuration
+	if err := SetProfile(""); err != nil {
+		t.Fatalf("SetProfile() error = %v", err)
+	}
+
+	personal := NewConfigManager()
+	if err := personal.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if want := filepath.Join(tempDir, ".gh-notif.yaml"); personal.GetConfigFile() != want {
+		t.Errorf("GetConfigFile() = %q, want %q", personal.GetConfigFile(), want)
+	}
+	if got := personal.GetConfig().Display.Theme; got != "auto" {
+		t.Errorf("Display.Theme = %q, want %q", got, "auto")
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// WebhookFormats are the payload formats of webhooks
+var WebhookFormats = []string{"slack", "discord", "teams", "generic"}
+
+// WebhookEvents are the watch events webhooks can receive
+var WebhookEvents = []string{"new", "updated", "read", "unsnoozed"}
+
+// WebhookSecretEnv is the environment variable holding the secret of
+// webhooks without one. It is read directly so that saving the
+// configuration never writes it to disk.
+const WebhookSecretEnv = "GH_NOTIF_WEBHOOK_SECRET"
+
+// WebhookConfig holds the settings of a webhook that watch events are
+// posted to
+type WebhookConfig struct {
+	// Name identifies the webhook, e.g. in failed deliveries
+	// Default: the host of the URL
+	Name string `mapstructure:"name"`
+
+	// URL is where events are posted
+	URL string `mapstructure:"url"`
+
+	// Format is the payload format: slack, discord, teams or generic
+	// Default: detected from the URL, generic for unknown hosts
+	Format string `mapstructure:"format"`
+
+	// Secret signs payloads with HMAC-SHA256
+	// Default: the GH_NOTIF_WEBHOOK_SECRET environment variable
+	Secret string `mapstructure:"secret"`
+
+	// Events are the watch events posted: new, updated, read or unsnoozed
+	// Default: new, updated and unsnoozed
+	Events []string `mapstructure:"events"`
+
+	// Filter is a filter expression notifications must match, e.g.
+	// "reason:review_requested"
+	Filter string `mapstructure:"filter"`
+}
+
+// DisplayName returns the name of the webhook, or the host of its URL
+func (w WebhookConfig) DisplayName() string {
+	if w.Name != "" {
+		return w.Name
+	}
+	if u, err := url.Parse(w.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return w.URL
+}
+
+// SigningSecret returns the secret that signs the payloads of the webhook
+func (w WebhookConfig) SigningSecret() string {
+	if w.Secret != "" {
+		return w.Secret
+	}
+	return os.Getenv(WebhookSecretEnv)
+}
+
+// Webhook returns the configured webhook with a name or URL
+func (c *Config) Webhook(nameOrURL string) (WebhookConfig, bool) {
+	for _, w := range c.Webhooks {
+		if w.Name == nameOrURL || w.URL == nameOrURL {
+			return w, true
+		}
+	}
+	return WebhookConfig{}, false
+}
+
+// ValidateWebhookURL checks that a webhook URL is an absolute HTTP(S) URL
+func ValidateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("%q is not an http or https URL", rawURL)
+	}
+	return nil
+}
+
+// validateWebhooks checks the configured webhooks
+func validateWebhooks(webhooks []WebhookConfig) error {
+	names := make(map[string]bool, len(webhooks))
+	for i, w := range webhooks {
+		name := w.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if err := ValidateWebhookURL(w.URL); err != nil {
+			return fmt.Errorf("invalid webhook %s: %w", name, err)
+		}
+		if w.Format != "" && !contains(WebhookFormats, w.Format) {
+			return fmt.Errorf("invalid webhook %s: format must be one of %s", name, strings.Join(WebhookFormats, ", "))
+		}
+		for _, event := range w.Events {
+			if !contains(WebhookEvents, event) {
+				return fmt.Errorf("invalid webhook %s: events must be among %s", name, strings.Join(WebhookEvents, ", "))
+			}
+		}
+		if w.Name != "" {
+			if names[w.Name] {
+				return fmt.Errorf("invalid webhook %s: configured more than once", name)
+			}
+			names[w.Name] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import "testing"
+
+func TestValidateWebhooks(t *testing.T) {
+	slack := WebhookConfig{Name: "reviews", URL: "https://hooks.slack.com/services/T000/B000/XXXX", Events: []string{"new"}}
+
+	with := func(change func(w *WebhookConfig)) []WebhookConfig {
+		w := slack
+		change(&w)
+		return []WebhookConfig{w}
+	}
+
+	tests := []struct {
+		name     string
+		webhooks []WebhookConfig
+		wantErr  bool
+	}{
+		{name: "none"},
+		{name: "slack", webhooks: []WebhookConfig{slack}},
+		{name: "unnamed generic", webhooks: with(func(w *WebhookConfig) { w.Name = ""; w.URL = "http://localhost:8080/hook"; w.Format = "generic" })},
+		{name: "relative URL", webhooks: with(func(w *WebhookConfig) { w.URL = "/hook" }), wantErr: true},
+		{name: "unsupported scheme", webhooks: with(func(w *WebhookConfig) { w.URL = "ftp://example.com/hook" }), wantErr: true},
+		{name: "unknown format", webhooks: with(func(w *WebhookConfig) { w.Format = "irc" }), wantErr: true},
+		{name: "unknown event", webhooks: with(func(w *WebhookConfig) { w.Events = []string{"deleted"} }), wantErr: true},
+		{name: "duplicate name", webhooks: []WebhookConfig{slack, slack}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWebhooks(tt.webhooks); (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhooks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := (WebhookConfig{URL: "https://example.com:8443/hook"}).DisplayName(); got != "example.com:8443" {
+		t.Errorf("DisplayName() = %q, want the host of the URL", got)
+	}
+
+	t.Setenv(WebhookSecretEnv, "from-env")
+	if got := slack.SigningSecret(); got != "from-env" {
+		t.Errorf("SigningSecret() = %q, want the environment variable", got)
+	}
+	slack.Secret = "configured"
+	if got := slack.SigningSecret(); got != "configured" {
+		t.Errorf("SigningSecret() = %q, want the configured secret", got)
+	}
+}
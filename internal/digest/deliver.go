@@ -0,0 +1,197 @@
+package digest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Deliverer delivers rendered digests
+type Deliverer interface {
+	// Deliver delivers a digest
+	Deliver(ctx context.Context, d *Digest) error
+}
+
+// DeliverFunc adapts a function to a Deliverer
+type DeliverFunc func(ctx context.Context, d *Digest) error
+
+// Deliver calls the function
+func (f DeliverFunc) Deliver(ctx context.Context, d *Digest) error {
+	return f(ctx, d)
+}
+
+// WriterDelivery writes digests to a writer, such as standard output
+type WriterDelivery struct {
+	// Writer receives the digests
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+// NewWriterDelivery creates a delivery writing digests to a writer
+func NewWriterDelivery(w io.Writer) *WriterDelivery {
+	return &WriterDelivery{Writer: w}
+}
+
+// Deliver writes the subject and body of a digest
+func (w *WriterDelivery) Deliver(ctx context.Context, d *Digest) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := fmt.Fprintf(w.Writer, "%s\n\n%s\n", d.Subject, strings.TrimRight(d.Body, "\n"))
+	return err
+}
+
+// FileDelivery appends digests to a file
+type FileDelivery struct {
+	// Path is the file digests are appended to
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewFileDelivery creates a delivery appending digests to a file
+func NewFileDelivery(path string) *FileDelivery {
+	return &FileDelivery{Path: path}
+}
+
+// Deliver appends a digest to the file, headed by its subject and time
+func (f *FileDelivery) Deliver(ctx context.Context, d *Digest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create digest directory: %w", err)
+	}
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open digest file: %w", err)
+	}
+
+	_, err = fmt.Fprintf(file, "## %s\n%s\n\n%s\n\n", d.Subject, d.Until.Format(time.RFC1123), strings.TrimRight(d.Body, "\n"))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write digest file: %w", err)
+	}
+	return nil
+}
+
+// EmailDelivery sends digests through an SMTP server. Port 465 connects
+// with TLS; other ports upgrade with STARTTLS when the server offers it.
+type EmailDelivery struct {
+	// Address is the host:port of the mail server
+	Address string
+	// Username authenticates with the server, empty to send without authentication
+	Username string
+	// Password authenticates with the server
+	Password string
+	// From is the sender address
+	From string
+	// To are the recipient addresses
+	To []string
+	// TLSConfig configures TLS connections, nil for the defaults
+	TLSConfig *tls.Config
+	// Timeout limits connecting to the server
+	Timeout time.Duration
+}
+
+// Deliver sends a digest as a plain text email
+func (e *EmailDelivery) Deliver(ctx context.Context, d *Digest) error {
+	host, port, err := net.SplitHostPort(e.Address)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", e.Address, err)
+	}
+
+	tlsConfig := e.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	if port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", e.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", e.Address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && port != "465" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(e.From); err != nil {
+		return fmt.Errorf("SMTP server rejected sender %s: %w", e.From, err)
+	}
+	for _, to := range e.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+	if _, err := w.Write(e.message(d)); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send digest: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// message formats a digest as an email message
+func (e *EmailDelivery) message(d *Digest) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", d.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", d.Until.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	for _, line := range strings.Split(strings.TrimRight(d.Body, "\n"), "\n") {
+		b.WriteString(strings.TrimRight(line, "\r"))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
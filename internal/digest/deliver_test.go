@@ -0,0 +1,166 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer is a local SMTP stand-in recording the messages it receives
+type smtpServer struct {
+	listener net.Listener
+	messages chan smtpMessage
+}
+
+// smtpMessage is a message received by the SMTP stand-in
+type smtpMessage struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer starts an SMTP stand-in offering PLAIN authentication
+func startSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &smtpServer{listener: listener, messages: make(chan smtpMessage, 1)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// serve handles one SMTP session
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var msg smtpMessage
+
+	tp.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			_, credentials, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(credentials)
+			msg.auth = string(decoded)
+			tp.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+			tp.PrintfLine("250 OK")
+			s.messages <- msg
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestEmailDelivery(t *testing.T) {
+	server := startSMTPServer(t)
+
+	delivery := &EmailDelivery{
+		Address:  server.listener.Addr().String(),
+		Username: "digest",
+		Password: "secret",
+		From:     "gh-notif@example.com",
+		To:       []string{"dev@example.com", "lead@example.com"},
+	}
+	d := &Digest{
+		Subject: "Daily digest for org/*: 2 notifications",
+		Body:    "org/api (2)\n  - [PullRequest] Pull request 1\n.hidden by dot stuffing\n",
+		Until:   time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := delivery.Deliver(ctx, d); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-server.messages:
+	case <-ctx.Done():
+		t.Fatal("the SMTP server received no message")
+	}
+
+	if msg.auth != "\x00digest\x00secret" {
+		t.Errorf("auth = %q", msg.auth)
+	}
+	if msg.from != "gh-notif@example.com" || strings.Join(msg.to, ",") != "dev@example.com,lead@example.com" {
+		t.Errorf("envelope = %s -> %v", msg.from, msg.to)
+	}
+
+	header, err := textproto.NewReader(bufio.NewReader(strings.NewReader(msg.data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("failed to parse message header: %v", err)
+	}
+	if header.Get("Subject") != d.Subject || header.Get("To") != "dev@example.com, lead@example.com" {
+		t.Errorf("header = %v", header)
+	}
+	// The dot reader turns line endings into \n and undoes dot stuffing
+	if !strings.HasSuffix(msg.data, "\n\norg/api (2)\n  - [PullRequest] Pull request 1\n.hidden by dot stuffing\n") {
+		t.Errorf("body = %q", msg.data)
+	}
+}
+
+func TestFileDelivery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "digests", "digest.md")
+	delivery := NewFileDelivery(path)
+
+	for _, subject := range []string{"First", "Second"} {
+		d := &Digest{Subject: subject, Body: "body\n", Until: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)}
+		if err := delivery.Deliver(context.Background(), d); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read digest file: %v", err)
+	}
+	want := "## First\nWed, 01 May 2024 09:00:00 UTC\n\nbody\n\n## Second\nWed, 01 May 2024 09:00:00 UTC\n\nbody\n\n"
+	if string(data) != want {
+		t.Errorf("digest file = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,316 @@
+// Package digest batches the notifications of hourly and daily repository
+// subscriptions and delivers them as grouped summaries
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/filelock"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/google/go-github/v60/github"
+)
+
+// FileName is the name of the digest queue in the cache directory
+const FileName = "digests.json"
+
+// Entry is a queued notification
+type Entry struct {
+	// Notification is the notification as it was last seen
+	Notification *github.Notification `json:"notification"`
+	// QueuedAt is when the notification was first queued
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// Batch is the queue of a subscription's next digest
+type Batch struct {
+	// Subscription is the repository or pattern of the subscription
+	Subscription string `json:"subscription"`
+	// Frequency is how often the subscription's digest is delivered
+	Frequency subscriptions.Frequency `json:"frequency"`
+	// Entries are the queued notifications, oldest first
+	Entries []Entry `json:"entries"`
+	// LastDelivered is when the last digest was delivered, zero if none was
+	LastDelivered time.Time `json:"last_delivered"`
+}
+
+// Since returns when the batch started collecting notifications: the last
+// delivery, or when its first notification was queued
+func (b Batch) Since() time.Time {
+	if !b.LastDelivered.IsZero() || len(b.Entries) == 0 {
+		return b.LastDelivered
+	}
+	since := b.Entries[0].QueuedAt
+	for _, e := range b.Entries[1:] {
+		if e.QueuedAt.Before(since) {
+			since = e.QueuedAt
+		}
+	}
+	return since
+}
+
+// Notifications returns the queued notifications
+func (b Batch) Notifications() []*github.Notification {
+	notifications := make([]*github.Notification, len(b.Entries))
+	for i, e := range b.Entries {
+		notifications[i] = e.Notification
+	}
+	return notifications
+}
+
+// Queue holds the notifications waiting for the digests of their
+// subscriptions
+type Queue struct {
+	// path is the queue file, empty for an in-memory queue
+	path string
+	// now returns the current time
+	now func() time.Time
+
+	mu      sync.RWMutex
+	batches map[string]*Batch
+}
+
+// NewQueue creates an in-memory digest queue
+func NewQueue() *Queue {
+	return &Queue{
+		now:     time.Now,
+		batches: make(map[string]*Batch),
+	}
+}
+
+// Open loads the digest queue from a file, creating it on the first change.
+// The file may be shared with other processes, such as a running watch and
+// digest send, so changes are made to its current content under its file
+// lock.
+func Open(path string) (*Queue, error) {
+	q := NewQueue()
+	q.path = path
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Add queues notifications for the digest of a subscription and returns how
+// many were queued or updated. A notification already queued is replaced
+// when it was updated since; one that was not updated since the last digest
+// was delivered is not queued again.
+func (q *Queue) Add(subscription string, frequency subscriptions.Frequency, notifications []*github.Notification) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := 0
+	err := q.update(func() bool {
+		b, ok := q.batches[subscription]
+		if !ok {
+			b = &Batch{Subscription: subscription}
+			q.batches[subscription] = b
+		}
+		changed := b.Frequency != frequency
+		b.Frequency = frequency
+
+		now := q.now()
+		for _, n := range notifications {
+			updatedAt := n.GetUpdatedAt().Time
+			if !b.LastDelivered.IsZero() && !updatedAt.After(b.LastDelivered) {
+				continue
+			}
+
+			index := -1
+			for i, e := range b.Entries {
+				if e.Notification.GetID() == n.GetID() {
+					index = i
+					break
+				}
+			}
+			switch {
+			case index < 0:
+				b.Entries = append(b.Entries, Entry{Notification: n, QueuedAt: now})
+			case updatedAt.After(b.Entries[index].Notification.GetUpdatedAt().Time):
+				b.Entries[index].Notification = n
+			default:
+				continue
+			}
+			count++
+		}
+		return count > 0 || changed
+	})
+	return count, err
+}
+
+// Batch returns the batch of a subscription
+func (q *Queue) Batch(subscription string) (Batch, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.refresh()
+	b, ok := q.batches[subscription]
+	if !ok {
+		return Batch{}, false
+	}
+	return copyBatch(b), true
+}
+
+// Batches returns the batches ordered by subscription
+func (q *Queue) Batches() []Batch {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.refresh()
+	batches := make([]Batch, 0, len(q.batches))
+	for _, b := range q.batches {
+		batches = append(batches, copyBatch(b))
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].Subscription < batches[j].Subscription })
+	return batches
+}
+
+// Delivered removes the notifications of a delivered digest from the batch
+// of its subscription. Notifications queued after the digest was rendered
+// are kept for the next one.
+func (q *Queue) Delivered(digest *Digest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.update(func() bool {
+		b, ok := q.batches[digest.Subscription]
+		if !ok {
+			return false
+		}
+
+		delivered := make(map[string]time.Time, len(digest.Notifications))
+		for _, n := range digest.Notifications {
+			delivered[n.GetID()] = n.GetUpdatedAt().Time
+		}
+		remaining := b.Entries[:0]
+		for _, e := range b.Entries {
+			updatedAt, ok := delivered[e.Notification.GetID()]
+			if !ok || e.Notification.GetUpdatedAt().Time.After(updatedAt) {
+				remaining = append(remaining, e)
+			}
+		}
+		b.Entries = remaining
+		b.LastDelivered = digest.Until
+		return true
+	})
+}
+
+// Clear drops the queued notifications of a subscription, or of every
+// subscription when subscription is empty, and returns how many were dropped
+func (q *Queue) Clear(subscription string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := 0
+	err := q.update(func() bool {
+		for key, b := range q.batches {
+			if subscription != "" && key != subscription {
+				continue
+			}
+			count += len(b.Entries)
+			b.Entries = nil
+		}
+		return count > 0
+	})
+	return count, err
+}
+
+// copyBatch copies a batch so callers cannot change the queue
+func copyBatch(b *Batch) Batch {
+	c := *b
+	c.Entries = append([]Entry(nil), b.Entries...)
+	return c
+}
+
+// update applies a change to the current content of the queue's file under
+// its file lock, and saves the queue when change reports that it changed
+// something. Callers must hold the write lock.
+func (q *Queue) update(change func() bool) error {
+	if q.path == "" {
+		change()
+		return nil
+	}
+
+	lock, err := filelock.Acquire(q.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := q.load(); err != nil {
+		return err
+	}
+	if !change() {
+		return nil
+	}
+	return q.save()
+}
+
+// refresh reloads the queue from its file, keeping the batches in memory
+// when it cannot be read. Callers must hold the write lock.
+func (q *Queue) refresh() {
+	if q.path != "" {
+		_ = q.load()
+	}
+}
+
+// load replaces the batches with the content of the queue's file, which is
+// replaced atomically. Callers must hold the write lock.
+func (q *Queue) load() error {
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		q.batches = make(map[string]*Batch)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read digest queue: %w", err)
+	}
+
+	var batches []*Batch
+	if err := json.Unmarshal(data, &batches); err != nil {
+		return fmt.Errorf("failed to parse digest queue: %w", err)
+	}
+	q.batches = make(map[string]*Batch, len(batches))
+	for _, b := range batches {
+		q.batches[b.Subscription] = b
+	}
+	return nil
+}
+
+// save writes the queue to its file. Callers must hold the write lock.
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	batches := make([]*Batch, 0, len(q.batches))
+	for _, b := range q.batches {
+		batches = append(batches, b)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].Subscription < batches[j].Subscription })
+
+	data, err := json.MarshalIndent(batches, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode digest queue: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0700); err != nil {
+		return fmt.Errorf("failed to create digest directory: %w", err)
+	}
+
+	tmpPath := q.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write digest queue: %w", err)
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to replace digest queue: %w", err)
+	}
+
+	return nil
+}
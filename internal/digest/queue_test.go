@@ -0,0 +1,118 @@
+package digest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/google/go-github/v60/github"
+)
+
+// testNotification creates a pull request notification updated at a time
+func testNotification(id, repo string, updatedAt time.Time) *github.Notification {
+	return &github.Notification{
+		ID:     github.String(id),
+		Reason: github.String("review_requested"),
+		Subject: &github.NotificationSubject{
+			Type:  github.String("PullRequest"),
+			Title: github.String("Pull request " + id),
+			URL:   github.String("https://api.github.com/repos/" + repo + "/pulls/" + id),
+		},
+		Repository: &github.Repository{FullName: github.String(repo)},
+		UpdatedAt:  &github.Timestamp{Time: updatedAt},
+	}
+}
+
+func TestQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	queue, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	queue.now = func() time.Time { return now }
+
+	updated := now.Add(-time.Hour)
+	count, err := queue.Add("org/*", subscriptions.FrequencyHourly, []*github.Notification{
+		testNotification("1", "org/api", updated), testNotification("2", "org/web", updated),
+	})
+	if err != nil || count != 2 {
+		t.Fatalf("Add() = %d, %v, want 2 queued", count, err)
+	}
+
+	// The same notifications are not queued twice, updated ones replace theirs
+	count, _ = queue.Add("org/*", subscriptions.FrequencyHourly, []*github.Notification{
+		testNotification("1", "org/api", updated), testNotification("2", "org/web", now),
+	})
+	if count != 1 {
+		t.Errorf("Add() of a known and an updated notification = %d, want 1", count)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	b, ok := reopened.Batch("org/*")
+	if !ok || len(b.Entries) != 2 || b.Frequency != subscriptions.FrequencyHourly {
+		t.Fatalf("Batch() after reopening = %+v", b)
+	}
+	if !b.Since().Equal(now) {
+		t.Errorf("Since() = %v, want when the first notification was queued", b.Since())
+	}
+
+	// A delivered digest keeps what was queued after it was rendered
+	digest := &Digest{Subscription: "org/*", Until: now, Notifications: b.Notifications()[:1]}
+	if err := reopened.Delivered(digest); err != nil {
+		t.Fatalf("Delivered() error = %v", err)
+	}
+	b, _ = reopened.Batch("org/*")
+	if len(b.Entries) != 1 || b.Entries[0].Notification.GetID() != "2" || !b.LastDelivered.Equal(now) {
+		t.Errorf("Batch() after delivery = %+v", b)
+	}
+
+	// Notifications not updated since the last digest are not queued again
+	count, _ = reopened.Add("org/*", subscriptions.FrequencyHourly, []*github.Notification{testNotification("1", "org/api", updated)})
+	if count != 0 {
+		t.Errorf("Add() of a delivered notification = %d, want 0", count)
+	}
+
+	if count, err := reopened.Clear(""); err != nil || count != 1 {
+		t.Errorf("Clear() = %d, %v, want 1 dropped", count, err)
+	}
+	if batches := reopened.Batches(); len(batches) != 1 || len(batches[0].Entries) != 0 {
+		t.Errorf("Batches() after Clear() = %+v", batches)
+	}
+}
+
+func TestQueueSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	updatedAt := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+
+	// A running watch and digest clear from another shell
+	watch, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := watch.Add("org/api", subscriptions.FrequencyDaily, []*github.Notification{testNotification("1", "org/api", updatedAt)}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	shell, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if cleared, err := shell.Clear(""); err != nil || cleared != 1 {
+		t.Fatalf("Clear() = %d, %v, want 1", cleared, err)
+	}
+
+	// The watch's next notification does not bring back the cleared one
+	if _, err := watch.Add("org/api", subscriptions.FrequencyDaily, []*github.Notification{testNotification("2", "org/api", updatedAt)}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	batch, ok := shell.Batch("org/api")
+	if !ok || len(batch.Entries) != 1 || batch.Entries[0].Notification.GetID() != "2" {
+		t.Errorf("Batch() = %+v, want only 2", batch)
+	}
+}
@@ -0,0 +1,190 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/grouping"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/google/go-github/v60/github"
+)
+
+// Digest is a rendered summary of the notifications of a subscription
+type Digest struct {
+	// Subscription is the repository or pattern of the subscription
+	Subscription string
+	// Frequency is how often the subscription's digest is delivered
+	Frequency subscriptions.Frequency
+	// Since is when the digest started collecting notifications
+	Since time.Time
+	// Until is when the digest was rendered
+	Until time.Time
+	// Count is the number of notifications in the digest
+	Count int
+	// Notifications are the notifications in the digest
+	Notifications []*github.Notification
+	// Groups are the notifications grouped for the summary
+	Groups []*grouping.Group
+	// Subject is the one-line summary of the digest
+	Subject string
+	// Body is the rendered summary
+	Body string
+}
+
+// templateFuncs are the functions available to digest templates
+var templateFuncs = template.FuncMap{
+	"webURL":  webURL,
+	"details": enrichment.Lookup,
+}
+
+// ParseTemplate parses a digest template. Templates are executed with the
+// Digest and can use the webURL and details functions on notifications.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("digest").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Renderer renders batches into digests
+type Renderer struct {
+	// Grouper groups the notifications of a digest
+	Grouper *grouping.Grouper
+}
+
+// NewRenderer creates a renderer grouping notifications by a grouping type.
+// Every notification is kept, however small its group.
+func NewRenderer(groupBy grouping.GroupType) *Renderer {
+	options := grouping.DefaultGroupOptions()
+	if groupBy != "" {
+		options.PrimaryGrouping = groupBy
+	}
+	options.MinGroupSize = 1
+	options.MaxGroups = 0
+	return &Renderer{Grouper: grouping.NewGrouper(options)}
+}
+
+// Render renders the digest of a batch with a template, or with the default
+// summary when the template is empty
+func (r *Renderer) Render(ctx context.Context, b Batch, text string, until time.Time) (*Digest, error) {
+	notifications := b.Notifications()
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].GetUpdatedAt().After(notifications[j].GetUpdatedAt().Time)
+	})
+
+	groups, err := r.group(ctx, notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Digest{
+		Subscription:  b.Subscription,
+		Frequency:     b.Frequency,
+		Since:         b.Since(),
+		Until:         until,
+		Count:         len(notifications),
+		Notifications: notifications,
+		Groups:        groups,
+	}
+	d.Subject = fmt.Sprintf("%s digest for %s: %s", capitalize(b.Frequency.String()), b.Subscription, countNotifications(d.Count))
+
+	if text == "" {
+		d.Body = defaultBody(d)
+		return d, nil
+	}
+
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	var body strings.Builder
+	if err := tmpl.Execute(&body, d); err != nil {
+		return nil, fmt.Errorf("failed to render digest for %s: %w", b.Subscription, err)
+	}
+	d.Body = body.String()
+	return d, nil
+}
+
+// group groups notifications, keeping the ones the grouping leaves out in
+// an "Other" group
+func (r *Renderer) group(ctx context.Context, notifications []*github.Notification) ([]*grouping.Group, error) {
+	groups, err := r.Grouper.Group(ctx, notifications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to group digest: %w", err)
+	}
+
+	grouped := make(map[string]bool, len(notifications))
+	for _, g := range groups {
+		for _, n := range g.Notifications {
+			grouped[n.GetID()] = true
+		}
+	}
+	other := &grouping.Group{ID: "other", Name: "Other", Type: r.Grouper.Options.PrimaryGrouping}
+	for _, n := range notifications {
+		if !grouped[n.GetID()] {
+			other.Notifications = append(other.Notifications, n)
+			other.Count++
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	if other.Count > 0 {
+		groups = append(groups, other)
+	}
+	return groups, nil
+}
+
+// defaultBody renders the default summary of a digest
+func defaultBody(d *Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s since %s\n", countNotifications(d.Count), d.Since.Format("Jan 2 15:04"))
+	for _, g := range d.Groups {
+		fmt.Fprintf(&b, "\n%s (%d)\n", g.Name, g.Count)
+		for _, n := range g.Notifications {
+			fmt.Fprintf(&b, "  - [%s] %s\n", n.GetSubject().GetType(), n.GetSubject().GetTitle())
+			if url := webURL(n); url != "" {
+				fmt.Fprintf(&b, "    %s\n", url)
+			}
+		}
+	}
+	return b.String()
+}
+
+// webURL returns the web URL of a notification's subject, or of its
+// repository when the subject has no URL
+func webURL(n *github.Notification) string {
+	if apiURL := n.GetSubject().GetURL(); apiURL != "" {
+		if url, err := githubclient.ConvertAPIURLToWebURL(apiURL); err == nil {
+			return url
+		}
+	}
+	return n.GetRepository().GetHTMLURL()
+}
+
+// countNotifications returns the number of notifications as text
+func countNotifications(count int) string {
+	if count == 1 {
+		return "1 notification"
+	}
+	return fmt.Sprintf("%d notifications", count)
+}
+
+// capitalize upper-cases the first letter of a word
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,61 @@
+package digest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/grouping"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b := Batch{
+		Subscription: "org/*",
+		Frequency:    subscriptions.FrequencyDaily,
+		Entries: []Entry{
+			{Notification: testNotification("1", "org/api", now.Add(-3*time.Hour)), QueuedAt: now.Add(-3 * time.Hour)},
+			{Notification: testNotification("2", "org/web", now.Add(-2*time.Hour)), QueuedAt: now.Add(-2 * time.Hour)},
+			{Notification: testNotification("3", "org/api", now.Add(-time.Hour)), QueuedAt: now.Add(-time.Hour)},
+		},
+	}
+
+	renderer := NewRenderer(grouping.GroupByRepository)
+	d, err := renderer.Render(context.Background(), b, "", now)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if d.Subject != "Daily digest for org/*: 3 notifications" {
+		t.Errorf("Subject = %q", d.Subject)
+	}
+	// Single notification groups are kept, the largest group comes first
+	if len(d.Groups) != 2 || d.Groups[0].Name != "org/api" || d.Groups[0].Count != 2 {
+		t.Fatalf("Groups = %+v", d.Groups)
+	}
+	for _, want := range []string{"3 notifications since May 1 07:00", "org/api (2)", "  - [PullRequest] Pull request 3\n    https://github.com/org/api/pull/3", "org/web (1)"} {
+		if !strings.Contains(d.Body, want) {
+			t.Errorf("Body does not contain %q:\n%s", want, d.Body)
+		}
+	}
+	if strings.Index(d.Body, "Pull request 3") > strings.Index(d.Body, "Pull request 1") {
+		t.Error("Body does not list the latest notification first")
+	}
+
+	// A subscription template renders the body
+	text := `{{.Subscription}}: {{.Count}}{{range .Groups}} {{.Name}}={{.Count}}{{end}}{{range .Notifications}} {{webURL .}}{{end}}`
+	d, err = renderer.Render(context.Background(), b, text, now)
+	if err != nil {
+		t.Fatalf("Render() with a template error = %v", err)
+	}
+	want := "org/*: 3 org/api=2 org/web=1 https://github.com/org/api/pull/3 https://github.com/org/web/pull/2 https://github.com/org/api/pull/1"
+	if d.Body != want {
+		t.Errorf("Body = %q, want %q", d.Body, want)
+	}
+
+	if _, err := renderer.Render(context.Background(), b, "{{.Missing", now); err == nil {
+		t.Error("Render() accepted an invalid template")
+	}
+}
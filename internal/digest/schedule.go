@@ -0,0 +1,53 @@
+package digest
+
+import (
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+)
+
+// Schedule decides when digests are delivered. Hourly digests are delivered
+// at the top of every hour and daily digests at a time of day.
+type Schedule struct {
+	// Hour is the hour of the day daily digests are delivered
+	Hour int
+	// Minute is the minute of the hour daily digests are delivered
+	Minute int
+	// Location is the time zone of the time of day, nil for local time
+	Location *time.Location
+}
+
+// DefaultSchedule delivers daily digests at 9:00 local time
+func DefaultSchedule() Schedule {
+	return Schedule{Hour: 9}
+}
+
+// Next returns when the digest of a batch collecting notifications since a
+// time is delivered. Real-time subscriptions are never batched and are due
+// right away.
+func (s Schedule) Next(frequency subscriptions.Frequency, since time.Time) time.Time {
+	location := s.Location
+	if location == nil {
+		location = time.Local
+	}
+	since = since.In(location)
+
+	switch frequency {
+	case subscriptions.FrequencyHourly:
+		top := time.Date(since.Year(), since.Month(), since.Day(), since.Hour(), 0, 0, 0, location)
+		return top.Add(time.Hour)
+	case subscriptions.FrequencyDaily:
+		next := time.Date(since.Year(), since.Month(), since.Day(), s.Hour, s.Minute, 0, 0, location)
+		if !next.After(since) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	default:
+		return since
+	}
+}
+
+// Due reports whether the digest of a batch is due
+func (s Schedule) Due(b Batch, now time.Time) bool {
+	return len(b.Entries) > 0 && !now.Before(s.Next(b.Frequency, b.Since()))
+}
@@ -0,0 +1,47 @@
+package digest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+)
+
+func TestScheduleNext(t *testing.T) {
+	location := time.FixedZone("test", 2*60*60)
+	schedule := Schedule{Hour: 9, Minute: 30, Location: location}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, location)
+	}
+
+	tests := []struct {
+		name      string
+		frequency subscriptions.Frequency
+		since     time.Time
+		want      time.Time
+	}{
+		{"hourly", subscriptions.FrequencyHourly, at(1, 10, 15), at(1, 11, 0)},
+		{"hourly at the top of the hour", subscriptions.FrequencyHourly, at(1, 10, 0), at(1, 11, 0)},
+		{"daily before the time", subscriptions.FrequencyDaily, at(1, 8, 0), at(1, 9, 30)},
+		{"daily after the time", subscriptions.FrequencyDaily, at(1, 9, 30), at(2, 9, 30)},
+		{"daily in another zone", subscriptions.FrequencyDaily, at(1, 23, 0).UTC(), at(2, 9, 30)},
+		{"real-time", subscriptions.FrequencyRealTime, at(1, 10, 15), at(1, 10, 15)},
+	}
+
+	for _, tt := range tests {
+		if got := schedule.Next(tt.frequency, tt.since); !got.Equal(tt.want) {
+			t.Errorf("%s: Next() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	b := Batch{Frequency: subscriptions.FrequencyDaily, Entries: []Entry{{QueuedAt: at(1, 8, 0)}}}
+	if schedule.Due(b, at(1, 9, 29)) {
+		t.Error("Due() before the daily time")
+	}
+	if !schedule.Due(b, at(1, 9, 30)) {
+		t.Error("not Due() at the daily time")
+	}
+	if schedule.Due(Batch{Frequency: subscriptions.FrequencyDaily, LastDelivered: at(1, 8, 0)}, at(3, 0, 0)) {
+		t.Error("an empty batch is Due()")
+	}
+}
@@ -0,0 +1,114 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/google/go-github/v60/github"
+)
+
+// Scheduler queues the notifications of hourly and daily subscriptions and
+// delivers their digests when they are due
+type Scheduler struct {
+	// Queue holds the notifications waiting for their digest
+	Queue *Queue
+	// Subscriptions finds the subscription governing a notification
+	Subscriptions *subscriptions.Evaluator
+	// Schedule decides when digests are due
+	Schedule Schedule
+	// Renderer renders digests
+	Renderer *Renderer
+	// Delivery delivers digests
+	Delivery Deliverer
+
+	// now returns the current time
+	now func() time.Time
+}
+
+// NewScheduler creates a scheduler for the subscriptions of an evaluator
+func NewScheduler(queue *Queue, evaluator *subscriptions.Evaluator, schedule Schedule, renderer *Renderer, delivery Deliverer) *Scheduler {
+	return &Scheduler{
+		Queue:         queue,
+		Subscriptions: evaluator,
+		Schedule:      schedule,
+		Renderer:      renderer,
+		Delivery:      delivery,
+		now:           time.Now,
+	}
+}
+
+// Enqueue queues the notifications matched by hourly and daily subscriptions
+// and returns the others, which are delivered in real time. Notifications a
+// digest subscription does not match are dropped.
+func (s *Scheduler) Enqueue(notifications []*github.Notification) ([]*github.Notification, error) {
+	var realTime []*github.Notification
+	batched := make(map[*subscriptions.SubscriptionFilter][]*github.Notification)
+	var order []*subscriptions.SubscriptionFilter
+
+	for _, n := range notifications {
+		f := s.Subscriptions.Lookup(n.GetRepository().GetFullName())
+		if f == nil || f.Subscription.Config.Frequency == subscriptions.FrequencyRealTime {
+			realTime = append(realTime, n)
+			continue
+		}
+		if !f.Apply(n) {
+			continue
+		}
+		if _, ok := batched[f]; !ok {
+			order = append(order, f)
+		}
+		batched[f] = append(batched[f], n)
+	}
+
+	var errs []error
+	for _, f := range order {
+		if _, err := s.Queue.Add(f.Subscription.Repository, f.Subscription.Config.Frequency, batched[f]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return realTime, errors.Join(errs...)
+}
+
+// Next returns when the digest of a batch is due
+func (s *Scheduler) Next(b Batch) time.Time {
+	return s.Schedule.Next(b.Frequency, b.Since())
+}
+
+// Deliver renders and delivers the digests that are due, or every pending
+// digest when force is set, and returns the delivered ones. A digest that
+// fails to be delivered stays queued for the next attempt.
+func (s *Scheduler) Deliver(ctx context.Context, force bool) ([]*Digest, error) {
+	now := s.now()
+
+	var delivered []*Digest
+	var errs []error
+	for _, b := range s.Queue.Batches() {
+		if len(b.Entries) == 0 || (!force && !s.Schedule.Due(b, now)) {
+			continue
+		}
+
+		var text string
+		if f := s.Subscriptions.Find(b.Subscription); f != nil {
+			text = f.Subscription.Config.Template
+		}
+
+		d, err := s.Renderer.Render(ctx, b, text, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.Delivery.Deliver(ctx, d); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deliver digest for %s: %w", b.Subscription, err))
+			continue
+		}
+		if err := s.Queue.Delivered(d); err != nil {
+			errs = append(errs, err)
+		}
+		delivered = append(delivered, d)
+	}
+
+	return delivered, errors.Join(errs...)
+}
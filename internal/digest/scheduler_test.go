@@ -0,0 +1,98 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/grouping"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestScheduler(t *testing.T) {
+	hourly := subscriptions.DefaultSubscriptionConfig()
+	hourly.Frequency = subscriptions.FrequencyHourly
+	hourly.ActivityTypes = []subscriptions.ActivityType{subscriptions.ActivityPRs}
+	daily := subscriptions.DefaultSubscriptionConfig()
+	daily.Frequency = subscriptions.FrequencyDaily
+	daily.Template = "{{.Count}} for {{.Subscription}}"
+
+	evaluator, err := subscriptions.NewEvaluator([]subscriptions.RepositorySubscription{
+		{Repository: "org/*", IsPattern: true, Active: true, Config: hourly},
+		{Repository: "org/docs", Active: true, Config: daily},
+		{Repository: "org/live", Active: true, Config: subscriptions.DefaultSubscriptionConfig()},
+	})
+	if err != nil {
+		t.Fatalf("NewEvaluator() error = %v", err)
+	}
+
+	var delivered []*Digest
+	var fail error
+	delivery := DeliverFunc(func(ctx context.Context, d *Digest) error {
+		if fail != nil {
+			return fail
+		}
+		delivered = append(delivered, d)
+		return nil
+	})
+
+	now := time.Date(2024, 5, 1, 10, 20, 0, 0, time.UTC)
+	queue := NewQueue()
+	queue.now = func() time.Time { return now }
+	scheduler := NewScheduler(queue, evaluator, Schedule{Hour: 9, Location: time.UTC}, NewRenderer(grouping.GroupByRepository), delivery)
+	scheduler.now = func() time.Time { return now }
+
+	issue := testNotification("4", "org/api", now)
+	issue.Subject.Type = github.String("Issue")
+	realTime, err := scheduler.Enqueue([]*github.Notification{
+		testNotification("1", "org/api", now), testNotification("2", "org/docs", now),
+		testNotification("3", "org/live", now), issue, testNotification("5", "other/repo", now),
+	})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	// Real-time subscriptions and repositories without one are not batched,
+	// and the issue is not matched by the hourly subscription
+	if len(realTime) != 2 || realTime[0].GetID() != "3" || realTime[1].GetID() != "5" {
+		t.Errorf("Enqueue() returned %d real-time notifications, want 3 and 5", len(realTime))
+	}
+	if b, _ := queue.Batch("org/*"); len(b.Entries) != 1 {
+		t.Errorf("hourly batch has %d entries, want 1", len(b.Entries))
+	}
+
+	// Nothing is due before the top of the hour
+	if digests, err := scheduler.Deliver(context.Background(), false); err != nil || len(digests) != 0 {
+		t.Fatalf("Deliver() = %d digests, %v, want none", len(digests), err)
+	}
+
+	// A failed delivery keeps the batch
+	now = now.Add(time.Hour)
+	fail = errors.New("mail server down")
+	if _, err := scheduler.Deliver(context.Background(), false); err == nil {
+		t.Fatal("Deliver() did not report the failure")
+	}
+	if b, _ := queue.Batch("org/*"); len(b.Entries) != 1 {
+		t.Fatal("a failed delivery dropped the batch")
+	}
+
+	fail = nil
+	digests, err := scheduler.Deliver(context.Background(), false)
+	if err != nil || len(digests) != 1 || digests[0].Subscription != "org/*" {
+		t.Fatalf("Deliver() = %d digests, %v, want the hourly digest", len(digests), err)
+	}
+	if b, _ := queue.Batch("org/*"); len(b.Entries) != 0 || !b.LastDelivered.Equal(now) {
+		t.Errorf("hourly batch after delivery = %+v", b)
+	}
+
+	// Forcing delivers the daily digest early, rendered with its template
+	digests, _ = scheduler.Deliver(context.Background(), true)
+	if len(digests) != 1 || digests[0].Body != "1 for org/docs" {
+		t.Fatalf("forced Deliver() = %+v", digests)
+	}
+	if len(delivered) != 2 {
+		t.Errorf("delivered %d digests, want 2", len(delivered))
+	}
+}
@@ -396,18 +396,40 @@ func (c *Client) matchesQuery(discussion Discussion, queryLower string) bool {
 	return false
 }
 
-// Placeholder methods for API calls that need to be implemented
+// getDiscussionByNumber fetches a discussion and, if requested, its comments
 func (c *Client) getDiscussionByNumber(ctx context.Context, owner, repo string, number int, options DiscussionOptions) (*Discussion, error) {
-	// TODO: Implement GraphQL query for single discussion
-	return nil, fmt.Errorf("not implemented")
+	discussion, err := c.graphqlClient.GetDiscussion(ctx, owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	if !options.IncludeComments {
+		return discussion, nil
+	}
+
+	comments, err := c.GetDiscussionComments(ctx, discussion.ID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The comment list only knows which comment is the answer, not when it was chosen
+	for i := range comments {
+		if comments[i].IsAnswer {
+			comments[i].MarkedAsAnswerAt = discussion.AnsweredAt
+			comments[i].MarkedAsAnswerBy = discussion.AnsweredBy
+		}
+	}
+	discussion.Comments = comments
+
+	return discussion, nil
 }
 
+// getDiscussionCommentsFromAPI fetches all comments of a discussion with nested replies
 func (c *Client) getDiscussionCommentsFromAPI(ctx context.Context, discussionID string, options DiscussionOptions) ([]Comment, error) {
-	// TODO: Implement GraphQL query for discussion comments
-	return nil, fmt.Errorf("not implemented")
+	return c.graphqlClient.GetDiscussionComments(ctx, discussionID, options)
 }
 
+// getDiscussionCategoriesFromAPI fetches the discussion categories of a repository
 func (c *Client) getDiscussionCategoriesFromAPI(ctx context.Context, owner, repo string) ([]Category, error) {
-	// TODO: Implement GraphQL query for discussion categories
-	return nil, fmt.Errorf("not implemented")
+	return c.graphqlClient.GetDiscussionCategories(ctx, owner, repo)
 }
@@ -45,6 +45,14 @@ func NewGraphQLClient(ctx context.Context) (*GraphQLClient, error) {
 	}, nil
 }
 
+// NewGraphQLClientWithHTTPClient creates a GraphQL client for an endpoint using the given HTTP client
+func NewGraphQLClientWithHTTPClient(httpClient *http.Client, endpoint string) *GraphQLClient {
+	return &GraphQLClient{
+		httpClient: httpClient,
+		baseURL:    endpoint,
+	}
+}
+
 // GraphQLRequest represents a GraphQL request
 type GraphQLRequest struct {
 	Query     string                 `json:"query"`
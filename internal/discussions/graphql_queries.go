@@ -0,0 +1,713 @@
+package discussions
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// commentsPageSize is the number of comments requested per page
+	commentsPageSize = 50
+	// repliesPageSize is the number of replies requested per page
+	repliesPageSize = 50
+	// categoriesPageSize is the number of categories requested per page
+	categoriesPageSize = 100
+	// reactionsPageSize is the number of individual reactions requested per comment
+	reactionsPageSize = 50
+)
+
+// commentFieldsFragment selects the fields shared by comments and replies
+const commentFieldsFragment = `
+	fragment CommentFields on DiscussionComment {
+		id
+		body
+		bodyHTML
+		bodyText
+		url
+		createdAt
+		updatedAt
+		isAnswer
+		upvoteCount
+		author {
+			login
+			avatarUrl
+			url
+		}
+		replyTo {
+			id
+		}
+		reactionGroups {
+			content
+			users {
+				totalCount
+			}
+		}
+		reactions(first: $reactionsFirst) @include(if: $includeReactions) {
+			nodes {
+				id
+				content
+				createdAt
+				user {
+					login
+					avatarUrl
+					url
+				}
+			}
+		}
+		viewerDidAuthor
+		viewerCanReact
+		viewerCanUpdate
+		viewerCanDelete
+		viewerCanMarkAsAnswer
+	}
+`
+
+// gqlPageInfo is the GraphQL pagination info of a connection
+type gqlPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// gqlActor is a GraphQL actor such as a user or bot
+type gqlActor struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatarUrl"`
+	URL       string `json:"url"`
+}
+
+// gqlReactionGroup is a GraphQL reaction group with its user count
+type gqlReactionGroup struct {
+	Content string `json:"content"`
+	Users   struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"users"`
+}
+
+// gqlCategory is a GraphQL discussion category
+type gqlCategory struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Emoji        string    `json:"emoji"`
+	Slug         string    `json:"slug"`
+	IsAnswerable bool      `json:"isAnswerable"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// gqlComment is a GraphQL discussion comment selected with CommentFields
+type gqlComment struct {
+	ID          string    `json:"id"`
+	Body        string    `json:"body"`
+	BodyHTML    string    `json:"bodyHTML"`
+	BodyText    string    `json:"bodyText"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	IsAnswer    bool      `json:"isAnswer"`
+	UpvoteCount int       `json:"upvoteCount"`
+	Author      *gqlActor `json:"author"`
+	ReplyTo     *struct {
+		ID string `json:"id"`
+	} `json:"replyTo"`
+	ReactionGroups []gqlReactionGroup `json:"reactionGroups"`
+	Reactions      *struct {
+		Nodes []struct {
+			ID        string    `json:"id"`
+			Content   string    `json:"content"`
+			CreatedAt time.Time `json:"createdAt"`
+			User      *gqlActor `json:"user"`
+		} `json:"nodes"`
+	} `json:"reactions"`
+	ViewerDidAuthor       bool `json:"viewerDidAuthor"`
+	ViewerCanReact        bool `json:"viewerCanReact"`
+	ViewerCanUpdate       bool `json:"viewerCanUpdate"`
+	ViewerCanDelete       bool `json:"viewerCanDelete"`
+	ViewerCanMarkAsAnswer bool `json:"viewerCanMarkAsAnswer"`
+}
+
+// gqlCommentWithReplies is a top-level comment with its first page of replies
+type gqlCommentWithReplies struct {
+	gqlComment
+	Replies gqlCommentConnection `json:"replies"`
+}
+
+// gqlCommentConnection is a page of comments or replies
+type gqlCommentConnection struct {
+	TotalCount int          `json:"totalCount"`
+	PageInfo   gqlPageInfo  `json:"pageInfo"`
+	Nodes      []gqlComment `json:"nodes"`
+}
+
+// GetDiscussion fetches a single discussion by number
+func (c *GraphQLClient) GetDiscussion(ctx context.Context, owner, repo string, number int) (*Discussion, error) {
+	query := `
+		query GetDiscussion($owner: String!, $name: String!, $number: Int!) {
+			repository(owner: $owner, name: $name) {
+				discussion(number: $number) {
+					id
+					number
+					title
+					body
+					bodyHTML
+					bodyText
+					url
+					locked
+					closed
+					closedAt
+					createdAt
+					updatedAt
+					upvoteCount
+					answerChosenAt
+					answerChosenBy {
+						login
+						avatarUrl
+						url
+					}
+					repository {
+						id
+						name
+						nameWithOwner
+						url
+						isPrivate
+						owner {
+							login
+							avatarUrl
+							url
+						}
+					}
+					category {
+						id
+						name
+						description
+						emoji
+						slug
+						isAnswerable
+						createdAt
+						updatedAt
+					}
+					author {
+						login
+						avatarUrl
+						url
+					}
+					answer {
+						id
+						body
+						bodyHTML
+						bodyText
+						url
+						createdAt
+						updatedAt
+						upvoteCount
+						author {
+							login
+							avatarUrl
+							url
+						}
+					}
+					labels(first: 20) {
+						nodes {
+							id
+							name
+							description
+							color
+							url
+						}
+					}
+					comments {
+						totalCount
+					}
+					reactionGroups {
+						content
+						users {
+							totalCount
+						}
+					}
+					viewerDidAuthor
+					viewerSubscription
+					viewerCanReact
+					viewerCanUpdate
+					viewerCanDelete
+				}
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"owner":  owner,
+		"name":   repo,
+		"number": number,
+	}
+
+	var result struct {
+		Repository *struct {
+			Discussion *struct {
+				ID             string     `json:"id"`
+				Number         int        `json:"number"`
+				Title          string     `json:"title"`
+				Body           string     `json:"body"`
+				BodyHTML       string     `json:"bodyHTML"`
+				BodyText       string     `json:"bodyText"`
+				URL            string     `json:"url"`
+				Locked         bool       `json:"locked"`
+				Closed         bool       `json:"closed"`
+				ClosedAt       *time.Time `json:"closedAt"`
+				CreatedAt      time.Time  `json:"createdAt"`
+				UpdatedAt      time.Time  `json:"updatedAt"`
+				UpvoteCount    int        `json:"upvoteCount"`
+				AnswerChosenAt *time.Time `json:"answerChosenAt"`
+				AnswerChosenBy *gqlActor  `json:"answerChosenBy"`
+				Repository     struct {
+					ID            string   `json:"id"`
+					Name          string   `json:"name"`
+					NameWithOwner string   `json:"nameWithOwner"`
+					URL           string   `json:"url"`
+					IsPrivate     bool     `json:"isPrivate"`
+					Owner         gqlActor `json:"owner"`
+				} `json:"repository"`
+				Category gqlCategory `json:"category"`
+				Author   *gqlActor   `json:"author"`
+				Answer   *struct {
+					ID          string    `json:"id"`
+					Body        string    `json:"body"`
+					BodyHTML    string    `json:"bodyHTML"`
+					BodyText    string    `json:"bodyText"`
+					URL         string    `json:"url"`
+					CreatedAt   time.Time `json:"createdAt"`
+					UpdatedAt   time.Time `json:"updatedAt"`
+					UpvoteCount int       `json:"upvoteCount"`
+					Author      *gqlActor `json:"author"`
+				} `json:"answer"`
+				Labels struct {
+					Nodes []Label `json:"nodes"`
+				} `json:"labels"`
+				Comments struct {
+					TotalCount int `json:"totalCount"`
+				} `json:"comments"`
+				ReactionGroups     []gqlReactionGroup `json:"reactionGroups"`
+				ViewerDidAuthor    bool               `json:"viewerDidAuthor"`
+				ViewerSubscription string             `json:"viewerSubscription"`
+				ViewerCanReact     bool               `json:"viewerCanReact"`
+				ViewerCanUpdate    bool               `json:"viewerCanUpdate"`
+				ViewerCanDelete    bool               `json:"viewerCanDelete"`
+			} `json:"discussion"`
+		} `json:"repository"`
+	}
+
+	if err := c.Execute(ctx, query, variables, &result); err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	if result.Repository == nil {
+		return nil, fmt.Errorf("repository %s/%s not found", owner, repo)
+	}
+	node := result.Repository.Discussion
+	if node == nil {
+		return nil, fmt.Errorf("discussion %s/%s#%d not found", owner, repo, number)
+	}
+
+	_, reactionCount := countReactions(node.ReactionGroups)
+
+	state := "OPEN"
+	if node.Closed {
+		state = "CLOSED"
+	}
+
+	discussion := &Discussion{
+		ID:            node.ID,
+		Number:        node.Number,
+		Title:         node.Title,
+		Body:          node.Body,
+		BodyHTML:      node.BodyHTML,
+		BodyText:      node.BodyText,
+		URL:           node.URL,
+		State:         state,
+		Locked:        node.Locked,
+		CreatedAt:     node.CreatedAt,
+		UpdatedAt:     node.UpdatedAt,
+		ClosedAt:      node.ClosedAt,
+		UpvoteCount:   node.UpvoteCount,
+		CommentCount:  node.Comments.TotalCount,
+		ReactionCount: reactionCount,
+		Repository: Repository{
+			ID:       node.Repository.ID,
+			Name:     node.Repository.Name,
+			FullName: node.Repository.NameWithOwner,
+			URL:      node.Repository.URL,
+			Private:  node.Repository.IsPrivate,
+			Owner:    node.Repository.Owner.toUser(),
+		},
+		Category:           node.Category.toCategory(),
+		Author:             node.Author.toUser(),
+		AnsweredAt:         node.AnswerChosenAt,
+		Labels:             node.Labels.Nodes,
+		Assignees:          []User{},
+		ViewerDidAuthor:    node.ViewerDidAuthor,
+		ViewerSubscription: node.ViewerSubscription,
+		ViewerCanReact:     node.ViewerCanReact,
+		ViewerCanUpdate:    node.ViewerCanUpdate,
+		ViewerCanDelete:    node.ViewerCanDelete,
+	}
+
+	if discussion.Labels == nil {
+		discussion.Labels = []Label{}
+	}
+
+	if node.AnswerChosenBy != nil {
+		answeredBy := node.AnswerChosenBy.toUser()
+		discussion.AnsweredBy = &answeredBy
+	}
+
+	if node.Answer != nil {
+		discussion.Answer = &Comment{
+			ID:               node.Answer.ID,
+			Body:             node.Answer.Body,
+			BodyHTML:         node.Answer.BodyHTML,
+			BodyText:         node.Answer.BodyText,
+			URL:              node.Answer.URL,
+			Author:           node.Answer.Author.toUser(),
+			CreatedAt:        node.Answer.CreatedAt,
+			UpdatedAt:        node.Answer.UpdatedAt,
+			IsAnswer:         true,
+			MarkedAsAnswerAt: discussion.AnsweredAt,
+			MarkedAsAnswerBy: discussion.AnsweredBy,
+			UpvoteCount:      node.Answer.UpvoteCount,
+		}
+	}
+
+	return discussion, nil
+}
+
+// GetDiscussionComments fetches the comments of a discussion with their replies.
+// Pages are followed until all comments are loaded or options.MaxComments is reached.
+func (c *GraphQLClient) GetDiscussionComments(ctx context.Context, discussionID string, options DiscussionOptions) ([]Comment, error) {
+	query := `
+		query GetDiscussionComments($id: ID!, $first: Int!, $after: String, $repliesFirst: Int!, $reactionsFirst: Int!, $includeReactions: Boolean!) {
+			node(id: $id) {
+				... on Discussion {
+					comments(first: $first, after: $after) {
+						totalCount
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+						nodes {
+							...CommentFields
+							replies(first: $repliesFirst) {
+								totalCount
+								pageInfo {
+									hasNextPage
+									endCursor
+								}
+								nodes {
+									...CommentFields
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	` + commentFieldsFragment
+
+	var comments []Comment
+	var after interface{}
+
+	for {
+		first := commentsPageSize
+		if options.MaxComments > 0 && options.MaxComments-len(comments) < first {
+			first = options.MaxComments - len(comments)
+		}
+
+		variables := map[string]interface{}{
+			"id":               discussionID,
+			"first":            first,
+			"after":            after,
+			"repliesFirst":     repliesPageSize,
+			"reactionsFirst":   reactionsPageSize,
+			"includeReactions": options.IncludeReactions,
+		}
+
+		var result struct {
+			Node *struct {
+				Comments *struct {
+					TotalCount int                     `json:"totalCount"`
+					PageInfo   gqlPageInfo             `json:"pageInfo"`
+					Nodes      []gqlCommentWithReplies `json:"nodes"`
+				} `json:"comments"`
+			} `json:"node"`
+		}
+
+		if err := c.Execute(ctx, query, variables, &result); err != nil {
+			return nil, fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		if result.Node == nil || result.Node.Comments == nil {
+			return nil, fmt.Errorf("discussion %s not found", discussionID)
+		}
+
+		for _, node := range result.Node.Comments.Nodes {
+			comment := node.toComment()
+
+			replies := node.Replies.Nodes
+			if node.Replies.PageInfo.HasNextPage {
+				more, err := c.getCommentReplies(ctx, node.ID, node.Replies.PageInfo.EndCursor, options)
+				if err != nil {
+					return nil, err
+				}
+				replies = append(replies, more...)
+			}
+
+			comment.Replies = make([]Comment, 0, len(replies))
+			for _, reply := range replies {
+				r := reply.toComment()
+				if r.ParentID == nil {
+					parentID := node.ID
+					r.ParentID = &parentID
+				}
+				comment.Replies = append(comment.Replies, r)
+			}
+
+			comments = append(comments, comment)
+		}
+
+		pageInfo := result.Node.Comments.PageInfo
+		if !pageInfo.HasNextPage || (options.MaxComments > 0 && len(comments) >= options.MaxComments) {
+			break
+		}
+		after = pageInfo.EndCursor
+	}
+
+	return comments, nil
+}
+
+// getCommentReplies fetches the remaining replies of a comment starting after a cursor
+func (c *GraphQLClient) getCommentReplies(ctx context.Context, commentID, after string, options DiscussionOptions) ([]gqlComment, error) {
+	query := `
+		query GetCommentReplies($id: ID!, $first: Int!, $after: String, $reactionsFirst: Int!, $includeReactions: Boolean!) {
+			node(id: $id) {
+				... on DiscussionComment {
+					replies(first: $first, after: $after) {
+						totalCount
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+						nodes {
+							...CommentFields
+						}
+					}
+				}
+			}
+		}
+	` + commentFieldsFragment
+
+	var replies []gqlComment
+	cursor := after
+
+	for {
+		variables := map[string]interface{}{
+			"id":               commentID,
+			"first":            repliesPageSize,
+			"after":            cursor,
+			"reactionsFirst":   reactionsPageSize,
+			"includeReactions": options.IncludeReactions,
+		}
+
+		var result struct {
+			Node *struct {
+				Replies *gqlCommentConnection `json:"replies"`
+			} `json:"node"`
+		}
+
+		if err := c.Execute(ctx, query, variables, &result); err != nil {
+			return nil, fmt.Errorf("failed to fetch replies for comment %s: %w", commentID, err)
+		}
+
+		if result.Node == nil || result.Node.Replies == nil {
+			return nil, fmt.Errorf("comment %s not found", commentID)
+		}
+
+		replies = append(replies, result.Node.Replies.Nodes...)
+
+		if !result.Node.Replies.PageInfo.HasNextPage {
+			break
+		}
+		cursor = result.Node.Replies.PageInfo.EndCursor
+	}
+
+	return replies, nil
+}
+
+// GetDiscussionCategories fetches all discussion categories of a repository
+func (c *GraphQLClient) GetDiscussionCategories(ctx context.Context, owner, repo string) ([]Category, error) {
+	query := `
+		query GetDiscussionCategories($owner: String!, $name: String!, $first: Int!, $after: String) {
+			repository(owner: $owner, name: $name) {
+				discussionCategories(first: $first, after: $after) {
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+					nodes {
+						id
+						name
+						description
+						emoji
+						slug
+						isAnswerable
+						createdAt
+						updatedAt
+					}
+				}
+			}
+		}
+	`
+
+	var categories []Category
+	var after interface{}
+
+	for {
+		variables := map[string]interface{}{
+			"owner": owner,
+			"name":  repo,
+			"first": categoriesPageSize,
+			"after": after,
+		}
+
+		var result struct {
+			Repository *struct {
+				DiscussionCategories struct {
+					PageInfo gqlPageInfo   `json:"pageInfo"`
+					Nodes    []gqlCategory `json:"nodes"`
+				} `json:"discussionCategories"`
+			} `json:"repository"`
+		}
+
+		if err := c.Execute(ctx, query, variables, &result); err != nil {
+			return nil, fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		if result.Repository == nil {
+			return nil, fmt.Errorf("repository %s/%s not found", owner, repo)
+		}
+
+		for _, node := range result.Repository.DiscussionCategories.Nodes {
+			categories = append(categories, node.toCategory())
+		}
+
+		pageInfo := result.Repository.DiscussionCategories.PageInfo
+		if !pageInfo.HasNextPage {
+			break
+		}
+		after = pageInfo.EndCursor
+	}
+
+	return categories, nil
+}
+
+// toUser converts a GraphQL actor to a User; deleted accounts become "ghost"
+func (a *gqlActor) toUser() User {
+	if a == nil {
+		return User{Login: "ghost"}
+	}
+	return User{
+		Login:     a.Login,
+		AvatarURL: a.AvatarURL,
+		URL:       a.URL,
+	}
+}
+
+// toCategory converts a GraphQL category to a Category
+func (c gqlCategory) toCategory() Category {
+	return Category{
+		ID:           c.ID,
+		Name:         c.Name,
+		Description:  c.Description,
+		Emoji:        c.Emoji,
+		Slug:         c.Slug,
+		IsAnswerable: c.IsAnswerable,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
+
+// toComment converts a GraphQL comment to a Comment
+func (c gqlComment) toComment() Comment {
+	_, reactionCount := countReactions(c.ReactionGroups)
+
+	comment := Comment{
+		ID:                    c.ID,
+		Body:                  c.Body,
+		BodyHTML:              c.BodyHTML,
+		BodyText:              c.BodyText,
+		URL:                   c.URL,
+		Author:                c.Author.toUser(),
+		CreatedAt:             c.CreatedAt,
+		UpdatedAt:             c.UpdatedAt,
+		IsAnswer:              c.IsAnswer,
+		UpvoteCount:           c.UpvoteCount,
+		ReactionCount:         reactionCount,
+		ViewerDidAuthor:       c.ViewerDidAuthor,
+		ViewerCanReact:        c.ViewerCanReact,
+		ViewerCanUpdate:       c.ViewerCanUpdate,
+		ViewerCanDelete:       c.ViewerCanDelete,
+		ViewerCanMarkAsAnswer: c.ViewerCanMarkAsAnswer,
+	}
+
+	if c.ReplyTo != nil && c.ReplyTo.ID != "" {
+		parentID := c.ReplyTo.ID
+		comment.ParentID = &parentID
+	}
+
+	if c.Reactions != nil {
+		comment.Reactions = make([]Reaction, 0, len(c.Reactions.Nodes))
+		for _, r := range c.Reactions.Nodes {
+			comment.Reactions = append(comment.Reactions, Reaction{
+				ID:        r.ID,
+				Content:   reactionContent(r.Content),
+				User:      r.User.toUser(),
+				CreatedAt: r.CreatedAt,
+			})
+		}
+	}
+
+	return comment
+}
+
+// countReactions returns the thumbs up count and the total reaction count
+func countReactions(groups []gqlReactionGroup) (upvotes, total int) {
+	for _, group := range groups {
+		if group.Content == "THUMBS_UP" {
+			upvotes = group.Users.TotalCount
+		}
+		total += group.Users.TotalCount
+	}
+	return upvotes, total
+}
+
+// reactionContent converts a GraphQL reaction content to its REST name
+func reactionContent(content string) string {
+	switch content {
+	case "THUMBS_UP":
+		return "+1"
+	case "THUMBS_DOWN":
+		return "-1"
+	case "LAUGH":
+		return "laugh"
+	case "HOORAY":
+		return "hooray"
+	case "CONFUSED":
+		return "confused"
+	case "HEART":
+		return "heart"
+	case "ROCKET":
+		return "rocket"
+	case "EYES":
+		return "eyes"
+	default:
+		return content
+	}
+}
@@ -0,0 +1,245 @@
+package discussions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/cache"
+)
+
+// graphQLStandIn serves canned GraphQL responses keyed by operation name and cursor
+type graphQLStandIn struct {
+	t        *testing.T
+	requests []GraphQLRequest
+}
+
+func (s *graphQLStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req GraphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.t.Errorf("failed to decode request: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.requests = append(s.requests, req)
+
+	after, _ := req.Variables["after"].(string)
+
+	var data string
+	switch {
+	case strings.Contains(req.Query, "query GetDiscussion("):
+		if req.Variables["number"].(float64) != 42 {
+			data = `{"repository": {"discussion": null}}`
+			break
+		}
+		data = `{"repository": {"discussion": {
+			"id": "D_42", "number": 42, "title": "How do I filter?", "body": "Question body",
+			"url": "https://github.com/owner/repo/discussions/42", "locked": false,
+			"closed": true, "closedAt": "2024-01-03T00:00:00Z",
+			"createdAt": "2024-01-01T00:00:00Z", "updatedAt": "2024-01-02T00:00:00Z",
+			"upvoteCount": 7, "answerChosenAt": "2024-01-02T12:00:00Z",
+			"answerChosenBy": {"login": "maintainer"},
+			"repository": {"id": "R_1", "name": "repo", "nameWithOwner": "owner/repo", "owner": {"login": "owner"}},
+			"category": {"id": "C_1", "name": "Q&A", "emoji": ":pray:", "slug": "q-a", "isAnswerable": true},
+			"author": {"login": "asker"},
+			"answer": {"id": "DC_2", "body": "Use --filter", "author": {"login": "helper"}, "upvoteCount": 3},
+			"labels": {"nodes": [{"id": "L_1", "name": "question", "color": "d876e3"}]},
+			"comments": {"totalCount": 3},
+			"reactionGroups": [
+				{"content": "THUMBS_UP", "users": {"totalCount": 7}},
+				{"content": "HEART", "users": {"totalCount": 2}}
+			],
+			"viewerDidAuthor": true, "viewerSubscription": "SUBSCRIBED"
+		}}}`
+	case strings.Contains(req.Query, "query GetDiscussionComments("):
+		if after == "" {
+			data = `{"node": {"comments": {"totalCount": 3,
+				"pageInfo": {"hasNextPage": true, "endCursor": "c1"},
+				"nodes": [
+					{"id": "DC_1", "body": "Same question", "author": {"login": "other"},
+					 "reactionGroups": [{"content": "EYES", "users": {"totalCount": 1}}],
+					 "reactions": {"nodes": [{"id": "RE_1", "content": "EYES", "user": {"login": "asker"}}]},
+					 "replies": {"totalCount": 3,
+						"pageInfo": {"hasNextPage": true, "endCursor": "r1"},
+						"nodes": [
+							{"id": "DC_1_1", "body": "+1", "author": null, "replyTo": {"id": "DC_1"}},
+							{"id": "DC_1_2", "body": "me too", "author": {"login": "third"}, "replyTo": {"id": "DC_1"}}
+						]}},
+					{"id": "DC_2", "body": "Use --filter", "isAnswer": true, "upvoteCount": 3,
+					 "author": {"login": "helper"}, "viewerCanMarkAsAnswer": true,
+					 "replies": {"totalCount": 0, "pageInfo": {"hasNextPage": false}, "nodes": []}}
+				]}}}`
+		} else {
+			data = `{"node": {"comments": {"totalCount": 3,
+				"pageInfo": {"hasNextPage": false, "endCursor": "c2"},
+				"nodes": [
+					{"id": "DC_3", "body": "Thanks!", "author": {"login": "asker"},
+					 "replies": {"totalCount": 0, "pageInfo": {"hasNextPage": false}, "nodes": []}}
+				]}}}`
+		}
+	case strings.Contains(req.Query, "query GetCommentReplies("):
+		if req.Variables["id"] != "DC_1" || after != "r1" {
+			s.t.Errorf("unexpected replies request: id=%v after=%v", req.Variables["id"], after)
+		}
+		data = `{"node": {"replies": {"totalCount": 3,
+			"pageInfo": {"hasNextPage": false, "endCursor": "r2"},
+			"nodes": [{"id": "DC_1_3", "body": "late reply", "author": {"login": "fourth"}, "replyTo": {"id": "DC_1"}}]}}}`
+	case strings.Contains(req.Query, "query GetDiscussionCategories("):
+		if after == "" {
+			data = `{"repository": {"discussionCategories": {
+				"pageInfo": {"hasNextPage": true, "endCursor": "k1"},
+				"nodes": [{"id": "C_1", "name": "Q&A", "slug": "q-a", "isAnswerable": true}]}}}`
+		} else {
+			data = `{"repository": {"discussionCategories": {
+				"pageInfo": {"hasNextPage": false, "endCursor": "k2"},
+				"nodes": [{"id": "C_2", "name": "Ideas", "emoji": ":bulb:", "slug": "ideas"}]}}}`
+		}
+	default:
+		s.t.Errorf("unexpected query: %s", req.Query)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"data": ` + data + `}`))
+}
+
+// newTestClient creates a discussions client that talks to a GraphQL stand-in
+func newTestClient(t *testing.T) (*Client, *graphQLStandIn) {
+	standIn := &graphQLStandIn{t: t}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	cacheImpl, err := cache.NewCache(cache.MemoryCacheType, &cache.Options{DefaultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	return &Client{
+		graphqlClient: NewGraphQLClientWithHTTPClient(server.Client(), server.URL),
+		cacheManager:  cache.NewManager(cacheImpl, &cache.ManagerOptions{DefaultTTL: time.Minute}),
+		cacheTTL:      time.Minute,
+	}, standIn
+}
+
+func TestGetDiscussion(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	discussion, err := client.GetDiscussion(context.Background(), "owner/repo", 42, DiscussionOptions{
+		IncludeComments:  true,
+		IncludeReactions: true,
+	})
+	if err != nil {
+		t.Fatalf("GetDiscussion() error = %v", err)
+	}
+
+	if discussion.Title != "How do I filter?" || discussion.State != "CLOSED" || discussion.ClosedAt == nil {
+		t.Errorf("unexpected discussion: title=%q state=%q closedAt=%v", discussion.Title, discussion.State, discussion.ClosedAt)
+	}
+	if discussion.Repository.FullName != "owner/repo" || discussion.Category.Slug != "q-a" || !discussion.Category.IsAnswerable {
+		t.Errorf("unexpected repository or category: %+v %+v", discussion.Repository, discussion.Category)
+	}
+	if discussion.UpvoteCount != 7 || discussion.ReactionCount != 9 || discussion.CommentCount != 3 {
+		t.Errorf("unexpected counts: upvotes=%d reactions=%d comments=%d", discussion.UpvoteCount, discussion.ReactionCount, discussion.CommentCount)
+	}
+	if len(discussion.Labels) != 1 || discussion.Labels[0].Name != "question" {
+		t.Errorf("unexpected labels: %+v", discussion.Labels)
+	}
+	if discussion.Answer == nil || discussion.Answer.ID != "DC_2" || !discussion.Answer.IsAnswer {
+		t.Fatalf("unexpected answer: %+v", discussion.Answer)
+	}
+	if discussion.AnsweredBy == nil || discussion.AnsweredBy.Login != "maintainer" {
+		t.Errorf("unexpected answered by: %+v", discussion.AnsweredBy)
+	}
+
+	// Comments are collected across both pages
+	if len(discussion.Comments) != 3 {
+		t.Fatalf("got %d comments, want 3", len(discussion.Comments))
+	}
+
+	first := discussion.Comments[0]
+	if len(first.Replies) != 3 {
+		t.Fatalf("got %d replies, want 3", len(first.Replies))
+	}
+	if first.Replies[0].Author.Login != "ghost" {
+		t.Errorf("deleted author login = %q, want ghost", first.Replies[0].Author.Login)
+	}
+	if first.Replies[2].ID != "DC_1_3" || first.Replies[2].ParentID == nil || *first.Replies[2].ParentID != "DC_1" {
+		t.Errorf("unexpected paginated reply: %+v", first.Replies[2])
+	}
+	if len(first.Reactions) != 1 || first.Reactions[0].Content != "eyes" || first.Reactions[0].User.Login != "asker" {
+		t.Errorf("unexpected reactions: %+v", first.Reactions)
+	}
+	if first.ReactionCount != 1 {
+		t.Errorf("reaction count = %d, want 1", first.ReactionCount)
+	}
+
+	answer := discussion.Comments[1]
+	if !answer.IsAnswer || answer.MarkedAsAnswerAt == nil || answer.MarkedAsAnswerBy == nil {
+		t.Errorf("answer comment is not marked: %+v", answer)
+	}
+	if discussion.Comments[0].IsAnswer || discussion.Comments[2].IsAnswer {
+		t.Errorf("non-answer comments are marked as answer")
+	}
+}
+
+func TestGetDiscussionNotFound(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	if _, err := client.GetDiscussion(context.Background(), "owner/repo", 1, DiscussionOptions{}); err == nil {
+		t.Errorf("GetDiscussion() for a missing discussion did not return error")
+	}
+}
+
+func TestGetDiscussionCommentsMaxComments(t *testing.T) {
+	client, standIn := newTestClient(t)
+
+	comments, err := client.GetDiscussionComments(context.Background(), "D_42", DiscussionOptions{MaxComments: 2})
+	if err != nil {
+		t.Fatalf("GetDiscussionComments() error = %v", err)
+	}
+
+	if len(comments) != 2 {
+		t.Errorf("got %d comments, want 2", len(comments))
+	}
+
+	// Only the first comments page is requested, plus the remaining replies of DC_1
+	var commentPages int
+	for _, req := range standIn.requests {
+		if strings.Contains(req.Query, "query GetDiscussionComments(") {
+			commentPages++
+			if req.Variables["first"].(float64) != 2 {
+				t.Errorf("first = %v, want 2", req.Variables["first"])
+			}
+			if req.Variables["includeReactions"] != false {
+				t.Errorf("includeReactions = %v, want false", req.Variables["includeReactions"])
+			}
+		}
+	}
+	if commentPages != 1 {
+		t.Errorf("requested %d comment pages, want 1", commentPages)
+	}
+}
+
+func TestGetDiscussionCategories(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	categories, err := client.GetDiscussionCategories(context.Background(), "owner/repo")
+	if err != nil {
+		t.Fatalf("GetDiscussionCategories() error = %v", err)
+	}
+
+	if len(categories) != 2 {
+		t.Fatalf("got %d categories, want 2", len(categories))
+	}
+	if categories[0].Name != "Q&A" || !categories[0].IsAnswerable {
+		t.Errorf("unexpected first category: %+v", categories[0])
+	}
+	if categories[1].Slug != "ideas" || categories[1].Emoji != ":bulb:" {
+		t.Errorf("unexpected second category: %+v", categories[1])
+	}
+}
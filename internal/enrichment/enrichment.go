@@ -0,0 +1,210 @@
+package enrichment
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// States of a subject
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+	StateMerged = "merged"
+)
+
+// Review decisions of a pull request
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewRequired         = "review_required"
+)
+
+// CI statuses of a pull request or commit
+const (
+	CISuccess = "success"
+	CIFailure = "failure"
+	CIPending = "pending"
+)
+
+// Details are the details of the subject of a notification, such as the
+// issue or pull request it is about
+type Details struct {
+	// State is open, closed or merged
+	State string `json:"state,omitempty"`
+	// Author is the login of the subject's author
+	Author string `json:"author,omitempty"`
+	// AuthorBot is true when the subject's author is a bot account
+	AuthorBot bool `json:"author_bot,omitempty"`
+	// Assignees are the logins of the subject's assignees
+	Assignees []string `json:"assignees,omitempty"`
+	// Labels are the names of the subject's labels
+	Labels []string `json:"labels,omitempty"`
+	// RequestedReviewers are the logins of the users asked to review a pull request
+	RequestedReviewers []string `json:"requested_reviewers,omitempty"`
+	// Mentions are the logins @mentioned in the subject's description
+	Mentions []string `json:"mentions,omitempty"`
+	// ReviewDecision is the review decision of a pull request
+	ReviewDecision string `json:"review_decision,omitempty"`
+	// CIStatus is the combined CI status of a pull request or commit
+	CIStatus string `json:"ci_status,omitempty"`
+	// Draft is true for draft pull requests
+	Draft bool `json:"draft,omitempty"`
+	// Comments is the number of comments, including review comments
+	Comments int `json:"comments"`
+	// Reactions is the number of reactions to the subject
+	Reactions int `json:"reactions"`
+	// MergeState is the mergeable state of a pull request, such as clean or dirty
+	MergeState string `json:"merge_state,omitempty"`
+	// BaseBranch is the branch a pull request is merged into
+	BaseBranch string `json:"base_branch,omitempty"`
+	// DefaultBranch is the default branch of the pull request's repository
+	DefaultBranch string `json:"default_branch,omitempty"`
+	// Files are the paths of the files changed by a pull request or commit
+	Files []string `json:"files,omitempty"`
+	// FetchedAt is when the details were fetched
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// IsAuthor reports whether a user is the subject's author
+func (d *Details) IsAuthor(login string) bool {
+	return login != "" && strings.EqualFold(d.Author, login)
+}
+
+// IsBot reports whether the subject's author is a bot, such as dependabot[bot]
+func (d *Details) IsBot() bool {
+	return d.AuthorBot || strings.HasSuffix(strings.ToLower(d.Author), "[bot]")
+}
+
+// IsAssignee reports whether a user is assigned to the subject
+func (d *Details) IsAssignee(login string) bool {
+	return containsFold(d.Assignees, login)
+}
+
+// IsRequestedReviewer reports whether a user is asked to review the subject
+func (d *Details) IsRequestedReviewer(login string) bool {
+	return containsFold(d.RequestedReviewers, login)
+}
+
+// IsMentioned reports whether a user is @mentioned in the subject's description
+func (d *Details) IsMentioned(login string) bool {
+	return containsFold(d.Mentions, login)
+}
+
+// HasLabel reports whether the subject has a label
+func (d *Details) HasLabel(label string) bool {
+	return containsFold(d.Labels, label)
+}
+
+// containsFold reports whether values contain a value, ignoring case
+func containsFold(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionPattern matches @mentions of users, but not e-mail addresses
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9](?:[A-Za-z0-9-]{0,38}))`)
+
+// ParseMentions returns the logins @mentioned in a text, once each
+func ParseMentions(text string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		login := strings.TrimRight(match[1], "-")
+		if key := strings.ToLower(login); !seen[key] {
+			seen[key] = true
+			mentions = append(mentions, login)
+		}
+	}
+	return mentions
+}
+
+// EnrichedNotification is a notification with the details of its subject
+type EnrichedNotification struct {
+	*github.Notification
+	// Details are nil when the details of the subject are unknown
+	Details *Details `json:"details,omitempty"`
+}
+
+// Store keeps the details of notification subjects, keyed by subject URL
+type Store struct {
+	mu      sync.RWMutex
+	details map[string]*Details
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{details: make(map[string]*Details)}
+}
+
+// Set records the details of a subject
+func (s *Store) Set(subjectURL string, details *Details) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.details[subjectURL] = details
+}
+
+// Get returns the details of a notification's subject, or nil if they are unknown
+func (s *Store) Get(n *github.Notification) *Details {
+	url := n.GetSubject().GetURL()
+	if url == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.details[url]
+}
+
+// Enrich pairs notifications with the details of their subjects
+func (s *Store) Enrich(notifications []*github.Notification) []*EnrichedNotification {
+	enriched := make([]*EnrichedNotification, 0, len(notifications))
+	for _, n := range notifications {
+		enriched = append(enriched, &EnrichedNotification{Notification: n, Details: s.Get(n)})
+	}
+	return enriched
+}
+
+// Singleton instance of Store
+var (
+	defaultStore     *Store
+	defaultStoreOnce sync.Once
+)
+
+// Default returns the store filled by the client and read by the filters,
+// output and UI
+func Default() *Store {
+	defaultStoreOnce.Do(func() {
+		defaultStore = NewStore()
+	})
+	return defaultStore
+}
+
+// SetDefault replaces the store returned by Default
+func SetDefault(store *Store) {
+	defaultStoreOnce.Do(func() {})
+	defaultStore = store
+}
+
+// Lookup returns the details of a notification's subject from the default
+// store, or nil if they are unknown
+func Lookup(n *github.Notification) *Details {
+	return Default().Get(n)
+}
+
+// Stale reports whether the details of a notification's subject in the
+// default store are unknown or older than the notification's last update
+func Stale(n *github.Notification) bool {
+	details := Lookup(n)
+	return details == nil || details.FetchedAt.Before(n.GetUpdatedAt().Time)
+}
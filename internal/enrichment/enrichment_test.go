@@ -0,0 +1,54 @@
+package enrichment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"@alice please review, cc @Bob and @alice", []string{"alice", "Bob"}},
+		{"mail me at me@example.com", nil},
+		{"(@team-lead) @@double", []string{"team-lead"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMentions(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore()
+	known := &github.Notification{Subject: &github.NotificationSubject{URL: github.String("https://api.github.com/repos/o/r/issues/1")}}
+	unknown := &github.Notification{Subject: &github.NotificationSubject{URL: github.String("https://api.github.com/repos/o/r/issues/2")}}
+
+	details := &Details{Author: "Alice", Assignees: []string{"me"}}
+	store.Set(known.GetSubject().GetURL(), details)
+
+	if store.Get(known) != details || store.Get(unknown) != nil || store.Get(&github.Notification{}) != nil {
+		t.Fatal("Get() returned the wrong details")
+	}
+	if !details.IsAuthor("alice") || details.IsAuthor("") || !details.IsAssignee("ME") || details.IsRequestedReviewer("me") {
+		t.Error("Details helpers gave wrong answers")
+	}
+
+	enriched := store.Enrich([]*github.Notification{known, unknown})
+	if len(enriched) != 2 || enriched[0].Details != details || enriched[1].Details != nil || enriched[1].Notification != unknown {
+		t.Errorf("Enrich() = %+v", enriched)
+	}
+
+	original := Default()
+	SetDefault(store)
+	defer SetDefault(original)
+	if Lookup(known) != details {
+		t.Error("Lookup() did not use the default store")
+	}
+}
@@ -0,0 +1,43 @@
+// Package filelock serializes access to files shared by gh-notif processes,
+// such as a running watch and a command run from another shell.
+package filelock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Lock is an exclusive lock on a file, held until Unlock is called
+type Lock struct {
+	file *os.File
+}
+
+// Acquire waits for and takes the exclusive lock of path. The lock is held
+// on a separate path+".lock" file, so path itself can be replaced while it
+// is locked.
+func Acquire(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
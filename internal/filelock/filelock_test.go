@@ -0,0 +1,45 @@
+package filelock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "store.json")
+
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// A second lock, as another process would take, waits for the first
+	acquired := make(chan *Lock)
+	go func() {
+		second, err := Acquire(path)
+		if err != nil {
+			t.Errorf("Acquire() error = %v", err)
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire() did not wait for the held lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	select {
+	case second := <-acquired:
+		if second != nil {
+			second.Unlock()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire() did not take the released lock")
+	}
+}
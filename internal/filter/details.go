@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/google/go-github/v60/github"
+)
+
+// DetailsField is a detail of a notification's subject matched by a DetailsFilter
+type DetailsField string
+
+const (
+	// DetailsState matches the state: open, closed, merged or draft
+	DetailsState DetailsField = "state"
+	// DetailsAuthor matches the author's login
+	DetailsAuthor DetailsField = "author"
+	// DetailsAssignee matches an assignee's login
+	DetailsAssignee DetailsField = "assignee"
+	// DetailsReviewer matches the login of a requested reviewer
+	DetailsReviewer DetailsField = "reviewer"
+	// DetailsLabel matches a label
+	DetailsLabel DetailsField = "label"
+	// DetailsReview matches the review decision: approved, changes_requested or review_required
+	DetailsReview DetailsField = "review"
+	// DetailsCI matches the CI status: success, failure or pending
+	DetailsCI DetailsField = "ci"
+)
+
+// DetailsFilter filters notifications by the details of their subjects.
+// Notifications whose details are unknown do not match.
+type DetailsFilter struct {
+	// Field is the detail to match
+	Field DetailsField
+	// Value is the value to match, ignoring case
+	Value string
+}
+
+// Apply returns true if the notification's subject has the detail
+func (f *DetailsFilter) Apply(notification *github.Notification) bool {
+	details := enrichment.Lookup(notification)
+	if details == nil {
+		return false
+	}
+
+	switch f.Field {
+	case DetailsState:
+		if strings.EqualFold(f.Value, "draft") {
+			return details.Draft
+		}
+		return strings.EqualFold(details.State, f.Value)
+	case DetailsAuthor:
+		return details.IsAuthor(f.Value)
+	case DetailsAssignee:
+		return details.IsAssignee(f.Value)
+	case DetailsReviewer:
+		return details.IsRequestedReviewer(f.Value)
+	case DetailsLabel:
+		return details.HasLabel(f.Value)
+	case DetailsReview:
+		return strings.EqualFold(details.ReviewDecision, f.Value)
+	case DetailsCI:
+		return strings.EqualFold(details.CIStatus, f.Value)
+	default:
+		return false
+	}
+}
+
+// Description returns a human-readable description of the filter
+func (f *DetailsFilter) Description() string {
+	return fmt.Sprintf("%s is %s", f.Field, f.Value)
+}
+
+// detailsMatcher is implemented by filters outside this package that match
+// the details of notification subjects
+type detailsMatcher interface {
+	NeedsDetails() bool
+}
+
+// NeedsDetails reports whether a filter matches the details of the
+// notification subjects, which then have to be fetched before filtering
+func NeedsDetails(f Filter) bool {
+	switch f := f.(type) {
+	case *DetailsFilter:
+		return true
+	case detailsMatcher:
+		return f.NeedsDetails()
+	case *AndFilter:
+		return anyNeedsDetails(f.Filters)
+	case *OrFilter:
+		return anyNeedsDetails(f.Filters)
+	case *CompositeFilter:
+		return anyNeedsDetails(f.Filters)
+	case *NotFilter:
+		return NeedsDetails(f.Filter)
+	default:
+		return false
+	}
+}
+
+// anyNeedsDetails reports whether any of the filters needs subject details
+func anyNeedsDetails(filters []Filter) bool {
+	for _, f := range filters {
+		if NeedsDetails(f) {
+			return true
+		}
+	}
+	return false
+}
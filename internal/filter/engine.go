@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -118,7 +119,7 @@ func (e *Engine) buildIndexes(notifications []*github.Notification) {
 	// Build status index
 	statusIndex := make(map[bool][]*github.Notification)
 	for _, n := range notifications {
-		unread := n.GetUnread()
+		unread := readstate.IsUnread(n)
 		statusIndex[unread] = append(statusIndex[unread], n)
 	}
 	e.indexes["status"] = statusIndex
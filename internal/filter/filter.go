@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/gobwas/glob"
 	"github.com/google/go-github/v60/github"
 )
@@ -206,7 +207,7 @@ func NewStatusFilter(unread bool) *StatusFilter {
 
 // Apply applies the status filter to a notification
 func (f *StatusFilter) Apply(n *github.Notification) bool {
-	return n.GetUnread() == f.Unread
+	return readstate.IsUnread(n) == f.Unread
 }
 
 // Description returns a human-readable description of the status filter
@@ -340,7 +341,7 @@ type ReadFilter struct {
 
 // Apply returns true if the notification matches the read status
 func (f *ReadFilter) Apply(notification *github.Notification) bool {
-	return notification.GetUnread() != f.Read
+	return readstate.IsUnread(notification) != f.Read
 }
 
 // Description returns a human-readable description of the filter
@@ -403,28 +404,37 @@ func (f *ReasonFilter) Description() string {
 type TextFilter struct {
 	// Text is the text to search for
 	Text string
+	// Fields are the fields to search in: title, repository, type or reason.
+	// All of them are searched when empty.
+	Fields []string
 }
 
+// TextFields are the fields a TextFilter can search in
+var TextFields = []string{"title", "repository", "type", "reason"}
+
 // Apply returns true if the notification contains the specified text
 func (f *TextFilter) Apply(notification *github.Notification) bool {
-	// Check the title
-	if strings.Contains(strings.ToLower(notification.GetSubject().GetTitle()), strings.ToLower(f.Text)) {
-		return true
+	fields := f.Fields
+	if len(fields) == 0 {
+		fields = TextFields
 	}
 
-	// Check the repository name
-	if strings.Contains(strings.ToLower(notification.GetRepository().GetFullName()), strings.ToLower(f.Text)) {
-		return true
-	}
-
-	// Check the type
-	if strings.Contains(strings.ToLower(notification.GetSubject().GetType()), strings.ToLower(f.Text)) {
-		return true
-	}
-
-	// Check the reason
-	if strings.Contains(strings.ToLower(notification.GetReason()), strings.ToLower(f.Text)) {
-		return true
+	text := strings.ToLower(f.Text)
+	for _, field := range fields {
+		var value string
+		switch strings.ToLower(field) {
+		case "title":
+			value = notification.GetSubject().GetTitle()
+		case "repository", "repo":
+			value = notification.GetRepository().GetFullName()
+		case "type":
+			value = notification.GetSubject().GetType()
+		case "reason":
+			value = notification.GetReason()
+		}
+		if strings.Contains(strings.ToLower(value), text) {
+			return true
+		}
 	}
 
 	return false
@@ -432,6 +442,9 @@ func (f *TextFilter) Apply(notification *github.Notification) bool {
 
 // Description returns a human-readable description of the filter
 func (f *TextFilter) Description() string {
+	if len(f.Fields) > 0 {
+		return fmt.Sprintf("%s contains text '%s'", strings.Join(f.Fields, " or "), f.Text)
+	}
 	return fmt.Sprintf("contains text '%s'", f.Text)
 }
 
@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -130,6 +132,91 @@ func TestStatusFilter(t *testing.T) {
 	}
 }
 
+// TestReadFilterOverlay tests that the read filter honors the read state overlay
+func TestReadFilterOverlay(t *testing.T) {
+	originalOverlay := readstate.Default()
+	overlay := readstate.NewOverlay()
+	readstate.SetDefault(overlay)
+	defer readstate.SetDefault(originalOverlay)
+
+	updatedAt := &github.Timestamp{Time: time.Now().Add(-time.Hour)}
+	n := &github.Notification{
+		ID:        github.String("1"),
+		Unread:    github.Bool(false),
+		UpdatedAt: updatedAt,
+	}
+
+	unreadFilter := &ReadFilter{Read: false}
+	if unreadFilter.Apply(n) {
+		t.Errorf("read notification matched the unread filter")
+	}
+
+	overlay.MarkUnread(n)
+	if !unreadFilter.Apply(n) {
+		t.Errorf("notification kept unread did not match the unread filter")
+	}
+	if (&ReadFilter{Read: true}).Apply(n) {
+		t.Errorf("notification kept unread matched the read filter")
+	}
+
+	// A newer update ends the overlay
+	n.UpdatedAt = &github.Timestamp{Time: time.Now()}
+	if unreadFilter.Apply(n) {
+		t.Errorf("updated notification still matched the unread filter")
+	}
+}
+
+// TestDetailsFilter tests filtering by the details of notification subjects
+func TestDetailsFilter(t *testing.T) {
+	originalStore := enrichment.Default()
+	store := enrichment.NewStore()
+	enrichment.SetDefault(store)
+	defer enrichment.SetDefault(originalStore)
+
+	notifications := createTestNotifications(3)
+	store.Set(notifications[0].GetSubject().GetURL(), &enrichment.Details{
+		State:              enrichment.StateOpen,
+		Author:             "alice",
+		Labels:             []string{"bug"},
+		RequestedReviewers: []string{"me"},
+		CIStatus:           enrichment.CIFailure,
+	})
+	store.Set(notifications[1].GetSubject().GetURL(), &enrichment.Details{
+		State:          enrichment.StateMerged,
+		Author:         "bob",
+		ReviewDecision: enrichment.ReviewApproved,
+		Draft:          true,
+	})
+
+	tests := []struct {
+		filter *DetailsFilter
+		want   []bool
+	}{
+		{&DetailsFilter{Field: DetailsState, Value: "OPEN"}, []bool{true, false, false}},
+		{&DetailsFilter{Field: DetailsState, Value: "draft"}, []bool{false, true, false}},
+		{&DetailsFilter{Field: DetailsAuthor, Value: "bob"}, []bool{false, true, false}},
+		{&DetailsFilter{Field: DetailsLabel, Value: "Bug"}, []bool{true, false, false}},
+		{&DetailsFilter{Field: DetailsReviewer, Value: "me"}, []bool{true, false, false}},
+		{&DetailsFilter{Field: DetailsReview, Value: "approved"}, []bool{false, true, false}},
+		{&DetailsFilter{Field: DetailsCI, Value: "failure"}, []bool{true, false, false}},
+	}
+
+	for _, tt := range tests {
+		for i, n := range notifications {
+			if got := tt.filter.Apply(n); got != tt.want[i] {
+				t.Errorf("%s: Apply(notification %d) = %v, want %v", tt.filter.Description(), i, got, tt.want[i])
+			}
+		}
+	}
+
+	if !NeedsDetails(&NotFilter{Filter: &AndFilter{Filters: []Filter{NewTypeFilter("Issue"), tests[0].filter}}}) {
+		t.Error("NeedsDetails() = false for a nested details filter")
+	}
+	if NeedsDetails(&OrFilter{Filters: []Filter{NewTypeFilter("Issue")}}) {
+		t.Error("NeedsDetails() = true without a details filter")
+	}
+}
+
 // TestTimeFilter tests the time filter
 func TestTimeFilter(t *testing.T) {
 	// Create test notifications with specific timestamps
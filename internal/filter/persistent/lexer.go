@@ -0,0 +1,233 @@
+package persistent
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SyntaxError is an error in a filter expression, at a column of it
+type SyntaxError struct {
+	// Expr is the filter expression
+	Expr string
+	// Column is the column of the error, starting at 1
+	Column int
+	// Msg describes the error
+	Msg string
+}
+
+// Error returns the error message with its column
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Msg, e.Column)
+}
+
+// Pointer returns the expression with a caret under the column of the error
+func (e *SyntaxError) Pointer() string {
+	return e.Expr + "\n" + strings.Repeat(" ", e.Column-1) + "^"
+}
+
+// tokenKind is the kind of a token in a filter expression
+type tokenKind int
+
+const (
+	// tokenEOF ends the expression
+	tokenEOF tokenKind = iota
+	// tokenTerm is a qualifier such as repo:owner/repo, or text to search for
+	tokenTerm
+	// tokenRef is a reference to a preset such as @prs
+	tokenRef
+	// tokenAnd, tokenOr and tokenNot are the operators AND, OR and NOT or -
+	tokenAnd
+	tokenOr
+	tokenNot
+	// tokenLParen and tokenRParen are parentheses
+	tokenLParen
+	tokenRParen
+)
+
+// token is a token in a filter expression
+type token struct {
+	kind tokenKind
+	// pos is the column of the token, starting at 1
+	pos int
+	// key is the qualifier of a term, empty for text
+	key string
+	// value is the value of a term, or the name of a referenced preset
+	value string
+	// valuePos is the column of the value of a term
+	valuePos int
+}
+
+// String describes a token in error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenAnd:
+		return "AND"
+	case tokenOr:
+		return "OR"
+	case tokenNot:
+		return "NOT"
+	case tokenLParen:
+		return `"("`
+	case tokenRParen:
+		return `")"`
+	case tokenRef:
+		return "@" + t.value
+	default:
+		if t.key != "" {
+			return fmt.Sprintf("%q", t.key+":"+t.value)
+		}
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexer splits a filter expression into tokens
+type lexer struct {
+	expr  string
+	runes []rune
+	pos   int
+}
+
+// lex splits a filter expression into tokens, ending with tokenEOF
+func lex(expr string) ([]token, error) {
+	l := &lexer{expr: expr, runes: []rune(expr)}
+
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// errorf returns a syntax error at an index of the expression
+func (l *lexer) errorf(index int, format string, args ...interface{}) error {
+	return &SyntaxError{Expr: l.expr, Column: index + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ends reports whether a term ends at an index
+func (l *lexer) ends(index int) bool {
+	return index >= len(l.runes) || unicode.IsSpace(l.runes[index]) || l.runes[index] == '(' || l.runes[index] == ')'
+}
+
+// next returns the next token
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.runes) && unicode.IsSpace(l.runes[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	if start >= len(l.runes) {
+		return token{kind: tokenEOF, pos: start + 1}, nil
+	}
+
+	switch r := l.runes[start]; {
+	case r == '(':
+		l.pos++
+		return token{kind: tokenLParen, pos: start + 1}, nil
+	case r == ')':
+		l.pos++
+		return token{kind: tokenRParen, pos: start + 1}, nil
+	case r == '-':
+		// A leading - negates the term or parentheses that follow it
+		l.pos++
+		if l.pos >= len(l.runes) || unicode.IsSpace(l.runes[l.pos]) || l.runes[l.pos] == ')' {
+			return token{}, l.errorf(start, "expected a filter after -")
+		}
+		return token{kind: tokenNot, pos: start + 1}, nil
+	case r == '@':
+		l.pos++
+		name := l.word()
+		if name == "" {
+			return token{}, l.errorf(start, "expected a preset name after @")
+		}
+		return token{kind: tokenRef, pos: start + 1, value: name}, nil
+	case r == '"':
+		value, err := l.quoted()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenTerm, pos: start + 1, value: value, valuePos: start + 1}, nil
+	}
+
+	word := l.word()
+	switch word {
+	case "AND":
+		return token{kind: tokenAnd, pos: start + 1}, nil
+	case "OR":
+		return token{kind: tokenOr, pos: start + 1}, nil
+	case "NOT":
+		return token{kind: tokenNot, pos: start + 1}, nil
+	}
+
+	// A qualifier is followed by its value, which may be quoted
+	if l.pos < len(l.runes) && l.runes[l.pos] == ':' {
+		if word == "" {
+			return token{}, l.errorf(start, "expected a qualifier before :")
+		}
+		l.pos++
+		valueStart := l.pos
+		var value string
+		if l.pos < len(l.runes) && l.runes[l.pos] == '"' {
+			var err error
+			if value, err = l.quoted(); err != nil {
+				return token{}, err
+			}
+		} else {
+			value = l.value()
+		}
+		if value == "" {
+			return token{}, l.errorf(valueStart, "missing value for %s:", word)
+		}
+		return token{kind: tokenTerm, pos: start + 1, key: word, value: value, valuePos: valueStart + 1}, nil
+	}
+
+	return token{kind: tokenTerm, pos: start + 1, value: word, valuePos: start + 1}, nil
+}
+
+// word reads a word, up to a colon or the end of the term
+func (l *lexer) word() string {
+	start := l.pos
+	for !l.ends(l.pos) && l.runes[l.pos] != ':' {
+		l.pos++
+	}
+	return string(l.runes[start:l.pos])
+}
+
+// value reads the value of a qualifier, up to the end of the term
+func (l *lexer) value() string {
+	start := l.pos
+	for !l.ends(l.pos) {
+		l.pos++
+	}
+	return string(l.runes[start:l.pos])
+}
+
+// quoted reads a quoted string, in which \" is a quote and \\ a backslash
+func (l *lexer) quoted() (string, error) {
+	start := l.pos
+	l.pos++
+
+	var sb strings.Builder
+	for l.pos < len(l.runes) {
+		r := l.runes[l.pos]
+		switch {
+		case r == '\\' && l.pos+1 < len(l.runes) && (l.runes[l.pos+1] == '"' || l.runes[l.pos+1] == '\\'):
+			sb.WriteRune(l.runes[l.pos+1])
+			l.pos += 2
+		case r == '"':
+			l.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteRune(r)
+			l.pos++
+		}
+	}
+	return "", l.errorf(start, "unterminated quoted string")
+}
@@ -2,311 +2,410 @@ package persistent
 
 import (
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/SharanRP/gh-notif/internal/scoring"
 )
 
-// Parser parses filter expressions into Filter objects
+// Parser parses filter expressions into Filter objects.
+//
+// The expressions follow the GitHub search syntax:
+//
+//	query   = or
+//	or      = and { "OR" and }
+//	and     = unary { [ "AND" ] unary }
+//	unary   = ( "NOT" | "-" ) unary | "(" or ")" | term
+//	term    = qualifier ":" value | "@" preset | text
+//
+// Terms next to each other must all match. Values and text may be quoted,
+// and ranges are written as from..to, with * for an open end.
 type Parser struct {
 	// store is the filter store for resolving references
 	store *FilterStore
+	// scorer scores notifications for score expressions, the default scorer if nil
+	scorer *scoring.Scorer
+	// now returns the current time, which relative times are taken from
+	now func() time.Time
+	// currentUser returns the login of the authenticated user, which @me
+	// stands for; @me is rejected if nil
+	currentUser func() (string, error)
+	// me is the resolved login of @me
+	me string
+}
+
+// maxReferenceDepth is how deep presets can refer to other presets
+const maxReferenceDepth = 10
+
+// qualifiers are the qualifiers of terms, for suggestions on typos
+var qualifiers = []string{
+	"is", "repo", "repository", "org", "organization", "type", "reason",
+	"updated", "since", "score", "in", "title",
+	"state", "author", "assignee", "reviewer", "label", "review", "ci",
 }
 
 // NewParser creates a new parser
 func NewParser(store *FilterStore) *Parser {
 	return &Parser{
 		store: store,
+		now:   time.Now,
 	}
 }
 
-// Parse parses a filter expression into a Filter
+// WithScorer sets the scorer that score expressions use
+func (p *Parser) WithScorer(scorer *scoring.Scorer) *Parser {
+	p.scorer = scorer
+	return p
+}
+
+// WithCurrentUser sets the function returning the login of the authenticated
+// user, which author:@me, assignee:@me and reviewer:@me stand for. It is
+// only called for expressions using @me.
+func (p *Parser) WithCurrentUser(currentUser func() (string, error)) *Parser {
+	p.currentUser = currentUser
+	p.me = ""
+	return p
+}
+
+// Parse parses a filter expression into a Filter. Errors in the expression
+// are returned as a *SyntaxError.
 func (p *Parser) Parse(expr string) (filter.Filter, error) {
-	// Trim whitespace
-	expr = strings.TrimSpace(expr)
+	return p.parse(expr, 0)
+}
+
+// parse parses an expression, which is referred to by depth presets
+func (p *Parser) parse(expr string, depth int) (filter.Filter, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
 
-	// Handle empty expression
-	if expr == "" {
+	st := &parseState{parser: p, expr: expr, tokens: tokens, depth: depth}
+	if st.peek().kind == tokenEOF {
 		return &filter.AllFilter{}, nil
 	}
 
-	// Check if it's a reference to a saved filter
-	if strings.HasPrefix(expr, "@") {
-		return p.parseReference(expr)
+	f, err := st.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := st.peek(); tok.kind != tokenEOF {
+		if tok.kind == tokenRParen {
+			return nil, st.errorf(tok.pos, "unexpected )")
+		}
+		return nil, st.errorf(tok.pos, "unexpected %s", tok)
+	}
+
+	// in: restricts all the text of the expression to some fields
+	if len(st.in) > 0 {
+		if len(st.texts) == 0 {
+			return nil, st.errorf(st.inPos, "in: needs text to search for")
+		}
+		for _, text := range st.texts {
+			text.Fields = st.in
+		}
 	}
 
-	// Check if it's a complex expression with boolean operators
-	if strings.Contains(expr, " AND ") || strings.Contains(expr, " OR ") || strings.Contains(expr, " NOT ") {
-		return p.parseComplexExpression(expr)
+	if f == nil {
+		return &filter.AllFilter{}, nil
 	}
+	return f, nil
+}
 
-	// Parse as a simple expression
-	return p.parseSimpleExpression(expr)
+// parseState is the state of parsing an expression
+type parseState struct {
+	parser *Parser
+	expr   string
+	tokens []token
+	next   int
+	// depth is the number of presets referring to the expression
+	depth int
+
+	// texts are the text filters, which in: applies to
+	texts []*filter.TextFilter
+	// in are the fields text is searched in, and inPos the column of in:
+	in    []string
+	inPos int
 }
 
-// parseReference parses a reference to a saved filter
-func (p *Parser) parseReference(expr string) (filter.Filter, error) {
-	// Extract the filter name
-	name := strings.TrimPrefix(expr, "@")
+// peek returns the next token without consuming it
+func (st *parseState) peek() token {
+	return st.tokens[st.next]
+}
 
-	// Get the filter preset
-	preset, err := p.store.Get(name)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve filter reference: %w", err)
+// consume returns the next token
+func (st *parseState) consume() token {
+	tok := st.tokens[st.next]
+	if tok.kind != tokenEOF {
+		st.next++
 	}
+	return tok
+}
 
-	// Parse the referenced expression
-	return p.Parse(preset.Expression)
+// errorf returns a syntax error at a column
+func (st *parseState) errorf(column int, format string, args ...interface{}) error {
+	return &SyntaxError{Expr: st.expr, Column: column, Msg: fmt.Sprintf(format, args...)}
 }
 
-// parseComplexExpression parses a complex expression with boolean operators
-func (p *Parser) parseComplexExpression(expr string) (filter.Filter, error) {
-	// Split the expression into tokens
-	tokens, err := p.tokenize(expr)
+// parseOr parses terms separated by OR. Terms that only qualify the others,
+// such as in:title, parse to nil.
+func (st *parseState) parseOr() (filter.Filter, error) {
+	first, err := st.parseAnd()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse the tokens into a filter
-	return p.parseTokens(tokens)
-}
-
-// Token represents a token in a filter expression
-type Token struct {
-	// Type is the token type
-	Type string
-	// Value is the token value
-	Value string
-}
-
-// tokenize splits a complex expression into tokens
-func (p *Parser) tokenize(expr string) ([]Token, error) {
-	// Replace parentheses with spaces around them for easier parsing
-	expr = strings.ReplaceAll(expr, "(", " ( ")
-	expr = strings.ReplaceAll(expr, ")", " ) ")
-
-	// Split the expression into parts
-	parts := strings.Fields(expr)
-
-	// Convert parts to tokens
-	var tokens []Token
-	for _, part := range parts {
-		switch strings.ToUpper(part) {
-		case "AND":
-			tokens = append(tokens, Token{Type: "AND", Value: "AND"})
-		case "OR":
-			tokens = append(tokens, Token{Type: "OR", Value: "OR"})
-		case "NOT":
-			tokens = append(tokens, Token{Type: "NOT", Value: "NOT"})
-		case "(":
-			tokens = append(tokens, Token{Type: "LPAREN", Value: "("})
-		case ")":
-			tokens = append(tokens, Token{Type: "RPAREN", Value: ")"})
-		default:
-			// If it's not an operator or parenthesis, it's an expression
-			tokens = append(tokens, Token{Type: "EXPR", Value: part})
+	filters := []filter.Filter{first}
+	for st.peek().kind == tokenOr {
+		or := st.consume()
+		f, err := st.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+		for _, f := range filters {
+			if f == nil {
+				return nil, st.errorf(or.pos, "OR needs a filter on both sides")
+			}
 		}
 	}
 
-	return tokens, nil
-}
-
-// parseTokens parses tokens into a filter using the shunting yard algorithm
-func (p *Parser) parseTokens(tokens []Token) (filter.Filter, error) {
-	// Implement a simplified version of the shunting yard algorithm
-	// This is a basic implementation that handles AND, OR, NOT, and parentheses
-
-	// Define operator precedence
-	precedence := map[string]int{
-		"NOT": 3,
-		"AND": 2,
-		"OR":  1,
+	if len(filters) == 1 {
+		return first, nil
 	}
+	return &filter.OrFilter{Filters: flatten(filters, func(f filter.Filter) []filter.Filter {
+		if or, ok := f.(*filter.OrFilter); ok {
+			return or.Filters
+		}
+		return nil
+	})}, nil
+}
 
-	// Output queue for expressions
-	var output []filter.Filter
-
-	// Operator stack
-	var operators []Token
-
-	// Process tokens
-	for i := 0; i < len(tokens); i++ {
-		token := tokens[i]
-
-		switch token.Type {
-		case "EXPR":
-			// Parse the expression and add it to the output queue
-			f, err := p.parseSimpleExpression(token.Value)
-			if err != nil {
-				return nil, err
+// parseAnd parses terms separated by AND or next to each other
+func (st *parseState) parseAnd() (filter.Filter, error) {
+	var filters []filter.Filter
+	parsed := 0
+	for {
+		tok := st.peek()
+		switch tok.kind {
+		case tokenEOF, tokenRParen, tokenOr:
+			if parsed == 0 {
+				return nil, st.errorf(tok.pos, "expected a filter, found %s", tok)
 			}
-			output = append(output, f)
-
-		case "LPAREN":
-			// Push left parenthesis onto the operator stack
-			operators = append(operators, token)
-
-		case "RPAREN":
-			// Pop operators until we find a left parenthesis
-			for len(operators) > 0 && operators[len(operators)-1].Type != "LPAREN" {
-				// Pop an operator and apply it
-				op := operators[len(operators)-1]
-				operators = operators[:len(operators)-1]
-
-				if err := p.applyOperator(op, &output); err != nil {
-					return nil, err
-				}
+			switch len(filters) {
+			case 0:
+				return nil, nil
+			case 1:
+				return filters[0], nil
+			default:
+				return &filter.AndFilter{Filters: flatten(filters, func(f filter.Filter) []filter.Filter {
+					if and, ok := f.(*filter.AndFilter); ok {
+						return and.Filters
+					}
+					return nil
+				})}, nil
 			}
-
-			// Pop the left parenthesis
-			if len(operators) > 0 && operators[len(operators)-1].Type == "LPAREN" {
-				operators = operators[:len(operators)-1]
-			} else {
-				return nil, fmt.Errorf("mismatched parentheses")
+		case tokenAnd:
+			if parsed == 0 {
+				return nil, st.errorf(tok.pos, "expected a filter before AND")
 			}
-
-		case "AND", "OR", "NOT":
-			// While there's an operator with higher precedence on the stack, pop it
-			for len(operators) > 0 && operators[len(operators)-1].Type != "LPAREN" &&
-				precedence[operators[len(operators)-1].Type] >= precedence[token.Type] {
-				// Pop an operator and apply it
-				op := operators[len(operators)-1]
-				operators = operators[:len(operators)-1]
-
-				if err := p.applyOperator(op, &output); err != nil {
-					return nil, err
-				}
+			st.consume()
+			if next := st.peek(); next.kind == tokenEOF || next.kind == tokenRParen || next.kind == tokenOr || next.kind == tokenAnd {
+				return nil, st.errorf(next.pos, "expected a filter after AND, found %s", next)
 			}
-
-			// Push the current operator onto the stack
-			operators = append(operators, token)
-		}
-	}
-
-	// Pop remaining operators
-	for len(operators) > 0 {
-		op := operators[len(operators)-1]
-		operators = operators[:len(operators)-1]
-
-		if op.Type == "LPAREN" || op.Type == "RPAREN" {
-			return nil, fmt.Errorf("mismatched parentheses")
 		}
 
-		if err := p.applyOperator(op, &output); err != nil {
+		f, err := st.parseUnary()
+		if err != nil {
 			return nil, err
 		}
+		parsed++
+		if f != nil {
+			filters = append(filters, f)
+		}
 	}
-
-	// The output queue should now contain exactly one filter
-	if len(output) != 1 {
-		return nil, fmt.Errorf("invalid expression")
-	}
-
-	return output[0], nil
 }
 
-// applyOperator applies an operator to the output queue
-func (p *Parser) applyOperator(op Token, output *[]filter.Filter) error {
-	switch op.Type {
-	case "AND":
-		// AND requires two operands
-		if len(*output) < 2 {
-			return fmt.Errorf("not enough operands for AND")
+// parseUnary parses a negated term, parentheses or a term
+func (st *parseState) parseUnary() (filter.Filter, error) {
+	tok := st.consume()
+	switch tok.kind {
+	case tokenNot:
+		if next := st.peek(); next.kind == tokenEOF || next.kind == tokenRParen || next.kind == tokenOr || next.kind == tokenAnd {
+			return nil, st.errorf(next.pos, "expected a filter after NOT, found %s", next)
 		}
-
-		// Pop the two operands
-		right := (*output)[len(*output)-1]
-		left := (*output)[len(*output)-2]
-		*output = (*output)[:len(*output)-2]
-
-		// Create a composite filter with AND
-		*output = append(*output, &filter.CompositeFilter{
-			Filters:  []filter.Filter{left, right},
-			Operator: filter.And,
-		})
-
-	case "OR":
-		// OR requires two operands
-		if len(*output) < 2 {
-			return fmt.Errorf("not enough operands for OR")
+		f, err := st.parseUnary()
+		if err != nil {
+			return nil, err
 		}
-
-		// Pop the two operands
-		right := (*output)[len(*output)-1]
-		left := (*output)[len(*output)-2]
-		*output = (*output)[:len(*output)-2]
-
-		// Create a composite filter with OR
-		*output = append(*output, &filter.CompositeFilter{
-			Filters:  []filter.Filter{left, right},
-			Operator: filter.Or,
-		})
-
-	case "NOT":
-		// NOT requires one operand
-		if len(*output) < 1 {
-			return fmt.Errorf("not enough operands for NOT")
+		if f == nil {
+			return nil, st.errorf(tok.pos, "in: cannot be negated")
 		}
-
-		// Pop the operand
-		operand := (*output)[len(*output)-1]
-		*output = (*output)[:len(*output)-1]
-
-		// Create a composite filter with NOT
-		*output = append(*output, &filter.CompositeFilter{
-			Filters:  []filter.Filter{operand},
-			Operator: filter.Not,
-		})
+		return &filter.NotFilter{Filter: f}, nil
+	case tokenLParen:
+		f, err := st.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if st.peek().kind != tokenRParen {
+			return nil, st.errorf(tok.pos, "missing ) for this (")
+		}
+		st.consume()
+		return f, nil
+	case tokenRef:
+		return st.parseReference(tok)
+	case tokenTerm:
+		return st.parseTerm(tok)
+	default:
+		return nil, st.errorf(tok.pos, "expected a filter, found %s", tok)
 	}
-
-	return nil
 }
 
-// parseSimpleExpression parses a simple expression into a Filter
-func (p *Parser) parseSimpleExpression(expr string) (filter.Filter, error) {
-	// Check for key:value format
-	if strings.Contains(expr, ":") {
-		return p.parseKeyValueExpression(expr)
+// parseReference parses a reference to a saved filter
+func (st *parseState) parseReference(tok token) (filter.Filter, error) {
+	p := st.parser
+	if p.store == nil {
+		return nil, st.errorf(tok.pos, "unknown filter preset @%s", tok.value)
+	}
+	if st.depth >= maxReferenceDepth {
+		return nil, st.errorf(tok.pos, "filter preset @%s refers to itself", tok.value)
+	}
+
+	preset, err := p.store.Get(tok.value)
+	if err != nil {
+		return nil, st.errorf(tok.pos, "unknown filter preset @%s", tok.value)
 	}
 
-	// Default to text search
-	return &filter.TextFilter{Text: expr}, nil
+	f, err := p.parse(preset.Expression, st.depth+1)
+	if err != nil {
+		return nil, st.errorf(tok.pos, "invalid filter preset @%s: %v", tok.value, err)
+	}
+	return f, nil
 }
 
-// parseKeyValueExpression parses a key:value expression into a Filter
-func (p *Parser) parseKeyValueExpression(expr string) (filter.Filter, error) {
-	// Split into key and value
-	parts := strings.SplitN(expr, ":", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid key:value expression: %s", expr)
+// parseTerm parses a qualifier with its value, or text to search for
+func (st *parseState) parseTerm(tok token) (filter.Filter, error) {
+	if tok.key == "" {
+		text := &filter.TextFilter{Text: tok.value}
+		st.texts = append(st.texts, text)
+		return text, nil
 	}
 
-	key := strings.ToLower(parts[0])
-	value := parts[1]
+	p := st.parser
+	value := tok.value
+	var f filter.Filter
+	var err error
 
-	// Handle different keys
+	key := strings.ToLower(tok.key)
 	switch key {
 	case "is":
-		return p.parseIsExpression(value)
+		f, err = p.parseIsExpression(value)
 	case "repo", "repository":
-		return p.parseRepoExpression(value)
+		f, err = p.parseRepoExpression(value)
 	case "org", "organization":
-		return p.parseOrgExpression(value)
+		f, err = p.parseOrgExpression(value)
 	case "type":
-		return p.parseTypeExpression(value)
+		f, err = p.parseTypeExpression(value)
 	case "reason":
-		return p.parseReasonExpression(value)
-	case "updated", "since":
-		return p.parseTimeExpression(value)
+		f, err = p.parseReasonExpression(value)
+	case "updated":
+		f, err = p.parseTimeExpression(value)
+	case "since":
+		f, err = p.parseSinceExpression(value)
 	case "score":
-		return p.parseScoreExpression(value)
+		f, err = p.parseScoreExpression(value)
+	case "title":
+		f, err = filter.NewRegexFilter(value, "title")
+	case "in":
+		return nil, st.parseIn(tok)
+	case "author", "assignee", "reviewer":
+		if strings.EqualFold(value, "@me") {
+			value, err = p.resolveMe()
+		}
+		f = &filter.DetailsFilter{Field: filter.DetailsField(key), Value: value}
+	case "state", "label", "review", "ci":
+		f = &filter.DetailsFilter{Field: filter.DetailsField(key), Value: value}
 	default:
-		// Default to regex search on the specified field
-		return p.parseRegexExpression(key, value)
+		if suggestion := suggestQualifier(key); suggestion != "" {
+			return nil, st.errorf(tok.pos, "unknown qualifier %q (did you mean %q?)", tok.key, suggestion)
+		}
+		return nil, st.errorf(tok.pos, "unknown qualifier %q", tok.key)
 	}
+
+	if err != nil {
+		return nil, st.errorf(tok.valuePos, "%v", err)
+	}
+	return f, nil
+}
+
+// parseIn parses in:fields, which restricts the text to search to some fields
+func (st *parseState) parseIn(tok token) error {
+	if st.in == nil {
+		st.inPos = tok.pos
+	}
+	for _, field := range strings.Split(strings.ToLower(tok.value), ",") {
+		if field == "repo" {
+			field = "repository"
+		}
+		valid := false
+		for _, name := range filter.TextFields {
+			valid = valid || field == name
+		}
+		if !valid {
+			return st.errorf(tok.valuePos, "invalid in: field %q (must be one of %s)", field, strings.Join(filter.TextFields, ", "))
+		}
+		st.in = append(st.in, field)
+	}
+	return nil
+}
+
+// flatten replaces filters by the filters they combine with the same operator
+func flatten(filters []filter.Filter, children func(filter.Filter) []filter.Filter) []filter.Filter {
+	var flat []filter.Filter
+	for _, f := range filters {
+		if inner := children(f); inner != nil {
+			flat = append(flat, inner...)
+		} else {
+			flat = append(flat, f)
+		}
+	}
+	return flat
+}
+
+// suggestQualifier returns the qualifier closest to a misspelled one, or an
+// empty string if none is close
+func suggestQualifier(key string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range qualifiers {
+		if d := editDistance(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
 }
 
 // parseIsExpression parses an is:value expression
@@ -316,6 +415,8 @@ func (p *Parser) parseIsExpression(value string) (filter.Filter, error) {
 		return &filter.ReadFilter{Read: true}, nil
 	case "unread":
 		return &filter.ReadFilter{Read: false}, nil
+	case "open", "closed", "merged", "draft":
+		return &filter.DetailsFilter{Field: filter.DetailsState, Value: strings.ToLower(value)}, nil
 	default:
 		return nil, fmt.Errorf("invalid is: value: %s", value)
 	}
@@ -341,96 +442,171 @@ func (p *Parser) parseReasonExpression(value string) (filter.Filter, error) {
 	return &filter.ReasonFilter{Reason: value}, nil
 }
 
-// parseTimeExpression parses a time-based expression
+// splitComparison splits a value into a comparison operator (>, >=, < or <=)
+// and the rest, with an empty operator if there is none
+func splitComparison(value string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, op) {
+			return op, strings.TrimPrefix(value, op)
+		}
+	}
+	return "", value
+}
+
+// parseTimeExpression parses a time-based expression. Times are dates
+// (2006-01-02), RFC 3339 times or durations before now such as 24h or 7d.
+// Comparisons select notifications updated after (>) or before (<) a time,
+// and from..to selects a range, including the day of to.
 func (p *Parser) parseTimeExpression(value string) (filter.Filter, error) {
-	// Check for comparison operators
-	if strings.HasPrefix(value, ">") {
-		// Greater than (newer than)
-		duration, err := parseDuration(strings.TrimPrefix(value, ">"))
-		if err != nil {
-			return nil, err
+	f := filter.NewTimeFilter()
+
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		if from != "*" {
+			since, _, err := p.parseTime(from)
+			if err != nil {
+				return nil, err
+			}
+			f.WithSince(since)
 		}
-		return &filter.TimeFilter{
-			UsesSince: true,
-			Since:     time.Now().Add(-duration),
-		}, nil
-	} else if strings.HasPrefix(value, "<") {
-		// Less than (older than)
-		duration, err := parseDuration(strings.TrimPrefix(value, "<"))
-		if err != nil {
-			return nil, err
+		if to != "*" {
+			before, day, err := p.parseTime(to)
+			if err != nil {
+				return nil, err
+			}
+			if day {
+				before = before.AddDate(0, 0, 1)
+			}
+			f.WithBefore(before)
+		}
+		if f.UsesSince && f.UsesBefore && !f.Since.Before(f.Before) {
+			return nil, fmt.Errorf("empty time range: %s", value)
 		}
-		return &filter.TimeFilter{
-			UsesBefore: true,
-			Before:     time.Now().Add(-duration),
-		}, nil
+		return f, nil
 	}
 
-	// Default to exact time
-	t, err := time.Parse(time.RFC3339, value)
+	op, rest := splitComparison(value)
+	t, day, err := p.parseTime(rest)
 	if err != nil {
-		return nil, fmt.Errorf("invalid time format: %s", value)
+		return nil, err
+	}
+	if day && (op == ">" || op == "<=") {
+		// A day is over at the start of the next one
+		t = t.AddDate(0, 0, 1)
+	}
+
+	switch op {
+	case ">", ">=":
+		return f.WithSince(t), nil
+	case "<", "<=":
+		return f.WithBefore(t), nil
+	}
+
+	if _, err := ParseDuration(rest); err == nil {
+		return nil, fmt.Errorf("invalid time: %s (use >%s for the last %s)", value, value, value)
+	}
+
+	// A day, or the day starting at a time
+	return f.WithSince(t).WithBefore(t.Add(24 * time.Hour)), nil
+}
+
+// parseSinceExpression parses a since: expression, which selects
+// notifications updated at or after a time, so since:7d is updated:>=7d.
+// Comparisons and ranges are parsed like updated: values.
+func (p *Parser) parseSinceExpression(value string) (filter.Filter, error) {
+	if op, _ := splitComparison(value); op == "" && !strings.Contains(value, "..") {
+		value = ">=" + value
 	}
+	return p.parseTimeExpression(value)
+}
 
-	return &filter.TimeFilter{
-		UsesSince:  true,
-		UsesBefore: true,
-		Since:      t,
-		Before:     t.Add(24 * time.Hour),
-	}, nil
+// parseTime parses a date, an RFC 3339 time or a duration before now,
+// reporting whether it is a date
+func (p *Parser) parseTime(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if d, err := ParseDuration(value); err == nil {
+		return p.now().Add(-d), false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time: %s (use a date such as 2006-01-02, an RFC 3339 time or a duration such as 24h)", value)
 }
 
-// parseScoreExpression parses a score:value expression
+// resolveMe returns the login of the authenticated user that @me stands for
+func (p *Parser) resolveMe() (string, error) {
+	if p.me != "" {
+		return p.me, nil
+	}
+	if p.currentUser == nil {
+		return "", fmt.Errorf("@me is not supported here, use a login instead")
+	}
+
+	login, err := p.currentUser()
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve @me: %w", err)
+	}
+	if login == "" {
+		return "", fmt.Errorf("cannot resolve @me: the authenticated user is unknown")
+	}
+	p.me = login
+	return login, nil
+}
+
+// parseScoreExpression parses a score expression: a score, a comparison such
+// as >70 or a range such as 50..75
 func (p *Parser) parseScoreExpression(value string) (filter.Filter, error) {
-	// Check for comparison operators
-	if strings.HasPrefix(value, ">") {
-		// Greater than
-		score, err := strconv.Atoi(strings.TrimPrefix(value, ">"))
-		if err != nil {
-			return nil, fmt.Errorf("invalid score: %s", value)
+	minScore, maxScore := 0, 100
+
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		var err error
+		if from != "*" {
+			if minScore, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("invalid score: %s", from)
+			}
 		}
-		return &ScoreFilter{
-			MinScore: score,
-		}, nil
-	} else if strings.HasPrefix(value, "<") {
-		// Less than
-		score, err := strconv.Atoi(strings.TrimPrefix(value, "<"))
+		if to != "*" {
+			if maxScore, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid score: %s", to)
+			}
+		}
+	} else {
+		op, rest := splitComparison(value)
+		score, err := strconv.Atoi(rest)
 		if err != nil {
 			return nil, fmt.Errorf("invalid score: %s", value)
 		}
-		return &ScoreFilter{
-			MaxScore: score,
-		}, nil
+		switch op {
+		case ">":
+			minScore = score + 1
+		case ">=":
+			minScore = score
+		case "<":
+			maxScore = score - 1
+		case "<=":
+			maxScore = score
+		default:
+			minScore, maxScore = score, score
+		}
 	}
 
-	// Default to exact score
-	score, err := strconv.Atoi(value)
-	if err != nil {
-		return nil, fmt.Errorf("invalid score: %s", value)
+	if minScore > maxScore || maxScore < 0 || minScore > 100 {
+		return nil, fmt.Errorf("empty score range: %s (scores are between 0 and 100)", value)
 	}
 
-	return &ScoreFilter{
-		MinScore: score,
-		MaxScore: score,
-	}, nil
-}
-
-// parseRegexExpression parses a field:regex expression
-func (p *Parser) parseRegexExpression(field, pattern string) (filter.Filter, error) {
-	// Compile the regex
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("invalid regex: %s", pattern)
+	// A ScoreFilter has no maximum of 0, but scores of at most 0 are below 1
+	if maxScore == 0 {
+		return &filter.NotFilter{Filter: scoring.NewScoreFilter(1, 0, p.scorer)}, nil
 	}
-
-	return &filter.RegexFilter{
-		Field:   field,
-		Pattern: re,
-	}, nil
+	if maxScore >= 100 {
+		maxScore = 0
+	}
+	return scoring.NewScoreFilter(minScore, maxScore, p.scorer), nil
 }
 
-// parseDuration parses a duration string
-func parseDuration(s string) (time.Duration, error) {
+// ParseDuration parses a duration such as 12h, 3d, 2w or a Go duration
+func ParseDuration(s string) (time.Duration, error) {
 	// Check for common time units
 	if strings.HasSuffix(s, "h") {
 		// Hours
@@ -0,0 +1,217 @@
+package persistent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestParserGrammar(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	parser := NewParser(nil)
+	parser.now = func() time.Time { return now }
+
+	notification := func(repo, typ, title string, updated time.Time) *github.Notification {
+		return &github.Notification{
+			ID:         github.String(title),
+			Repository: &github.Repository{FullName: github.String(repo)},
+			Subject:    &github.NotificationSubject{Type: github.String(typ), Title: github.String(title)},
+			Reason:     github.String("mention"),
+			Unread:     github.Bool(true),
+			UpdatedAt:  &github.Timestamp{Time: updated},
+		}
+	}
+	bugfix := notification("owner/repo", "PullRequest", "Fix the login bug", time.Date(2026, 1, 15, 9, 0, 0, 0, time.Local))
+	release := notification("owner/tools", "Release", "v1.2.0 is out", time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local))
+	recent := notification("other/repo", "Issue", "Login page is slow", now.Add(-2*time.Hour))
+
+	tests := []struct {
+		expr string
+		want []*github.Notification
+	}{
+		{"repo:owner/repo type:PullRequest", []*github.Notification{bugfix}},
+		{"repo:owner/* -type:Release", []*github.Notification{bugfix}},
+		{"repo:owner/* AND NOT type:Release", []*github.Notification{bugfix}},
+		{"type:Release OR type:Issue", []*github.Notification{release, recent}},
+		{"-(type:Release OR type:Issue)", []*github.Notification{bugfix}},
+		{`"login bug"`, []*github.Notification{bugfix}},
+		{"login", []*github.Notification{bugfix, recent}},
+		{"login bug", []*github.Notification{bugfix}},
+		{"repo in:title", nil},
+		{"repo in:title,repo", []*github.Notification{bugfix, recent}},
+		{`title:"^Fix "`, []*github.Notification{bugfix}},
+		{"updated:2026-01-01..2026-01-31", []*github.Notification{bugfix}},
+		{"updated:2026-01-15..2026-02-01", []*github.Notification{bugfix, release}},
+		{"updated:2026-02-01", []*github.Notification{release}},
+		{"updated:>2026-01-31", []*github.Notification{release, recent}},
+		{"updated:<=2026-01-15", []*github.Notification{bugfix}},
+		{"updated:2026-02-01..*", []*github.Notification{release, recent}},
+		{"updated:>24h", []*github.Notification{recent}},
+		{"updated:<7d", []*github.Notification{bugfix, release}},
+		{"since:24h", []*github.Notification{recent}},
+		{"since:2026-02-01", []*github.Notification{release, recent}},
+		{"since:<7d", []*github.Notification{bugfix, release}},
+		{"since:2026-01-01..2026-01-31", []*github.Notification{bugfix}},
+		{"(repo:owner/repo OR repo:other/repo) AND is:unread", []*github.Notification{bugfix, recent}},
+	}
+
+	all := []*github.Notification{bugfix, release, recent}
+	for _, tt := range tests {
+		f, err := parser.Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+
+		var got []*github.Notification
+		for _, n := range all {
+			if f.Apply(n) {
+				got = append(got, n)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Parse(%q) matched %d notifications, want %d (%s)", tt.expr, len(got), len(tt.want), f.Description())
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Parse(%q) matched %q, want %q", tt.expr, got[i].GetID(), tt.want[i].GetID())
+			}
+		}
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	parser := NewParser(nil)
+
+	tests := []struct {
+		expr    string
+		column  int
+		message string
+	}{
+		{"repro:foo", 1, `unknown qualifier "repro" (did you mean "repo"?)`},
+		{"is:unread AND frobnicate:x", 15, `unknown qualifier "frobnicate"`},
+		{"(repo:a OR type:Issue", 1, "missing ) for this ("},
+		{"repo:a)", 7, "unexpected )"},
+		{"repo:a OR", 10, "expected a filter, found end of filter"},
+		{"AND repo:a", 1, "expected a filter before AND"},
+		{"repo:a AND AND type:Issue", 12, "expected a filter after AND"},
+		{`title:"unterminated`, 7, "unterminated quoted string"},
+		{"repo:", 6, "missing value for repo:"},
+		{"is:sleeping", 4, "invalid is: value"},
+		{"score:abc", 7, "invalid score"},
+		{"score:>100", 7, "empty score range"},
+		{"updated:2026-13-01", 9, "invalid time"},
+		{"updated:24h", 9, "use >24h"},
+		{"since:yesterday", 7, "invalid time"},
+		{"author:@me", 8, "@me is not supported here"},
+		{"in:body fix", 4, "invalid in: field"},
+		{"in:title", 1, "in: needs text to search for"},
+		{"-in:title fix", 1, "in: cannot be negated"},
+		{"a - b", 3, "expected a filter after -"},
+		{"@missing", 1, "unknown filter preset @missing"},
+	}
+
+	for _, tt := range tests {
+		_, err := parser.Parse(tt.expr)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) error = %v, want a syntax error", tt.expr, err)
+			continue
+		}
+		if syntaxErr.Column != tt.column || !strings.Contains(syntaxErr.Msg, tt.message) {
+			t.Errorf("Parse(%q) error = %q at column %d, want %q at column %d", tt.expr, syntaxErr.Msg, syntaxErr.Column, tt.message, tt.column)
+		}
+	}
+
+	_, err := parser.Parse("type:Issue repro:foo")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Parse() error = %v, want a syntax error", err)
+	}
+	if want := "type:Issue repro:foo\n           ^"; syntaxErr.Pointer() != want {
+		t.Errorf("Pointer() = %q, want %q", syntaxErr.Pointer(), want)
+	}
+}
+
+func TestParserResolvesMe(t *testing.T) {
+	calls := 0
+	parser := NewParser(nil).WithCurrentUser(func() (string, error) {
+		calls++
+		return "octocat", nil
+	})
+
+	f, err := parser.Parse("author:@me OR reviewer:@ME OR assignee:hubot")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("current user looked up %d times, want 1", calls)
+	}
+	if got, want := f.Description(), "(author is octocat OR reviewer is octocat OR assignee is hubot)"; got != want {
+		t.Errorf("Description() = %q, want %q", got, want)
+	}
+
+	failing := NewParser(nil).WithCurrentUser(func() (string, error) {
+		return "", errors.New("not authenticated")
+	})
+	_, err = failing.Parse("is:open author:@me")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Parse() error = %v, want a syntax error", err)
+	}
+	if syntaxErr.Column != 16 || !strings.Contains(syntaxErr.Msg, "cannot resolve @me: not authenticated") {
+		t.Errorf("Parse() error = %q at column %d", syntaxErr.Msg, syntaxErr.Column)
+	}
+}
+
+func TestParserScoreRanges(t *testing.T) {
+	parser := NewParser(nil)
+
+	tests := map[string]string{
+		"score:>70":     "score >= 71",
+		"score:>=70":    "score >= 70",
+		"score:<30":     "score <= 29",
+		"score:50..75":  "score between 50 and 75",
+		"score:50..*":   "score >= 50",
+		"score:42":      "score between 42 and 42",
+		"score:<1":      "NOT (score >= 1)",
+		"score:0..100":  "any score",
+		"-score:>=90":   "NOT (score >= 90)",
+		"score:>50 low": "(score >= 51 AND contains text 'low')",
+	}
+	for expr, want := range tests {
+		f, err := parser.Parse(expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", expr, err)
+			continue
+		}
+		if got := f.Description(); got != want {
+			t.Errorf("Parse(%q).Description() = %q, want %q", expr, got, want)
+		}
+	}
+}
+
+func TestParserFlattensOperators(t *testing.T) {
+	f, err := NewParser(nil).Parse("type:Issue type:PullRequest AND (reason:mention reason:assign) OR is:read OR is:unread")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := `OR
+  AND
+    type is one of [Issue]
+    type is one of [PullRequest]
+    reason is mention
+    reason is assign
+  read notifications
+  unread notifications
+`
+	if got := filter.DescribeTree(f); got != want {
+		t.Errorf("DescribeTree() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -56,7 +56,8 @@ func NewFilterStore(configManager *config.ConfigManager) (*FilterStore, error) {
 
 	// Set up the presets file path
 	configDir := filepath.Dir(configManager.GetConfigFile())
-	store.filePath = filepath.Join(configDir, "filter_presets.json")
+	fileName := config.ProfileFileName("filter_presets", configManager.GetProfile()) + ".json"
+	store.filePath = filepath.Join(configDir, fileName)
 
 	// Load existing presets
 	if err := store.Load(); err != nil {
@@ -1,12 +1,16 @@
 package persistent
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/google/go-github/v60/github"
 )
 
 func TestFilterStore(t *testing.T) {
@@ -23,10 +27,13 @@ func TestFilterStore(t *testing.T) {
 		t.Fatalf("Failed to create config file: %v", err)
 	}
 
-	// Create a config manager
+	// Point the config manager at the test config file, so presets are
+	// saved next to it rather than in the working directory
+	t.Setenv("GH_NOTIF_CONFIG", configFile)
 	configManager := config.NewConfigManager()
-	// Set the config file path through environment variable
-	os.Setenv("GH_NOTIF_CONFIG", configFile)
+	if err := configManager.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
 
 	// Create a filter store
 	store, err := NewFilterStore(configManager)
@@ -108,10 +115,13 @@ func TestParserSimple(t *testing.T) {
 		t.Fatalf("Failed to create config file: %v", err)
 	}
 
-	// Create a config manager
+	// Point the config manager at the test config file, so presets are
+	// saved next to it rather than in the working directory
+	t.Setenv("GH_NOTIF_CONFIG", configFile)
 	configManager := config.NewConfigManager()
-	// Set the config file path through environment variable
-	os.Setenv("GH_NOTIF_CONFIG", configFile)
+	if err := configManager.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
 
 	// Create a filter store
 	store, err := NewFilterStore(configManager)
@@ -178,10 +188,13 @@ func TestParserComplex(t *testing.T) {
 		t.Fatalf("Failed to create config file: %v", err)
 	}
 
-	// Create a config manager
+	// Point the config manager at the test config file, so presets are
+	// saved next to it rather than in the working directory
+	t.Setenv("GH_NOTIF_CONFIG", configFile)
 	configManager := config.NewConfigManager()
-	// Set the config file path through environment variable
-	os.Setenv("GH_NOTIF_CONFIG", configFile)
+	if err := configManager.Load(); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
 
 	// Create a filter store
 	store, err := NewFilterStore(configManager)
@@ -193,22 +206,78 @@ func TestParserComplex(t *testing.T) {
 	parser := NewParser(store)
 
 	// Test parsing a complex expression with parentheses
-	filter, err := parser.Parse("(repo:owner/repo OR repo:other/repo) AND is:unread")
+	f, err := parser.Parse("(repo:owner/repo OR repo:other/repo) AND is:unread")
 	if err != nil {
 		t.Fatalf("Failed to parse complex expression: %v", err)
 	}
 
-	if filter == nil {
+	if f == nil {
 		t.Fatalf("Expected filter, got nil")
 	}
 
 	// Test parsing a complex expression with NOT
-	filter, err = parser.Parse("repo:owner/repo AND NOT is:read")
+	f, err = parser.Parse("repo:owner/repo AND NOT is:read")
 	if err != nil {
 		t.Fatalf("Failed to parse complex expression with NOT: %v", err)
 	}
 
-	if filter == nil {
+	if f == nil {
 		t.Fatalf("Expected filter, got nil")
 	}
+
+	// Test parsing an expression on the details of the subjects
+	f, err = parser.Parse("is:merged AND label:bug AND NOT ci:failure")
+	if err != nil {
+		t.Fatalf("Failed to parse details expression: %v", err)
+	}
+
+	if !filter.NeedsDetails(f) {
+		t.Errorf("Expected the details expression to need subject details")
+	}
+}
+
+func TestParserScore(t *testing.T) {
+	scorer := scoring.NewScorer(nil)
+	parser := NewParser(nil).WithScorer(scorer)
+
+	notification := &github.Notification{
+		ID:         github.String("1"),
+		Subject:    &github.NotificationSubject{Type: github.String("PullRequest")},
+		Reason:     github.String("review_requested"),
+		Repository: &github.Repository{FullName: github.String("owner/repo")},
+		UpdatedAt:  &github.Timestamp{Time: time.Now()},
+	}
+	total := scorer.ScoreOf(notification).Total
+
+	// Score expressions score the notifications themselves
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{fmt.Sprintf("score:>%d", total-1), true},
+		{fmt.Sprintf("score:>%d", total+1), false},
+		{fmt.Sprintf("score:<%d", total+1), true},
+		{fmt.Sprintf("score:%d", total), true},
+		{fmt.Sprintf("repo:owner/repo AND NOT score:>%d", total+1), true},
+	}
+	for _, tt := range tests {
+		f, err := parser.Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if !filter.NeedsScores(f) {
+			t.Errorf("Parse(%q) should need scores", tt.expr)
+		}
+		if got := f.Apply(notification); got != tt.want {
+			t.Errorf("Parse(%q).Apply() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	f, err := parser.Parse("repo:owner/repo AND is:unread")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if filter.NeedsScores(f) {
+		t.Error("Expected an expression without score to not need scores")
+	}
 }
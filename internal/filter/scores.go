@@ -0,0 +1,35 @@
+package filter
+
+// scoreMatcher is implemented by filters that match notification scores
+type scoreMatcher interface {
+	NeedsScores() bool
+}
+
+// NeedsScores reports whether a filter matches the scores of notifications,
+// which are then worth scoring for the current user before filtering
+func NeedsScores(f Filter) bool {
+	switch f := f.(type) {
+	case scoreMatcher:
+		return f.NeedsScores()
+	case *AndFilter:
+		return anyNeedsScores(f.Filters)
+	case *OrFilter:
+		return anyNeedsScores(f.Filters)
+	case *CompositeFilter:
+		return anyNeedsScores(f.Filters)
+	case *NotFilter:
+		return NeedsScores(f.Filter)
+	default:
+		return false
+	}
+}
+
+// anyNeedsScores reports whether any of the filters needs scores
+func anyNeedsScores(filters []Filter) bool {
+	for _, f := range filters {
+		if NeedsScores(f) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"strings"
+)
+
+// DescribeTree describes a filter as an indented tree, with one line for each
+// AND, OR and NOT and the descriptions of the other filters as leaves
+func DescribeTree(f Filter) string {
+	var sb strings.Builder
+	describeTree(&sb, f, 0)
+	return sb.String()
+}
+
+// describeTree writes the tree of a filter at a depth
+func describeTree(sb *strings.Builder, f Filter, depth int) {
+	var op string
+	var children []Filter
+
+	switch f := f.(type) {
+	case *AndFilter:
+		op, children = "AND", f.Filters
+	case *OrFilter:
+		op, children = "OR", f.Filters
+	case *NotFilter:
+		op, children = "NOT", []Filter{f.Filter}
+	case *CompositeFilter:
+		switch f.Operator {
+		case And:
+			op = "AND"
+		case Or:
+			op = "OR"
+		case Not:
+			op = "NOT"
+		}
+		children = f.Filters
+	}
+
+	sb.WriteString(strings.Repeat("  ", depth))
+	if op == "" {
+		sb.WriteString(f.Description())
+		sb.WriteString("\n")
+		return
+	}
+
+	sb.WriteString(op)
+	sb.WriteString("\n")
+	for _, child := range children {
+		describeTree(sb, child, depth+1)
+	}
+}
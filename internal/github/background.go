@@ -104,19 +104,35 @@ func (b *BackgroundRefresher) refreshLoop() {
 	b.refresh()
 
 	// Set up a ticker for periodic refresh
-	ticker := time.NewTicker(b.interval)
+	ticker := time.NewTicker(b.nextInterval())
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			b.refresh()
+
+			// The server may have asked to poll less often
+			ticker.Reset(b.nextInterval())
 		case <-b.ctx.Done():
 			return
 		}
 	}
 }
 
+// nextInterval returns the refresh interval, stretched to the poll interval
+// advertised by the server
+func (b *BackgroundRefresher) nextInterval() time.Duration {
+	b.mu.RLock()
+	interval := b.interval
+	b.mu.RUnlock()
+
+	if pollInterval := b.client.PollInterval(); pollInterval > interval {
+		return pollInterval
+	}
+	return interval
+}
+
 // refresh fetches notifications and updates the internal state
 func (b *BackgroundRefresher) refresh() {
 	var notifications []*github.Notification
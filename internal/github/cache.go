@@ -4,35 +4,111 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/auth"
 	"github.com/SharanRP/gh-notif/internal/cache"
 	"github.com/SharanRP/gh-notif/internal/config"
 	"github.com/google/go-github/v60/github"
 )
 
+// cacheEncryptionKey returns the key that encrypts the cache, replaced in tests
+var cacheEncryptionKey = auth.CacheKey
+
+// notificationListKeys matches the keys of the cached notification lists
+const notificationListKeys = "{notifications_*,all_notifications_*,repo_notifications_*}"
+
 // CacheManager manages the GitHub API cache
 type CacheManager struct {
 	// Manager is the cache manager
 	Manager *cache.Manager
 
+	// Notifications is the typed view of the cached notification lists
+	Notifications *cache.Typed[[]*github.Notification]
+
 	// Client is the GitHub client
 	Client *Client
 
 	// Config is the configuration
 	Config *config.Config
+
+	// dir is the cache directory, which may be shared with other clients
+	dir string
 }
 
+// sharedCache is a cache opened by this process, shared by the clients using
+// its directory because a cache directory can only be opened once
+type sharedCache struct {
+	manager *cache.Manager
+	refs    int
+}
+
+// Open caches by directory
+var (
+	sharedCaches   = make(map[string]*sharedCache)
+	sharedCachesMu sync.Mutex
+)
+
 // NewCacheManager creates a new cache manager
 func NewCacheManager(client *Client, cfg *config.Config) (*CacheManager, error) {
+	// Each profile has its own cache directory
+	cacheDir := cfg.Advanced.CacheDir
+	if cacheDir == "" {
+		cacheDir = config.DefaultConfig().Advanced.CacheDir
+	}
+
+	// Other hosts have their own cache so their responses cannot mix
+	if client != nil && !client.defaultHost && client.host != "" {
+		cacheDir = filepath.Join(cacheDir, "hosts", strings.ReplaceAll(client.host, ":", "_"))
+	}
+
+	sharedCachesMu.Lock()
+	defer sharedCachesMu.Unlock()
+
+	shared, ok := sharedCaches[cacheDir]
+	if !ok {
+		manager, err := openCacheManager(cacheDir, cfg)
+		if err != nil {
+			return nil, err
+		}
+		shared = &sharedCache{manager: manager}
+		sharedCaches[cacheDir] = shared
+	}
+	shared.refs++
+
+	return &CacheManager{
+		Manager:       shared.manager,
+		Notifications: cache.NewTyped[[]*github.Notification](shared.manager),
+		Client:        client,
+		Config:        cfg,
+		dir:           cacheDir,
+	}, nil
+}
+
+// openCacheManager opens the cache in a directory
+func openCacheManager(cacheDir string, cfg *config.Config) (*cache.Manager, error) {
 	// Create cache options
 	cacheOpts := &cache.Options{
-		CacheDir:          ".gh-notif-cache", // Use a local directory for testing
+		CacheDir:          cacheDir,
 		DefaultTTL:        time.Duration(cfg.Advanced.CacheTTL) * time.Second,
-		MaxSize:           1 * 1024 * 1024 * 1024, // 1GB
-		MemoryLimit:       100 * 1024 * 1024,      // 100MB
+		MaxSize:           cfg.Advanced.CacheMaxSize,
+		MemoryLimit:       cfg.Advanced.CacheMemoryLimit,
 		EnablePrefetching: true,
-		EnableCompression: true,
+		EnableCompression: cfg.Advanced.CacheCompression != "none",
+		Compression:       cache.Compression(cfg.Advanced.CacheCompression),
+	}
+
+	// Cached notifications include private repository titles, so they are
+	// encrypted at rest with a key kept next to the token
+	if cfg.Advanced.CacheEncryption {
+		key, err := cacheEncryptionKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cache encryption key: %w", err)
+		}
+		cacheOpts.EnableEncryption = true
+		cacheOpts.EncryptionKey = key[:]
 	}
 
 	// Create the cache
@@ -53,22 +129,33 @@ func NewCacheManager(client *Client, cfg *config.Config) (*CacheManager, error)
 	// Create the manager
 	manager := cache.NewManager(cacheImpl, managerOpts)
 
-	// Add invalidation patterns
-	manager.AddInvalidationPattern(cache.InvalidationPattern{
-		Pattern:   "notifications_*",
+	// Changes made through the API make the cached notification lists stale
+	err = manager.AddInvalidationPattern(cache.InvalidationPattern{
+		Pattern:   "write:*",
+		Targets:   notificationListKeys,
 		Action:    cache.InvalidateDelete,
 		Condition: cache.OnWrite,
 	})
+	if err != nil {
+		manager.Close()
+		return nil, err
+	}
 
-	return &CacheManager{
-		Manager: manager,
-		Client:  client,
-		Config:  cfg,
-	}, nil
+	return manager, nil
 }
 
-// Close closes the cache manager
+// Close closes the cache manager, and the cache once no other client uses it
 func (cm *CacheManager) Close() error {
+	sharedCachesMu.Lock()
+	defer sharedCachesMu.Unlock()
+
+	if shared, ok := sharedCaches[cm.dir]; ok && shared.manager == cm.Manager {
+		shared.refs--
+		if shared.refs > 0 {
+			return nil
+		}
+		delete(sharedCaches, cm.dir)
+	}
 	return cm.Manager.Close()
 }
 
@@ -79,20 +166,18 @@ func (cm *CacheManager) GetNotifications(ctx context.Context, opts *github.Notif
 		opts.All, opts.Participating, opts.Since, opts.Before, opts.PerPage)
 
 	// Try to get from cache
-	if cached, found := cm.Manager.Get(cacheKey); found {
-		if notifications, ok := cached.([]*github.Notification); ok {
-			// Create a mock response
-			resp := &github.Response{
-				NextPage: 0,
-				LastPage: 0,
-				Rate: github.Rate{
-					Limit:     5000,
-					Remaining: 5000,
-					Reset:     github.Timestamp{Time: time.Now().Add(1 * time.Hour)},
-				},
-			}
-			return notifications, resp, nil
+	if notifications, found := cm.Notifications.Get(cacheKey); found {
+		// Create a mock response
+		resp := &github.Response{
+			NextPage: 0,
+			LastPage: 0,
+			Rate: github.Rate{
+				Limit:     5000,
+				Remaining: 5000,
+				Reset:     github.Timestamp{Time: time.Now().Add(1 * time.Hour)},
+			},
 		}
+		return notifications, resp, nil
 	}
 
 	// Not in cache, fetch from API
@@ -102,7 +187,7 @@ func (cm *CacheManager) GetNotifications(ctx context.Context, opts *github.Notif
 	}
 
 	// Cache the result
-	cm.Manager.Set(cacheKey, notifications, time.Duration(cm.Config.Advanced.CacheTTL)*time.Second)
+	cm.Notifications.Set(cacheKey, notifications, time.Duration(cm.Config.Advanced.CacheTTL)*time.Second)
 
 	return notifications, resp, nil
 }
@@ -114,20 +199,18 @@ func (cm *CacheManager) GetRepositoryNotifications(ctx context.Context, owner, r
 		owner, repo, opts.All, opts.Participating, opts.Since, opts.Before, opts.PerPage)
 
 	// Try to get from cache
-	if cached, found := cm.Manager.Get(cacheKey); found {
-		if notifications, ok := cached.([]*github.Notification); ok {
-			// Create a mock response
-			resp := &github.Response{
-				NextPage: 0,
-				LastPage: 0,
-				Rate: github.Rate{
-					Limit:     5000,
-					Remaining: 5000,
-					Reset:     github.Timestamp{Time: time.Now().Add(1 * time.Hour)},
-				},
-			}
-			return notifications, resp, nil
+	if notifications, found := cm.Notifications.Get(cacheKey); found {
+		// Create a mock response
+		resp := &github.Response{
+			NextPage: 0,
+			LastPage: 0,
+			Rate: github.Rate{
+				Limit:     5000,
+				Remaining: 5000,
+				Reset:     github.Timestamp{Time: time.Now().Add(1 * time.Hour)},
+			},
 		}
+		return notifications, resp, nil
 	}
 
 	// Not in cache, fetch from API
@@ -137,16 +220,23 @@ func (cm *CacheManager) GetRepositoryNotifications(ctx context.Context, owner, r
 	}
 
 	// Cache the result
-	cm.Manager.Set(cacheKey, notifications, time.Duration(cm.Config.Advanced.CacheTTL)*time.Second)
+	cm.Notifications.Set(cacheKey, notifications, time.Duration(cm.Config.Advanced.CacheTTL)*time.Second)
 
 	return notifications, resp, nil
 }
 
 // InvalidateNotificationsCache invalidates the notifications cache
 func (cm *CacheManager) InvalidateNotificationsCache() {
-	// Delete all notification cache entries
-	// In a real implementation, we would use a pattern match
-	cm.Manager.Delete("notifications_*")
+	cm.Manager.Invalidate(notificationListKeys, cache.InvalidateDelete)
+}
+
+// RecordWrite records a change made through the API, such as marking a
+// thread as read, which invalidates the cached notification lists
+func (c *Client) RecordWrite(operation, target string) {
+	if c.cacheManager == nil {
+		return
+	}
+	c.cacheManager.Manager.RecordWrite(fmt.Sprintf("write:%s:%s", operation, target))
 }
 
 // PrefetchNotificationDetails prefetches notification details
@@ -0,0 +1,181 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/readstate"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestCacheManagerSurvivesRestart(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Advanced.CacheDir = t.TempDir()
+	cfg.Advanced.CacheTTL = 3600
+
+	originalKey := cacheEncryptionKey
+	defer func() { cacheEncryptionKey = originalKey }()
+	cacheEncryptionKey = func() ([32]byte, error) { return [32]byte{1, 2, 3}, nil }
+
+	ctx := context.Background()
+	opts := &github.NotificationListOptions{All: true}
+
+	// The first run fetches the notifications and caches them on disk
+	client, server, err := NewTestClient(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*github.Notification{
+			{ID: github.String("1"), Subject: &github.NotificationSubject{Title: github.String("Cached")}},
+		})
+	}))
+	if err != nil {
+		t.Fatalf("NewTestClient() error = %v", err)
+	}
+	defer server.Close()
+
+	cm, err := NewCacheManager(client, cfg)
+	if err != nil {
+		t.Fatalf("NewCacheManager() error = %v", err)
+	}
+	if _, _, err := cm.GetNotifications(ctx, opts); err != nil {
+		t.Fatalf("GetNotifications() error = %v", err)
+	}
+	if metrics := cm.Manager.GetMetrics(); metrics.Get(&metrics.Encryptions) == 0 {
+		t.Error("cached notifications should be encrypted")
+	}
+	if err := cm.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Nothing readable is left on disk
+	err = filepath.WalkDir(cfg.Advanced.CacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(data, []byte("Cached")) {
+			t.Errorf("%s contains a cached title in plain text", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read cache directory: %v", err)
+	}
+
+	// The next run is served from the disk cache
+	offline, offlineServer, err := NewTestClient(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s, want a cache hit", r.URL)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	if err != nil {
+		t.Fatalf("NewTestClient() error = %v", err)
+	}
+	defer offlineServer.Close()
+
+	cm, err = NewCacheManager(offline, cfg)
+	if err != nil {
+		t.Fatalf("NewCacheManager() error = %v", err)
+	}
+	defer cm.Close()
+
+	notifications, _, err := cm.GetNotifications(ctx, opts)
+	if err != nil {
+		t.Fatalf("GetNotifications() error = %v", err)
+	}
+	if len(notifications) != 1 || notifications[0].GetSubject().GetTitle() != "Cached" {
+		t.Errorf("GetNotifications() = %+v, want the cached notification", notifications)
+	}
+}
+
+func TestMarkThreadReadInvalidatesLists(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Advanced.CacheDir = t.TempDir()
+	cfg.Advanced.CacheEncryption = false
+
+	originalOverlay := readstate.Default()
+	readstate.SetDefault(readstate.NewOverlay())
+	defer readstate.SetDefault(originalOverlay)
+
+	ctx := context.Background()
+	client, server, err := NewTestClient(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusResetContent)
+	}))
+	if err != nil {
+		t.Fatalf("NewTestClient() error = %v", err)
+	}
+	defer server.Close()
+
+	cm, err := NewCacheManager(client, cfg)
+	if err != nil {
+		t.Fatalf("NewCacheManager() error = %v", err)
+	}
+	defer cm.Close()
+	client.cacheManager = cm
+
+	lists := []string{"notifications_true", "all_notifications_false", "repo_notifications_org_repo"}
+	for _, key := range lists {
+		cm.Notifications.Set(key, []*github.Notification{{ID: github.String("1")}}, time.Hour)
+	}
+	cm.Manager.Set("issue_org_repo_1", "details", time.Hour)
+
+	if _, err := client.MarkThreadRead("1"); err != nil {
+		t.Fatalf("MarkThreadRead() error = %v", err)
+	}
+
+	for _, key := range lists {
+		if _, found := cm.Notifications.Get(key); found {
+			t.Errorf("%s should be invalidated after marking a thread as read", key)
+		}
+	}
+	if _, found := cm.Manager.Get("issue_org_repo_1"); !found {
+		t.Error("issue details should stay cached")
+	}
+}
+
+func TestCacheManagersShareADirectory(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Advanced.CacheDir = t.TempDir()
+	cfg.Advanced.CacheEncryption = false
+
+	first, err := NewCacheManager(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewCacheManager() error = %v", err)
+	}
+	// A second client in the same process opens the same cache directory
+	second, err := NewCacheManager(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewCacheManager() error = %v", err)
+	}
+	if first.Manager != second.Manager {
+		t.Fatal("cache managers of the same directory do not share the cache")
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// The cache stays open for the second client
+	second.Manager.Set("key", "value", time.Hour)
+	if _, ok := second.Manager.Get("key"); !ok {
+		t.Error("cache was closed while still in use")
+	}
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Once closed, the directory can be opened again
+	third, err := NewCacheManager(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewCacheManager() error = %v", err)
+	}
+	third.Close()
+}
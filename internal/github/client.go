@@ -7,11 +7,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/SharanRP/gh-notif/internal/auth"
+	"github.com/SharanRP/gh-notif/internal/cache"
 	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/google/go-github/v60/github"
 	"github.com/hashicorp/go-retryablehttp"
 	"golang.org/x/time/rate"
@@ -24,7 +27,11 @@ type Client struct {
 	rateLimiter   *rate.Limiter
 	retryClient   *retryablehttp.Client
 	cacheManager  *CacheManager
+	validators    *validatorStore
+	subjects      *cache.Typed[*subjectResponse]
 	configManager *config.ConfigManager
+	host          string
+	defaultHost   bool
 	baseURL       string
 	uploadURL     string
 	maxConcurrent int
@@ -42,6 +49,14 @@ type Client struct {
 // ClientOption is a function that configures a Client
 type ClientOption func(*Client)
 
+// WithHost selects the GitHub or GitHub Enterprise Server host of the client.
+// An empty host selects the host chosen with config.SetHost.
+func WithHost(host string) ClientOption {
+	return func(c *Client) {
+		c.host = host
+	}
+}
+
 // WithBaseURL sets the base URL for the GitHub API
 func WithBaseURL(baseURL string) ClientOption {
 	return func(c *Client) {
@@ -106,6 +121,9 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	// The host selected for the whole process, used unless WithHost is given
+	selectedHost := config.Host()
+
 	// Get the config
 	config := cm.GetConfig()
 
@@ -113,8 +131,6 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	client := &Client{
 		ctx:           ctx,
 		configManager: cm,
-		baseURL:       config.API.BaseURL,
-		uploadURL:     config.API.UploadURL,
 		maxConcurrent: config.Advanced.MaxConcurrent,
 		retryCount:    config.API.RetryCount,
 		retryDelay:    time.Duration(config.API.RetryDelay) * time.Second,
@@ -140,6 +156,20 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 		opt(client)
 	}
 
+	// Resolve the endpoints of the host unless they were set explicitly
+	if client.host == "" {
+		client.host = selectedHost
+	}
+	host := config.ResolveHost(client.host)
+	client.host = host.Host
+	client.defaultHost = host.Default
+	if client.baseURL == "" {
+		client.baseURL = host.APIURL
+	}
+	if client.uploadURL == "" {
+		client.uploadURL = host.UploadURL
+	}
+
 	// Create a rate limiter (default: 5000 requests per hour = ~1.4 requests per second)
 	client.rateLimiter = rate.NewLimiter(rate.Limit(1.4), 5)
 
@@ -151,8 +181,12 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	retryClient.Logger = nil // Disable default logging
 	client.retryClient = retryClient
 
-	// Get an authenticated HTTP client
-	httpClient, err := auth.GetClient(ctx)
+	// Get an HTTP client authenticated with the token of the host
+	tokenHost := ""
+	if !client.defaultHost {
+		tokenHost = client.host
+	}
+	httpClient, err := auth.GetHostClient(ctx, tokenHost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get authenticated client: %w", err)
 	}
@@ -169,12 +203,13 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 
 	// Set custom base URL if provided (for GitHub Enterprise)
 	if client.baseURL != "https://api.github.com" {
-		baseURL, err := url.Parse(client.baseURL)
+		// Relative API paths are resolved against the URLs, so they need a trailing slash
+		baseURL, err := url.Parse(strings.TrimSuffix(client.baseURL, "/") + "/")
 		if err != nil {
 			return nil, fmt.Errorf("invalid base URL: %w", err)
 		}
 
-		uploadURL, err := url.Parse(client.uploadURL)
+		uploadURL, err := url.Parse(strings.TrimSuffix(client.uploadURL, "/") + "/")
 		if err != nil {
 			return nil, fmt.Errorf("invalid upload URL: %w", err)
 		}
@@ -192,6 +227,10 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 	}
 	client.cacheManager = cacheManager
 
+	// Validators of notifications requests are kept in the cache across runs
+	client.validators = newValidatorStore(cacheManager.Manager)
+	client.subjects = cache.NewTyped[*subjectResponse](cacheManager.Manager)
+
 	return client, nil
 }
 
@@ -285,26 +324,17 @@ func (c *Client) isTransientError(err error) bool {
 	return errors.As(err, &transportErr)
 }
 
-// ListNotifications lists GitHub notifications with rate limiting and retries
+// ListNotifications lists GitHub notifications with conditional requests,
+// rate limiting and retries
 func (c *Client) ListNotifications(opts *github.NotificationListOptions) ([]*github.Notification, *github.Response, error) {
-	// Wait for rate limiter
-	if err := c.waitForRateLimit(c.ctx); err != nil {
-		return nil, nil, err
-	}
-
-	// Log the request
-	c.logRequest("GET", "notifications", opts)
-
 	// Try the request with retries
 	var notifications []*github.Notification
 	var resp *github.Response
 	var err error
 
 	for attempt := 0; attempt <= c.retryCount; attempt++ {
-		notifications, resp, err = c.client.Activity.ListNotifications(c.ctx, opts)
-
-		// Log the response
-		c.logResponse(resp, notifications, err)
+		// Conditional requests wait for the rate limiter and log themselves
+		notifications, resp, err = c.listNotificationsConditional(c.ctx, opts)
 
 		// If successful or not a transient error, break
 		if err == nil || !c.isTransientError(err) {
@@ -357,6 +387,12 @@ func (c *Client) MarkThreadRead(threadID string) (*github.Response, error) {
 	// Handle rate limiting
 	c.handleRateLimit(resp)
 
+	// The thread was read, so it no longer needs to be kept unread locally
+	if err == nil {
+		c.logReadStateError(readstate.Default().Clear(threadID))
+		c.RecordWrite("MarkThreadRead", threadID)
+	}
+
 	return resp, err
 }
 
@@ -382,6 +418,16 @@ func (c *Client) GetThread(threadID string) (*github.Notification, *github.Respo
 	return notification, resp, err
 }
 
+// CurrentUser returns the login of the authenticated user. The response is
+// cached like subject details, so later calls are answered with 304 Not Modified.
+func (c *Client) CurrentUser() (string, error) {
+	var user github.User
+	if err := c.getSubject(c.ctx, "user", &user); err != nil {
+		return "", fmt.Errorf("failed to get the current user: %w", err)
+	}
+	return user.GetLogin(), nil
+}
+
 // WithContext returns a new Client with the given context
 func (c *Client) WithContext(ctx context.Context) *Client {
 	newClient := &Client{
@@ -390,7 +436,11 @@ func (c *Client) WithContext(ctx context.Context) *Client {
 		rateLimiter:   c.rateLimiter,
 		retryClient:   c.retryClient,
 		cacheManager:  c.cacheManager,
+		validators:    c.validators,
+		subjects:      c.subjects,
 		configManager: c.configManager,
+		host:          c.host,
+		defaultHost:   c.defaultHost,
 		baseURL:       c.baseURL,
 		uploadURL:     c.uploadURL,
 		maxConcurrent: c.maxConcurrent,
@@ -436,6 +486,11 @@ func (c *Client) Do(req *http.Request, v interface{}) (*github.Response, error)
 	return resp, err
 }
 
+// Host returns the GitHub or GitHub Enterprise Server host of the client
+func (c *Client) Host() string {
+	return c.host
+}
+
 // GetRawClient returns the underlying GitHub client
 func (c *Client) GetRawClient() *github.Client {
 	return c.client
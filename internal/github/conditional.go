@@ -0,0 +1,229 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/cache"
+	"github.com/google/go-github/v60/github"
+	"github.com/google/go-querystring/query"
+)
+
+// validatorTTL is how long the validators of a request are kept in the cache
+const validatorTTL = 7 * 24 * time.Hour
+
+// validatorKeyPrefix prefixes the cache keys of request validators
+const validatorKeyPrefix = "validators_"
+
+// validators are the validators of a notifications response, sent back with
+// the next request of the same shape to make it conditional
+type validators struct {
+	// ETag is the ETag header of the response
+	ETag string `json:"etag,omitempty"`
+	// LastModified is the Last-Modified header of the response
+	LastModified string `json:"last_modified,omitempty"`
+	// PollInterval is the X-Poll-Interval advertised with the response
+	PollInterval time.Duration `json:"poll_interval,omitempty"`
+	// NextPage and LastPage are the pagination of the response
+	NextPage int `json:"next_page,omitempty"`
+	LastPage int `json:"last_page,omitempty"`
+	// Notifications are the notifications of the response, returned again
+	// when the server answers 304 Not Modified
+	Notifications []*github.Notification `json:"notifications"`
+}
+
+// validatorStore keeps the validators of notifications requests in memory
+// and, when a cache is available, across runs
+type validatorStore struct {
+	mu           sync.Mutex
+	entries      map[string][]byte
+	cache        *cache.Manager
+	pollInterval time.Duration
+}
+
+// newValidatorStore creates a validator store persisted in the given cache,
+// which may be nil
+func newValidatorStore(manager *cache.Manager) *validatorStore {
+	return &validatorStore{
+		entries: make(map[string][]byte),
+		cache:   manager,
+	}
+}
+
+// get returns the validators stored for a request, or nil. Every call
+// decodes a fresh copy, so callers may modify the notifications.
+func (s *validatorStore) get(key string) *validators {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.entries[key]
+	if !ok && s.cache != nil {
+		if cached, found := s.cache.Get(key); found {
+			if encoded, isString := cached.(string); isString {
+				data = []byte(encoded)
+				s.entries[key] = data
+				ok = true
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	var v validators
+	if err := json.Unmarshal(data, &v); err != nil {
+		delete(s.entries, key)
+		return nil
+	}
+	if v.PollInterval > s.pollInterval {
+		s.pollInterval = v.PollInterval
+	}
+	return &v
+}
+
+// set stores the validators of a request
+func (s *validatorStore) set(key string, v *validators) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = data
+	if s.cache != nil {
+		// Stored as a string so every cache implementation returns it unchanged
+		s.cache.Set(key, string(data), validatorTTL)
+	}
+}
+
+// observe records the poll interval advertised by a response
+func (s *validatorStore) observe(header http.Header) time.Duration {
+	interval := parsePollInterval(header)
+	if interval > 0 {
+		s.mu.Lock()
+		s.pollInterval = interval
+		s.mu.Unlock()
+	}
+	return interval
+}
+
+// PollInterval returns the poll interval last advertised by the server
+func (s *validatorStore) PollInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pollInterval
+}
+
+// parsePollInterval parses the X-Poll-Interval header, which is in seconds
+func parsePollInterval(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("X-Poll-Interval"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// PollInterval returns the minimum interval between notification polls
+// advertised by the server with X-Poll-Interval, or 0 if it is unknown
+func (c *Client) PollInterval() time.Duration {
+	if c.validators == nil {
+		return 0
+	}
+	return c.validators.PollInterval()
+}
+
+// listNotificationsConditional lists a page of notifications with a
+// conditional request. The ETag and Last-Modified of the previous response
+// to the same request are sent as If-None-Match and If-Modified-Since, and
+// when the server answers 304 Not Modified the notifications of that
+// response are returned. GitHub does not count 304s against the rate limit,
+// so conditional requests are only charged to the rate limiter when they
+// return a full response.
+func (c *Client) listNotificationsConditional(ctx context.Context, opts *github.NotificationListOptions) ([]*github.Notification, *github.Response, error) {
+	path := "notifications"
+	if opts != nil {
+		values, err := query.Values(opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode notification options: %w", err)
+		}
+		if encoded := values.Encode(); encoded != "" {
+			path += "?" + encoded
+		}
+	}
+
+	req, err := c.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	key := validatorKeyPrefix + path
+	var previous *validators
+	if c.validators != nil {
+		previous = c.validators.get(key)
+	}
+
+	if previous != nil {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	} else if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	// Log the request
+	c.logRequest("GET", path, nil)
+
+	var notifications []*github.Notification
+	resp, err := c.client.Do(ctx, req, &notifications)
+
+	// Log the response
+	c.logResponse(resp, notifications, err)
+
+	var pollInterval time.Duration
+	if resp != nil && c.validators != nil {
+		pollInterval = c.validators.observe(resp.Header)
+	}
+
+	if previous != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotModified {
+			if c.debug {
+				fmt.Printf("Notifications not modified, using %d stored notifications\n", len(previous.Notifications))
+			}
+			resp.NextPage = previous.NextPage
+			resp.LastPage = previous.LastPage
+			return previous.Notifications, resp, nil
+		}
+
+		// The request was not answered from the validators, so charge it now
+		c.rateLimiter.Reserve()
+	}
+
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if c.validators != nil {
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			c.validators.set(key, &validators{
+				ETag:          etag,
+				LastModified:  lastModified,
+				PollInterval:  pollInterval,
+				NextPage:      resp.NextPage,
+				LastPage:      resp.LastPage,
+				Notifications: notifications,
+			})
+		}
+	}
+
+	return notifications, resp, nil
+}
@@ -0,0 +1,131 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/cache"
+	"github.com/google/go-github/v60/github"
+	"golang.org/x/time/rate"
+)
+
+func TestListNotificationsConditional(t *testing.T) {
+	var mu sync.Mutex
+	etag := `"v1"`
+	var requests, notModified int
+	var lastIfNoneMatch string
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		lastIfNoneMatch = r.Header.Get("If-None-Match")
+
+		w.Header().Set("X-Poll-Interval", "60")
+		if lastIfNoneMatch == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Mon, 12 Oct 2026 10:00:00 GMT")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]*github.Notification{
+			{ID: github.String("1"), Subject: &github.NotificationSubject{Title: github.String(etag)}},
+		})
+	})
+
+	ctx := context.Background()
+	client, server, err := NewTestClient(ctx, handler)
+	if err != nil {
+		t.Fatalf("NewTestClient() error = %v", err)
+	}
+	defer server.Close()
+
+	// A single token: the first request spends it, so any further request
+	// charged before it is made would block until the context times out
+	client.rateLimiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+
+	opts := &github.NotificationListOptions{All: true}
+	first, _, err := client.listNotificationsConditional(ctx, opts)
+	if err != nil {
+		t.Fatalf("first request error = %v", err)
+	}
+	if client.PollInterval() != time.Minute {
+		t.Errorf("PollInterval() = %v, want %v", client.PollInterval(), time.Minute)
+	}
+
+	// Callers may modify what they get back without affecting later polls
+	first[0].Subject.Title = github.String("modified")
+
+	shortCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	second, resp, err := client.listNotificationsConditional(shortCtx, opts)
+	if err != nil {
+		t.Fatalf("conditional request error = %v", err)
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("StatusCode = %d, want 304", resp.StatusCode)
+	}
+	if lastIfNoneMatch != `"v1"` {
+		t.Errorf("If-None-Match = %q, want %q", lastIfNoneMatch, `"v1"`)
+	}
+	if len(second) != 1 || second[0].GetSubject().GetTitle() != `"v1"` {
+		t.Errorf("304 returned %+v, want the stored notification", second)
+	}
+
+	// A changed inbox returns the new response, which is charged afterwards
+	mu.Lock()
+	etag = `"v2"`
+	mu.Unlock()
+	third, _, err := client.listNotificationsConditional(shortCtx, opts)
+	if err != nil {
+		t.Fatalf("changed request error = %v", err)
+	}
+	if len(third) != 1 || third[0].GetSubject().GetTitle() != `"v2"` {
+		t.Errorf("changed request returned %+v, want the new notification", third)
+	}
+	if tokens := client.rateLimiter.Tokens(); tokens >= 0 {
+		t.Errorf("rate limiter tokens = %v, want the full response to be charged", tokens)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 3 || notModified != 1 {
+		t.Errorf("requests = %d, not modified = %d, want 3 and 1", requests, notModified)
+	}
+}
+
+func TestValidatorStorePersists(t *testing.T) {
+	manager := cache.NewManager(cache.NewMemoryCache(nil), &cache.ManagerOptions{DefaultTTL: time.Hour})
+	defer manager.Close()
+
+	store := newValidatorStore(manager)
+	store.set("validators_notifications", &validators{
+		ETag:          `"abc"`,
+		PollInterval:  2 * time.Minute,
+		NextPage:      2,
+		Notifications: []*github.Notification{{ID: github.String("1")}},
+	})
+
+	// A new store, as in the next run, reads the validators back from the cache
+	restored := newValidatorStore(manager)
+	v := restored.get("validators_notifications")
+	if v == nil {
+		t.Fatal("get() = nil, want the stored validators")
+	}
+	if v.ETag != `"abc"` || v.NextPage != 2 || len(v.Notifications) != 1 {
+		t.Errorf("get() = %+v, want the stored validators", v)
+	}
+	if restored.PollInterval() != 2*time.Minute {
+		t.Errorf("PollInterval() = %v, want %v", restored.PollInterval(), 2*time.Minute)
+	}
+	if restored.get("validators_other") != nil {
+		t.Error("get() of an unknown request should return nil")
+	}
+}
@@ -0,0 +1,362 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/google/go-github/v60/github"
+)
+
+// subjectTTL is how long subject responses are kept in the cache
+const subjectTTL = 7 * 24 * time.Hour
+
+// subjectKeyPrefix prefixes the cache keys of subject responses
+const subjectKeyPrefix = "subject_"
+
+// subjectResponse is a cached response to a subject request, returned again
+// when the server answers the next request with 304 Not Modified
+type subjectResponse struct {
+	// ETag is the ETag header of the response
+	ETag string `json:"etag,omitempty"`
+	// LastModified is the Last-Modified header of the response
+	LastModified string `json:"last_modified,omitempty"`
+	// Body is the body of the response
+	Body json.RawMessage `json:"body"`
+}
+
+// subjectRef identifies the subject of a notification from its API URL
+type subjectRef struct {
+	owner string
+	repo  string
+	id    string
+}
+
+// parseSubjectURL parses an API URL such as
+// https://api.github.com/repos/owner/repo/issues/1, which has an api/v3
+// prefix on GitHub Enterprise Server
+func parseSubjectURL(url string) (subjectRef, error) {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	for i, part := range parts {
+		if part == "repos" && i+4 < len(parts) {
+			return subjectRef{owner: parts[i+1], repo: parts[i+2], id: parts[i+4]}, nil
+		}
+	}
+	return subjectRef{}, fmt.Errorf("invalid subject URL: %s", url)
+}
+
+// path returns the API path of the subject with a suffix
+func (r subjectRef) path(suffix string) string {
+	return fmt.Sprintf("repos/%s/%s/%s", r.owner, r.repo, suffix)
+}
+
+// getSubject fetches a subject resource into v with a conditional request.
+// Responses are cached with their ETag and Last-Modified, so unchanged
+// subjects are answered with 304 Not Modified from the cache, which GitHub
+// does not count against the rate limit.
+func (c *Client) getSubject(ctx context.Context, path string, v interface{}) error {
+	req, err := c.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	key := subjectKeyPrefix + path
+	var previous *subjectResponse
+	if c.subjects != nil {
+		previous, _ = c.subjects.Get(key)
+	}
+
+	if previous != nil {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	} else if err := c.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+
+	// Log the request
+	c.logRequest("GET", path, nil)
+
+	var body json.RawMessage
+	resp, err := c.client.Do(ctx, req, &body)
+
+	// Log the response
+	c.logResponse(resp, nil, err)
+
+	if previous != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotModified {
+			return json.Unmarshal(previous.Body, v)
+		}
+
+		// The request was not answered from the cache, so charge it now
+		c.rateLimiter.Reserve()
+	}
+
+	// Handle rate limiting
+	c.handleRateLimit(resp)
+
+	if err != nil {
+		return err
+	}
+
+	if c.subjects != nil {
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			c.subjects.Set(key, &subjectResponse{
+				ETag:         etag,
+				LastModified: lastModified,
+				Body:         body,
+			}, subjectTTL)
+		}
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// fetchIssueDetails fetches the details of an issue
+func (c *Client) fetchIssueDetails(ctx context.Context, ref subjectRef) (*enrichment.Details, error) {
+	var issue github.Issue
+	if err := c.getSubject(ctx, ref.path("issues/"+ref.id), &issue); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue details: %w", err)
+	}
+	return issueDetails(&issue), nil
+}
+
+// fetchPullRequestDetails fetches the details of a pull request, including
+// its reviews and CI status
+func (c *Client) fetchPullRequestDetails(ctx context.Context, ref subjectRef) (*enrichment.Details, error) {
+	// The issue of a pull request has its labels, assignees and reactions
+	var issue github.Issue
+	if err := c.getSubject(ctx, ref.path("issues/"+ref.id), &issue); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request details: %w", err)
+	}
+	details := issueDetails(&issue)
+
+	var pr github.PullRequest
+	if err := c.getSubject(ctx, ref.path("pulls/"+ref.id), &pr); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request details: %w", err)
+	}
+
+	details.Draft = pr.GetDraft()
+	details.MergeState = pr.GetMergeableState()
+	details.BaseBranch = pr.GetBase().GetRef()
+	details.DefaultBranch = pr.GetBase().GetRepo().GetDefaultBranch()
+	details.Comments += pr.GetReviewComments()
+	if pr.GetMerged() {
+		details.State = enrichment.StateMerged
+	}
+	for _, reviewer := range pr.RequestedReviewers {
+		details.RequestedReviewers = append(details.RequestedReviewers, reviewer.GetLogin())
+	}
+
+	var reviews []*github.PullRequestReview
+	if err := c.getSubject(ctx, ref.path("pulls/"+ref.id+"/reviews"), &reviews); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request reviews: %w", err)
+	}
+	details.ReviewDecision = reviewDecision(reviews, details.RequestedReviewers)
+
+	// Subscriptions filter pull requests by the files they change
+	var files []*github.CommitFile
+	if err := c.getSubject(ctx, ref.path("pulls/"+ref.id+"/files?per_page=100"), &files); err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request files: %w", err)
+	}
+	for _, file := range files {
+		details.Files = append(details.Files, file.GetFilename())
+	}
+
+	if sha := pr.GetHead().GetSHA(); sha != "" {
+		status, err := c.fetchCIStatus(ctx, ref, sha)
+		if err != nil {
+			return nil, err
+		}
+		details.CIStatus = status
+	}
+
+	return details, nil
+}
+
+// fetchCommitDetails fetches the details of a commit
+func (c *Client) fetchCommitDetails(ctx context.Context, ref subjectRef) (*enrichment.Details, error) {
+	var commit github.RepositoryCommit
+	if err := c.getSubject(ctx, ref.path("commits/"+ref.id), &commit); err != nil {
+		return nil, fmt.Errorf("failed to fetch commit details: %w", err)
+	}
+
+	details := &enrichment.Details{
+		Author:    commit.GetAuthor().GetLogin(),
+		AuthorBot: commit.GetAuthor().GetType() == "Bot",
+		Comments:  commit.GetCommit().GetCommentCount(),
+		Mentions:  enrichment.ParseMentions(commit.GetCommit().GetMessage()),
+		FetchedAt: time.Now(),
+	}
+	for _, file := range commit.Files {
+		details.Files = append(details.Files, file.GetFilename())
+	}
+
+	status, err := c.fetchCIStatus(ctx, ref, ref.id)
+	if err != nil {
+		return nil, err
+	}
+	details.CIStatus = status
+
+	return details, nil
+}
+
+// fetchReleaseDetails fetches the details of a release
+func (c *Client) fetchReleaseDetails(ctx context.Context, ref subjectRef) (*enrichment.Details, error) {
+	var release github.RepositoryRelease
+	if err := c.getSubject(ctx, ref.path("releases/"+ref.id), &release); err != nil {
+		return nil, fmt.Errorf("failed to fetch release details: %w", err)
+	}
+
+	return &enrichment.Details{
+		Author:    release.GetAuthor().GetLogin(),
+		AuthorBot: release.GetAuthor().GetType() == "Bot",
+		Mentions:  enrichment.ParseMentions(release.GetBody()),
+		Draft:     release.GetDraft(),
+		FetchedAt: time.Now(),
+	}, nil
+}
+
+// fetchCIStatus combines the commit statuses and check runs of a commit
+func (c *Client) fetchCIStatus(ctx context.Context, ref subjectRef, sha string) (string, error) {
+	var combined github.CombinedStatus
+	if err := c.getSubject(ctx, ref.path("commits/"+sha+"/status"), &combined); err != nil {
+		return "", fmt.Errorf("failed to fetch commit status: %w", err)
+	}
+
+	var checks github.ListCheckRunsResults
+	if err := c.getSubject(ctx, ref.path("commits/"+sha+"/check-runs"), &checks); err != nil {
+		return "", fmt.Errorf("failed to fetch check runs: %w", err)
+	}
+
+	var states []string
+	// The combined state is pending when a commit has no statuses at all
+	if combined.GetTotalCount() > 0 {
+		states = append(states, combined.GetState())
+	}
+	for _, run := range checks.CheckRuns {
+		if run.GetStatus() != "completed" {
+			states = append(states, enrichment.CIPending)
+			continue
+		}
+		switch run.GetConclusion() {
+		case "success", "neutral", "skipped":
+			states = append(states, enrichment.CISuccess)
+		default:
+			states = append(states, enrichment.CIFailure)
+		}
+	}
+
+	return combineCIStates(states), nil
+}
+
+// combineCIStates combines CI states: any failure fails, then any pending
+// state is pending
+func combineCIStates(states []string) string {
+	result := ""
+	for _, state := range states {
+		switch state {
+		case "failure", "error":
+			return enrichment.CIFailure
+		case "pending":
+			result = enrichment.CIPending
+		case "success":
+			if result == "" {
+				result = enrichment.CISuccess
+			}
+		}
+	}
+	return result
+}
+
+// issueDetails returns the details of an issue
+func issueDetails(issue *github.Issue) *enrichment.Details {
+	details := &enrichment.Details{
+		State:     issue.GetState(),
+		Author:    issue.GetUser().GetLogin(),
+		AuthorBot: issue.GetUser().GetType() == "Bot",
+		Mentions:  enrichment.ParseMentions(issue.GetBody()),
+		Comments:  issue.GetComments(),
+		Reactions: issue.GetReactions().GetTotalCount(),
+		FetchedAt: time.Now(),
+	}
+	for _, assignee := range issue.Assignees {
+		details.Assignees = append(details.Assignees, assignee.GetLogin())
+	}
+	for _, label := range issue.Labels {
+		details.Labels = append(details.Labels, label.GetName())
+	}
+	return details
+}
+
+// reviewDecision derives the review decision of a pull request from the
+// latest review of each reviewer
+func reviewDecision(reviews []*github.PullRequestReview, requested []string) string {
+	latest := make(map[string]string)
+	for _, review := range reviews {
+		switch state := review.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[review.GetUser().GetLogin()] = state
+		}
+	}
+
+	approved := false
+	for _, state := range latest {
+		switch state {
+		case "CHANGES_REQUESTED":
+			return enrichment.ReviewChangesRequested
+		case "APPROVED":
+			approved = true
+		}
+	}
+
+	switch {
+	case len(requested) > 0:
+		return enrichment.ReviewRequired
+	case approved:
+		return enrichment.ReviewApproved
+	default:
+		return ""
+	}
+}
+
+// fetchSubjectDetails fetches the details of a notification's subject, or
+// returns nil for subjects without details
+func (c *Client) fetchSubjectDetails(ctx context.Context, n *github.Notification) (*enrichment.Details, error) {
+	ref, err := parseSubjectURL(n.GetSubject().GetURL())
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.GetSubject().GetType() {
+	case "Issue":
+		return c.fetchIssueDetails(ctx, ref)
+	case "PullRequest":
+		return c.fetchPullRequestDetails(ctx, ref)
+	case "Commit":
+		return c.fetchCommitDetails(ctx, ref)
+	case "Release":
+		return c.fetchReleaseDetails(ctx, ref)
+	default:
+		// Discussions have no REST endpoint
+		return nil, nil
+	}
+}
+
+// EnrichNotifications fetches the details of the notifications' subjects and
+// returns the notifications with their details
+func (c *Client) EnrichNotifications(notifications []*github.Notification) ([]*enrichment.EnrichedNotification, error) {
+	err := c.FetchNotificationDetails(notifications)
+	return enrichment.Default().Enrich(notifications), err
+}
@@ -0,0 +1,171 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/google/go-github/v60/github"
+)
+
+func TestFetchNotificationDetails(t *testing.T) {
+	originalStore := enrichment.Default()
+	enrichment.SetDefault(enrichment.NewStore())
+	defer enrichment.SetDefault(originalStore)
+
+	responses := map[string]string{
+		"/repos/org/repo/issues/1": `{"state":"open","user":{"login":"alice"},"body":"cc @bob",
+			"assignees":[{"login":"me"}],"labels":[{"name":"bug"}],"comments":3,"reactions":{"total_count":2}}`,
+		"/repos/org/repo/issues/2": `{"state":"closed","user":{"login":"renovate","type":"Bot"},"labels":[{"name":"feature"}],"comments":1}`,
+		"/repos/org/repo/pulls/2": `{"state":"closed","merged":true,"draft":false,"mergeable_state":"clean",
+			"review_comments":4,"requested_reviewers":[],"head":{"sha":"abc"},
+			"base":{"ref":"release/1.0","repo":{"default_branch":"main"}}}`,
+		"/repos/org/repo/pulls/2/files": `[{"filename":"docs/index.md"},{"filename":"main.go"}]`,
+		"/repos/org/repo/pulls/2/reviews": `[{"user":{"login":"carol"},"state":"CHANGES_REQUESTED"},
+			{"user":{"login":"carol"},"state":"APPROVED"},{"user":{"login":"dave"},"state":"COMMENTED"}]`,
+		"/repos/org/repo/commits/abc/status":     `{"state":"success","total_count":1}`,
+		"/repos/org/repo/commits/abc/check-runs": `{"total_count":2,"check_runs":[{"status":"completed","conclusion":"success"},{"status":"in_progress"}]}`,
+	}
+
+	var mu sync.Mutex
+	var requests, notModified int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag := `"` + r.URL.Path + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+
+	client, server, err := NewTestClient(context.Background(), handler)
+	if err != nil {
+		t.Fatalf("NewTestClient() error = %v", err)
+	}
+	defer server.Close()
+
+	notifications := []*github.Notification{
+		{ID: github.String("1"), Subject: &github.NotificationSubject{
+			Type: github.String("Issue"), URL: github.String("https://api.github.com/repos/org/repo/issues/1"),
+		}},
+		{ID: github.String("2"), Subject: &github.NotificationSubject{
+			Type: github.String("PullRequest"), URL: github.String("https://ghe.example.com/api/v3/repos/org/repo/pulls/2"),
+		}},
+		{ID: github.String("3"), Subject: &github.NotificationSubject{Type: github.String("Discussion")}},
+	}
+
+	enriched, err := client.EnrichNotifications(notifications)
+	if err != nil {
+		t.Fatalf("EnrichNotifications() error = %v", err)
+	}
+	if len(enriched) != 3 || enriched[2].Details != nil {
+		t.Fatalf("EnrichNotifications() = %+v, want 3 notifications without details for the discussion", enriched)
+	}
+
+	issue := enriched[0].Details
+	if issue == nil {
+		t.Fatal("issue details missing")
+	}
+	if issue.State != "open" || issue.Author != "alice" || !issue.IsAssignee("me") || !issue.HasLabel("BUG") ||
+		issue.Comments != 3 || issue.Reactions != 2 || !issue.IsMentioned("bob") {
+		t.Errorf("issue details = %+v", issue)
+	}
+
+	pr := enriched[1].Details
+	if pr == nil {
+		t.Fatal("pull request details missing")
+	}
+	if pr.State != enrichment.StateMerged || pr.MergeState != "clean" || pr.Comments != 5 ||
+		pr.ReviewDecision != enrichment.ReviewApproved || pr.CIStatus != enrichment.CIPending ||
+		!pr.IsBot() || pr.BaseBranch != "release/1.0" || pr.DefaultBranch != "main" ||
+		!reflect.DeepEqual(pr.Files, []string{"docs/index.md", "main.go"}) {
+		t.Errorf("pull request details = %+v", pr)
+	}
+
+	// The original notifications are left alone
+	if notifications[0].GetSubject().LatestCommentURL != nil {
+		t.Error("LatestCommentURL should not be modified")
+	}
+
+	// Fetching again is answered from the cache with 304 Not Modified
+	mu.Lock()
+	first := requests
+	mu.Unlock()
+	if err := client.FetchNotificationDetails(notifications); err != nil {
+		t.Fatalf("FetchNotificationDetails() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if notModified != requests-first {
+		t.Errorf("%d of %d repeated requests were not modified, want all", notModified, requests-first)
+	}
+	if enrichment.Lookup(notifications[1]).ReviewDecision != enrichment.ReviewApproved {
+		t.Error("details should be unchanged after a 304")
+	}
+}
+
+func TestParseSubjectURL(t *testing.T) {
+	ref, err := parseSubjectURL("https://ghe.example.com/api/v3/repos/org/repo/pulls/12")
+	if err != nil {
+		t.Fatalf("parseSubjectURL() error = %v", err)
+	}
+	if got := ref.path("pulls/" + ref.id); got != "repos/org/repo/pulls/12" {
+		t.Errorf("path = %q, want repos/org/repo/pulls/12", got)
+	}
+
+	if _, err := parseSubjectURL("https://api.github.com/notifications"); err == nil || !strings.Contains(err.Error(), "invalid subject URL") {
+		t.Errorf("parseSubjectURL() error = %v, want an invalid subject URL error", err)
+	}
+}
+
+func TestCurrentUser(t *testing.T) {
+	var requests int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/user" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"me"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"me"`)
+		w.Write([]byte(`{"login":"octocat"}`))
+	})
+
+	client, server, err := NewTestClient(context.Background(), handler)
+	if err != nil {
+		t.Fatalf("NewTestClient() error = %v", err)
+	}
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		login, err := client.CurrentUser()
+		if err != nil {
+			t.Fatalf("CurrentUser() error = %v", err)
+		}
+		if login != "octocat" {
+			t.Errorf("CurrentUser() = %q, want octocat", login)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+}
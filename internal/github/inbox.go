@@ -0,0 +1,97 @@
+package github
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/google/go-github/v60/github"
+)
+
+// newHostClient creates the client of a host, replaced in tests
+var newHostClient = func(ctx context.Context, host string) (*Client, error) {
+	return NewClient(ctx, WithHost(host))
+}
+
+// Inbox holds the merged notifications of several hosts
+type Inbox struct {
+	// Notifications are the notifications of all hosts, most recently updated first
+	Notifications []*github.Notification
+	// Errors holds the failures of hosts whose notifications could not be fetched
+	Errors map[string]error
+}
+
+// FetchInbox fetches the notifications of several hosts concurrently and
+// merges them. Each notification is tagged with its host, see
+// NotificationHost. Hosts that fail are reported in Errors while the
+// notifications of the other hosts are still returned.
+func FetchInbox(ctx context.Context, hosts []string, opts NotificationOptions) *Inbox {
+	inbox := &Inbox{Errors: make(map[string]error)}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+
+			notifications, err := fetchHostNotifications(ctx, host, opts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				inbox.Errors[host] = err
+				return
+			}
+			inbox.Notifications = append(inbox.Notifications, notifications...)
+		}(host)
+	}
+	wg.Wait()
+
+	sort.SliceStable(inbox.Notifications, func(i, j int) bool {
+		return inbox.Notifications[i].GetUpdatedAt().After(inbox.Notifications[j].GetUpdatedAt().Time)
+	})
+
+	return inbox
+}
+
+// fetchHostNotifications fetches and tags the notifications of a host
+func fetchHostNotifications(ctx context.Context, host string, opts NotificationOptions) ([]*github.Notification, error) {
+	client, err := newHostClient(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifications []*github.Notification
+	if opts.All {
+		notifications, err = client.GetAllNotifications(opts)
+	} else {
+		notifications, err = client.GetUnreadNotifications(opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range notifications {
+		client.tagHost(n)
+	}
+	return notifications, nil
+}
+
+// tagHost makes sure a notification carries the API URL of its thread,
+// which identifies the host it came from
+func (c *Client) tagHost(n *github.Notification) {
+	if n.GetURL() != "" {
+		return
+	}
+	url := strings.TrimSuffix(c.baseURL, "/") + "/notifications/threads/" + n.GetID()
+	n.URL = &url
+}
+
+// NotificationHost returns the host a notification came from, derived from
+// the API URL of its thread
+func NotificationHost(n *github.Notification) string {
+	return config.HostFromAPIURL(n.GetURL())
+}
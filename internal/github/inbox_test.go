@@ -0,0 +1,60 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/SharanRP/gh-notif/internal/readstate"
+)
+
+func TestFetchInbox(t *testing.T) {
+	originalOverlay := readstate.Default()
+	readstate.SetDefault(readstate.NewOverlay())
+	t.Cleanup(func() { readstate.SetDefault(originalOverlay) })
+
+	// The first host includes thread URLs, the second one does not
+	responses := map[string]string{
+		"github.com": `[{"id": "1", "unread": true, "updated_at": "2024-01-01T00:00:00Z",
+			"url": "https://api.github.com/notifications/threads/1"}]`,
+		"ghe.corp.example": `[{"id": "1", "unread": true, "updated_at": "2024-01-02T00:00:00Z"}]`,
+	}
+
+	originalNewHostClient := newHostClient
+	newHostClient = func(ctx context.Context, host string) (*Client, error) {
+		body, ok := responses[host]
+		if !ok {
+			return nil, errors.New("not authenticated")
+		}
+		client, server, err := NewTestClient(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(server.Close)
+		client.host = host
+		client.baseURL = "https://" + host + "/api/v3"
+		return client, nil
+	}
+	t.Cleanup(func() { newHostClient = originalNewHostClient })
+
+	inbox := FetchInbox(context.Background(), []string{"github.com", "ghe.corp.example", "down.example"}, NotificationOptions{})
+
+	if len(inbox.Errors) != 1 || inbox.Errors["down.example"] == nil {
+		t.Errorf("Errors = %v, want a failure for down.example only", inbox.Errors)
+	}
+	if len(inbox.Notifications) != 2 {
+		t.Fatalf("got %d notifications, want 2", len(inbox.Notifications))
+	}
+
+	// Threads with the same ID on different hosts are kept apart, newest first
+	if got := NotificationHost(inbox.Notifications[0]); got != "ghe.corp.example" {
+		t.Errorf("first notification host = %q, want ghe.corp.example", got)
+	}
+	if got := NotificationHost(inbox.Notifications[1]); got != "github.com" {
+		t.Errorf("second notification host = %q, want github.com", got)
+	}
+}
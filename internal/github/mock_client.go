@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/cache"
 	"github.com/google/go-github/v60/github"
 	"golang.org/x/time/rate"
 )
@@ -53,6 +54,10 @@ func NewMockClient(ctx context.Context) (*Client, *httptest.Server, error) {
 
 	client.client = ghClient
 
+	// Keep validators and subjects in memory only
+	client.validators = newValidatorStore(nil)
+	client.subjects = cache.NewTyped[*subjectResponse](cache.NewMemoryCache(nil))
+
 	return client, server, nil
 }
 
@@ -94,5 +99,9 @@ func NewTestClient(ctx context.Context, handler http.Handler) (*Client, *httptes
 
 	client.client = ghClient
 
+	// Keep validators and subjects in memory only
+	client.validators = newValidatorStore(nil)
+	client.subjects = cache.NewTyped[*subjectResponse](cache.NewMemoryCache(nil))
+
 	return client, server, nil
 }
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -146,6 +148,10 @@ func (c *Client) MarkNotificationRead(threadID string) error {
 		return fmt.Errorf("failed to mark thread as read: %w", err)
 	}
 
+	// The thread was read, so it no longer needs to be kept unread locally
+	c.logReadStateError(readstate.Default().Clear(threadID))
+	c.RecordWrite("MarkThreadRead", threadID)
+
 	return nil
 }
 
@@ -172,6 +178,9 @@ func (c *Client) MarkAllNotificationsRead() error {
 		return fmt.Errorf("failed to mark all notifications as read: %w", err)
 	}
 
+	c.logReadStateError(readstate.Default().ClearAll())
+	c.RecordWrite("MarkNotificationsRead", "all")
+
 	return nil
 }
 
@@ -198,6 +207,9 @@ func (c *Client) MarkRepositoryNotificationsRead(owner, repo string) error {
 		return fmt.Errorf("failed to mark repository notifications as read: %w", err)
 	}
 
+	c.logReadStateError(readstate.Default().ClearRepository(owner + "/" + repo))
+	c.RecordWrite("MarkRepositoryNotificationsRead", owner+"/"+repo)
+
 	return nil
 }
 
@@ -216,13 +228,11 @@ func (c *Client) GetAllNotifications(opts NotificationOptions) ([]*github.Notifi
 
 	// Check cache if enabled
 	if opts.UseCache && c.cacheManager != nil {
-		if cached, found := c.cacheManager.Manager.Get(cacheKey); found {
-			if notifications, ok := cached.([]*github.Notification); ok {
-				if c.debug {
-					fmt.Printf("Using cached notifications (%d items)\n", len(notifications))
-				}
-				return notifications, nil
+		if notifications, found := c.cacheManager.Notifications.Get(cacheKey); found {
+			if c.debug {
+				fmt.Printf("Using cached notifications (%d items)\n", len(notifications))
 			}
+			return notifications, nil
 		}
 	}
 
@@ -269,14 +279,13 @@ func (c *Client) GetAllNotifications(opts NotificationOptions) ([]*github.Notifi
 	}
 
 	// For multi-page requests, fetch the first page to get pagination info
-	notifications, resp, err := c.client.Activity.ListNotifications(ctx, listOptions)
+	notifications, resp, err := c.listNotificationsConditional(ctx, listOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
 	}
 
 	// Handle rate limiting
 	c.handleRateLimit(resp)
-	c.logResponse(resp, notifications, err)
 
 	// Filter by repo or org if needed
 	notifications = c.filterNotifications(notifications, opts.RepoName, opts.OrgName)
@@ -285,7 +294,7 @@ func (c *Client) GetAllNotifications(opts NotificationOptions) ([]*github.Notifi
 	if resp.NextPage == 0 {
 		// Cache the results if enabled
 		if opts.UseCache && opts.CacheTTL > 0 && c.cacheManager != nil {
-			c.cacheManager.Manager.Set(cacheKey, notifications, opts.CacheTTL)
+			c.cacheManager.Notifications.Set(cacheKey, notifications, opts.CacheTTL)
 		}
 		return notifications, nil
 	}
@@ -327,7 +336,7 @@ func (c *Client) GetAllNotifications(opts NotificationOptions) ([]*github.Notifi
 			pageOpts.Page = pageNum
 
 			// Fetch the page
-			pageNotifications, pageResp, pageErr := c.client.Activity.ListNotifications(ctx, &pageOpts)
+			pageNotifications, pageResp, pageErr := c.listNotificationsConditional(ctx, &pageOpts)
 
 			// Send the result to the channel
 			resultCh <- NotificationResult{
@@ -352,7 +361,6 @@ func (c *Client) GetAllNotifications(opts NotificationOptions) ([]*github.Notifi
 
 		// Handle rate limiting
 		c.handleRateLimit(result.Response)
-		c.logResponse(result.Response, result.Notifications, result.Error)
 
 		// Add the notifications to the result
 		allNotifications = append(allNotifications, result.Notifications...)
@@ -365,19 +373,25 @@ func (c *Client) GetAllNotifications(opts NotificationOptions) ([]*github.Notifi
 
 	// Cache the results if enabled
 	if opts.UseCache && opts.CacheTTL > 0 && c.cacheManager != nil {
-		c.cacheManager.Manager.Set(cacheKey, allNotifications, opts.CacheTTL)
+		c.cacheManager.Notifications.Set(cacheKey, allNotifications, opts.CacheTTL)
 	}
 
 	return allNotifications, nil
 }
 
-// GetUnreadNotifications fetches only unread notifications
+// GetUnreadNotifications fetches only unread notifications, including
+// threads that are read on GitHub but kept unread by the read state overlay
 func (c *Client) GetUnreadNotifications(opts NotificationOptions) ([]*github.Notification, error) {
 	// Force unread to true
 	opts.Unread = true
 	opts.All = false
 
-	return c.GetAllNotifications(opts)
+	notifications, err := c.GetAllNotifications(opts)
+	if err != nil {
+		return notifications, err
+	}
+
+	return c.withOverlayUnread(notifications, opts), nil
 }
 
 // GetNotificationsByRepo fetches notifications for a specific repository
@@ -402,20 +416,10 @@ func (c *Client) fetchNotificationsWithRetry(ctx context.Context, opts *github.N
 	var resp *github.Response
 	var err error
 
-	// Wait for rate limiter
-	if err := c.waitForRateLimit(ctx); err != nil {
-		return nil, err
-	}
-
-	// Log the request
-	c.logRequest("GET", "notifications", opts)
-
 	// Try the request with retries
 	for attempt := 0; attempt <= c.retryCount; attempt++ {
-		notifications, resp, err = c.client.Activity.ListNotifications(ctx, opts)
-
-		// Log the response
-		c.logResponse(resp, notifications, err)
+		// Conditional requests wait for the rate limiter and log themselves
+		notifications, resp, err = c.listNotificationsConditional(ctx, opts)
 
 		// If successful or not a transient error, break
 		if err == nil || !c.isTransientError(err) {
@@ -479,7 +483,8 @@ func (c *Client) filterNotifications(notifications []*github.Notification, repoF
 	return filtered
 }
 
-// FetchNotificationDetails fetches additional details for notifications in parallel
+// FetchNotificationDetails fetches the details of the notifications'
+// subjects in parallel and records them in the default enrichment store
 func (c *Client) FetchNotificationDetails(notifications []*github.Notification) error {
 	if len(notifications) == 0 {
 		return nil
@@ -502,6 +507,10 @@ func (c *Client) FetchNotificationDetails(notifications []*github.Notification)
 	semaphore := make(chan struct{}, maxConcurrent)
 
 	for _, notification := range notifications {
+		if notification.GetSubject().GetURL() == "" {
+			continue // Skip if no URL
+		}
+
 		wg.Add(1)
 		go func(n *github.Notification) {
 			defer wg.Done()
@@ -510,39 +519,13 @@ func (c *Client) FetchNotificationDetails(notifications []*github.Notification)
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			// Wait for rate limiter
-			if err := c.waitForRateLimit(ctx); err != nil {
-				errCh <- err
+			details, err := c.fetchSubjectDetails(ctx, n)
+			if err != nil {
+				errCh <- fmt.Errorf("error fetching details for %s: %w", n.GetSubject().GetType(), err)
 				return
 			}
-
-			// Fetch additional details based on notification type
-			subjectType := n.GetSubject().GetType()
-			subjectURL := n.GetSubject().GetURL()
-
-			if subjectURL == "" {
-				return // Skip if no URL
-			}
-
-			// Log the request
-			c.logRequest("GET", subjectURL, nil)
-
-			var err error
-			switch subjectType {
-			case "Issue":
-				err = c.fetchIssueDetails(ctx, n)
-			case "PullRequest":
-				err = c.fetchPullRequestDetails(ctx, n)
-			case "Commit":
-				err = c.fetchCommitDetails(ctx, n)
-			case "Release":
-				err = c.fetchReleaseDetails(ctx, n)
-			case "Discussion":
-				err = c.fetchDiscussionDetails(ctx, n)
-			}
-
-			if err != nil {
-				errCh <- fmt.Errorf("error fetching details for %s: %w", subjectType, err)
+			if details != nil {
+				enrichment.Default().Set(n.GetSubject().GetURL(), details)
 			}
 		}(notification)
 	}
@@ -565,156 +548,3 @@ func (c *Client) FetchNotificationDetails(notifications []*github.Notification)
 
 	return nil
 }
-
-// fetchIssueDetails fetches details for an issue notification
-func (c *Client) fetchIssueDetails(ctx context.Context, notification *github.Notification) error {
-	// Parse the URL to get owner, repo, and issue number
-	url := notification.GetSubject().GetURL()
-	parts := strings.Split(url, "/")
-	if len(parts) < 7 {
-		return fmt.Errorf("invalid issue URL: %s", url)
-	}
-
-	owner := parts[4]
-	repo := parts[5]
-	issueNumber := parts[7]
-
-	// Convert issue number to int
-	var issueNum int
-	if _, err := fmt.Sscanf(issueNumber, "%d", &issueNum); err != nil {
-		return fmt.Errorf("invalid issue number: %s", issueNumber)
-	}
-
-	// Fetch issue details
-	issue, resp, err := c.client.Issues.Get(ctx, owner, repo, issueNum)
-
-	// Log the response
-	c.logResponse(resp, issue, err)
-
-	// Handle rate limiting
-	c.handleRateLimit(resp)
-
-	if err != nil {
-		return fmt.Errorf("failed to fetch issue details: %w", err)
-	}
-
-	// Store the details in the notification (using the latest_comment_url field)
-	notification.Subject.LatestCommentURL = github.String(fmt.Sprintf("%s#%d", url, issue.GetID()))
-
-	return nil
-}
-
-// fetchPullRequestDetails fetches details for a pull request notification
-func (c *Client) fetchPullRequestDetails(ctx context.Context, notification *github.Notification) error {
-	// Parse the URL to get owner, repo, and PR number
-	url := notification.GetSubject().GetURL()
-	parts := strings.Split(url, "/")
-	if len(parts) < 7 {
-		return fmt.Errorf("invalid pull request URL: %s", url)
-	}
-
-	owner := parts[4]
-	repo := parts[5]
-	prNumber := parts[7]
-
-	// Convert PR number to int
-	var prNum int
-	if _, err := fmt.Sscanf(prNumber, "%d", &prNum); err != nil {
-		return fmt.Errorf("invalid pull request number: %s", prNumber)
-	}
-
-	// Fetch PR details
-	pr, resp, err := c.client.PullRequests.Get(ctx, owner, repo, prNum)
-
-	// Log the response
-	c.logResponse(resp, pr, err)
-
-	// Handle rate limiting
-	c.handleRateLimit(resp)
-
-	if err != nil {
-		return fmt.Errorf("failed to fetch pull request details: %w", err)
-	}
-
-	// Store the details in the notification
-	notification.Subject.LatestCommentURL = github.String(fmt.Sprintf("%s#%d", url, pr.GetID()))
-
-	return nil
-}
-
-// fetchCommitDetails fetches details for a commit notification
-func (c *Client) fetchCommitDetails(ctx context.Context, notification *github.Notification) error {
-	// Parse the URL to get owner, repo, and commit SHA
-	url := notification.GetSubject().GetURL()
-	parts := strings.Split(url, "/")
-	if len(parts) < 7 {
-		return fmt.Errorf("invalid commit URL: %s", url)
-	}
-
-	owner := parts[4]
-	repo := parts[5]
-	sha := parts[7]
-
-	// Fetch commit details
-	commit, resp, err := c.client.Repositories.GetCommit(ctx, owner, repo, sha, nil)
-
-	// Log the response
-	c.logResponse(resp, commit, err)
-
-	// Handle rate limiting
-	c.handleRateLimit(resp)
-
-	if err != nil {
-		return fmt.Errorf("failed to fetch commit details: %w", err)
-	}
-
-	// Store the details in the notification
-	notification.Subject.LatestCommentURL = github.String(fmt.Sprintf("%s#%s", url, commit.GetSHA()))
-
-	return nil
-}
-
-// fetchReleaseDetails fetches details for a release notification
-func (c *Client) fetchReleaseDetails(ctx context.Context, notification *github.Notification) error {
-	// Parse the URL to get owner, repo, and release ID
-	url := notification.GetSubject().GetURL()
-	parts := strings.Split(url, "/")
-	if len(parts) < 7 {
-		return fmt.Errorf("invalid release URL: %s", url)
-	}
-
-	owner := parts[4]
-	repo := parts[5]
-	releaseID := parts[7]
-
-	// Convert release ID to int64
-	var id int64
-	if _, err := fmt.Sscanf(releaseID, "%d", &id); err != nil {
-		return fmt.Errorf("invalid release ID: %s", releaseID)
-	}
-
-	// Fetch release details
-	release, resp, err := c.client.Repositories.GetRelease(ctx, owner, repo, id)
-
-	// Log the response
-	c.logResponse(resp, release, err)
-
-	// Handle rate limiting
-	c.handleRateLimit(resp)
-
-	if err != nil {
-		return fmt.Errorf("failed to fetch release details: %w", err)
-	}
-
-	// Store the details in the notification
-	notification.Subject.LatestCommentURL = github.String(fmt.Sprintf("%s#%d", url, release.GetID()))
-
-	return nil
-}
-
-// fetchDiscussionDetails fetches details for a discussion notification
-func (c *Client) fetchDiscussionDetails(ctx context.Context, notification *github.Notification) error {
-	// GitHub API doesn't have a direct endpoint for discussions yet
-	// This is a placeholder for when it becomes available
-	return nil
-}
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -239,3 +242,77 @@ func TestGetNotificationsByRepo(t *testing.T) {
 		t.Errorf("GetNotificationsByRepo() repo = %s, want %s", notifications[0].GetRepository().GetFullName(), "org1/repo1")
 	}
 }
+
+func TestGetUnreadNotificationsReadStateOverlay(t *testing.T) {
+	markedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	originalOverlay := readstate.Default()
+	overlay := readstate.NewOverlay()
+	readstate.SetDefault(overlay)
+	defer readstate.SetDefault(originalOverlay)
+
+	threads := map[string]*github.Notification{
+		"2": {
+			ID:         github.String("2"),
+			Repository: &github.Repository{FullName: github.String("org1/repo1")},
+			Unread:     github.Bool(false),
+			UpdatedAt:  &github.Timestamp{Time: markedAt},
+		},
+		"3": {
+			ID:         github.String("3"),
+			Repository: &github.Repository{FullName: github.String("org1/repo1")},
+			Unread:     github.Bool(false),
+			UpdatedAt:  &github.Timestamp{Time: markedAt.Add(time.Hour)},
+		},
+	}
+	overlay.MarkUnread(threads["2"])
+	overlay.MarkUnread(&github.Notification{
+		ID:         github.String("3"),
+		Repository: &github.Repository{FullName: github.String("org1/repo1")},
+		UpdatedAt:  &github.Timestamp{Time: markedAt},
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/notifications":
+			json.NewEncoder(w).Encode([]*github.Notification{{
+				ID:         github.String("1"),
+				Repository: &github.Repository{FullName: github.String("org1/repo1")},
+				Unread:     github.Bool(true),
+			}})
+		case r.Method == http.MethodPatch && r.URL.Path == "/notifications/threads/2":
+			w.WriteHeader(http.StatusResetContent)
+		case strings.HasPrefix(r.URL.Path, "/notifications/threads/"):
+			json.NewEncoder(w).Encode(threads[strings.TrimPrefix(r.URL.Path, "/notifications/threads/")])
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	})
+	client, testServer, err := NewTestClient(context.Background(), handler)
+	if err != nil {
+		t.Fatalf("Failed to create test client: %v", err)
+	}
+	defer testServer.Close()
+
+	notifications, err := client.GetUnreadNotifications(NotificationOptions{PerPage: 10})
+	if err != nil {
+		t.Fatalf("GetUnreadNotifications() error = %v", err)
+	}
+
+	// Thread 2 is kept unread, thread 3 was updated since it was marked
+	if len(notifications) != 2 || notifications[1].GetID() != "2" || !notifications[1].GetUnread() {
+		t.Fatalf("unexpected notifications: %v", notifications)
+	}
+	if entries := overlay.Entries(); len(entries) != 1 || entries[0].ThreadID != "2" {
+		t.Errorf("unexpected overlay entries: %+v", entries)
+	}
+
+	// Reading the thread removes it from the overlay
+	if _, err := client.MarkThreadRead("2"); err != nil {
+		t.Fatalf("MarkThreadRead() error = %v", err)
+	}
+	if entries := overlay.Entries(); len(entries) != 0 {
+		t.Errorf("overlay entries after reading = %+v, want none", entries)
+	}
+}
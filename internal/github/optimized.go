@@ -3,7 +3,6 @@ package github
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"sync"
 
 	"github.com/SharanRP/gh-notif/internal/cache"
@@ -18,26 +17,24 @@ func (c *Client) OptimizedGetAllNotifications(opts NotificationOptions) ([]*gith
 
 	// Check cache if enabled
 	if opts.UseCache && c.cacheManager != nil {
-		if cached, found := c.cacheManager.Manager.Get(cacheKey); found {
-			if notifications, ok := cached.([]*github.Notification); ok {
-				if c.debug {
-					fmt.Printf("Using cached notifications (%d items)\n", len(notifications))
-				}
-
-				// Queue background refresh if enabled
-				if opts.BackgroundRefresh {
-					c.cacheManager.Manager.Prefetch(cache.PrefetchRequest{
-						Key:      cacheKey,
-						Priority: 1,
-						Callback: func(ctx context.Context) (interface{}, error) {
-							// This would refresh the notifications in the background
-							return c.fetchAllNotificationsWithETag(ctx, opts, cacheKey)
-						},
-					})
-				}
-
-				return notifications, nil
+		if notifications, found := c.cacheManager.Notifications.Get(cacheKey); found {
+			if c.debug {
+				fmt.Printf("Using cached notifications (%d items)\n", len(notifications))
 			}
+
+			// Queue background refresh if enabled
+			if opts.BackgroundRefresh {
+				c.cacheManager.Manager.Prefetch(cache.PrefetchRequest{
+					Key:      cacheKey,
+					Priority: 1,
+					Callback: func(ctx context.Context) (interface{}, error) {
+						// This would refresh the notifications in the background
+						return c.fetchAllNotificationsWithETag(ctx, opts, cacheKey)
+					},
+				})
+			}
+
+			return notifications, nil
 		}
 	}
 
@@ -72,7 +69,8 @@ func (c *Client) OptimizedGetAllNotifications(opts NotificationOptions) ([]*gith
 	return c.fetchAllNotificationsWithETag(ctx, opts, cacheKey)
 }
 
-// fetchAllNotificationsWithETag fetches all notifications with ETag support
+// fetchAllNotificationsWithETag fetches all notifications with conditional
+// requests, see listNotificationsConditional
 func (c *Client) fetchAllNotificationsWithETag(ctx context.Context, opts NotificationOptions, cacheKey string) ([]*github.Notification, error) {
 	// Create notification list options
 	listOptions := &github.NotificationListOptions{
@@ -91,70 +89,24 @@ func (c *Client) fetchAllNotificationsWithETag(ctx context.Context, opts Notific
 		listOptions.Before = opts.Before
 	}
 
-	// Get ETag from cache metadata if available
-	var etag string
-	if opts.UseCache && c.cacheManager != nil {
-		// In a real implementation, we would store and retrieve the ETag
-		// For now, we'll just use a placeholder
-		etag = ""
-	}
-
-	// Create a request for the first page
-	req, err := c.client.NewRequest("GET", "notifications", listOptions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set If-None-Match header if we have an ETag
-	if etag != "" {
-		req.Header.Set("If-None-Match", etag)
-	}
-
-	// Execute the request
-	var notifications []*github.Notification
-	resp, err := c.client.Do(ctx, req, &notifications)
-
-	// Handle 304 Not Modified
-	if resp != nil && resp.StatusCode == http.StatusNotModified {
-		// Use cached data
-		if c.cacheManager != nil {
-			if cached, found := c.cacheManager.Manager.Get(cacheKey); found {
-				if cachedNotifications, ok := cached.([]*github.Notification); ok {
-					if c.debug {
-						fmt.Printf("Using cached notifications (304 Not Modified)\n")
-					}
-					return cachedNotifications, nil
-				}
-			}
-		}
-	}
-
+	// Fetch the first page with a conditional request, so an unchanged inbox
+	// costs neither a full response nor rate limit budget
+	notifications, resp, err := c.listNotificationsConditional(ctx, listOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
 	}
 
 	// Handle rate limiting
 	c.handleRateLimit(resp)
-	c.logResponse(resp, notifications, err)
 
 	// Filter by repo or org if needed
 	notifications = c.filterNotifications(notifications, opts.RepoName, opts.OrgName)
 
-	// Store the ETag for future requests
-	newETag := resp.Header.Get("ETag")
-	if newETag != "" {
-		// In a real implementation, we would store this ETag
-		// For now, we'll just log it
-		if c.debug {
-			fmt.Printf("New ETag: %s\n", newETag)
-		}
-	}
-
 	// If there's only one page, return the results
 	if resp.NextPage == 0 {
 		// Cache the results if enabled
 		if opts.UseCache && opts.CacheTTL > 0 && c.cacheManager != nil {
-			c.cacheManager.Manager.Set(cacheKey, notifications, opts.CacheTTL)
+			c.cacheManager.Notifications.Set(cacheKey, notifications, opts.CacheTTL)
 		}
 		return notifications, nil
 	}
@@ -255,7 +207,7 @@ func (c *Client) fetchRemainingPagesOptimized(
 
 	// Cache the results if enabled
 	if opts.UseCache && opts.CacheTTL > 0 && c.cacheManager != nil {
-		c.cacheManager.Manager.Set(cacheKey, allNotifications, opts.CacheTTL)
+		c.cacheManager.Notifications.Set(cacheKey, allNotifications, opts.CacheTTL)
 	}
 
 	return allNotifications, nil
@@ -277,14 +229,13 @@ func (c *Client) fetchPageBatch(
 		pageOpts.Page = page
 
 		// Fetch the page
-		notifications, resp, err := c.client.Activity.ListNotifications(ctx, &pageOpts)
+		notifications, resp, err := c.listNotificationsConditional(ctx, &pageOpts)
 		if err != nil {
 			return allNotifications, err
 		}
 
 		// Handle rate limiting
 		c.handleRateLimit(resp)
-		c.logResponse(resp, notifications, nil)
 
 		// Filter and add to results
 		filtered := c.filterNotifications(notifications, repoFilter, orgFilter)
@@ -0,0 +1,69 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SharanRP/gh-notif/internal/readstate"
+	"github.com/google/go-github/v60/github"
+)
+
+// withOverlayUnread adds the threads kept unread by the read state overlay to
+// a list of unread notifications
+func (c *Client) withOverlayUnread(notifications []*github.Notification, opts NotificationOptions) []*github.Notification {
+	overlay := readstate.Default()
+	entries := overlay.Entries()
+	if len(entries) == 0 {
+		return notifications
+	}
+
+	// Copy so that cached results are not extended
+	notifications = append([]*github.Notification(nil), notifications...)
+
+	present := make(map[string]bool, len(notifications))
+	for _, n := range notifications {
+		present[n.GetID()] = true
+	}
+
+	for _, entry := range entries {
+		if present[entry.ThreadID] {
+			continue
+		}
+		if opts.RepoName != "" && entry.Repository != opts.RepoName {
+			continue
+		}
+		if opts.OrgName != "" && !strings.HasPrefix(entry.Repository, opts.OrgName+"/") {
+			continue
+		}
+
+		// Threads that cannot be fetched are skipped, they may have been deleted
+		thread, _, err := c.GetThread(entry.ThreadID)
+		if err != nil || thread == nil {
+			continue
+		}
+		if !opts.Since.IsZero() && thread.GetUpdatedAt().Before(opts.Since) {
+			continue
+		}
+
+		notifications = append(notifications, thread)
+	}
+
+	c.logReadStateError(overlay.Apply(notifications))
+
+	// Newer updates drop threads from the overlay, keep only unread ones
+	unread := make([]*github.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		if n.GetUnread() {
+			unread = append(unread, n)
+		}
+	}
+	return unread
+}
+
+// logReadStateError logs a failure to persist the read state overlay. The
+// overlay only affects local display, so the failure is not returned.
+func (c *Client) logReadStateError(err error) {
+	if err != nil && c.debug {
+		fmt.Printf("Failed to update read state: %v\n", err)
+	}
+}
@@ -110,7 +110,7 @@ func (s *NotificationStream) stream() {
 	}
 
 	// Fetch the first page to get pagination info
-	notifications, resp, err := s.client.client.Activity.ListNotifications(s.ctx, listOptions)
+	notifications, resp, err := s.client.listNotificationsConditional(s.ctx, listOptions)
 	if err != nil {
 		s.errorCh <- fmt.Errorf("failed to fetch notifications: %w", err)
 		return
@@ -118,7 +118,6 @@ func (s *NotificationStream) stream() {
 
 	// Handle rate limiting
 	s.client.handleRateLimit(resp)
-	s.client.logResponse(resp, notifications, err)
 
 	// Stream the first page of notifications
 	for _, n := range s.client.filterNotifications(notifications, s.options.RepoName, s.options.OrgName) {
@@ -167,14 +166,8 @@ func (s *NotificationStream) stream() {
 			pageOpts := *listOptions
 			pageOpts.Page = pageNum
 
-			// Wait for rate limiter
-			if err := s.client.waitForRateLimit(s.ctx); err != nil {
-				s.errorCh <- err
-				return
-			}
-
-			// Fetch the page
-			pageNotifications, pageResp, pageErr := s.client.client.Activity.ListNotifications(s.ctx, &pageOpts)
+			// Fetch the page, waiting for the rate limiter unless the request is conditional
+			pageNotifications, pageResp, pageErr := s.client.listNotificationsConditional(s.ctx, &pageOpts)
 			if pageErr != nil {
 				s.errorCh <- fmt.Errorf("failed to fetch page %d: %w", pageNum, pageErr)
 				return
@@ -182,7 +175,6 @@ func (s *NotificationStream) stream() {
 
 			// Handle rate limiting
 			s.client.handleRateLimit(pageResp)
-			s.client.logResponse(pageResp, pageNotifications, pageErr)
 
 			// Filter and stream the notifications
 			filtered := s.client.filterNotifications(pageNotifications, s.options.RepoName, s.options.OrgName)
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/google/go-github/v60/github"
+)
+
+// detailFields are the fields taken from the details of a notification's subject
+var detailFields = map[string]string{
+	"state":     "State",
+	"author":    "Author",
+	"assignees": "Assignees",
+	"labels":    "Labels",
+	"review":    "Review",
+	"ci":        "CI",
+	"comments":  "Comments",
+	"draft":     "Draft",
+}
+
+// NeedsDetails reports whether any of the fields is taken from the details
+// of the notification subjects, which then have to be fetched first
+func NeedsDetails(fields []string) bool {
+	for _, field := range fields {
+		if _, ok := detailFields[strings.ToLower(field)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// detailHeader returns the header of a detail field
+func detailHeader(field string) (string, bool) {
+	header, ok := detailFields[strings.ToLower(field)]
+	return header, ok
+}
+
+// detailValue returns the value of a detail field for a notification, which
+// is empty when the details of its subject are unknown
+func detailValue(n *github.Notification, field string) (string, bool) {
+	field = strings.ToLower(field)
+	if _, ok := detailFields[field]; !ok {
+		return "", false
+	}
+
+	details := enrichment.Lookup(n)
+	if details == nil {
+		return "", true
+	}
+
+	switch field {
+	case "state":
+		return details.State, true
+	case "author":
+		return details.Author, true
+	case "assignees":
+		return strings.Join(details.Assignees, ","), true
+	case "labels":
+		return strings.Join(details.Labels, ","), true
+	case "review":
+		return details.ReviewDecision, true
+	case "ci":
+		return details.CIStatus, true
+	case "comments":
+		return strconv.Itoa(details.Comments), true
+	default:
+		return strconv.FormatBool(details.Draft), true
+	}
+}
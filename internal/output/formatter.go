@@ -9,6 +9,9 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/scoring"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/google/go-github/v60/github"
 )
@@ -43,6 +46,8 @@ type Formatter struct {
 	Fields []string
 	// TemplateCache caches parsed templates
 	TemplateCache map[string]*template.Template
+	// Scores are the scores of the notifications by ID, included in JSON output
+	Scores map[string]*scoring.NotificationScore
 }
 
 // NewFormatter creates a new formatter
@@ -90,6 +95,12 @@ func (f *Formatter) WithFields(fields []string) *Formatter {
 	return f
 }
 
+// WithScores sets the scores of the notifications to include in JSON output
+func (f *Formatter) WithScores(scores map[string]*scoring.NotificationScore) *Formatter {
+	f.Scores = scores
+	return f
+}
+
 // Format formats notifications for output
 func (f *Formatter) Format(notifications []*github.Notification) error {
 	switch f.OutputFormat {
@@ -155,8 +166,14 @@ func (f *Formatter) formatText(notifications []*github.Notification) error {
 			headerText = "Status"
 		case "reason":
 			headerText = "Reason"
+		case "host":
+			headerText = "Host"
 		default:
-			headerText = strings.Title(field)
+			if detail, ok := detailHeader(field); ok {
+				headerText = detail
+			} else {
+				headerText = strings.Title(field)
+			}
 		}
 		if !f.NoColor {
 			headerText = headerStyle.Render(headerText)
@@ -221,8 +238,17 @@ func (f *Formatter) formatText(notifications []*github.Notification) error {
 				if !f.NoColor {
 					value = reasonStyle.Render(value)
 				}
+			case "host":
+				value = config.HostFromAPIURL(n.GetURL())
+				if !f.NoColor {
+					value = repoStyle.Render(value)
+				}
 			default:
-				value = "N/A"
+				if detail, ok := detailValue(n, field); ok {
+					value = detail
+				} else {
+					value = "N/A"
+				}
 			}
 			row = append(row, value)
 		}
@@ -232,23 +258,29 @@ func (f *Formatter) formatText(notifications []*github.Notification) error {
 	return nil
 }
 
-// formatJSON formats notifications as JSON
-func (f *Formatter) formatJSON(notifications []*github.Notification) error {
-	// Create a custom representation for JSON output
-	type jsonNotification struct {
-		ID         string    `json:"id"`
-		Repository string    `json:"repository"`
-		Type       string    `json:"type"`
-		Title      string    `json:"title"`
-		URL        string    `json:"url"`
-		UpdatedAt  time.Time `json:"updated_at"`
-		Unread     bool      `json:"unread"`
-		Reason     string    `json:"reason"`
-	}
+// jsonNotification is the JSON representation of a notification
+type jsonNotification struct {
+	ID         string    `json:"id"`
+	Repository string    `json:"repository"`
+	Type       string    `json:"type"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Unread     bool      `json:"unread"`
+	Reason     string    `json:"reason"`
+	Host       string    `json:"host,omitempty"`
+	// Details are the details of the subject, when they were fetched
+	Details *enrichment.Details `json:"details,omitempty"`
+	// Score and ScoreBreakdown are set when the notifications were scored
+	Score          *int                  `json:"score,omitempty"`
+	ScoreBreakdown []scoring.FactorScore `json:"score_breakdown,omitempty"`
+}
 
+// toJSONNotifications converts notifications to their JSON representation
+func toJSONNotifications(notifications []*github.Notification, scores map[string]*scoring.NotificationScore) []jsonNotification {
 	jsonNotifications := make([]jsonNotification, 0, len(notifications))
 	for _, n := range notifications {
-		jsonNotifications = append(jsonNotifications, jsonNotification{
+		jn := jsonNotification{
 			ID:         n.GetID(),
 			Repository: n.GetRepository().GetFullName(),
 			Type:       n.GetSubject().GetType(),
@@ -257,12 +289,23 @@ func (f *Formatter) formatJSON(notifications []*github.Notification) error {
 			UpdatedAt:  n.GetUpdatedAt().Time,
 			Unread:     n.GetUnread(),
 			Reason:     n.GetReason(),
-		})
+			Host:       config.HostFromAPIURL(n.GetURL()),
+			Details:    enrichment.Lookup(n),
+		}
+		if score, ok := scores[n.GetID()]; ok && score != nil {
+			jn.Score = &score.Total
+			jn.ScoreBreakdown = score.Breakdown
+		}
+		jsonNotifications = append(jsonNotifications, jn)
 	}
+	return jsonNotifications
+}
 
+// formatJSON formats notifications as JSON
+func (f *Formatter) formatJSON(notifications []*github.Notification) error {
 	encoder := json.NewEncoder(f.Writer)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(jsonNotifications)
+	return encoder.Encode(toJSONNotifications(notifications, f.Scores))
 }
 
 // formatCSV formats notifications as CSV
@@ -273,24 +316,7 @@ func (f *Formatter) formatCSV(notifications []*github.Notification) error {
 	// Write header
 	header := make([]string, 0, len(f.Fields))
 	for _, field := range f.Fields {
-		switch strings.ToLower(field) {
-		case "id":
-			header = append(header, "ID")
-		case "repository", "repo":
-			header = append(header, "Repository")
-		case "type":
-			header = append(header, "Type")
-		case "title":
-			header = append(header, "Title")
-		case "updated":
-			header = append(header, "Updated")
-		case "status":
-			header = append(header, "Status")
-		case "reason":
-			header = append(header, "Reason")
-		default:
-			header = append(header, strings.Title(field))
-		}
+		header = append(header, csvHeader(field))
 	}
 	if err := writer.Write(header); err != nil {
 		return err
@@ -300,30 +326,7 @@ func (f *Formatter) formatCSV(notifications []*github.Notification) error {
 	for _, n := range notifications {
 		row := make([]string, 0, len(f.Fields))
 		for _, field := range f.Fields {
-			var value string
-			switch strings.ToLower(field) {
-			case "id":
-				value = n.GetID()
-			case "repository", "repo":
-				value = n.GetRepository().GetFullName()
-			case "type":
-				value = n.GetSubject().GetType()
-			case "title":
-				value = n.GetSubject().GetTitle()
-			case "updated":
-				value = n.GetUpdatedAt().Format(time.RFC3339)
-			case "status":
-				if n.GetUnread() {
-					value = "Unread"
-				} else {
-					value = "Read"
-				}
-			case "reason":
-				value = n.GetReason()
-			default:
-				value = "N/A"
-			}
-			row = append(row, value)
+			row = append(row, csvValue(n, field))
 		}
 		if err := writer.Write(row); err != nil {
 			return err
@@ -333,6 +336,63 @@ func (f *Formatter) formatCSV(notifications []*github.Notification) error {
 	return nil
 }
 
+// csvHeader returns the CSV column header for a field
+func csvHeader(field string) string {
+	switch strings.ToLower(field) {
+	case "id":
+		return "ID"
+	case "repository", "repo":
+		return "Repository"
+	case "type":
+		return "Type"
+	case "title":
+		return "Title"
+	case "updated":
+		return "Updated"
+	case "status":
+		return "Status"
+	case "reason":
+		return "Reason"
+	case "host":
+		return "Host"
+	default:
+		if detail, ok := detailHeader(field); ok {
+			return detail
+		}
+		return strings.Title(field)
+	}
+}
+
+// csvValue returns the CSV cell value of a field for a notification
+func csvValue(n *github.Notification, field string) string {
+	switch strings.ToLower(field) {
+	case "id":
+		return n.GetID()
+	case "repository", "repo":
+		return n.GetRepository().GetFullName()
+	case "type":
+		return n.GetSubject().GetType()
+	case "title":
+		return n.GetSubject().GetTitle()
+	case "updated":
+		return n.GetUpdatedAt().Format(time.RFC3339)
+	case "status":
+		if n.GetUnread() {
+			return "Unread"
+		}
+		return "Read"
+	case "reason":
+		return n.GetReason()
+	case "host":
+		return config.HostFromAPIURL(n.GetURL())
+	default:
+		if detail, ok := detailValue(n, field); ok {
+			return detail
+		}
+		return "N/A"
+	}
+}
+
 // formatTemplate formats notifications using a custom template
 func (f *Formatter) formatTemplate(notifications []*github.Notification) error {
 	if f.Template == "" {
@@ -346,6 +406,7 @@ func (f *Formatter) formatTemplate(notifications []*github.Notification) error {
 		var err error
 		tmpl, err = template.New("notifications").Funcs(template.FuncMap{
 			"formatTime": formatTime,
+			"details":    enrichment.Lookup,
 		}).Parse(f.Template)
 		if err != nil {
 			return fmt.Errorf("failed to parse template: %w", err)
@@ -2,12 +2,15 @@ package output
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/scoring"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -245,6 +248,89 @@ func TestCustomFields(t *testing.T) {
 	}
 }
 
+// TestDetailFields tests formatting the details of notification subjects
+func TestDetailFields(t *testing.T) {
+	originalStore := enrichment.Default()
+	store := enrichment.NewStore()
+	enrichment.SetDefault(store)
+	defer enrichment.SetDefault(originalStore)
+
+	notifications := createTestNotifications(2)
+	store.Set(notifications[0].GetSubject().GetURL(), &enrichment.Details{
+		State:    enrichment.StateOpen,
+		Author:   "alice",
+		Labels:   []string{"bug", "ui"},
+		CIStatus: enrichment.CISuccess,
+	})
+
+	fields := []string{"title", "state", "labels", "ci"}
+	if !NeedsDetails(fields) || NeedsDetails([]string{"title", "repository"}) {
+		t.Fatal("NeedsDetails() did not detect the detail fields")
+	}
+
+	var buf bytes.Buffer
+	formatter := NewFormatter(&buf).WithFormat(FormatCSV).WithFields(fields)
+	if err := formatter.Format(notifications); err != nil {
+		t.Fatalf("Failed to format notifications: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "Title,State,Labels,CI") {
+		t.Errorf("Expected detail headers, got: %s", output)
+	}
+	if !strings.Contains(output, `open,"bug,ui",success`) {
+		t.Errorf("Expected detail values, got: %s", output)
+	}
+
+	buf.Reset()
+	formatter = NewFormatter(&buf).WithFormat(FormatJSON)
+	if err := formatter.Format(notifications); err != nil {
+		t.Fatalf("Failed to format notifications: %v", err)
+	}
+	var result []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	details, ok := result[0]["details"].(map[string]interface{})
+	if !ok || details["author"] != "alice" {
+		t.Errorf("Expected details of the first notification, got: %v", result[0]["details"])
+	}
+	if _, ok := result[1]["details"]; ok {
+		t.Errorf("Expected no details for the second notification, got: %v", result[1]["details"])
+	}
+}
+
+// TestJSONScoreBreakdown tests including scores in JSON output
+func TestJSONScoreBreakdown(t *testing.T) {
+	notifications := createTestNotifications(2)
+	scores, err := scoring.NewScorer(nil).Score(context.Background(), notifications[:1])
+	if err != nil {
+		t.Fatalf("Failed to score notifications: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewFormatter(&buf).WithFormat(FormatJSON).WithScores(scores).Format(notifications); err != nil {
+		t.Fatalf("Failed to format notifications: %v", err)
+	}
+
+	var result []struct {
+		Score          *int                  `json:"score"`
+		ScoreBreakdown []scoring.FactorScore `json:"score_breakdown"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+
+	if result[0].Score == nil || *result[0].Score != scores["1"].Total {
+		t.Errorf("Expected score %d, got %v", scores["1"].Total, result[0].Score)
+	}
+	if len(result[0].ScoreBreakdown) != 6 || result[0].ScoreBreakdown[0].Factor != "age" || result[0].ScoreBreakdown[0].Weight == 0 {
+		t.Errorf("Expected the score breakdown, got %+v", result[0].ScoreBreakdown)
+	}
+	if result[1].Score != nil || result[1].ScoreBreakdown != nil {
+		t.Errorf("Expected no score for an unscored notification, got %+v", result[1])
+	}
+}
+
 // TestEmptyNotifications tests formatting empty notifications
 func TestEmptyNotifications(t *testing.T) {
 	// Create empty notifications
@@ -0,0 +1,176 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/grouping"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// jsonGroup is the JSON representation of a notification group
+type jsonGroup struct {
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	Type          string             `json:"type"`
+	Count         int                `json:"count"`
+	UnreadCount   int                `json:"unread_count"`
+	Notifications []jsonNotification `json:"notifications"`
+	Subgroups     []jsonGroup        `json:"subgroups,omitempty"`
+}
+
+// FormatGroups formats grouped notifications for output
+func (f *Formatter) FormatGroups(groups []*grouping.Group) error {
+	switch f.OutputFormat {
+	case FormatText:
+		return f.formatGroupsText(groups, 0)
+	case FormatJSON:
+		return f.formatGroupsJSON(groups)
+	case FormatCSV:
+		return f.formatGroupsCSV(groups)
+	case FormatTemplate:
+		return f.formatGroupsTemplate(groups)
+	default:
+		return fmt.Errorf("unsupported format: %s", f.OutputFormat)
+	}
+}
+
+// formatGroupsText formats groups as human-readable text
+func (f *Formatter) formatGroupsText(groups []*grouping.Group, depth int) error {
+	if len(groups) == 0 && depth == 0 {
+		fmt.Fprintln(f.Writer, "No notifications found.")
+		return nil
+	}
+
+	var groupStyle lipgloss.Style
+	if !f.NoColor {
+		groupStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("4"))
+	}
+
+	indent := strings.Repeat("  ", depth)
+	for i, group := range groups {
+		if i > 0 && depth == 0 {
+			fmt.Fprintln(f.Writer)
+		}
+
+		heading := fmt.Sprintf("%s (%d, %d unread)", group.Name, group.Count, group.UnreadCount)
+		if !f.NoColor {
+			heading = groupStyle.Render(heading)
+		}
+		fmt.Fprintf(f.Writer, "%s%s\n", indent, heading)
+
+		if len(group.Subgroups) > 0 {
+			if err := f.formatGroupsText(group.Subgroups, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := f.formatText(group.Notifications); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatGroupsJSON formats groups as JSON
+func (f *Formatter) formatGroupsJSON(groups []*grouping.Group) error {
+	encoder := json.NewEncoder(f.Writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(toJSONGroups(groups, f.Scores))
+}
+
+// toJSONGroups converts groups to their JSON representation
+func toJSONGroups(groups []*grouping.Group, scores map[string]*scoring.NotificationScore) []jsonGroup {
+	jsonGroups := make([]jsonGroup, 0, len(groups))
+	for _, group := range groups {
+		jsonGroups = append(jsonGroups, jsonGroup{
+			ID:            group.ID,
+			Name:          group.Name,
+			Type:          string(group.Type),
+			Count:         group.Count,
+			UnreadCount:   group.UnreadCount,
+			Notifications: toJSONNotifications(group.Notifications, scores),
+			Subgroups:     toJSONGroups(group.Subgroups, scores),
+		})
+	}
+	return jsonGroups
+}
+
+// formatGroupsCSV formats groups as CSV with a leading group column
+func (f *Formatter) formatGroupsCSV(groups []*grouping.Group) error {
+	writer := csv.NewWriter(f.Writer)
+	defer writer.Flush()
+
+	header := []string{"Group"}
+	for _, field := range f.Fields {
+		header = append(header, csvHeader(field))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	var writeGroup func(group *grouping.Group, prefix string) error
+	writeGroup = func(group *grouping.Group, prefix string) error {
+		name := group.Name
+		if prefix != "" {
+			name = prefix + " / " + name
+		}
+
+		if len(group.Subgroups) > 0 {
+			for _, subgroup := range group.Subgroups {
+				if err := writeGroup(subgroup, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, n := range group.Notifications {
+			row := []string{name}
+			for _, field := range f.Fields {
+				row = append(row, csvValue(n, field))
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, group := range groups {
+		if err := writeGroup(group, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatGroupsTemplate formats groups using a custom template
+func (f *Formatter) formatGroupsTemplate(groups []*grouping.Group) error {
+	if f.Template == "" {
+		return fmt.Errorf("template not specified")
+	}
+
+	tmpl, ok := f.TemplateCache[f.Template]
+	if !ok {
+		var err error
+		tmpl, err = template.New("groups").Funcs(template.FuncMap{
+			"formatTime": formatTime,
+			"details":    enrichment.Lookup,
+		}).Parse(f.Template)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		f.TemplateCache[f.Template] = tmpl
+	}
+
+	return tmpl.Execute(f.Writer, groups)
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/SharanRP/gh-notif/internal/grouping"
+)
+
+// TestGroupFormatter tests formatting grouped notifications
+func TestGroupFormatter(t *testing.T) {
+	notifications := createTestNotifications(5)
+
+	options := grouping.DefaultGroupOptions()
+	options.PrimaryGrouping = grouping.GroupByRepository
+	groups, err := grouping.NewGrouper(options).Group(context.Background(), notifications)
+	if err != nil {
+		t.Fatalf("Failed to group notifications: %v", err)
+	}
+
+	// Text output should contain a heading per group
+	var buf bytes.Buffer
+	if err := NewFormatter(&buf).WithNoColor(true).FormatGroups(groups); err != nil {
+		t.Fatalf("Failed to format groups: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "test/repo1 (3, 3 unread)") {
+		t.Errorf("Expected output to contain group heading for test/repo1, got: %s", output)
+	}
+	if !strings.Contains(output, "test/repo2 (2, 0 unread)") {
+		t.Errorf("Expected output to contain group heading for test/repo2, got: %s", output)
+	}
+
+	// JSON output should round-trip into groups
+	buf.Reset()
+	if err := NewFormatter(&buf).WithFormat(FormatJSON).FormatGroups(groups); err != nil {
+		t.Fatalf("Failed to format groups: %v", err)
+	}
+	var decoded []jsonGroup
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(decoded))
+	}
+	total := 0
+	for _, g := range decoded {
+		total += len(g.Notifications)
+	}
+	if total != 5 {
+		t.Errorf("Expected 5 notifications across groups, got %d", total)
+	}
+
+	// CSV output should have a group column
+	buf.Reset()
+	if err := NewFormatter(&buf).WithFormat(FormatCSV).FormatGroups(groups); err != nil {
+		t.Fatalf("Failed to format groups: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Errorf("Expected 6 lines, got %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "Group,") {
+		t.Errorf("Expected CSV header to start with Group, got: %s", lines[0])
+	}
+}
@@ -0,0 +1,292 @@
+package readstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/filelock"
+	"github.com/google/go-github/v60/github"
+)
+
+// FileName is the name of the read state overlay in the cache directory
+const FileName = "read_state.json"
+
+// Entry is a thread the user wants treated as unread
+type Entry struct {
+	// ThreadID is the ID of the notification thread
+	ThreadID string `json:"thread_id"`
+	// Repository is the full name of the thread's repository
+	Repository string `json:"repository,omitempty"`
+	// UpdatedAt is when the thread was last updated when it was marked unread
+	UpdatedAt time.Time `json:"updated_at"`
+	// MarkedAt is when the thread was marked unread
+	MarkedAt time.Time `json:"marked_at"`
+}
+
+// Overlay records threads that are read on GitHub but should be treated as
+// unread locally. GitHub has no API to mark a thread unread, so the overlay
+// applies until the thread gets a newer update or is read again.
+type Overlay struct {
+	// path is the overlay file, empty for an in-memory overlay
+	path string
+
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+// NewOverlay creates an in-memory overlay
+func NewOverlay() *Overlay {
+	return &Overlay{entries: make(map[string]Entry)}
+}
+
+// Open loads the overlay from a file, creating it on the first change. The
+// file may be shared with other processes, such as a running watch, so
+// changes are made to its current content under its file lock.
+func Open(path string) (*Overlay, error) {
+	o := &Overlay{
+		path:    path,
+		entries: make(map[string]Entry),
+	}
+
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// MarkUnread records that a thread should be treated as unread until it is
+// updated after its current update time
+func (o *Overlay) MarkUnread(thread *github.Notification) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	entry := Entry{
+		ThreadID:   thread.GetID(),
+		Repository: thread.GetRepository().GetFullName(),
+		UpdatedAt:  thread.GetUpdatedAt().Time,
+		MarkedAt:   time.Now(),
+	}
+
+	return o.update(func() bool {
+		o.entries[entry.ThreadID] = entry
+		return true
+	})
+}
+
+// Clear removes a thread from the overlay
+func (o *Overlay) Clear(threadID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.update(func() bool {
+		if _, ok := o.entries[threadID]; !ok {
+			return false
+		}
+		delete(o.entries, threadID)
+		return true
+	})
+}
+
+// ClearRepository removes all threads of a repository from the overlay
+func (o *Overlay) ClearRepository(fullName string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.update(func() bool {
+		changed := false
+		for id, entry := range o.entries {
+			if entry.Repository == fullName {
+				delete(o.entries, id)
+				changed = true
+			}
+		}
+		return changed
+	})
+}
+
+// ClearAll removes all threads from the overlay
+func (o *Overlay) ClearAll() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.update(func() bool {
+		if len(o.entries) == 0 {
+			return false
+		}
+		o.entries = make(map[string]Entry)
+		return true
+	})
+}
+
+// Entries returns the threads in the overlay
+func (o *Overlay) Entries() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.refresh()
+	entries := make([]Entry, 0, len(o.entries))
+	for _, entry := range o.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// IsUnread reports whether a notification should be treated as unread
+func (o *Overlay) IsUnread(n *github.Notification) bool {
+	if n.GetUnread() {
+		return true
+	}
+
+	o.mu.RLock()
+	entry, ok := o.entries[n.GetID()]
+	o.mu.RUnlock()
+
+	return ok && !n.GetUpdatedAt().Time.After(entry.UpdatedAt)
+}
+
+// Apply marks the notifications in the overlay as unread and drops overlay
+// entries of threads that were updated since they were marked unread.
+// Threads marked unread by other processes since the overlay was opened are
+// included.
+func (o *Overlay) Apply(notifications []*github.Notification) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.update(func() bool {
+		changed := false
+		for _, n := range notifications {
+			entry, ok := o.entries[n.GetID()]
+			if !ok {
+				continue
+			}
+
+			// A newer update takes over from the overlay
+			if n.GetUpdatedAt().Time.After(entry.UpdatedAt) {
+				delete(o.entries, n.GetID())
+				changed = true
+				continue
+			}
+
+			n.Unread = github.Bool(true)
+		}
+		return changed
+	})
+}
+
+// update applies a change to the current content of the overlay's file
+// under its file lock, and saves the overlay when change reports that it
+// changed something. Callers must hold the write lock.
+func (o *Overlay) update(change func() bool) error {
+	if o.path == "" {
+		change()
+		return nil
+	}
+
+	lock, err := filelock.Acquire(o.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := o.load(); err != nil {
+		return err
+	}
+	if !change() {
+		return nil
+	}
+	return o.save()
+}
+
+// refresh reloads the overlay from its file, keeping the entries in memory
+// when it cannot be read. Callers must hold the write lock.
+func (o *Overlay) refresh() {
+	if o.path != "" {
+		_ = o.load()
+	}
+}
+
+// load replaces the entries with the content of the overlay's file. The
+// file is replaced atomically, so it can be read without the file lock.
+// Callers must hold the write lock.
+func (o *Overlay) load() error {
+	data, err := os.ReadFile(o.path)
+	if os.IsNotExist(err) {
+		o.entries = make(map[string]Entry)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read read state: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse read state: %w", err)
+	}
+	o.entries = make(map[string]Entry, len(entries))
+	for _, entry := range entries {
+		o.entries[entry.ThreadID] = entry
+	}
+	return nil
+}
+
+// save writes the overlay to its file. Callers must hold the write lock.
+func (o *Overlay) save() error {
+	if o.path == "" {
+		return nil
+	}
+
+	entries := make([]Entry, 0, len(o.entries))
+	for _, entry := range o.entries {
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode read state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(o.path), 0700); err != nil {
+		return fmt.Errorf("failed to create read state directory: %w", err)
+	}
+
+	tmpPath := o.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write read state: %w", err)
+	}
+	if err := os.Rename(tmpPath, o.path); err != nil {
+		return fmt.Errorf("failed to replace read state: %w", err)
+	}
+
+	return nil
+}
+
+// Singleton instance of Overlay
+var (
+	defaultOverlay     *Overlay
+	defaultOverlayOnce sync.Once
+)
+
+// Default returns the overlay used by the client, filters and UI
+func Default() *Overlay {
+	defaultOverlayOnce.Do(func() {
+		defaultOverlay = NewOverlay()
+	})
+	return defaultOverlay
+}
+
+// SetDefault replaces the overlay returned by Default, typically with one
+// opened from the cache directory
+func SetDefault(overlay *Overlay) {
+	defaultOverlayOnce.Do(func() {})
+	defaultOverlay = overlay
+}
+
+// IsUnread reports whether a notification should be treated as unread,
+// taking the default overlay into account
+func IsUnread(n *github.Notification) bool {
+	return Default().IsUnread(n)
+}
@@ -0,0 +1,138 @@
+package readstate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// thread creates a notification thread for tests
+func thread(id, repo string, unread bool, updatedAt time.Time) *github.Notification {
+	return &github.Notification{
+		ID:         github.String(id),
+		Repository: &github.Repository{FullName: github.String(repo)},
+		Unread:     github.Bool(unread),
+		UpdatedAt:  &github.Timestamp{Time: updatedAt},
+	}
+}
+
+func TestOverlayPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", FileName)
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	overlay, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := overlay.MarkUnread(thread("1", "owner/repo", false, updatedAt)); err != nil {
+		t.Fatalf("MarkUnread() error = %v", err)
+	}
+	if err := overlay.MarkUnread(thread("2", "owner/other", false, updatedAt)); err != nil {
+		t.Fatalf("MarkUnread() error = %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := len(reopened.Entries()); got != 2 {
+		t.Fatalf("got %d entries, want 2", got)
+	}
+
+	if !reopened.IsUnread(thread("1", "owner/repo", false, updatedAt)) {
+		t.Errorf("thread in overlay is not unread")
+	}
+	if reopened.IsUnread(thread("1", "owner/repo", false, updatedAt.Add(time.Minute))) {
+		t.Errorf("thread updated after it was marked unread is still unread")
+	}
+	if reopened.IsUnread(thread("3", "owner/repo", false, updatedAt)) {
+		t.Errorf("thread outside the overlay is unread")
+	}
+
+	if err := reopened.ClearRepository("owner/other"); err != nil {
+		t.Fatalf("ClearRepository() error = %v", err)
+	}
+	if err := reopened.Clear("1"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	cleared, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := len(cleared.Entries()); got != 0 {
+		t.Errorf("got %d entries after clearing, want 0", got)
+	}
+}
+
+func TestOverlayApply(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	overlay := NewOverlay()
+	overlay.MarkUnread(thread("1", "owner/repo", false, updatedAt))
+	overlay.MarkUnread(thread("2", "owner/repo", false, updatedAt))
+
+	kept := thread("1", "owner/repo", false, updatedAt)
+	updated := thread("2", "owner/repo", false, updatedAt.Add(time.Hour))
+	if err := overlay.Apply([]*github.Notification{kept, updated}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if !kept.GetUnread() {
+		t.Errorf("thread in overlay was not marked unread")
+	}
+	if updated.GetUnread() {
+		t.Errorf("updated thread was marked unread")
+	}
+
+	// The updated thread is dropped from the overlay
+	entries := overlay.Entries()
+	if len(entries) != 1 || entries[0].ThreadID != "1" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestOverlaySharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// A running watch and a mark-unread command from another shell
+	watch, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	shell, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if err := watch.MarkUnread(thread("1", "owner/repo", false, updatedAt)); err != nil {
+		t.Fatalf("MarkUnread() error = %v", err)
+	}
+	if err := shell.MarkUnread(thread("2", "owner/repo", false, updatedAt)); err != nil {
+		t.Fatalf("MarkUnread() error = %v", err)
+	}
+
+	// The watch applies the other shell's entry, and saving its own change
+	// keeps it
+	notifications := []*github.Notification{
+		thread("1", "owner/repo", false, updatedAt.Add(time.Hour)),
+		thread("2", "owner/repo", false, updatedAt),
+	}
+	if err := watch.Apply(notifications); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if notifications[0].GetUnread() || !notifications[1].GetUnread() {
+		t.Errorf("Apply() unread = %v, %v, want false, true", notifications[0].GetUnread(), notifications[1].GetUnread())
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if entries := reopened.Entries(); len(entries) != 1 || entries[0].ThreadID != "2" {
+		t.Errorf("Entries() = %+v, want only 2", entries)
+	}
+}
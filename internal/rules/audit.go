@@ -0,0 +1,175 @@
+package rules
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditFileName is the name of the rule audit log in the cache directory
+const AuditFileName = "rules_audit.jsonl"
+
+// auditMaxEntries is the number of entries kept when the audit log is opened
+const auditMaxEntries = 1000
+
+// AuditEntry records what a rule did to a notification
+type AuditEntry struct {
+	// Time is when the rule acted
+	Time time.Time `json:"time"`
+	// Rule is the name of the rule
+	Rule string `json:"rule"`
+	// Action is the action the rule performed
+	Action string `json:"action"`
+	// NotificationID is the notification the rule acted on
+	NotificationID string `json:"notification_id,omitempty"`
+	// Repository is the repository of the notification
+	Repository string `json:"repository,omitempty"`
+	// Title is the title of the notification subject
+	Title string `json:"title,omitempty"`
+	// Success is whether the action succeeded
+	Success bool `json:"success"`
+	// Error describes why the action failed
+	Error string `json:"error,omitempty"`
+}
+
+// AuditLog is an append-only log of the actions performed by rules
+type AuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// OpenAuditLog opens the audit log at path, trimming it to the most recent
+// entries when it grew too long
+func OpenAuditLog(path string) (*AuditLog, error) {
+	log := &AuditLog{path: path}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	entries, err := log.read()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > auditMaxEntries {
+		if err := log.rewrite(entries[len(entries)-auditMaxEntries:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return log, nil
+}
+
+// Append adds entries to the audit log
+func (l *AuditLog) Append(entries []AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return file.Close()
+}
+
+// Entries returns the last n entries, most recent first. n <= 0 returns all entries.
+func (l *AuditLog) Entries(n int) ([]AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// read returns the entries of the audit log in the order they were written
+func (l *AuditLog) read() ([]AuditEntry, error) {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Skip lines that cannot be parsed, such as a write torn by a crash
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// rewrite replaces the audit log with the given entries
+func (l *AuditLog) rewrite(entries []AuditEntry) error {
+	tmpPath := l.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return fmt.Errorf("failed to replace audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/actions"
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/filter/persistent"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/google/go-github/v60/github"
+)
+
+// Match is a notification matched by a rule
+type Match struct {
+	// Rule is the first rule that matched the notification
+	Rule *Rule
+	// Notification is the matched notification
+	Notification *github.Notification
+}
+
+// Result is the outcome of a rule acting on a notification
+type Result struct {
+	Match
+	// Success is whether the action succeeded, always true in a dry run
+	Success bool
+	// Error describes why the action failed
+	Error error
+}
+
+// Report describes what the rules did, or would do in a dry run
+type Report struct {
+	// DryRun is whether the actions were only planned
+	DryRun bool
+	// Results holds a result per matched notification
+	Results []Result
+}
+
+// FailureCount returns the number of actions that failed
+func (r *Report) FailureCount() int {
+	count := 0
+	for _, result := range r.Results {
+		if !result.Success {
+			count++
+		}
+	}
+	return count
+}
+
+// Remaining returns the notifications that were not read, archived, muted
+// or snoozed by a rule
+func (r *Report) Remaining(notifications []*github.Notification) []*github.Notification {
+	if r == nil || r.DryRun || len(r.Results) == 0 {
+		return notifications
+	}
+
+	handled := make(map[string]bool, len(r.Results))
+	for _, result := range r.Results {
+		if result.Success && result.Rule.Action != ActionUnsubscribe {
+			handled[result.Notification.GetID()] = true
+		}
+	}
+
+	remaining := make([]*github.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		if !handled[n.GetID()] {
+			remaining = append(remaining, n)
+		}
+	}
+	return remaining
+}
+
+// Engine applies triage rules to notifications
+type Engine struct {
+	// configs are the rule configurations, compiled on every run so relative
+	// times in expressions stay current
+	configs []config.RuleConfig
+	// parser parses the rule expressions
+	parser *persistent.Parser
+	// audit records the actions performed, nil to disable auditing
+	audit *AuditLog
+	// options are the batch options used to perform the actions
+	options *actions.BatchOptions
+	// now returns the current time
+	now func() time.Time
+
+	// mu protects unsubscribed
+	mu sync.Mutex
+	// unsubscribed maps the threads the rules unsubscribed from in this
+	// process to when they did, for engines without an audit log
+	unsubscribed map[string]time.Time
+}
+
+// NewEngine creates a rule engine, checking that all rules compile
+func NewEngine(configs []config.RuleConfig, parser *persistent.Parser) (*Engine, error) {
+	if parser == nil {
+		parser = persistent.NewParser(nil)
+	}
+
+	e := &Engine{
+		configs:      configs,
+		parser:       parser,
+		options:      actions.DefaultBatchOptions(),
+		now:          time.Now,
+		unsubscribed: make(map[string]time.Time),
+	}
+
+	if _, err := e.Rules(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// WithAuditLog sets the audit log that records the actions performed
+func (e *Engine) WithAuditLog(log *AuditLog) *Engine {
+	e.audit = log
+	return e
+}
+
+// WithBatchOptions sets the batch options used to perform the actions
+func (e *Engine) WithBatchOptions(options *actions.BatchOptions) *Engine {
+	if options != nil {
+		e.options = options
+	}
+	return e
+}
+
+// Rules returns the enabled rules
+func (e *Engine) Rules() ([]*Rule, error) {
+	return Compile(e.configs, e.parser, e.now())
+}
+
+// Plan returns the notifications matched by the rules. Each notification is
+// matched by the first rule that applies to it. Unsubscribing leaves a
+// thread unread, so threads a rule unsubscribed from are skipped until they
+// have new activity.
+func (e *Engine) Plan(notifications []*github.Notification) ([]Match, error) {
+	rules, err := e.Rules()
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	unsubscribed, err := e.unsubscribedThreads()
+	if err != nil {
+		return nil, err
+	}
+
+	now := e.now()
+	var matches []Match
+	for _, n := range notifications {
+		if at, ok := unsubscribed[n.GetID()]; ok && !n.GetUpdatedAt().Time.After(at) {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Matches(n, now) {
+				matches = append(matches, Match{Rule: rule, Notification: n})
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// DryRun reports what the rules would do without performing any action
+func (e *Engine) DryRun(notifications []*github.Notification) (*Report, error) {
+	matches, err := e.Plan(notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: true}
+	for _, match := range matches {
+		report.Results = append(report.Results, Result{Match: match, Success: true})
+	}
+	return report, nil
+}
+
+// Apply performs the actions of the rules on the matching notifications
+// and records them in the audit log
+func (e *Engine) Apply(ctx context.Context, notifications []*github.Notification) (*Report, error) {
+	matches, err := e.Plan(notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Results: make([]Result, len(matches))}
+	if len(matches) == 0 {
+		return report, nil
+	}
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, e.options.Timeout)
+	defer cancel()
+
+	processor := actions.NewBatchProcessor(ctx, e.options)
+
+	// Repositories are muted once, however many of their notifications
+	// match, on behalf of the first rule that matched
+	type mute struct{ host, repo string }
+	mutes := make(map[mute][]int)
+	var muteOrder []mute
+
+	for i, match := range matches {
+		// Tasks that never run because the batch was canceled keep this error
+		report.Results[i] = Result{Match: match, Error: actions.ErrOperationCanceled}
+
+		if match.Rule.Action == ActionMute {
+			key := mute{
+				host: githubclient.NotificationHost(match.Notification),
+				repo: match.Notification.GetRepository().GetFullName(),
+			}
+			if _, ok := mutes[key]; !ok {
+				muteOrder = append(muteOrder, key)
+			}
+			mutes[key] = append(mutes[key], i)
+			continue
+		}
+
+		processor.AddTask(func() (actions.Action, error) {
+			action, err := perform(ctx, match)
+			report.Results[i].Success = err == nil
+			report.Results[i].Error = err
+			return action, err
+		})
+	}
+
+	for _, key := range muteOrder {
+		indices := mutes[key]
+		processor.AddTask(func() (actions.Action, error) {
+			ctx := actions.WithRule(actions.WithHost(ctx, key.host), matches[indices[0]].Rule.Name)
+			action, err := muteRepository(ctx, key.repo)
+			for _, i := range indices {
+				report.Results[i].Success = err == nil
+				report.Results[i].Error = err
+			}
+			return action, err
+		})
+	}
+
+	processor.Process()
+
+	now := e.now()
+	e.mu.Lock()
+	for _, result := range report.Results {
+		if result.Success && result.Rule.Action == ActionUnsubscribe {
+			e.unsubscribed[result.Notification.GetID()] = now
+		}
+	}
+	e.mu.Unlock()
+
+	if e.audit != nil {
+		if err := e.audit.Append(auditEntries(report, e.now())); err != nil {
+			return report, fmt.Errorf("failed to record rule actions: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// unsubscribedThreads returns when the rules last unsubscribed from each
+// thread, in this process or, from the audit log, in earlier runs
+func (e *Engine) unsubscribedThreads() (map[string]time.Time, error) {
+	e.mu.Lock()
+	threads := make(map[string]time.Time, len(e.unsubscribed))
+	for id, at := range e.unsubscribed {
+		threads[id] = at
+	}
+	e.mu.Unlock()
+
+	if e.audit == nil {
+		return threads, nil
+	}
+	entries, err := e.audit.Entries(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule audit log: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.Success && entry.Action == ActionUnsubscribe && entry.Time.After(threads[entry.NotificationID]) {
+			threads[entry.NotificationID] = entry.Time
+		}
+	}
+	return threads, nil
+}
+
+// perform runs the action of the rule that matched a notification
+func perform(ctx context.Context, match Match) (actions.Action, error) {
+	id := match.Notification.GetID()
+
+	// Notifications of a merged inbox are acted on at their own host, and
+	// the history tells the rule's actions from the user's. The history
+	// describes the notification from the one fetched, saving an API call
+	// per action.
+	ctx = actions.WithHost(ctx, githubclient.NotificationHost(match.Notification))
+	ctx = actions.WithRule(ctx, match.Rule.Name)
+	ctx = actions.WithNotifications(ctx, match.Notification)
+
+	var result *actions.ActionResult
+	var err error
+	switch match.Rule.Action {
+	case ActionRead:
+		result, err = actions.MarkAsRead(ctx, id)
+	case ActionArchive:
+		result, err = actions.ArchiveNotification(ctx, id)
+	case ActionUnsubscribe:
+		result, err = actions.UnsubscribeFromThread(ctx, id)
+	case ActionSnooze:
+		result, err = actions.SnoozeNotification(ctx, id, match.Rule.Until)
+	default:
+		err = fmt.Errorf("unknown rule action %q", match.Rule.Action)
+	}
+
+	if result != nil {
+		return result.Action, err
+	}
+	return actions.Action{NotificationID: id, Timestamp: time.Now(), Error: err}, err
+}
+
+// muteRepository mutes a repository matched by a rule
+func muteRepository(ctx context.Context, repo string) (actions.Action, error) {
+	result, err := actions.MuteRepository(ctx, repo)
+	if result != nil {
+		return result.Action, err
+	}
+	return actions.Action{Type: actions.ActionMute, RepositoryName: repo, Timestamp: time.Now(), Error: err}, err
+}
+
+// auditEntries converts the results of a report into audit log entries
+func auditEntries(report *Report, now time.Time) []AuditEntry {
+	entries := make([]AuditEntry, 0, len(report.Results))
+	for _, result := range report.Results {
+		entry := AuditEntry{
+			Time:           now,
+			Rule:           result.Rule.Name,
+			Action:         result.Rule.Action,
+			NotificationID: result.Notification.GetID(),
+			Repository:     result.Notification.GetRepository().GetFullName(),
+			Title:          result.Notification.GetSubject().GetTitle(),
+			Success:        result.Success,
+		}
+		if result.Error != nil {
+			entry.Error = result.Error.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
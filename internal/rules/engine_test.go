@@ -0,0 +1,219 @@
+package rules
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/actions"
+	"github.com/SharanRP/gh-notif/internal/config"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/google/go-github/v60/github"
+)
+
+// useStandIn routes the actions of the engine to a test server
+func useStandIn(t *testing.T, handler http.Handler) {
+	client, server, err := githubclient.NewTestClient(context.Background(), handler)
+	if err != nil {
+		t.Fatalf("failed to create test client: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	originalGetClient := actions.GetClient
+	actions.GetClient = func(ctx context.Context) (*githubclient.Client, error) {
+		return client, nil
+	}
+
+	originalHistory := actions.GetActionHistory()
+	actions.SetActionHistory(actions.NewActionHistory(10))
+
+	originalStore := snooze.Default()
+	snooze.SetDefault(snooze.NewStore())
+
+	t.Cleanup(func() {
+		actions.GetClient = originalGetClient
+		actions.SetActionHistory(originalHistory)
+		snooze.SetDefault(originalStore)
+	})
+}
+
+func TestEngineApply(t *testing.T) {
+	var mu sync.Mutex
+	var writes []string
+	useStandIn(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			id := strings.TrimPrefix(r.URL.Path, "/notifications/threads/")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": "` + id + `", "unread": true, "updated_at": "2024-01-02T00:00:00Z",
+				"repository": {"full_name": "owner/repo"}, "subject": {"title": "Title ` + id + `"}}`))
+			return
+		}
+		mu.Lock()
+		writes = append(writes, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/2") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusResetContent)
+	}))
+
+	engine, err := NewEngine([]config.RuleConfig{
+		{Name: "ci", When: "reason:ci_activity", Then: ActionRead},
+		{Name: "later", When: "repo:owner/roadmap", Then: ActionSnooze},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), AuditFileName))
+	if err != nil {
+		t.Fatalf("OpenAuditLog() error = %v", err)
+	}
+	engine.WithAuditLog(audit)
+
+	now := time.Now()
+	notifications := []*github.Notification{
+		testNotification("1", "owner/repo", "ci_activity", now),
+		testNotification("2", "owner/repo", "ci_activity", now),
+		testNotification("3", "owner/roadmap", "mention", now),
+		testNotification("4", "owner/repo", "mention", now),
+	}
+
+	// A dry run plans the actions without performing them
+	dryRun, err := engine.DryRun(notifications)
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+	if len(dryRun.Results) != 3 || len(writes) != 0 {
+		t.Fatalf("DryRun() planned %d actions and made %d writes, want 3 and 0", len(dryRun.Results), len(writes))
+	}
+	if remaining := dryRun.Remaining(notifications); len(remaining) != len(notifications) {
+		t.Errorf("dry run removed notifications: %d remaining", len(remaining))
+	}
+
+	report, err := engine.Apply(context.Background(), notifications)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if report.FailureCount() != 1 {
+		t.Errorf("FailureCount() = %d, want 1", report.FailureCount())
+	}
+	if _, ok := snooze.Default().Entry("3"); !ok {
+		t.Errorf("notification 3 was not snoozed")
+	}
+
+	remaining := report.Remaining(notifications)
+	var ids []string
+	for _, n := range remaining {
+		ids = append(ids, n.GetID())
+	}
+	if strings.Join(ids, ",") != "2,4" {
+		t.Errorf("Remaining() = %v, want the failed and unmatched notifications 2,4", ids)
+	}
+
+	entries, err := audit.Entries(0)
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("audit log has %d entries, want 3", len(entries))
+	}
+	failed := 0
+	for _, entry := range entries {
+		if !entry.Success {
+			failed++
+			if entry.NotificationID != "2" || entry.Error == "" {
+				t.Errorf("unexpected failed audit entry: %+v", entry)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("audit log has %d failed entries, want 1", failed)
+	}
+}
+
+func TestAuditLogTrim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), AuditFileName)
+	audit, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatalf("OpenAuditLog() error = %v", err)
+	}
+
+	entries := make([]AuditEntry, auditMaxEntries+5)
+	for i := range entries {
+		entries[i] = AuditEntry{Rule: "r", Action: ActionRead, NotificationID: string(rune('a' + i%26)), Success: true}
+	}
+	if err := audit.Append(entries); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	reopened, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatalf("OpenAuditLog() error = %v", err)
+	}
+	all, err := reopened.Entries(0)
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(all) != auditMaxEntries {
+		t.Errorf("reopened audit log has %d entries, want %d", len(all), auditMaxEntries)
+	}
+	if latest, _ := reopened.Entries(1); len(latest) != 1 || latest[0].NotificationID != entries[len(entries)-1].NotificationID {
+		t.Errorf("Entries(1) = %+v, want the most recent entry", latest)
+	}
+}
+
+func TestEngineSkipsUnsubscribed(t *testing.T) {
+	useStandIn(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": "1", "unread": true}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	configs := []config.RuleConfig{{Name: "bots", When: "reason:subscribed", Then: ActionUnsubscribe}}
+	engine, err := NewEngine(configs, nil)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), AuditFileName))
+	if err != nil {
+		t.Fatalf("OpenAuditLog() error = %v", err)
+	}
+	engine.WithAuditLog(audit)
+
+	updatedAt := time.Now().Add(-time.Hour)
+	notifications := []*github.Notification{testNotification("1", "owner/repo", "subscribed", updatedAt)}
+
+	report, err := engine.Apply(context.Background(), notifications)
+	if err != nil || len(report.Results) != 1 || report.FailureCount() != 0 {
+		t.Fatalf("Apply() = %+v, %v, want one unsubscribe", report, err)
+	}
+
+	// The thread stays unread, but is not unsubscribed from again, neither
+	// by this engine nor by one of a later run sharing the audit log
+	again, err := engine.Apply(context.Background(), notifications)
+	if err != nil || len(again.Results) != 0 {
+		t.Errorf("Apply() again = %+v, %v, want nothing to do", again, err)
+	}
+	later, err := NewEngine(configs, nil)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	if matches, err := later.WithAuditLog(audit).Plan(notifications); err != nil || len(matches) != 0 {
+		t.Errorf("Plan() of a later run = %v, %v, want nothing to do", matches, err)
+	}
+
+	// New activity makes the rule apply again
+	notifications[0] = testNotification("1", "owner/repo", "subscribed", time.Now().Add(time.Hour))
+	if matches, err := later.Plan(notifications); err != nil || len(matches) != 1 {
+		t.Errorf("Plan() after new activity = %v, %v, want the thread matched", matches, err)
+	}
+}
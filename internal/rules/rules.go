@@ -0,0 +1,129 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/SharanRP/gh-notif/internal/filter/persistent"
+	"github.com/SharanRP/gh-notif/internal/readstate"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/google/go-github/v60/github"
+)
+
+// Rule actions
+const (
+	// ActionRead marks matching notifications as read
+	ActionRead = "read"
+	// ActionArchive archives matching notifications
+	ActionArchive = "archive"
+	// ActionUnsubscribe unsubscribes from the threads of matching notifications
+	ActionUnsubscribe = "unsubscribe"
+	// ActionMute mutes the repositories of matching notifications
+	ActionMute = "mute"
+	// ActionSnooze snoozes matching notifications
+	ActionSnooze = "snooze"
+)
+
+// Rule is a compiled triage rule
+type Rule struct {
+	// Name identifies the rule in reports and the audit log
+	Name string
+	// Expression is the filter expression of the rule
+	Expression string
+	// OlderThan only matches notifications not updated for this long, 0 for any age
+	OlderThan time.Duration
+	// Action is the action performed on matching notifications
+	Action string
+	// Until is when snoozed notifications resurface, zero for new activity only
+	Until time.Time
+
+	// filter matches the notifications of the rule
+	filter filter.Filter
+}
+
+// Compile parses rule configurations into rules. Disabled rules are skipped
+// and unnamed rules are named after their position.
+func Compile(configs []config.RuleConfig, parser *persistent.Parser, now time.Time) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(configs))
+	for i, cfg := range configs {
+		if cfg.Disabled {
+			continue
+		}
+
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+
+		rule, err := compileRule(cfg, parser, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %w", cfg.Name, err)
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compileRule parses a single rule configuration
+func compileRule(cfg config.RuleConfig, parser *persistent.Parser, now time.Time) (*Rule, error) {
+	rule := &Rule{
+		Name:       cfg.Name,
+		Expression: cfg.When,
+		Action:     cfg.Then,
+	}
+
+	switch cfg.Then {
+	case ActionRead, ActionArchive, ActionUnsubscribe, ActionMute:
+	case ActionSnooze:
+		until := cfg.Until
+		if until == "" {
+			until = snooze.NextActivity
+		}
+		var err error
+		if rule.Until, err = snooze.ParseUntil(until, now); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q", cfg.Then)
+	}
+
+	if cfg.OlderThan != "" {
+		olderThan, err := persistent.ParseDuration(cfg.OlderThan)
+		if err != nil || olderThan < 0 {
+			return nil, fmt.Errorf("invalid older_than %q", cfg.OlderThan)
+		}
+		rule.OlderThan = olderThan
+	}
+
+	f, err := parser.Parse(cfg.When)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", cfg.When, err)
+	}
+	rule.filter = f
+
+	return rule, nil
+}
+
+// Matches reports whether the rule applies to a notification. Rules only act
+// on unread notifications; threads that stay unread after a rule acted on
+// them, as unsubscribed ones do, are skipped by Engine.Plan.
+func (r *Rule) Matches(n *github.Notification, now time.Time) bool {
+	if !readstate.IsUnread(n) {
+		return false
+	}
+	if r.OlderThan > 0 && n.GetUpdatedAt().Time.After(now.Add(-r.OlderThan)) {
+		return false
+	}
+	return r.filter.Apply(n)
+}
+
+// Description returns a human-readable description of the rule
+func (r *Rule) Description() string {
+	description := fmt.Sprintf("%s when %s", r.Action, r.Expression)
+	if r.OlderThan > 0 {
+		description += fmt.Sprintf(" older than %s", r.OlderThan)
+	}
+	return description
+}
@@ -0,0 +1,93 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/filter/persistent"
+	"github.com/google/go-github/v60/github"
+)
+
+func testNotification(id, repo, reason string, updatedAt time.Time) *github.Notification {
+	return &github.Notification{
+		ID:         github.String(id),
+		Reason:     github.String(reason),
+		Unread:     github.Bool(true),
+		UpdatedAt:  &github.Timestamp{Time: updatedAt},
+		Repository: &github.Repository{FullName: github.String(repo)},
+		Subject:    &github.NotificationSubject{Title: github.String("Title " + id), Type: github.String("Issue")},
+	}
+}
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.Local)
+
+	rules, err := Compile([]config.RuleConfig{
+		{When: "reason:ci_activity", Then: ActionRead, OlderThan: "1d"},
+		{Name: "off", When: "reason:mention", Then: ActionArchive, Disabled: true},
+		{Name: "later", When: "repo:owner/roadmap", Then: ActionSnooze, Until: "2h"},
+		{Name: "forever", When: "repo:owner/quiet", Then: ActionSnooze},
+	}, persistent.NewParser(nil), now)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	if len(rules) != 3 {
+		t.Fatalf("Compile() returned %d rules, want 3 without the disabled rule", len(rules))
+	}
+	if rules[0].Name != "rule-1" || rules[0].OlderThan != 24*time.Hour {
+		t.Errorf("unnamed rule = %+v", rules[0])
+	}
+	if !rules[1].Until.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("snooze until = %v, want %v", rules[1].Until, now.Add(2*time.Hour))
+	}
+	if !rules[2].Until.IsZero() {
+		t.Errorf("snooze without until = %v, want next activity", rules[2].Until)
+	}
+
+	invalid := []config.RuleConfig{
+		{Name: "bad-action", When: "reason:mention", Then: "delete"},
+		{Name: "bad-age", When: "reason:mention", Then: ActionRead, OlderThan: "soon"},
+		{Name: "bad-until", When: "reason:mention", Then: ActionSnooze, Until: "never"},
+		{Name: "bad-expression", When: "reason:mention AND (", Then: ActionRead},
+	}
+	for _, cfg := range invalid {
+		_, err := Compile([]config.RuleConfig{cfg}, persistent.NewParser(nil), now)
+		if err == nil || !strings.Contains(err.Error(), cfg.Name) {
+			t.Errorf("Compile(%s) error = %v, want an error naming the rule", cfg.Name, err)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	now := time.Now()
+
+	rules, err := Compile([]config.RuleConfig{
+		{Name: "ci", When: "reason:ci_activity", Then: ActionRead, OlderThan: "1d"},
+	}, persistent.NewParser(nil), now)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	rule := rules[0]
+
+	old := testNotification("1", "owner/repo", "ci_activity", now.Add(-48*time.Hour))
+	recent := testNotification("2", "owner/repo", "ci_activity", now.Add(-time.Hour))
+	other := testNotification("3", "owner/repo", "mention", now.Add(-48*time.Hour))
+	read := testNotification("4", "owner/repo", "ci_activity", now.Add(-48*time.Hour))
+	read.Unread = github.Bool(false)
+
+	if !rule.Matches(old, now) {
+		t.Errorf("rule does not match an old notification")
+	}
+	if rule.Matches(recent, now) {
+		t.Errorf("rule matches a notification younger than older_than")
+	}
+	if rule.Matches(other, now) {
+		t.Errorf("rule matches a notification its expression excludes")
+	}
+	if rule.Matches(read, now) {
+		t.Errorf("rule matches a read notification")
+	}
+}
@@ -0,0 +1,98 @@
+package scoring
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// FactorScore explains how a factor contributed to a notification's score
+type FactorScore struct {
+	// Factor is the name of the factor, such as age or involvement
+	Factor string `json:"factor"`
+	// Raw is the value of the factor before weighting (0-1)
+	Raw float64 `json:"raw"`
+	// Weight is the weight of the factor
+	Weight float64 `json:"weight"`
+	// Contribution is the number of points the factor adds to the total
+	Contribution float64 `json:"contribution"`
+	// Detail describes what the raw value is based on
+	Detail string `json:"detail,omitempty"`
+}
+
+// factorScores returns the raw value, weight and detail of each factor, in
+// the order they are shown
+func (s *Scorer) factorScores(n *github.Notification) []FactorScore {
+	return []FactorScore{
+		{Factor: "age", Raw: s.ageValue(n), Weight: s.Factors.AgeWeight, Detail: describeAge(n)},
+		{Factor: "activity", Raw: s.activityValue(n), Weight: s.Factors.ActivityWeight, Detail: s.describeActivity(n)},
+		{Factor: "involvement", Raw: s.involvementValue(n), Weight: s.Factors.InvolvementWeight, Detail: s.describeInvolvement(n)},
+		{Factor: "type", Raw: s.typeValue(n), Weight: s.Factors.TypeWeight, Detail: n.GetSubject().GetType()},
+		{Factor: "reason", Raw: s.reasonValue(n), Weight: s.Factors.ReasonWeight, Detail: n.GetReason()},
+		{Factor: "repository", Raw: s.repoValue(n), Weight: s.Factors.RepoWeight, Detail: s.describeRepo(n)},
+	}
+}
+
+// describeAge describes how long ago a notification was updated
+func describeAge(n *github.Notification) string {
+	age := time.Since(n.GetUpdatedAt().Time)
+	switch {
+	case age < time.Minute:
+		return "updated just now"
+	case age < time.Hour:
+		return fmt.Sprintf("updated %d minutes ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("updated %d hours ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("updated %d days ago", int(age.Hours()/24))
+	}
+}
+
+// describeActivity describes the activity on a notification's subject
+func (s *Scorer) describeActivity(n *github.Notification) string {
+	sig := s.signalsFor(n)
+	if !sig.known {
+		return "subject details unknown"
+	}
+	return fmt.Sprintf("%d comments, %d reactions", sig.commentCount, sig.reactionCount)
+}
+
+// describeInvolvement describes how the user is involved in a notification's subject
+func (s *Scorer) describeInvolvement(n *github.Notification) string {
+	author, assignee, reviewer, mentioned := s.involvement(n)
+
+	var kinds []string
+	for _, kind := range []struct {
+		involved bool
+		name     string
+	}{
+		{author, "author"},
+		{assignee, "assignee"},
+		{reviewer, "requested reviewer"},
+		{mentioned, "mentioned"},
+	} {
+		if kind.involved {
+			kinds = append(kinds, kind.name)
+		}
+	}
+	if len(kinds) == 0 {
+		return "not involved"
+	}
+
+	description := strings.Join(kinds, ", ")
+	if sig := s.signalsFor(n); !sig.known || s.Username == "" {
+		description += " (from the reason)"
+	}
+	return description
+}
+
+// describeRepo describes the repository of a notification
+func (s *Scorer) describeRepo(n *github.Notification) string {
+	repo := n.GetRepository().GetFullName()
+	if _, ok := s.Factors.CustomRepoWeights[repo]; ok {
+		return repo + " (custom weight)"
+	}
+	return repo
+}
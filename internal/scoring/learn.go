@@ -0,0 +1,381 @@
+package scoring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// ModelFileName is the name of the learned weights in the cache directory
+const ModelFileName = "score_model.json"
+
+// MinExamples is the number of examples needed to learn weights
+const MinExamples = 10
+
+// ErrNotEnoughExamples is returned when there are too few examples to learn from
+var ErrNotEnoughExamples = errors.New("not enough actions to learn from")
+
+// Training settings. The regularization keeps the weights close to the
+// defaults when there are few examples.
+const (
+	trainEpochs       = 500
+	trainLearningRate = 0.5
+	trainRegularize   = 0.05
+)
+
+// factorNames are the factors in the order they are shown
+var factorNames = []string{"age", "activity", "involvement", "type", "reason", "repository"}
+
+// factorWeight returns the weight of a factor
+func factorWeight(f *ScoreFactors, factor string) *float64 {
+	switch factor {
+	case "age":
+		return &f.AgeWeight
+	case "activity":
+		return &f.ActivityWeight
+	case "involvement":
+		return &f.InvolvementWeight
+	case "type":
+		return &f.TypeWeight
+	case "reason":
+		return &f.ReasonWeight
+	case "repository":
+		return &f.RepoWeight
+	default:
+		return nil
+	}
+}
+
+// Features returns the raw factor values of a notification, which the
+// weights are learned from
+func (s *Scorer) Features(n *github.Notification) map[string]float64 {
+	features := make(map[string]float64, len(factorNames))
+	for _, factor := range s.factorScores(n) {
+		features[factor.Factor] = factor.Raw
+	}
+	return features
+}
+
+// Example is an action on a notification to learn from
+type Example struct {
+	// Features are the raw factor values of the notification when the action was taken
+	Features map[string]float64
+	// Engaged is true when the user read the notification soon after it was
+	// updated, and false when they dismissed it unread
+	Engaged bool
+}
+
+// TrainingData are the examples learned from the action history
+type TrainingData struct {
+	// Examples are the notifications the user engaged with or dismissed
+	Examples []Example
+	// Muted maps repositories to whether they are muted
+	Muted map[string]bool
+}
+
+// LearnedWeights are the weights learned from a user's actions
+type LearnedWeights struct {
+	// Weights are the learned weights of the factors
+	Weights map[string]float64 `json:"weights"`
+	// RepoWeights are the learned custom repository weights
+	RepoWeights map[string]float64 `json:"repo_weights,omitempty"`
+	// Engaged and Dismissed are the numbers of examples of each kind
+	Engaged   int `json:"engaged"`
+	Dismissed int `json:"dismissed"`
+	// TrainedAt is when the weights were learned
+	TrainedAt time.Time `json:"trained_at"`
+}
+
+// Apply returns a copy of factors with the learned weights
+func (w *LearnedWeights) Apply(factors *ScoreFactors) *ScoreFactors {
+	learned := *factors
+	learned.CustomRepoWeights = make(map[string]float64, len(factors.CustomRepoWeights))
+	for repo, weight := range factors.CustomRepoWeights {
+		learned.CustomRepoWeights[repo] = weight
+	}
+	if w == nil {
+		return &learned
+	}
+
+	for factor, weight := range w.Weights {
+		if field := factorWeight(&learned, factor); field != nil {
+			*field = weight
+		}
+	}
+	for repo, weight := range w.RepoWeights {
+		learned.CustomRepoWeights[repo] = weight
+	}
+	return &learned
+}
+
+// Train learns factor weights from the examples with logistic regression,
+// starting from the weights of factors. The learned weights are scaled to the
+// same total as the starting weights, so scores stay between 0 and 100.
+func Train(data TrainingData, factors *ScoreFactors) (*LearnedWeights, error) {
+	if len(data.Examples) < MinExamples {
+		return nil, fmt.Errorf("%w: have %d, need at least %d", ErrNotEnoughExamples, len(data.Examples), MinExamples)
+	}
+
+	learned := &LearnedWeights{
+		Weights:   make(map[string]float64, len(factorNames)),
+		TrainedAt: time.Now(),
+	}
+	for _, example := range data.Examples {
+		if example.Engaged {
+			learned.Engaged++
+		} else {
+			learned.Dismissed++
+		}
+	}
+	if learned.Engaged == 0 || learned.Dismissed == 0 {
+		return nil, fmt.Errorf("%w: need both read and dismissed notifications", ErrNotEnoughExamples)
+	}
+
+	initial := make([]float64, len(factorNames))
+	var total float64
+	for i, factor := range factorNames {
+		initial[i] = *factorWeight(factors, factor)
+		total += initial[i]
+	}
+
+	// Factor weights are small, so the regression scales them up to be able
+	// to separate the examples
+	x := make([][]float64, len(data.Examples))
+	y := make([]float64, len(data.Examples))
+	var mean float64
+	for i, example := range data.Examples {
+		x[i] = make([]float64, len(factorNames))
+		for j, factor := range factorNames {
+			x[i][j] = example.Features[factor]
+			mean += initial[j] * x[i][j]
+		}
+		if example.Engaged {
+			y[i] = 1
+		}
+	}
+	mean /= float64(len(data.Examples))
+
+	const scale = 10.0
+	w := append([]float64(nil), initial...)
+	bias := -scale * mean
+	n := float64(len(data.Examples))
+	for epoch := 0; epoch < trainEpochs; epoch++ {
+		gradW := make([]float64, len(w))
+		var gradBias float64
+		for i := range x {
+			z := bias
+			for j := range w {
+				z += scale * w[j] * x[i][j]
+			}
+			diff := sigmoid(z) - y[i]
+			for j := range w {
+				gradW[j] += diff * scale * x[i][j] / n
+			}
+			gradBias += diff / n
+		}
+		for j := range w {
+			w[j] -= trainLearningRate * (gradW[j] + trainRegularize*(w[j]-initial[j]))
+			w[j] = math.Max(0, w[j])
+		}
+		bias -= trainLearningRate * gradBias
+	}
+
+	// Keep the scores on the same scale as before
+	var learnedTotal float64
+	for _, weight := range w {
+		learnedTotal += weight
+	}
+	for j, factor := range factorNames {
+		weight := initial[j]
+		if learnedTotal > 0 {
+			weight = w[j] * total / learnedTotal
+		}
+		learned.Weights[factor] = math.Round(weight*1000) / 1000
+	}
+
+	// Muted repositories score lowest
+	for repo, muted := range data.Muted {
+		if muted {
+			if learned.RepoWeights == nil {
+				learned.RepoWeights = make(map[string]float64)
+			}
+			learned.RepoWeights[repo] = 0
+		}
+	}
+
+	return learned, nil
+}
+
+// sigmoid is the logistic function
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+// WeightChange is the change of a factor's weight by training or resetting
+type WeightChange struct {
+	Factor string  `json:"factor"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// CompareWeights returns how the weights of each factor changed
+func CompareWeights(before, after *ScoreFactors) []WeightChange {
+	changes := make([]WeightChange, 0, len(factorNames))
+	for _, factor := range factorNames {
+		changes = append(changes, WeightChange{
+			Factor: factor,
+			Before: *factorWeight(before, factor),
+			After:  *factorWeight(after, factor),
+		})
+	}
+
+	// Repositories whose weight changed are listed after the factors
+	repos := make(map[string]bool)
+	for repo := range before.CustomRepoWeights {
+		repos[repo] = true
+	}
+	for repo := range after.CustomRepoWeights {
+		repos[repo] = true
+	}
+	names := make([]string, 0, len(repos))
+	for repo := range repos {
+		names = append(names, repo)
+	}
+	sort.Strings(names)
+	for _, repo := range names {
+		b, ok := before.CustomRepoWeights[repo]
+		if !ok {
+			b = 0.5
+		}
+		a, ok := after.CustomRepoWeights[repo]
+		if !ok {
+			a = 0.5
+		}
+		if a != b {
+			changes = append(changes, WeightChange{Factor: "repository " + repo, Before: b, After: a})
+		}
+	}
+	return changes
+}
+
+// Model keeps the learned weights of a user
+type Model struct {
+	// path is the model file, empty for an in-memory model
+	path string
+
+	mu      sync.RWMutex
+	learned *LearnedWeights
+}
+
+// NewModel creates an in-memory model without learned weights
+func NewModel() *Model {
+	return &Model{}
+}
+
+// OpenModel loads the learned weights from a file, creating it when weights are learned
+func OpenModel(path string) (*Model, error) {
+	m := NewModel()
+	m.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read learned weights: %w", err)
+	}
+
+	var learned LearnedWeights
+	if err := json.Unmarshal(data, &learned); err != nil {
+		return nil, fmt.Errorf("failed to parse learned weights: %w", err)
+	}
+	m.learned = &learned
+
+	return m, nil
+}
+
+// Learned returns the learned weights, or nil if none were learned
+func (m *Model) Learned() *LearnedWeights {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.learned
+}
+
+// Factors returns the default factors with the learned weights
+func (m *Model) Factors() *ScoreFactors {
+	return m.Learned().Apply(DefaultScoreFactors())
+}
+
+// Set replaces the learned weights
+func (m *Model) Set(learned *LearnedWeights) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.learned = learned
+	if m.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(learned, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode learned weights: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0700); err != nil {
+		return fmt.Errorf("failed to create model directory: %w", err)
+	}
+
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write learned weights: %w", err)
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		return fmt.Errorf("failed to replace learned weights: %w", err)
+	}
+	return nil
+}
+
+// Reset forgets the learned weights
+func (m *Model) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.learned = nil
+	if m.path == "" {
+		return nil
+	}
+	if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove learned weights: %w", err)
+	}
+	return nil
+}
+
+// Singleton instance of Model
+var (
+	defaultModel     *Model
+	defaultModelOnce sync.Once
+)
+
+// DefaultModel returns the model whose weights scorers use by default
+func DefaultModel() *Model {
+	defaultModelOnce.Do(func() {
+		defaultModel = NewModel()
+	})
+	return defaultModel
+}
+
+// SetDefaultModel replaces the model returned by DefaultModel, typically with
+// one opened from the cache directory. The default scorer is replaced too, to
+// use its weights.
+func SetDefaultModel(model *Model) {
+	defaultModelOnce.Do(func() {})
+	defaultModel = model
+	SetDefaultScorer(nil)
+}
@@ -0,0 +1,118 @@
+package scoring
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// examples returns examples where the user engages with notifications they
+// are involved in and dismisses the others
+func examples() []Example {
+	var result []Example
+	for i := 0; i < 20; i++ {
+		involved := i%2 == 0
+		features := map[string]float64{"age": 0.5, "activity": 0.5, "type": 0.7, "reason": 0.5, "repository": 0.5}
+		if involved {
+			features["involvement"] = 0.9
+		}
+		result = append(result, Example{Features: features, Engaged: involved})
+	}
+	return result
+}
+
+func TestTrain(t *testing.T) {
+	defaults := DefaultScoreFactors()
+
+	if _, err := Train(TrainingData{Examples: examples()[:5]}, defaults); !errors.Is(err, ErrNotEnoughExamples) {
+		t.Errorf("Train() with few examples error = %v, want ErrNotEnoughExamples", err)
+	}
+	engaged := examples()
+	for i := range engaged {
+		engaged[i].Engaged = true
+	}
+	if _, err := Train(TrainingData{Examples: engaged}, defaults); !errors.Is(err, ErrNotEnoughExamples) {
+		t.Errorf("Train() without dismissed examples error = %v, want ErrNotEnoughExamples", err)
+	}
+
+	learned, err := Train(TrainingData{Examples: examples(), Muted: map[string]bool{"org/noisy": true, "org/fine": false}}, defaults)
+	if err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+	if learned.Engaged != 10 || learned.Dismissed != 10 {
+		t.Errorf("got %d engaged and %d dismissed, want 10 each", learned.Engaged, learned.Dismissed)
+	}
+
+	factors := learned.Apply(defaults)
+	if factors.InvolvementWeight <= defaults.InvolvementWeight {
+		t.Errorf("involvement weight %f did not grow from %f", factors.InvolvementWeight, defaults.InvolvementWeight)
+	}
+
+	// The total weight stays the same, so scores stay between 0 and 100
+	var before, after float64
+	for _, factor := range factorNames {
+		before += *factorWeight(defaults, factor)
+		after += *factorWeight(factors, factor)
+	}
+	if math.Abs(before-after) > 0.01 {
+		t.Errorf("total weight changed from %f to %f", before, after)
+	}
+
+	if weight, ok := factors.CustomRepoWeights["org/noisy"]; !ok || weight != 0 {
+		t.Errorf("muted repository weight = %v, want 0", factors.CustomRepoWeights["org/noisy"])
+	}
+	if _, ok := factors.CustomRepoWeights["org/fine"]; ok {
+		t.Error("unmuted repository has a learned weight")
+	}
+	if len(defaults.CustomRepoWeights) != 0 {
+		t.Error("Apply() modified the factors it was given")
+	}
+
+	changes := CompareWeights(defaults, factors)
+	if len(changes) != len(factorNames)+1 || changes[len(changes)-1].Factor != "repository org/noisy" {
+		t.Errorf("CompareWeights() = %+v", changes)
+	}
+}
+
+func TestModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ModelFileName)
+	model, err := OpenModel(path)
+	if err != nil {
+		t.Fatalf("OpenModel() error = %v", err)
+	}
+	if model.Learned() != nil || model.Factors().InvolvementWeight != DefaultScoreFactors().InvolvementWeight {
+		t.Fatal("a new model should use the default weights")
+	}
+
+	learned := &LearnedWeights{Weights: map[string]float64{"involvement": 0.6}, Engaged: 3, Dismissed: 7}
+	if err := model.Set(learned); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	reopened, err := OpenModel(path)
+	if err != nil {
+		t.Fatalf("OpenModel() error = %v", err)
+	}
+	if reopened.Factors().InvolvementWeight != 0.6 || reopened.Learned().Dismissed != 7 {
+		t.Errorf("reopened model has weights %+v", reopened.Learned())
+	}
+
+	// Scorers use the learned weights of the default model
+	original := DefaultModel()
+	SetDefaultModel(reopened)
+	defer SetDefaultModel(original)
+	if NewScorer(nil).Factors.InvolvementWeight != 0.6 {
+		t.Error("NewScorer() did not use the learned weights")
+	}
+
+	if err := reopened.Reset(); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if reopened.Learned() != nil {
+		t.Error("Reset() kept the learned weights")
+	}
+	if model, err := OpenModel(path); err != nil || model.Learned() != nil {
+		t.Errorf("OpenModel() after Reset() = %+v, %v", model, err)
+	}
+}
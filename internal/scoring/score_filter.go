@@ -1,39 +1,39 @@
 package scoring
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/google/go-github/v60/github"
 )
 
-// ScoreFilter filters notifications by score
+// ScoreFilter filters notifications by score. Notifications are scored
+// lazily when the filter is applied, so it works without scoring them first.
 type ScoreFilter struct {
-	// MinScore is the minimum score to match (inclusive)
+	// MinScore is the minimum score to match (inclusive), 0 for no minimum
 	MinScore int
-	// MaxScore is the maximum score to match (inclusive)
+	// MaxScore is the maximum score to match (inclusive), 0 for no maximum
 	MaxScore int
-	// Scorer is the scorer to use
+	// Scorer is the scorer to use, the default scorer if nil
 	Scorer *Scorer
-	// Scores is a cache of notification scores
-	Scores map[string]*NotificationScore
+}
+
+// NewScoreFilter creates a new score filter. Without a scorer, it uses the
+// default scorer.
+func NewScoreFilter(minScore, maxScore int, scorer *Scorer) *ScoreFilter {
+	return &ScoreFilter{
+		MinScore: minScore,
+		MaxScore: maxScore,
+		Scorer:   scorer,
+	}
 }
 
 // Apply applies the score filter to a notification
 func (f *ScoreFilter) Apply(n *github.Notification) bool {
-	// Get the notification score
-	score, ok := f.Scores[n.GetID()]
-	if !ok {
-		// Score not cached, calculate it
-		ctx := context.Background()
-		scores, err := f.Scorer.Score(ctx, []*github.Notification{n})
-		if err != nil {
-			// If there's an error, assume the notification doesn't match
-			return false
-		}
-		score = scores[n.GetID()]
-		f.Scores[n.GetID()] = score
+	scorer := f.Scorer
+	if scorer == nil {
+		scorer = DefaultScorer()
 	}
+	score := scorer.ScoreOf(n)
 
 	// Check if the score is within the range
 	if f.MinScore > 0 && score.Total < f.MinScore {
@@ -59,12 +59,7 @@ func (f *ScoreFilter) Description() string {
 	return "any score"
 }
 
-// SetScores sets the scores for notifications
-func (f *ScoreFilter) SetScores(scores map[string]*NotificationScore) {
-	f.Scores = scores
-}
-
-// GetScores gets the scores for notifications
-func (f *ScoreFilter) GetScores() map[string]*NotificationScore {
-	return f.Scores
+// NeedsScores reports that the filter matches notification scores
+func (f *ScoreFilter) NeedsScores() bool {
+	return true
 }
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/enrichment"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -127,6 +129,8 @@ type NotificationScore struct {
 	Components map[string]float64 `json:"components"`
 	// Factors are the factors used to calculate the score
 	Factors *ScoreFactors `json:"factors"`
+	// Breakdown explains how each factor contributed to the total
+	Breakdown []FactorScore `json:"breakdown"`
 }
 
 // Scorer calculates scores for notifications
@@ -141,12 +145,68 @@ type Scorer struct {
 	Timeout time.Duration
 	// Username is the current user's GitHub username
 	Username string
+
+	// signals caches the activity and involvement signals by notification ID
+	signals   map[string]*signals
+	signalsMu sync.Mutex
+
+	// scores memoizes the scores by scoreKey
+	scores   map[string]*memoizedScore
+	scoresMu sync.Mutex
+}
+
+// memoizedScore is a score with what it was calculated for
+type memoizedScore struct {
+	updatedAt time.Time
+	fetchedAt time.Time
+	username  string
+	scoredAt  time.Time
+	score     *NotificationScore
+}
+
+// Memoized scores are calculated again after scoreTTL, so the age factor
+// keeps decaying in long-running sessions, and at most maxMemoizedScores
+// are kept
+const (
+	scoreTTL          = time.Minute
+	maxMemoizedScores = 10000
+)
+
+// signals are the activity and involvement signals of a notification, taken
+// from the details of its subject
+type signals struct {
+	// updatedAt, fetchedAt and username are what the signals were found for
+	updatedAt time.Time
+	fetchedAt time.Time
+	username  string
+
+	// known is false when the details of the subject are unknown
+	known bool
+	// commentCount and reactionCount are the counts on the subject, and
+	// comments and reactions the saturated counts (0-1)
+	commentCount  int
+	reactionCount int
+	comments      float64
+	reactions     float64
+
+	author    bool
+	assignee  bool
+	reviewer  bool
+	mentioned bool
 }
 
-// NewScorer creates a new scorer
+// Activity saturation scales: a subject with this many comments or reactions
+// has about two thirds of the maximum activity score for them
+const (
+	commentScale  = 10.0
+	reactionScale = 5.0
+)
+
+// NewScorer creates a new scorer. Without factors, it uses the default
+// factors with the weights learned from the user's actions.
 func NewScorer(factors *ScoreFactors) *Scorer {
 	if factors == nil {
-		factors = DefaultScoreFactors()
+		factors = DefaultModel().Factors()
 	}
 
 	return &Scorer{
@@ -154,6 +214,8 @@ func NewScorer(factors *ScoreFactors) *Scorer {
 		Concurrency: 5,
 		BatchSize:   100,
 		Timeout:     5 * time.Second,
+		signals:     make(map[string]*signals),
+		scores:      make(map[string]*memoizedScore),
 	}
 }
 
@@ -222,7 +284,6 @@ func (s *Scorer) scoreConcurrent(ctx context.Context, notifications []*github.No
 		id    string
 		score *NotificationScore
 	}, s.BatchSize)
-	done := make(chan struct{})
 
 	// Start worker goroutines
 	var wg sync.WaitGroup
@@ -248,7 +309,6 @@ func (s *Scorer) scoreConcurrent(ctx context.Context, notifications []*github.No
 	go func() {
 		wg.Wait()
 		close(output)
-		close(done)
 	}()
 
 	// Feed input channel with notifications
@@ -263,7 +323,8 @@ func (s *Scorer) scoreConcurrent(ctx context.Context, notifications []*github.No
 		}
 	}()
 
-	// Collect results
+	// Collect results until the output channel is closed, so that no score
+	// still buffered in it is lost
 	scores := make(map[string]*NotificationScore, len(notifications))
 	for {
 		select {
@@ -274,46 +335,101 @@ func (s *Scorer) scoreConcurrent(ctx context.Context, notifications []*github.No
 			scores[result.id] = result.score
 		case <-ctx.Done():
 			return scores, ctx.Err()
-		case <-done:
-			return scores, nil
 		}
 	}
 }
 
-// scoreNotification calculates a score for a notification
+// ScoreOf returns the score of a notification. Scores are memoized for a
+// minute, or until the notification is updated, its details are fetched
+// again or the username changes, so filters and sorts over the same
+// notifications score them once.
+func (s *Scorer) ScoreOf(n *github.Notification) *NotificationScore {
+	return s.scoreNotification(n)
+}
+
+// scoreNotification returns the memoized score of a notification, calculating
+// it if needed
 func (s *Scorer) scoreNotification(n *github.Notification) *NotificationScore {
-	// Create a score object
-	score := &NotificationScore{
-		Components: make(map[string]float64),
-		Factors:    s.Factors,
+	var fetchedAt time.Time
+	if details := enrichment.Lookup(n); details != nil {
+		fetchedAt = details.FetchedAt
 	}
 
-	// Calculate age score
-	ageScore := s.calculateAgeScore(n)
-	score.Components["age"] = ageScore
+	username := s.Username
+	now := time.Now()
 
-	// Calculate activity score
-	activityScore := s.calculateActivityScore(n)
-	score.Components["activity"] = activityScore
+	key := scoreKey(n)
 
-	// Calculate user involvement score
-	involvementScore := s.calculateInvolvementScore(n)
-	score.Components["involvement"] = involvementScore
+	s.scoresMu.Lock()
+	cached, ok := s.scores[key]
+	s.scoresMu.Unlock()
+	if ok && cached.updatedAt.Equal(n.GetUpdatedAt().Time) &&
+		cached.fetchedAt.Equal(fetchedAt) &&
+		cached.username == username &&
+		now.Sub(cached.scoredAt) < scoreTTL {
+		return cached.score
+	}
 
-	// Calculate type score
-	typeScore := s.calculateTypeScore(n)
-	score.Components["type"] = typeScore
+	score := s.calculateScore(n)
 
-	// Calculate reason score
-	reasonScore := s.calculateReasonScore(n)
-	score.Components["reason"] = reasonScore
+	s.scoresMu.Lock()
+	defer s.scoresMu.Unlock()
+	if s.scores == nil {
+		s.scores = make(map[string]*memoizedScore)
+	}
+	if len(s.scores) >= maxMemoizedScores {
+		s.evictScores(now)
+	}
+	s.scores[key] = &memoizedScore{
+		updatedAt: n.GetUpdatedAt().Time,
+		fetchedAt: fetchedAt,
+		username:  username,
+		scoredAt:  now,
+		score:     score,
+	}
+	return score
+}
 
-	// Calculate repository score
-	repoScore := s.calculateRepoScore(n)
-	score.Components["repository"] = repoScore
+// scoreKey returns the key of a notification's memoized score. Notification
+// IDs are only unique per host, so the notifications of a merged inbox are
+// told apart by the host they came from.
+func scoreKey(n *github.Notification) string {
+	return config.HostFromAPIURL(n.GetURL()) + "/" + n.GetID()
+}
+
+// evictScores drops the expired memoized scores, or all of them when none
+// expired. Callers must hold scoresMu.
+func (s *Scorer) evictScores(now time.Time) {
+	for id, cached := range s.scores {
+		if now.Sub(cached.scoredAt) >= scoreTTL {
+			delete(s.scores, id)
+		}
+	}
+	if len(s.scores) >= maxMemoizedScores {
+		s.scores = make(map[string]*memoizedScore)
+	}
+}
+
+// calculateScore calculates a score for a notification
+func (s *Scorer) calculateScore(n *github.Notification) *NotificationScore {
+	// Create a score object
+	score := &NotificationScore{
+		Components: make(map[string]float64),
+		Factors:    s.Factors,
+	}
+
+	// Each factor's raw value (0-1) is multiplied by its weight
+	var totalScore float64
+	for _, factor := range s.factorScores(n) {
+		weighted := factor.Raw * factor.Weight
+		score.Components[factor.Factor] = weighted
+		totalScore += weighted
+
+		factor.Contribution = weighted * 100
+		score.Breakdown = append(score.Breakdown, factor)
+	}
 
 	// Calculate total score (0-100)
-	totalScore := ageScore + activityScore + involvementScore + typeScore + reasonScore + repoScore
 	score.Total = int(math.Min(100, math.Max(0, totalScore*100)))
 
 	return score
@@ -321,44 +437,147 @@ func (s *Scorer) scoreNotification(n *github.Notification) *NotificationScore {
 
 // calculateAgeScore calculates the age component of the score
 func (s *Scorer) calculateAgeScore(n *github.Notification) float64 {
+	return s.ageValue(n) * s.Factors.AgeWeight
+}
+
+// ageValue calculates the raw age value of a notification
+func (s *Scorer) ageValue(n *github.Notification) float64 {
 	// Get the age in hours
 	age := time.Since(n.GetUpdatedAt().Time).Hours()
 
-	// Cap the age at the maximum
+	// Cap the age at the maximum, and at zero for clocks that are behind
 	if age > s.Factors.MaxAge {
 		age = s.Factors.MaxAge
 	}
+	if age < 0 {
+		age = 0
+	}
 
 	// Calculate the age score (newer is better)
 	// Use an exponential decay function
-	ageScore := math.Exp(-s.Factors.AgeDecay * age / 24)
+	return math.Exp(-s.Factors.AgeDecay * age / 24)
+}
+
+// signalsFor returns the activity and involvement signals of a notification.
+// They are cached until the notification is updated, its details are fetched
+// again or the username changes, so re-scoring a large inbox stays cheap.
+func (s *Scorer) signalsFor(n *github.Notification) *signals {
+	details := enrichment.Lookup(n)
+	var fetchedAt time.Time
+	if details != nil {
+		fetchedAt = details.FetchedAt
+	}
+
+	s.signalsMu.Lock()
+	defer s.signalsMu.Unlock()
+
+	if cached, ok := s.signals[n.GetID()]; ok &&
+		cached.updatedAt.Equal(n.GetUpdatedAt().Time) &&
+		cached.fetchedAt.Equal(fetchedAt) &&
+		cached.username == s.Username {
+		return cached
+	}
+
+	sig := &signals{
+		updatedAt: n.GetUpdatedAt().Time,
+		fetchedAt: fetchedAt,
+		username:  s.Username,
+	}
+	if details != nil {
+		sig.known = true
+		sig.commentCount = details.Comments
+		sig.reactionCount = details.Reactions
+		sig.comments = 1 - math.Exp(-float64(details.Comments)/commentScale)
+		sig.reactions = 1 - math.Exp(-float64(details.Reactions)/reactionScale)
+		sig.author = details.IsAuthor(s.Username)
+		sig.assignee = details.IsAssignee(s.Username)
+		sig.reviewer = details.IsRequestedReviewer(s.Username)
+		sig.mentioned = details.IsMentioned(s.Username)
+	}
 
-	// Apply the age weight
-	return ageScore * s.Factors.AgeWeight
+	if s.signals == nil {
+		s.signals = make(map[string]*signals)
+	}
+	s.signals[n.GetID()] = sig
+	return sig
 }
 
 // calculateActivityScore calculates the activity component of the score
 func (s *Scorer) calculateActivityScore(n *github.Notification) float64 {
-	// This would ideally use the number of comments and reactions
-	// For now, use a placeholder based on the updated time
-	activityScore := 0.5
+	return s.activityValue(n) * s.Factors.ActivityWeight
+}
 
-	// Apply the activity weight
-	return activityScore * s.Factors.ActivityWeight
+// activityValue calculates the raw activity value of a notification
+func (s *Scorer) activityValue(n *github.Notification) float64 {
+	sig := s.signalsFor(n)
+
+	// Without the details of the subject, assume average activity
+	activityScore := 0.5
+	if sig.known {
+		activityScore = 0
+		if total := s.Factors.CommentWeight + s.Factors.ReactionWeight; total > 0 {
+			activityScore = (sig.comments*s.Factors.CommentWeight + sig.reactions*s.Factors.ReactionWeight) / total
+		}
+	}
+	return activityScore
 }
 
 // calculateInvolvementScore calculates the user involvement component of the score
 func (s *Scorer) calculateInvolvementScore(n *github.Notification) float64 {
-	// This would ideally check if the user is the author, assignee, mentioned, etc.
-	// For now, use a placeholder based on the reason
-	involvementScore := 0.5
+	return s.involvementValue(n) * s.Factors.InvolvementWeight
+}
 
-	// Apply the involvement weight
-	return involvementScore * s.Factors.InvolvementWeight
+// involvement returns how the user is involved in a notification's subject
+func (s *Scorer) involvement(n *github.Notification) (author, assignee, reviewer, mentioned bool) {
+	sig := s.signalsFor(n)
+
+	author, assignee, reviewer, mentioned = sig.author, sig.assignee, sig.reviewer, sig.mentioned
+	if !sig.known || s.Username == "" {
+		// Without the details of the subject, infer the involvement from the reason
+		switch n.GetReason() {
+		case "author":
+			author = true
+		case "assign":
+			assignee = true
+		case "review_requested":
+			reviewer = true
+		case "mention", "team_mention":
+			mentioned = true
+		}
+	}
+	return author, assignee, reviewer, mentioned
+}
+
+// involvementValue calculates the raw involvement value of a notification
+func (s *Scorer) involvementValue(n *github.Notification) float64 {
+	author, assignee, reviewer, mentioned := s.involvement(n)
+
+	// Combine the kinds of involvement so that each one adds to the score
+	// without exceeding 1
+	remaining := 1.0
+	for _, involvement := range []struct {
+		involved bool
+		weight   float64
+	}{
+		{author, s.Factors.AuthorWeight},
+		{assignee, s.Factors.AssigneeWeight},
+		{reviewer, s.Factors.ReviewWeight},
+		{mentioned, s.Factors.MentionWeight},
+	} {
+		if involvement.involved {
+			remaining *= 1 - math.Min(1, math.Max(0, involvement.weight))
+		}
+	}
+	return 1 - remaining
 }
 
 // calculateTypeScore calculates the type component of the score
 func (s *Scorer) calculateTypeScore(n *github.Notification) float64 {
+	return s.typeValue(n) * s.Factors.TypeWeight
+}
+
+// typeValue calculates the raw type value of a notification
+func (s *Scorer) typeValue(n *github.Notification) float64 {
 	// Get the notification type
 	typ := n.GetSubject().GetType()
 
@@ -378,13 +597,16 @@ func (s *Scorer) calculateTypeScore(n *github.Notification) float64 {
 	default:
 		typeScore = 0.5
 	}
-
-	// Apply the type weight
-	return typeScore * s.Factors.TypeWeight
+	return typeScore
 }
 
 // calculateReasonScore calculates the reason component of the score
 func (s *Scorer) calculateReasonScore(n *github.Notification) float64 {
+	return s.reasonValue(n) * s.Factors.ReasonWeight
+}
+
+// reasonValue calculates the raw reason value of a notification
+func (s *Scorer) reasonValue(n *github.Notification) float64 {
 	// Get the notification reason
 	reason := n.GetReason()
 
@@ -410,21 +632,50 @@ func (s *Scorer) calculateReasonScore(n *github.Notification) float64 {
 	default:
 		reasonScore = 0.5
 	}
-
-	// Apply the reason weight
-	return reasonScore * s.Factors.ReasonWeight
+	return reasonScore
 }
 
 // calculateRepoScore calculates the repository component of the score
 func (s *Scorer) calculateRepoScore(n *github.Notification) float64 {
+	return s.repoValue(n) * s.Factors.RepoWeight
+}
+
+// repoValue calculates the raw repository value of a notification
+func (s *Scorer) repoValue(n *github.Notification) float64 {
 	// Get the repository name
 	repo := n.GetRepository().GetFullName()
 
 	// Check for a custom weight
 	if weight, ok := s.Factors.CustomRepoWeights[repo]; ok {
-		return weight * s.Factors.RepoWeight
+		return weight
 	}
 
 	// Default repository score
-	return 0.5 * s.Factors.RepoWeight
+	return 0.5
+}
+
+// Shared instance of Scorer
+var (
+	defaultScorer   *Scorer
+	defaultScorerMu sync.Mutex
+)
+
+// DefaultScorer returns the scorer shared by the filters, sorts and views of
+// a process, so each notification is scored once. It uses the weights of the
+// default model.
+func DefaultScorer() *Scorer {
+	defaultScorerMu.Lock()
+	defer defaultScorerMu.Unlock()
+	if defaultScorer == nil {
+		defaultScorer = NewScorer(nil)
+	}
+	return defaultScorer
+}
+
+// SetDefaultScorer replaces the scorer returned by DefaultScorer. A nil
+// scorer is replaced by a new one on the next call.
+func SetDefaultScorer(scorer *Scorer) {
+	defaultScorerMu.Lock()
+	defer defaultScorerMu.Unlock()
+	defaultScorer = scorer
 }
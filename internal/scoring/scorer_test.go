@@ -2,9 +2,12 @@ package scoring
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/enrichment"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -73,6 +76,154 @@ func TestScorer(t *testing.T) {
 	}
 }
 
+func TestScoreConcurrentScoresAll(t *testing.T) {
+	notifications := make([]*github.Notification, 500)
+	for i := range notifications {
+		notifications[i] = &github.Notification{
+			ID:        github.String(fmt.Sprint(i)),
+			Reason:    github.String("subscribed"),
+			UpdatedAt: &github.Timestamp{Time: time.Now()},
+		}
+	}
+
+	// Scores still buffered when the workers finish must not be lost
+	for run := 0; run < 50; run++ {
+		scores, err := NewScorer(nil).Score(context.Background(), notifications)
+		if err != nil {
+			t.Fatalf("Score() error = %v", err)
+		}
+		if len(scores) != len(notifications) {
+			t.Fatalf("run %d: expected %d scores, got %d", run, len(notifications), len(scores))
+		}
+	}
+}
+
+func TestActivityAndInvolvementScores(t *testing.T) {
+	originalStore := enrichment.Default()
+	store := enrichment.NewStore()
+	enrichment.SetDefault(store)
+	defer enrichment.SetDefault(originalStore)
+
+	newNotification := func(id, reason string) *github.Notification {
+		return &github.Notification{
+			ID: github.String(id),
+			Subject: &github.NotificationSubject{
+				Type: github.String("PullRequest"),
+				URL:  github.String("https://api.github.com/repos/owner/repo/pulls/" + id),
+			},
+			Reason:    github.String(reason),
+			UpdatedAt: &github.Timestamp{Time: time.Now()},
+		}
+	}
+
+	busy := newNotification("1", "subscribed")
+	quiet := newNotification("2", "subscribed")
+	unknown := newNotification("3", "review_requested")
+	store.Set(busy.GetSubject().GetURL(), &enrichment.Details{
+		Comments:           25,
+		Reactions:          10,
+		Author:             "alice",
+		Assignees:          []string{"me"},
+		RequestedReviewers: []string{"me"},
+		FetchedAt:          time.Now(),
+	})
+	store.Set(quiet.GetSubject().GetURL(), &enrichment.Details{Author: "alice", FetchedAt: time.Now()})
+
+	scorer := NewScorer(nil).WithUsername("me")
+	factors := scorer.Factors
+
+	if got := scorer.calculateActivityScore(quiet); got != 0 {
+		t.Errorf("activity score without comments = %f, want 0", got)
+	}
+	if got := scorer.calculateActivityScore(busy); got <= 0.8*factors.ActivityWeight || got > factors.ActivityWeight {
+		t.Errorf("activity score of a busy subject = %f, want close to %f", got, factors.ActivityWeight)
+	}
+	if got := scorer.calculateActivityScore(unknown); got != 0.5*factors.ActivityWeight {
+		t.Errorf("activity score without details = %f, want %f", got, 0.5*factors.ActivityWeight)
+	}
+
+	if got := scorer.calculateInvolvementScore(quiet); got != 0 {
+		t.Errorf("involvement score of an uninvolved user = %f, want 0", got)
+	}
+	want := (1 - (1-factors.AssigneeWeight)*(1-factors.ReviewWeight)) * factors.InvolvementWeight
+	if got := scorer.calculateInvolvementScore(busy); math.Abs(got-want) > 1e-9 {
+		t.Errorf("involvement score of an assigned reviewer = %f, want %f", got, want)
+	}
+	if got := scorer.calculateInvolvementScore(unknown); got != factors.ReviewWeight*factors.InvolvementWeight {
+		t.Errorf("involvement score from the reason = %f, want %f", got, factors.ReviewWeight*factors.InvolvementWeight)
+	}
+
+	// The signals are cached until the details are fetched again
+	cached := scorer.signalsFor(quiet)
+	if scorer.signalsFor(quiet) != cached {
+		t.Error("signals were not cached")
+	}
+	store.Set(quiet.GetSubject().GetURL(), &enrichment.Details{Author: "me", FetchedAt: time.Now().Add(time.Second)})
+	if got := scorer.calculateInvolvementScore(quiet); got != factors.AuthorWeight*factors.InvolvementWeight {
+		t.Errorf("involvement score after fetching the details again = %f, want %f", got, factors.AuthorWeight*factors.InvolvementWeight)
+	}
+
+	// Changing the username invalidates the signals too
+	scorer.WithUsername("alice")
+	if got := scorer.calculateInvolvementScore(busy); got != factors.AuthorWeight*factors.InvolvementWeight {
+		t.Errorf("involvement score of the author = %f, want %f", got, factors.AuthorWeight*factors.InvolvementWeight)
+	}
+}
+
+func TestScoreBreakdown(t *testing.T) {
+	factors := DefaultScoreFactors()
+	factors.CustomRepoWeights["owner/repo"] = 1
+	scorer := NewScorer(factors)
+
+	notification := &github.Notification{
+		ID: github.String("123"),
+		Subject: &github.NotificationSubject{
+			Type: github.String("Issue"),
+		},
+		Reason: github.String("assign"),
+		Repository: &github.Repository{
+			FullName: github.String("owner/repo"),
+		},
+		UpdatedAt: &github.Timestamp{Time: time.Now().Add(-3 * time.Hour)},
+	}
+
+	score := scorer.scoreNotification(notification)
+	want := []string{"age", "activity", "involvement", "type", "reason", "repository"}
+	if len(score.Breakdown) != len(want) {
+		t.Fatalf("Expected %d factors, got %d", len(want), len(score.Breakdown))
+	}
+
+	var total float64
+	for i, factor := range score.Breakdown {
+		if factor.Factor != want[i] {
+			t.Errorf("Expected factor %s, got %s", want[i], factor.Factor)
+		}
+		if math.Abs(factor.Contribution-factor.Raw*factor.Weight*100) > 1e-9 {
+			t.Errorf("%s: contribution %f is not raw %f times weight %f", factor.Factor, factor.Contribution, factor.Raw, factor.Weight)
+		}
+		if math.Abs(factor.Contribution-score.Components[factor.Factor]*100) > 1e-9 {
+			t.Errorf("%s: contribution %f does not match the component %f", factor.Factor, factor.Contribution, score.Components[factor.Factor])
+		}
+		total += factor.Contribution
+	}
+	if int(total) != score.Total {
+		t.Errorf("Expected the contributions to add up to %d, got %f", score.Total, total)
+	}
+
+	details := map[string]string{
+		"age":         "updated 3 hours ago",
+		"activity":    "subject details unknown",
+		"involvement": "assignee (from the reason)",
+		"reason":      "assign",
+		"repository":  "owner/repo (custom weight)",
+	}
+	for _, factor := range score.Breakdown {
+		if want, ok := details[factor.Factor]; ok && factor.Detail != want {
+			t.Errorf("%s: expected detail %q, got %q", factor.Factor, want, factor.Detail)
+		}
+	}
+}
+
 func TestScoreFilter(t *testing.T) {
 	// Create a test scorer
 	factors := DefaultScoreFactors()
@@ -100,9 +251,6 @@ func TestScoreFilter(t *testing.T) {
 	filter := &ScoreFilter{
 		MinScore: score.Total - 1,
 		Scorer:   scorer,
-		Scores: map[string]*NotificationScore{
-			"123": score,
-		},
 	}
 
 	// Test that the notification passes the filter
@@ -114,9 +262,6 @@ func TestScoreFilter(t *testing.T) {
 	filter = &ScoreFilter{
 		MaxScore: score.Total + 1,
 		Scorer:   scorer,
-		Scores: map[string]*NotificationScore{
-			"123": score,
-		},
 	}
 
 	// Test that the notification passes the filter
@@ -129,9 +274,6 @@ func TestScoreFilter(t *testing.T) {
 		MinScore: score.Total + 1,
 		MaxScore: score.Total + 10,
 		Scorer:   scorer,
-		Scores: map[string]*NotificationScore{
-			"123": score,
-		},
 	}
 
 	// Test that the notification does not pass the filter
@@ -144,6 +286,92 @@ func TestScoreFilter(t *testing.T) {
 	if description == "" {
 		t.Errorf("Expected non-empty description")
 	}
+
+	// Without a scorer, the default scorer is used
+	filter = NewScoreFilter(score.Total, score.Total, nil)
+	if !filter.Apply(notification) {
+		t.Errorf("Expected notification to pass filter with the default scorer")
+	}
+}
+
+func TestScoreOfIsMemoized(t *testing.T) {
+	scorer := NewScorer(nil)
+	notification := &github.Notification{
+		ID:        github.String("1"),
+		Subject:   &github.NotificationSubject{Type: github.String("Issue")},
+		Reason:    github.String("subscribed"),
+		UpdatedAt: &github.Timestamp{Time: time.Now().Add(-48 * time.Hour)},
+	}
+
+	first := scorer.ScoreOf(notification)
+	if second := scorer.ScoreOf(notification); second != first {
+		t.Error("Expected the score to be memoized")
+	}
+
+	scores, err := scorer.Score(context.Background(), []*github.Notification{notification})
+	if err != nil {
+		t.Fatalf("Score() error = %v", err)
+	}
+	if scores["1"] != first {
+		t.Error("Expected Score to use the memoized score")
+	}
+
+	// An update invalidates the memoized score
+	notification.UpdatedAt = &github.Timestamp{Time: time.Now()}
+	updated := scorer.ScoreOf(notification)
+	if updated == first {
+		t.Error("Expected the score to be recalculated after an update")
+	}
+	if updated.Total <= first.Total {
+		t.Errorf("Expected a newer notification to score higher, got %d <= %d", updated.Total, first.Total)
+	}
+
+	// Scores expire as the notification ages
+	scorer.scores[scoreKey(notification)].scoredAt = time.Now().Add(-scoreTTL)
+	if expired := scorer.ScoreOf(notification); expired == updated {
+		t.Error("Expected the score to be recalculated once expired")
+	}
+
+	// The memo is bounded, dropping expired scores first
+	for i := 0; i < maxMemoizedScores+10; i++ {
+		scorer.ScoreOf(&github.Notification{ID: github.String(fmt.Sprint("n", i)), UpdatedAt: notification.UpdatedAt})
+	}
+	if len(scorer.scores) > maxMemoizedScores {
+		t.Errorf("Expected at most %d memoized scores, got %d", maxMemoizedScores, len(scorer.scores))
+	}
+}
+
+func TestScoreOfTellsHostsApart(t *testing.T) {
+	scorer := NewScorer(nil)
+	updatedAt := &github.Timestamp{Time: time.Now().Add(-time.Hour)}
+
+	// The same thread ID on github.com and on an Enterprise Server host
+	public := &github.Notification{
+		ID:        github.String("1"),
+		URL:       github.String("https://api.github.com/notifications/threads/1"),
+		Subject:   &github.NotificationSubject{Type: github.String("PullRequest")},
+		Reason:    github.String("review_requested"),
+		UpdatedAt: updatedAt,
+	}
+	enterprise := &github.Notification{
+		ID:        github.String("1"),
+		URL:       github.String("https://github.example.com/api/v3/notifications/threads/1"),
+		Subject:   &github.NotificationSubject{Type: github.String("Release")},
+		Reason:    github.String("subscribed"),
+		UpdatedAt: updatedAt,
+	}
+
+	publicScore := scorer.ScoreOf(public)
+	enterpriseScore := scorer.ScoreOf(enterprise)
+	if enterpriseScore == publicScore {
+		t.Fatal("Expected notifications of different hosts to be scored separately")
+	}
+	if enterpriseScore.Total >= publicScore.Total {
+		t.Errorf("Expected the subscribed release to score lower, got %d >= %d", enterpriseScore.Total, publicScore.Total)
+	}
+	if scorer.ScoreOf(public) != publicScore {
+		t.Error("Expected the score of each host's notification to stay memoized")
+	}
 }
 
 func TestScoreFactors(t *testing.T) {
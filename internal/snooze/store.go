@@ -0,0 +1,329 @@
+package snooze
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/filelock"
+	"github.com/google/go-github/v60/github"
+)
+
+// FileName is the name of the snooze store in the cache directory
+const FileName = "snoozed.json"
+
+// Entry is a snoozed notification thread
+type Entry struct {
+	// ThreadID is the ID of the notification thread
+	ThreadID string `json:"thread_id"`
+	// Repository is the full name of the thread's repository
+	Repository string `json:"repository,omitempty"`
+	// Title is the title of the thread's subject
+	Title string `json:"title,omitempty"`
+	// UpdatedAt is when the thread was last updated when it was snoozed
+	UpdatedAt time.Time `json:"updated_at"`
+	// Until is when the snooze ends, zero to wait for new activity only
+	Until time.Time `json:"until"`
+	// SnoozedAt is when the thread was snoozed
+	SnoozedAt time.Time `json:"snoozed_at"`
+}
+
+// Ended reports whether the snooze of n is over, either because its time
+// passed or because the thread was updated since it was snoozed
+func (e Entry) Ended(n *github.Notification, now time.Time) bool {
+	if !e.Until.IsZero() && !now.Before(e.Until) {
+		return true
+	}
+	return n != nil && n.GetUpdatedAt().Time.After(e.UpdatedAt)
+}
+
+// Store records snoozed notification threads. Snoozing is local; nothing is
+// changed on GitHub.
+type Store struct {
+	// path is the store file, empty for an in-memory store
+	path string
+	// now returns the current time
+	now func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]Entry
+}
+
+// NewStore creates an in-memory snooze store
+func NewStore() *Store {
+	return &Store{
+		now:     time.Now,
+		entries: make(map[string]Entry),
+	}
+}
+
+// Open loads the snooze store from a file, creating it on the first change.
+// The file may be shared with other processes, such as a running watch, so
+// changes are made to its current content under its file lock.
+func Open(path string) (*Store, error) {
+	s := NewStore()
+	s.path = path
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Snooze hides a thread until the given time or until it is updated. A zero
+// until waits for new activity only.
+func (s *Store) Snooze(thread *github.Notification, until time.Time) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := Entry{
+		ThreadID:   thread.GetID(),
+		Repository: thread.GetRepository().GetFullName(),
+		Title:      thread.GetSubject().GetTitle(),
+		UpdatedAt:  thread.GetUpdatedAt().Time,
+		Until:      until,
+		SnoozedAt:  s.now(),
+	}
+
+	return entry, s.update(func() bool {
+		s.entries[entry.ThreadID] = entry
+		return true
+	})
+}
+
+// Restore puts back a previously removed entry
+func (s *Store) Restore(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func() bool {
+		s.entries[entry.ThreadID] = entry
+		return true
+	})
+}
+
+// Unsnooze removes a thread from the store and returns its entry
+func (s *Store) Unsnooze(threadID string) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entry Entry
+	var ok bool
+	err := s.update(func() bool {
+		entry, ok = s.entries[threadID]
+		delete(s.entries, threadID)
+		return ok
+	})
+	return entry, ok, err
+}
+
+// Entry returns the snooze of a thread
+func (s *Store) Entry(threadID string) (Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[threadID]
+	return entry, ok
+}
+
+// Entries returns the snoozed threads, ending soonest first. Snoozes that
+// wait for new activity only come last.
+func (s *Store) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Until.IsZero() != b.Until.IsZero() {
+			return b.Until.IsZero()
+		}
+		if !a.Until.Equal(b.Until) {
+			return a.Until.Before(b.Until)
+		}
+		return a.ThreadID < b.ThreadID
+	})
+	return entries
+}
+
+// IsSnoozed reports whether a notification is snoozed and should be hidden
+func (s *Store) IsSnoozed(n *github.Notification) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.isSnoozed(n)
+}
+
+// isSnoozed is IsSnoozed for callers holding the lock
+func (s *Store) isSnoozed(n *github.Notification) bool {
+	entry, ok := s.entries[n.GetID()]
+	return ok && !entry.Ended(n, s.now())
+}
+
+// Filter returns the notifications that are not snoozed, including threads
+// snoozed by other processes since the store was opened
+func (s *Store) Filter(notifications []*github.Notification) []*github.Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refresh()
+	if len(s.entries) == 0 {
+		return notifications
+	}
+
+	result := make([]*github.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		if !s.isSnoozed(n) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// Resurface removes the snoozes that ended and returns the notifications
+// whose snooze ended. Snoozes whose time passed are removed even when their
+// thread is not among the notifications.
+func (s *Store) Resurface(notifications []*github.Notification) ([]*github.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var resurfaced []*github.Notification
+	err := s.update(func() bool {
+		now := s.now()
+		for _, n := range notifications {
+			entry, ok := s.entries[n.GetID()]
+			if ok && entry.Ended(n, now) {
+				delete(s.entries, n.GetID())
+				resurfaced = append(resurfaced, n)
+			}
+		}
+
+		changed := len(resurfaced) > 0
+		for id, entry := range s.entries {
+			if entry.Ended(nil, now) {
+				delete(s.entries, id)
+				changed = true
+			}
+		}
+		return changed
+	})
+	return resurfaced, err
+}
+
+// update applies a change to the current content of the store's file under
+// its file lock, and saves the store when change reports that it changed
+// something. Callers must hold the write lock.
+func (s *Store) update(change func() bool) error {
+	if s.path == "" {
+		change()
+		return nil
+	}
+
+	lock, err := filelock.Acquire(s.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	if !change() {
+		return nil
+	}
+	return s.save()
+}
+
+// refresh reloads the store from its file, keeping the entries in memory
+// when it cannot be read. Callers must hold the write lock.
+func (s *Store) refresh() {
+	if s.path != "" {
+		_ = s.load()
+	}
+}
+
+// load replaces the entries with the content of the store's file. The file
+// is replaced atomically, so it can be read without the file lock. Callers
+// must hold the write lock.
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.entries = make(map[string]Entry)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snoozed notifications: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse snoozed notifications: %w", err)
+	}
+	s.entries = make(map[string]Entry, len(entries))
+	for _, entry := range entries {
+		s.entries[entry.ThreadID] = entry
+	}
+	return nil
+}
+
+// save writes the store to its file. Callers must hold the write lock.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ThreadID < entries[j].ThreadID })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snoozed notifications: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create snooze directory: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write snoozed notifications: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace snoozed notifications: %w", err)
+	}
+
+	return nil
+}
+
+// Singleton instance of Store
+var (
+	defaultStore     *Store
+	defaultStoreOnce sync.Once
+)
+
+// Default returns the snooze store used by the list command, the UI and watch
+func Default() *Store {
+	defaultStoreOnce.Do(func() {
+		defaultStore = NewStore()
+	})
+	return defaultStore
+}
+
+// SetDefault replaces the store returned by Default, typically with one
+// opened from the cache directory
+func SetDefault(store *Store) {
+	defaultStoreOnce.Do(func() {})
+	defaultStore = store
+}
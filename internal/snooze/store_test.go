@@ -0,0 +1,139 @@
+package snooze
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// thread creates a notification thread for tests
+func thread(id string, updatedAt time.Time) *github.Notification {
+	return &github.Notification{
+		ID:         github.String(id),
+		Repository: &github.Repository{FullName: github.String("owner/repo")},
+		Subject:    &github.NotificationSubject{Title: github.String("Thread " + id)},
+		UpdatedAt:  &github.Timestamp{Time: updatedAt},
+	}
+}
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", FileName)
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := store.Snooze(thread("1", updatedAt), until); err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+	if _, err := store.Snooze(thread("2", updatedAt), time.Time{}); err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	entries := reopened.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].ThreadID != "1" || !entries[0].Until.Equal(until) || entries[0].Title != "Thread 1" {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].ThreadID != "2" || !entries[1].Until.IsZero() {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+
+	if _, ok, err := reopened.Unsnooze("1"); !ok || err != nil {
+		t.Fatalf("Unsnooze() = %v, %v", ok, err)
+	}
+	reopened, _ = Open(path)
+	if _, ok := reopened.Entry("1"); ok {
+		t.Errorf("unsnoozed thread is still in the store")
+	}
+}
+
+func TestStoreResurface(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	updatedAt := now.Add(-time.Hour)
+
+	store := NewStore()
+	store.now = func() time.Time { return now }
+
+	store.Snooze(thread("timed", updatedAt), now.Add(time.Hour))
+	store.Snooze(thread("activity", updatedAt), time.Time{})
+	store.Snooze(thread("gone", updatedAt), now.Add(time.Hour))
+
+	notifications := []*github.Notification{
+		thread("timed", updatedAt),
+		thread("activity", updatedAt),
+		thread("other", updatedAt),
+	}
+
+	if got := store.Filter(notifications); len(got) != 1 || got[0].GetID() != "other" {
+		t.Fatalf("Filter() kept %d notifications, want only other", len(got))
+	}
+	if resurfaced, _ := store.Resurface(notifications); len(resurfaced) != 0 {
+		t.Fatalf("Resurface() returned %d notifications before any snooze ended", len(resurfaced))
+	}
+
+	// New activity ends a snooze, and so does its time passing
+	notifications[1] = thread("activity", now)
+	now = now.Add(2 * time.Hour)
+
+	resurfaced, err := store.Resurface(notifications)
+	if err != nil {
+		t.Fatalf("Resurface() error = %v", err)
+	}
+	if len(resurfaced) != 2 || resurfaced[0].GetID() != "timed" || resurfaced[1].GetID() != "activity" {
+		t.Errorf("Resurface() = %v, want timed and activity", resurfaced)
+	}
+	if entries := store.Entries(); len(entries) != 0 {
+		t.Errorf("store still has %d entries", len(entries))
+	}
+}
+
+func TestStoreSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// A running watch and a snooze command from another shell
+	watch, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	shell, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if _, err := watch.Snooze(thread("1", updatedAt), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+	if _, err := shell.Snooze(thread("2", updatedAt), time.Time{}); err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+
+	// The watch sees the other shell's snooze
+	notifications := []*github.Notification{thread("2", updatedAt), thread("3", updatedAt)}
+	if got := watch.Filter(notifications); len(got) != 1 || got[0].GetID() != "3" {
+		t.Errorf("Filter() = %v, want only 3", got)
+	}
+
+	// Saving the watch's changes keeps the other shell's snooze
+	if _, err := watch.Resurface(notifications); err != nil {
+		t.Fatalf("Resurface() error = %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if entries := reopened.Entries(); len(entries) != 1 || entries[0].ThreadID != "2" {
+		t.Errorf("Entries() = %+v, want only 2", entries)
+	}
+}
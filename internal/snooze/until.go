@@ -0,0 +1,158 @@
+package snooze
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NextActivity is the --until value that snoozes a thread until it is updated
+const NextActivity = "next-activity"
+
+// defaultClock is the time of day used when a day is given without a time
+const defaultClock = 9 * time.Hour
+
+// clockLayouts are the accepted time of day formats
+var clockLayouts = []string{"3pm", "3:04pm", "15:04"}
+
+// dateLayouts are the accepted absolute date formats, in local time
+var dateLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+// ParseUntil parses when a snooze ends, relative to now. It accepts
+// "next-activity", durations such as "2h", "3d" or "1w", "tomorrow 9am",
+// "today 5pm", weekdays such as "monday 10:30", a time of day, and absolute
+// dates. A zero time means the snooze only ends with new activity.
+func ParseUntil(value string, now time.Time) (time.Time, error) {
+	until, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		value = normalize(value)
+		if value == "" {
+			return time.Time{}, fmt.Errorf("empty snooze time")
+		}
+		if value == NextActivity {
+			return time.Time{}, nil
+		}
+
+		until, err = parseUntil(value, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if !until.After(now) {
+		return time.Time{}, fmt.Errorf("snooze time %q is in the past", value)
+	}
+	return until, nil
+}
+
+// parseUntil parses a normalized snooze time
+func parseUntil(value string, now time.Time) (time.Time, error) {
+	if d, ok := parseDuration(value); ok {
+		return now.Add(d), nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			if layout == "2006-01-02" {
+				t = t.Add(defaultClock)
+			}
+			return t, nil
+		}
+	}
+
+	day, clock, _ := strings.Cut(value, " ")
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch {
+	case day == "today":
+		return atClock(today, clock, now.Sub(today))
+	case day == "tomorrow":
+		return atClock(today.AddDate(0, 0, 1), clock, defaultClock)
+	}
+
+	if weekday, ok := parseWeekday(day); ok {
+		days := (int(weekday) - int(now.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return atClock(today.AddDate(0, 0, days), clock, defaultClock)
+	}
+
+	// A bare time of day means its next occurrence
+	if offset, err := parseClock(value); err == nil {
+		until := today.Add(offset)
+		if !until.After(now) {
+			until = today.AddDate(0, 0, 1).Add(offset)
+		}
+		return until, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid snooze time %q (use a duration like 2h, tomorrow 9am, a weekday, a date or %s)", value, NextActivity)
+}
+
+// atClock returns day at the given time of day, or at fallback if clock is empty
+func atClock(day time.Time, clock string, fallback time.Duration) (time.Time, error) {
+	if clock == "" {
+		return day.Add(fallback), nil
+	}
+
+	offset, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.Add(offset), nil
+}
+
+// parseClock parses a time of day into the offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day %q", value)
+}
+
+// parseDuration parses a Go duration, or a number of days or weeks
+func parseDuration(value string) (time.Duration, bool) {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, true
+	}
+
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// parseWeekday parses a full or abbreviated weekday name
+func parseWeekday(value string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if value == name || value == name[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// normalize lowercases a snooze time, collapses spaces and joins a separate
+// am or pm suffix to the time before it
+func normalize(value string) string {
+	fields := strings.Fields(strings.ToLower(value))
+	if n := len(fields); n > 1 && (fields[n-1] == "am" || fields[n-1] == "pm") {
+		fields = append(fields[:n-2], fields[n-2]+fields[n-1])
+	}
+	return strings.Join(fields, " ")
+}
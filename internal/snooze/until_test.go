@@ -0,0 +1,55 @@
+package snooze
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUntil(t *testing.T) {
+	// Wednesday afternoon
+	now := time.Date(2024, 5, 15, 14, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "next-activity", want: time.Time{}},
+		{value: "2h", want: now.Add(2 * time.Hour)},
+		{value: "90m", want: now.Add(90 * time.Minute)},
+		{value: "3d", want: now.Add(72 * time.Hour)},
+		{value: "1w", want: now.Add(7 * 24 * time.Hour)},
+		{value: "tomorrow", want: time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{value: "tomorrow 9am", want: time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{value: "Tomorrow 9 AM", want: time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{value: "today 5pm", want: time.Date(2024, 5, 15, 17, 0, 0, 0, time.UTC)},
+		{value: "monday 10:30", want: time.Date(2024, 5, 20, 10, 30, 0, 0, time.UTC)},
+		{value: "wed", want: time.Date(2024, 5, 22, 9, 0, 0, 0, time.UTC)},
+		{value: "16:00", want: time.Date(2024, 5, 15, 16, 0, 0, 0, time.UTC)},
+		{value: "8am", want: time.Date(2024, 5, 16, 8, 0, 0, 0, time.UTC)},
+		{value: "2024-06-01", want: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)},
+		{value: "2024-06-01 18:15", want: time.Date(2024, 6, 1, 18, 15, 0, 0, time.UTC)},
+		{value: "2024-06-01T18:15:00Z", want: time.Date(2024, 6, 1, 18, 15, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseUntil(tc.value, now)
+			if err != nil {
+				t.Fatalf("ParseUntil(%q) error = %v", tc.value, err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("ParseUntil(%q) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseUntilInvalid(t *testing.T) {
+	now := time.Date(2024, 5, 15, 14, 30, 0, 0, time.UTC)
+
+	for _, value := range []string{"", "soon", "-2h", "today 9am", "2024-01-01", "tomorrow 25:00"} {
+		if _, err := ParseUntil(value, now); err == nil {
+			t.Errorf("ParseUntil(%q) succeeded, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,605 @@
+package subscriptions
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/gobwas/glob"
+	"github.com/google/go-github/v60/github"
+)
+
+// Outcome is the outcome of a subscription condition for a notification
+type Outcome string
+
+const (
+	// OutcomeMatch means the notification meets the condition
+	OutcomeMatch Outcome = "match"
+	// OutcomeNoMatch means the notification does not meet the condition
+	OutcomeNoMatch Outcome = "no match"
+	// OutcomeUnknown means the condition could not be checked because the
+	// subject details are unknown, so the notification is let through
+	OutcomeUnknown Outcome = "unknown"
+	// OutcomeNotApplicable means the condition does not apply to the
+	// notification's subject, such as a branch filter for an issue
+	OutcomeNotApplicable Outcome = "n/a"
+)
+
+// Check is the outcome of one condition of a subscription for a notification
+type Check struct {
+	// Condition describes the condition
+	Condition string `json:"condition"`
+	// Outcome is the outcome of the condition
+	Outcome Outcome `json:"outcome"`
+	// Reason explains the outcome
+	Reason string `json:"reason,omitempty"`
+}
+
+// Condition is a filter compiled from one part of a subscription that can
+// explain its outcome for a notification
+type Condition interface {
+	filter.Filter
+	// Check checks the condition for a notification
+	Check(n *github.Notification) Check
+}
+
+// mainBranches are taken for the default branch when it is unknown
+var mainBranches = []string{"main", "master"}
+
+// activityTypes maps notification subject types to activity types
+var activityTypes = map[string]ActivityType{
+	"PullRequest":                      ActivityPRs,
+	"Issue":                            ActivityIssues,
+	"Discussion":                       ActivityDiscussions,
+	"Release":                          ActivityReleases,
+	"Commit":                           ActivityCommits,
+	"CheckSuite":                       ActivityCommits,
+	"WorkflowRun":                      ActivityCommits,
+	"RepositoryVulnerabilityAlert":     ActivitySecurity,
+	"RepositoryDependabotAlertsThread": ActivitySecurity,
+	"SecurityAdvisory":                 ActivitySecurity,
+}
+
+// ActivityOf returns the activity type of a notification's subject, and
+// false for subjects without a known activity type
+func ActivityOf(n *github.Notification) (ActivityType, bool) {
+	activity, ok := activityTypes[n.GetSubject().GetType()]
+	return activity, ok
+}
+
+// compileGlobs compiles file or branch glob patterns
+func compileGlobs(patterns []string) ([]glob.Glob, error) {
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+// matchGlob returns the first pattern matching a value, if any
+func matchGlob(globs []glob.Glob, patterns []string, value string) (string, bool) {
+	for i, g := range globs {
+		if g.Match(value) {
+			return patterns[i], true
+		}
+	}
+	return "", false
+}
+
+// applyCheck applies a condition to a notification. Only a definite
+// mismatch filters a notification out.
+func applyCheck(c Condition, n *github.Notification) bool {
+	return c.Check(n).Outcome != OutcomeNoMatch
+}
+
+// repositoryCondition matches the repository or pattern of a subscription
+type repositoryCondition struct {
+	pattern string
+	glob    glob.Glob
+}
+
+// newRepositoryCondition compiles the repository of a subscription, which
+// matches repository names ignoring case
+func newRepositoryCondition(repository string) (*repositoryCondition, error) {
+	g, err := glob.Compile(strings.ToLower(repository), '/')
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository pattern %q: %w", repository, err)
+	}
+	return &repositoryCondition{pattern: repository, glob: g}, nil
+}
+
+// matches reports whether a repository name is covered by the condition
+func (c *repositoryCondition) matches(repository string) bool {
+	return c.glob.Match(strings.ToLower(repository))
+}
+
+// Apply returns true if the notification belongs to the repository
+func (c *repositoryCondition) Apply(n *github.Notification) bool {
+	return applyCheck(c, n)
+}
+
+// Description returns a human-readable description of the condition
+func (c *repositoryCondition) Description() string {
+	return fmt.Sprintf("repository matches %s", c.pattern)
+}
+
+// Check checks the repository of a notification
+func (c *repositoryCondition) Check(n *github.Notification) Check {
+	repository := n.GetRepository().GetFullName()
+	check := Check{Condition: c.Description(), Outcome: OutcomeMatch, Reason: "repository is " + repository}
+	if !c.matches(repository) {
+		check.Outcome = OutcomeNoMatch
+	}
+	return check
+}
+
+// activityCondition matches the activity types of a subscription
+type activityCondition struct {
+	types []ActivityType
+}
+
+// Apply returns true if the notification is about a subscribed activity
+func (c *activityCondition) Apply(n *github.Notification) bool {
+	return applyCheck(c, n)
+}
+
+// Description returns a human-readable description of the condition
+func (c *activityCondition) Description() string {
+	names := make([]string, len(c.types))
+	for i, t := range c.types {
+		names[i] = string(t)
+	}
+	return "activity is " + strings.Join(names, ", ")
+}
+
+// Check checks the activity type of a notification's subject
+func (c *activityCondition) Check(n *github.Notification) Check {
+	check := Check{Condition: c.Description()}
+
+	activity, ok := ActivityOf(n)
+	if !ok {
+		check.Outcome = OutcomeUnknown
+		check.Reason = fmt.Sprintf("%s notifications have no activity type", n.GetSubject().GetType())
+		return check
+	}
+
+	check.Reason = fmt.Sprintf("%s notifications are %s", n.GetSubject().GetType(), activity)
+	check.Outcome = OutcomeNoMatch
+	for _, t := range c.types {
+		if t == activity {
+			check.Outcome = OutcomeMatch
+			break
+		}
+	}
+	return check
+}
+
+// branchCondition matches the base branch of pull requests
+type branchCondition struct {
+	config  BranchFilter
+	include []glob.Glob
+	exclude []glob.Glob
+}
+
+// newBranchCondition compiles a branch filter, or returns nil when it lets
+// every branch through
+func newBranchCondition(config BranchFilter) (*branchCondition, error) {
+	if config.All && len(config.ExcludePatterns) == 0 {
+		return nil, nil
+	}
+
+	include, err := compileGlobs(config.Patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid branch filter: %w", err)
+	}
+	exclude, err := compileGlobs(config.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid branch filter: %w", err)
+	}
+
+	return &branchCondition{config: config, include: include, exclude: exclude}, nil
+}
+
+// Apply returns true if the notification's pull request targets a subscribed branch
+func (c *branchCondition) Apply(n *github.Notification) bool {
+	return applyCheck(c, n)
+}
+
+// NeedsDetails returns true because the base branch is a subject detail
+func (c *branchCondition) NeedsDetails() bool {
+	return true
+}
+
+// Description returns a human-readable description of the condition
+func (c *branchCondition) Description() string {
+	var parts []string
+	switch {
+	case c.config.All:
+		parts = append(parts, "any branch")
+	case c.config.MainOnly && len(c.config.Patterns) > 0:
+		parts = append(parts, fmt.Sprintf("branch is the default branch or matches %s", strings.Join(c.config.Patterns, ", ")))
+	case c.config.MainOnly:
+		parts = append(parts, "branch is the default branch")
+	default:
+		parts = append(parts, fmt.Sprintf("branch matches %s", strings.Join(c.config.Patterns, ", ")))
+	}
+	if len(c.config.ExcludePatterns) > 0 {
+		parts = append(parts, fmt.Sprintf("not %s", strings.Join(c.config.ExcludePatterns, ", ")))
+	}
+	return strings.Join(parts, " but ")
+}
+
+// Check checks the base branch of a notification's pull request
+func (c *branchCondition) Check(n *github.Notification) Check {
+	check := Check{Condition: c.Description()}
+
+	if n.GetSubject().GetType() != "PullRequest" {
+		check.Outcome = OutcomeNotApplicable
+		check.Reason = "only pull requests have a base branch"
+		return check
+	}
+
+	details := enrichment.Lookup(n)
+	if details == nil || details.BaseBranch == "" {
+		check.Outcome = OutcomeUnknown
+		check.Reason = "base branch is unknown"
+		return check
+	}
+
+	branch := details.BaseBranch
+	if pattern, ok := matchGlob(c.exclude, c.config.ExcludePatterns, branch); ok {
+		check.Outcome = OutcomeNoMatch
+		check.Reason = fmt.Sprintf("base branch %s is excluded by %s", branch, pattern)
+		return check
+	}
+
+	switch {
+	case c.config.All:
+		check.Outcome = OutcomeMatch
+		check.Reason = fmt.Sprintf("base branch %s is not excluded", branch)
+	case c.config.MainOnly && isMainBranch(branch, details.DefaultBranch):
+		check.Outcome = OutcomeMatch
+		check.Reason = fmt.Sprintf("base branch %s is the default branch", branch)
+	default:
+		if pattern, ok := matchGlob(c.include, c.config.Patterns, branch); ok {
+			check.Outcome = OutcomeMatch
+			check.Reason = fmt.Sprintf("base branch %s matches %s", branch, pattern)
+		} else {
+			check.Outcome = OutcomeNoMatch
+			check.Reason = fmt.Sprintf("base branch is %s", branch)
+		}
+	}
+	return check
+}
+
+// isMainBranch reports whether a branch is the default branch, which is
+// assumed to be main or master when the default branch is unknown
+func isMainBranch(branch, defaultBranch string) bool {
+	if defaultBranch != "" {
+		return branch == defaultBranch
+	}
+	for _, main := range mainBranches {
+		if branch == main {
+			return true
+		}
+	}
+	return false
+}
+
+// authorCondition matches the author of the subject
+type authorCondition struct {
+	config AuthorFilter
+}
+
+// newAuthorCondition compiles an author filter, or returns nil when it lets
+// every author through
+func newAuthorCondition(config AuthorFilter) *authorCondition {
+	restrictsInclude := !config.All && len(config.Include) > 0
+	if !restrictsInclude && len(config.Exclude) == 0 && !config.ExcludeBots {
+		return nil
+	}
+	return &authorCondition{config: config}
+}
+
+// Apply returns true if the notification's subject has a subscribed author
+func (c *authorCondition) Apply(n *github.Notification) bool {
+	return applyCheck(c, n)
+}
+
+// NeedsDetails returns true because the author is a subject detail
+func (c *authorCondition) NeedsDetails() bool {
+	return true
+}
+
+// Description returns a human-readable description of the condition
+func (c *authorCondition) Description() string {
+	var parts []string
+	if !c.config.All && len(c.config.Include) > 0 {
+		parts = append(parts, "author is "+strings.Join(c.config.Include, ", "))
+	}
+	if len(c.config.Exclude) > 0 {
+		parts = append(parts, "author is not "+strings.Join(c.config.Exclude, ", "))
+	}
+	if c.config.ExcludeBots {
+		parts = append(parts, "author is not a bot")
+	}
+	return strings.Join(parts, " and ")
+}
+
+// Check checks the author of a notification's subject
+func (c *authorCondition) Check(n *github.Notification) Check {
+	check := Check{Condition: c.Description()}
+
+	details := enrichment.Lookup(n)
+	if details == nil || details.Author == "" {
+		check.Outcome = OutcomeUnknown
+		check.Reason = "author is unknown"
+		return check
+	}
+
+	author := details.Author
+	check.Outcome = OutcomeNoMatch
+	switch {
+	case c.config.ExcludeBots && details.IsBot():
+		check.Reason = fmt.Sprintf("author %s is a bot", author)
+	case containsFold(c.config.Exclude, author):
+		check.Reason = fmt.Sprintf("author %s is excluded", author)
+	case !c.config.All && len(c.config.Include) > 0 && !containsFold(c.config.Include, author):
+		check.Reason = fmt.Sprintf("author is %s", author)
+	default:
+		check.Outcome = OutcomeMatch
+		check.Reason = fmt.Sprintf("author is %s", author)
+	}
+	return check
+}
+
+// fileCondition matches the files changed by pull requests and commits
+type fileCondition struct {
+	config     FileFilter
+	include    []glob.Glob
+	exclude    []glob.Glob
+	extensions []string
+	paths      []string
+}
+
+// newFileCondition compiles a file filter, or returns nil when it lets
+// every file through
+func newFileCondition(config FileFilter) (*fileCondition, error) {
+	var includes []string
+	for _, pattern := range config.Include {
+		if pattern != "*" && pattern != "**" {
+			includes = append(includes, pattern)
+		}
+	}
+	if len(includes) == 0 && len(config.Exclude) == 0 && len(config.Extensions) == 0 && len(config.Paths) == 0 {
+		return nil, nil
+	}
+	config.Include = includes
+
+	include, err := compileGlobs(includes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file filter: %w", err)
+	}
+	exclude, err := compileGlobs(config.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file filter: %w", err)
+	}
+
+	c := &fileCondition{config: config, include: include, exclude: exclude}
+	for _, ext := range config.Extensions {
+		c.extensions = append(c.extensions, "."+strings.TrimPrefix(ext, "."))
+	}
+	for _, p := range config.Paths {
+		c.paths = append(c.paths, strings.Trim(p, "/"))
+	}
+	return c, nil
+}
+
+// Apply returns true if the notification's subject changes a subscribed file
+func (c *fileCondition) Apply(n *github.Notification) bool {
+	return applyCheck(c, n)
+}
+
+// NeedsDetails returns true because the changed files are a subject detail
+func (c *fileCondition) NeedsDetails() bool {
+	return true
+}
+
+// Description returns a human-readable description of the condition
+func (c *fileCondition) Description() string {
+	var includes []string
+	includes = append(includes, c.config.Include...)
+	for _, p := range c.paths {
+		includes = append(includes, p+"/")
+	}
+	includes = append(includes, c.extensions...)
+
+	var parts []string
+	if len(includes) > 0 {
+		parts = append(parts, "changes "+strings.Join(includes, ", "))
+	} else {
+		parts = append(parts, "changes any file")
+	}
+	if len(c.config.Exclude) > 0 {
+		parts = append(parts, "not "+strings.Join(c.config.Exclude, ", "))
+	}
+	return strings.Join(parts, " but ")
+}
+
+// Check checks the files changed by a notification's subject
+func (c *fileCondition) Check(n *github.Notification) Check {
+	check := Check{Condition: c.Description()}
+
+	switch n.GetSubject().GetType() {
+	case "PullRequest", "Commit":
+	default:
+		check.Outcome = OutcomeNotApplicable
+		check.Reason = "only pull requests and commits change files"
+		return check
+	}
+
+	details := enrichment.Lookup(n)
+	if details == nil || len(details.Files) == 0 {
+		check.Outcome = OutcomeUnknown
+		check.Reason = "changed files are unknown"
+		return check
+	}
+
+	for _, file := range details.Files {
+		if c.selects(file) {
+			check.Outcome = OutcomeMatch
+			check.Reason = "changes " + file
+			return check
+		}
+	}
+
+	check.Outcome = OutcomeNoMatch
+	check.Reason = fmt.Sprintf("none of the %d changed files match", len(details.Files))
+	return check
+}
+
+// selects reports whether the filter selects a changed file
+func (c *fileCondition) selects(file string) bool {
+	if matchFile(c.exclude, file) {
+		return false
+	}
+	if len(c.include) == 0 && len(c.paths) == 0 && len(c.extensions) == 0 {
+		return true
+	}
+	if matchFile(c.include, file) {
+		return true
+	}
+	for _, p := range c.paths {
+		if file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	for _, ext := range c.extensions {
+		if strings.EqualFold(path.Ext(file), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFile reports whether a file matches any of the patterns. Patterns
+// without a slash match the file name, like in .gitignore.
+func matchFile(globs []glob.Glob, file string) bool {
+	for _, g := range globs {
+		if g.Match(file) || g.Match(path.Base(file)) {
+			return true
+		}
+	}
+	return false
+}
+
+// participatingReasons are the notification reasons of threads the user takes part in
+var participatingReasons = []string{"author", "comment", "mention", "team_mention", "manual"}
+
+// discussionCondition matches discussions
+type discussionCondition struct {
+	config DiscussionFilter
+}
+
+// newDiscussionCondition compiles a discussion filter, or returns nil when it
+// lets every discussion through
+func newDiscussionCondition(config DiscussionFilter) *discussionCondition {
+	c := &discussionCondition{config: config}
+	if len(config.Keywords) == 0 && len(config.ExcludeKeywords) == 0 && !config.MentionsOnly &&
+		!config.ParticipatingOnly && !c.needsDiscussionData() {
+		return nil
+	}
+	return c
+}
+
+// needsDiscussionData reports whether the filter matches data that
+// notifications do not carry for discussions, such as categories and upvotes
+func (c *discussionCondition) needsDiscussionData() bool {
+	return (!c.config.All && len(c.config.Categories) > 0) || len(c.config.ExcludeCategories) > 0 ||
+		c.config.AnsweredOnly || c.config.UnansweredOnly || c.config.MinUpvotes > 0 || c.config.MinComments > 0 ||
+		(len(c.config.States) > 0 && !containsFold(c.config.States, "all"))
+}
+
+// Apply returns true if the notification's discussion is subscribed
+func (c *discussionCondition) Apply(n *github.Notification) bool {
+	return applyCheck(c, n)
+}
+
+// Description returns a human-readable description of the condition
+func (c *discussionCondition) Description() string {
+	var parts []string
+	if len(c.config.Keywords) > 0 {
+		parts = append(parts, "title mentions "+strings.Join(c.config.Keywords, ", "))
+	}
+	if len(c.config.ExcludeKeywords) > 0 {
+		parts = append(parts, "title does not mention "+strings.Join(c.config.ExcludeKeywords, ", "))
+	}
+	if c.config.MentionsOnly {
+		parts = append(parts, "you are mentioned")
+	}
+	if c.config.ParticipatingOnly {
+		parts = append(parts, "you are participating")
+	}
+	if c.needsDiscussionData() {
+		parts = append(parts, "category, state, answer, upvotes and comments")
+	}
+	return "discussion " + strings.Join(parts, " and ")
+}
+
+// Check checks the discussion of a notification
+func (c *discussionCondition) Check(n *github.Notification) Check {
+	check := Check{Condition: c.Description()}
+
+	if n.GetSubject().GetType() != "Discussion" {
+		check.Outcome = OutcomeNotApplicable
+		check.Reason = "not a discussion"
+		return check
+	}
+
+	title := strings.ToLower(n.GetSubject().GetTitle())
+	reason := n.GetReason()
+	check.Outcome = OutcomeNoMatch
+	switch {
+	case len(c.config.Keywords) > 0 && !containsKeyword(title, c.config.Keywords):
+		check.Reason = "title mentions none of the keywords"
+	case containsKeyword(title, c.config.ExcludeKeywords):
+		check.Reason = "title mentions an excluded keyword"
+	case c.config.MentionsOnly && reason != "mention" && reason != "team_mention":
+		check.Reason = "notification reason is " + reason
+	case c.config.ParticipatingOnly && !containsFold(participatingReasons, reason):
+		check.Reason = "notification reason is " + reason
+	case c.needsDiscussionData():
+		// Discussion notifications do not link to the discussion
+		check.Outcome = OutcomeUnknown
+		check.Reason = "category, state, answer, upvotes and comments of discussions are unknown"
+	default:
+		check.Outcome = OutcomeMatch
+	}
+	return check
+}
+
+// containsKeyword reports whether a lowercase text contains any keyword
+func containsKeyword(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values contain a value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,326 @@
+package subscriptions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DeclarativeFileName is the conventional name of a declarative
+// subscriptions file
+const DeclarativeFileName = "subscriptions.yaml"
+
+// declaredFile is the document of a declarative subscriptions file
+type declaredFile struct {
+	// Include are further subscriptions files, relative to this one
+	Include []string `yaml:"include"`
+	// Templates are named subscription settings entries can start from
+	Templates map[string]yaml.Node `yaml:"templates"`
+	// Subscriptions are the declared subscriptions
+	Subscriptions []yaml.Node `yaml:"subscriptions"`
+}
+
+// declaredSubscription is an entry of a declarative subscriptions file
+type declaredSubscription struct {
+	// Repository is owner/repo or a pattern such as owner/*-service
+	Repository string `yaml:"repository"`
+	// Template names the settings the entry starts from
+	Template string `yaml:"template"`
+	// Priority defaults to normal
+	Priority *Priority `yaml:"priority"`
+	// Active defaults to true
+	Active *bool `yaml:"active"`
+	// Config overrides settings of the template
+	Config yaml.Node `yaml:"config"`
+}
+
+// Issue is a problem found in a declarative subscriptions file
+type Issue struct {
+	// File is the file with the problem
+	File string `json:"file"`
+	// Line is the line of the problem, 0 for the whole file
+	Line int `json:"line,omitempty"`
+	// Repository is the repository or pattern of the entry with the problem
+	Repository string `json:"repository,omitempty"`
+	// Field is the setting with the problem
+	Field string `json:"field,omitempty"`
+	// Message describes the problem
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+	b.WriteString(i.File)
+	if i.Line > 0 {
+		fmt.Fprintf(&b, ":%d", i.Line)
+	}
+	b.WriteString(": ")
+	if i.Repository != "" {
+		b.WriteString(i.Repository + ": ")
+	}
+	if i.Field != "" {
+		b.WriteString(i.Field + ": ")
+	}
+	b.WriteString(i.Message)
+	return b.String()
+}
+
+// loadedFile is a parsed declarative subscriptions file
+type loadedFile struct {
+	path string
+	doc  declaredFile
+}
+
+// declarationLoader reads a declarative subscriptions file and its includes
+type declarationLoader struct {
+	files    []loadedFile
+	visiting map[string]bool
+	loaded   map[string]bool
+	issues   []Issue
+}
+
+// LoadFile reads a declarative subscriptions file and the files it
+// includes. Entries start from the default settings, then apply their
+// template and their own config. An entry of the including file replaces an
+// included entry for the same repository, and a template of the including
+// file replaces an included template with the same name.
+//
+// Entries with problems are left out and reported as issues, validated as
+// subscriptions added on the command line are. An error is returned only
+// when the file itself cannot be read or parsed.
+func (m *Manager) LoadFile(path string) ([]RepositorySubscription, []Issue, error) {
+	l := &declarationLoader{visiting: make(map[string]bool), loaded: make(map[string]bool)}
+	if err := l.load(path); err != nil {
+		return nil, nil, err
+	}
+
+	templates := make(map[string]yaml.Node)
+	for _, f := range l.files {
+		names := make([]string, 0, len(f.doc.Templates))
+		for name := range f.doc.Templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			node := f.doc.Templates[name]
+			for _, key := range unknownKeys(&node, reflect.TypeOf(SubscriptionConfig{})) {
+				l.issues = append(l.issues, Issue{File: f.path, Line: key.Line, Field: "templates." + name, Message: fmt.Sprintf("unknown setting %q", key.Value)})
+			}
+			templates[name] = node
+		}
+	}
+
+	// Files are loaded after their includes, so the including files come last
+	var declared []RepositorySubscription
+	seen := make(map[string]string)
+	for i := len(l.files) - 1; i >= 0; i-- {
+		f := l.files[i]
+		for j := range f.doc.Subscriptions {
+			sub, issues := m.declare(f.path, &f.doc.Subscriptions[j], templates)
+			l.issues = append(l.issues, issues...)
+			if sub == nil {
+				continue
+			}
+
+			key := strings.ToLower(sub.Repository)
+			if previous, ok := seen[key]; ok {
+				if previous == f.path {
+					l.issues = append(l.issues, Issue{File: f.path, Line: f.doc.Subscriptions[j].Line, Repository: sub.Repository, Message: "declared more than once"})
+				}
+				continue
+			}
+			seen[key] = f.path
+			declared = append(declared, *sub)
+		}
+	}
+
+	return declared, l.issues, nil
+}
+
+// load reads a file after the files it includes
+func (l *declarationLoader) load(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if l.loaded[abs] {
+		return nil
+	}
+	l.visiting[abs] = true
+	defer delete(l.visiting, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read subscriptions file: %w", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	f := loadedFile{path: path}
+	if len(root.Content) > 0 {
+		doc := root.Content[0]
+		for _, key := range unknownKeys(doc, reflect.TypeOf(declaredFile{})) {
+			l.issues = append(l.issues, Issue{File: path, Line: key.Line, Message: fmt.Sprintf("unknown key %q", key.Value)})
+		}
+		if err := doc.Decode(&f.doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		for i, include := range f.doc.Include {
+			line := includeLine(doc, i)
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+			includeAbs, _ := filepath.Abs(include)
+			if l.visiting[includeAbs] {
+				l.issues = append(l.issues, Issue{File: path, Line: line, Field: "include", Message: fmt.Sprintf("%s includes itself", include)})
+				continue
+			}
+			if err := l.load(include); err != nil {
+				l.issues = append(l.issues, Issue{File: path, Line: line, Field: "include", Message: err.Error()})
+			}
+		}
+	}
+
+	l.loaded[abs] = true
+	l.files = append(l.files, f)
+	return nil
+}
+
+// declare turns an entry into a subscription, or reports why it cannot
+func (m *Manager) declare(path string, node *yaml.Node, templates map[string]yaml.Node) (*RepositorySubscription, []Issue) {
+	var issues []Issue
+	report := func(repository, field, message string) {
+		issues = append(issues, Issue{File: path, Line: node.Line, Repository: repository, Field: field, Message: message})
+	}
+
+	for _, key := range unknownKeys(node, reflect.TypeOf(declaredSubscription{})) {
+		issues = append(issues, Issue{File: path, Line: key.Line, Message: fmt.Sprintf("unknown key %q", key.Value)})
+	}
+	var entry declaredSubscription
+	if err := node.Decode(&entry); err != nil {
+		report("", "", err.Error())
+		return nil, issues
+	}
+
+	config := DefaultSubscriptionConfig()
+	if entry.Template != "" {
+		template, ok := templates[entry.Template]
+		if !ok {
+			report(entry.Repository, "template", fmt.Sprintf("unknown template %q", entry.Template))
+			return nil, issues
+		}
+		if err := template.Decode(&config); err != nil {
+			report(entry.Repository, "template", err.Error())
+			return nil, issues
+		}
+	}
+	if !entry.Config.IsZero() {
+		for _, key := range unknownKeys(&entry.Config, reflect.TypeOf(SubscriptionConfig{})) {
+			issues = append(issues, Issue{File: path, Line: key.Line, Repository: entry.Repository, Field: "config", Message: fmt.Sprintf("unknown setting %q", key.Value)})
+		}
+		if err := entry.Config.Decode(&config); err != nil {
+			report(entry.Repository, "config", err.Error())
+			return nil, issues
+		}
+	}
+
+	isPattern := strings.Contains(entry.Repository, "*")
+	sub := RepositorySubscription{
+		Repository: entry.Repository,
+		IsPattern:  isPattern,
+		Priority:   PriorityNormal,
+		Config:     config,
+		Active:     true,
+		HasAccess:  !isPattern,
+		Metadata:   map[string]interface{}{"source": fmt.Sprintf("%s:%d", path, node.Line)},
+	}
+	if entry.Priority != nil {
+		sub.Priority = *entry.Priority
+	}
+	if entry.Active != nil {
+		sub.Active = *entry.Active
+	}
+
+	valid := true
+	if entry.Repository != "" {
+		if err := m.validateRepository(entry.Repository); err != nil {
+			report(entry.Repository, "repository", err.Error())
+			valid = false
+		}
+	}
+	if result := m.validateSubscription(sub); !result.Valid {
+		for _, e := range result.Errors {
+			report(entry.Repository, e.Field, e.Message)
+		}
+		valid = false
+	}
+	if valid {
+		if _, err := Compile(sub); err != nil {
+			report(entry.Repository, "", err.Error())
+			valid = false
+		}
+	}
+	if !valid {
+		return nil, issues
+	}
+	return &sub, issues
+}
+
+// includeLine returns the line of an include of a document
+func includeLine(doc *yaml.Node, i int) int {
+	for k := 0; k+1 < len(doc.Content); k += 2 {
+		if doc.Content[k].Value == "include" && i < len(doc.Content[k+1].Content) {
+			return doc.Content[k+1].Content[i].Line
+		}
+	}
+	return doc.Line
+}
+
+// unknownKeys returns the keys of a mapping, and of the mappings nested in
+// it, that are not fields of a type
+func unknownKeys(node *yaml.Node, t reflect.Type) []*yaml.Node {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(yaml.Node{}) {
+		return nil
+	}
+
+	var unknown []*yaml.Node
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fields[name] = field.Type
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			fieldType, ok := fields[node.Content[i].Value]
+			if !ok {
+				unknown = append(unknown, node.Content[i])
+				continue
+			}
+			unknown = append(unknown, unknownKeys(node.Content[i+1], fieldType)...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			unknown = append(unknown, unknownKeys(item, t.Elem())...)
+		}
+	}
+	return unknown
+}
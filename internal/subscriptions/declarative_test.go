@@ -0,0 +1,160 @@
+package subscriptions
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "team", "services.yaml"), `
+templates:
+  services:
+    activity_types: [prs, releases]
+    frequency: daily
+    branch_filter:
+      all: false
+      main_only: true
+subscriptions:
+  - repository: myorg/*-service
+    template: services
+  - repository: myorg/billing-service
+    template: services
+`)
+	path := filepath.Join(dir, DeclarativeFileName)
+	writeFile(t, path, `
+include:
+  - team/services.yaml
+subscriptions:
+  - repository: myorg/billing-service
+    template: services
+    priority: critical
+    config:
+      frequency: real-time
+      author_filter:
+        exclude: [renovate]
+  - repository: cli/cli
+    active: false
+`)
+
+	declared, issues, err := NewManager(nil, nil).LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("LoadFile() issues = %v", issues)
+	}
+
+	var names []string
+	for _, sub := range declared {
+		names = append(names, sub.Repository)
+	}
+	if want := []string{"myorg/billing-service", "cli/cli", "myorg/*-service"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("LoadFile() = %v, want %v", names, want)
+	}
+
+	billing := declared[0]
+	if billing.Priority != PriorityCritical || billing.Config.Frequency != FrequencyRealTime {
+		t.Errorf("override = %v %v, want critical and real-time", billing.Priority, billing.Config.Frequency)
+	}
+	if !billing.Config.BranchFilter.MainOnly || billing.Config.BranchFilter.All {
+		t.Errorf("override branch filter = %+v, want the template's", billing.Config.BranchFilter)
+	}
+	if !reflect.DeepEqual(billing.Config.AuthorFilter.Exclude, []string{"renovate"}) || !billing.Config.AuthorFilter.ExcludeBots {
+		t.Errorf("override author filter = %+v, want the default with renovate excluded", billing.Config.AuthorFilter)
+	}
+	if !reflect.DeepEqual(billing.Config.ActivityTypes, []ActivityType{ActivityPRs, ActivityReleases}) {
+		t.Errorf("template activity types = %v", billing.Config.ActivityTypes)
+	}
+
+	if cli := declared[1]; cli.Active || cli.Priority != PriorityNormal || !reflect.DeepEqual(cli.Config, DefaultSubscriptionConfig()) {
+		t.Errorf("cli/cli = %+v, want an inactive default subscription", cli)
+	}
+
+	pattern := declared[2]
+	if !pattern.IsPattern || pattern.Config.Frequency != FrequencyDaily {
+		t.Errorf("pattern = %+v, want a daily pattern", pattern)
+	}
+	f, err := Compile(pattern)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if !f.Covers("myorg/search-service") || f.Covers("myorg/website") {
+		t.Error("myorg/*-service covers the wrong repositories")
+	}
+}
+
+func TestLoadFileIssues(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DeclarativeFileName)
+	writeFile(t, path, `include:
+  - missing.yaml
+  - subscriptions.yaml
+templates:
+  typo:
+    activity_type: [prs]
+subscriptions:
+  - repository: org/api
+    template: unknown
+  - repository: "*/api"
+  - repository: org/web
+    config:
+      activity_types: [prz]
+      webhook_url: slack
+  - repository: org/docs
+    prioirty: low
+  - repository: org/docs
+  - repository: org/ok
+    priority: urgent
+`)
+
+	declared, issues, err := NewManager(nil, nil).LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if len(declared) != 1 || declared[0].Repository != "org/docs" {
+		t.Errorf("LoadFile() = %v, want only org/docs", declared)
+	}
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, strings.TrimPrefix(issue.String(), path))
+	}
+	want := []string{
+		`:2: include: failed to read subscriptions file`,
+		`:3: include: ` + path + ` includes itself`,
+		`:6: templates.typo: unknown setting "activity_type"`,
+		`:8: org/api: template: unknown template "unknown"`,
+		`:10: */api: repository: the owner of a pattern cannot contain wildcards`,
+		`:11: org/web: activity_types: unknown activity type "prz"`,
+		`:11: org/web: webhook_url: webhook URL must be an http or https URL`,
+		`:16: unknown key "prioirty"`,
+		`:17: org/docs: declared more than once`,
+		`:18: invalid priority "urgent"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("issues = %q, want %d", got, len(want))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("issue %d = %q, want prefix %q", i, got[i], want[i])
+		}
+	}
+
+	if _, _, err := NewManager(nil, nil).LoadFile(filepath.Join(dir, "none.yaml")); err == nil {
+		t.Error("LoadFile() of a missing file succeeded")
+	}
+}
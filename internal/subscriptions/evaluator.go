@@ -0,0 +1,238 @@
+package subscriptions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/google/go-github/v60/github"
+)
+
+// SubscriptionFilter is a subscription compiled into a filter. A
+// notification matches when none of the subscription's conditions rule it
+// out; conditions that cannot be checked let it through.
+type SubscriptionFilter struct {
+	// Subscription is the compiled subscription
+	Subscription RepositorySubscription
+	// Conditions are the conditions of the subscription, starting with its repository
+	Conditions []Condition
+
+	repository *repositoryCondition
+}
+
+// Compile compiles a subscription into a filter using the activity type of
+// notifications and the enriched details of their subjects: the base branch,
+// author and changed files
+func Compile(subscription RepositorySubscription) (*SubscriptionFilter, error) {
+	repository, err := newRepositoryCondition(subscription.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &SubscriptionFilter{Subscription: subscription, repository: repository}
+	f.Conditions = append(f.Conditions, repository)
+
+	config := subscription.Config
+	if len(config.ActivityTypes) > 0 {
+		f.Conditions = append(f.Conditions, &activityCondition{types: config.ActivityTypes})
+	}
+
+	branch, err := newBranchCondition(config.BranchFilter)
+	if err != nil {
+		return nil, err
+	}
+	if branch != nil {
+		f.Conditions = append(f.Conditions, branch)
+	}
+
+	if author := newAuthorCondition(config.AuthorFilter); author != nil {
+		f.Conditions = append(f.Conditions, author)
+	}
+
+	files, err := newFileCondition(config.FileFilter)
+	if err != nil {
+		return nil, err
+	}
+	if files != nil {
+		f.Conditions = append(f.Conditions, files)
+	}
+
+	if discussion := newDiscussionCondition(config.DiscussionFilter); discussion != nil {
+		f.Conditions = append(f.Conditions, discussion)
+	}
+
+	return f, nil
+}
+
+// Apply returns true if the subscription lets the notification through
+func (f *SubscriptionFilter) Apply(n *github.Notification) bool {
+	for _, c := range f.Conditions {
+		if !c.Apply(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// Description returns a human-readable description of the filter
+func (f *SubscriptionFilter) Description() string {
+	descriptions := make([]string, len(f.Conditions))
+	for i, c := range f.Conditions {
+		descriptions[i] = c.Description()
+	}
+	return fmt.Sprintf("(%s)", strings.Join(descriptions, " AND "))
+}
+
+// NeedsDetails reports whether the subscription matches subject details
+func (f *SubscriptionFilter) NeedsDetails() bool {
+	for _, c := range f.Conditions {
+		if m, ok := c.(interface{ NeedsDetails() bool }); ok && m.NeedsDetails() {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether the subscription is for a repository
+func (f *SubscriptionFilter) Covers(repository string) bool {
+	return f.repository.matches(repository)
+}
+
+// Explanation explains whether a subscription lets a notification through
+type Explanation struct {
+	// Subscription is the repository or pattern of the subscription
+	Subscription string `json:"subscription"`
+	// NotificationID is the ID of the notification
+	NotificationID string `json:"notification_id"`
+	// Repository is the repository of the notification
+	Repository string `json:"repository"`
+	// Title is the title of the notification's subject
+	Title string `json:"title"`
+	// Matched is true when the subscription lets the notification through
+	Matched bool `json:"matched"`
+	// Checks are the outcomes of the subscription's conditions
+	Checks []Check `json:"checks"`
+}
+
+// Explain checks every condition of the subscription for a notification
+func (f *SubscriptionFilter) Explain(n *github.Notification) *Explanation {
+	explanation := &Explanation{
+		Subscription:   f.Subscription.Repository,
+		NotificationID: n.GetID(),
+		Repository:     n.GetRepository().GetFullName(),
+		Title:          n.GetSubject().GetTitle(),
+		Matched:        true,
+	}
+	for _, c := range f.Conditions {
+		check := c.Check(n)
+		if check.Outcome == OutcomeNoMatch {
+			explanation.Matched = false
+		}
+		explanation.Checks = append(explanation.Checks, check)
+	}
+	return explanation
+}
+
+// Evaluator gates notifications by the subscriptions of their repositories.
+// Notifications of repositories without an active subscription are let
+// through, so subscribing to one repository does not hide the others.
+type Evaluator struct {
+	filters []*SubscriptionFilter
+}
+
+// NewEvaluator compiles the active subscriptions
+func NewEvaluator(subscriptions []RepositorySubscription) (*Evaluator, error) {
+	e := &Evaluator{}
+	for _, subscription := range subscriptions {
+		if !subscription.Active {
+			continue
+		}
+		f, err := Compile(subscription)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s: %w", subscription.Repository, err)
+		}
+		e.filters = append(e.filters, f)
+	}
+	return e, nil
+}
+
+// Filters returns the compiled subscriptions
+func (e *Evaluator) Filters() []*SubscriptionFilter {
+	return e.filters
+}
+
+// Find returns the subscription with a repository or pattern, or nil if
+// there is none
+func (e *Evaluator) Find(repository string) *SubscriptionFilter {
+	for _, f := range e.filters {
+		if strings.EqualFold(f.Subscription.Repository, repository) {
+			return f
+		}
+	}
+	return nil
+}
+
+// Lookup returns the subscription that governs a repository, or nil if no
+// subscription covers it. A subscription for the repository itself takes
+// precedence over patterns, which are tried in order.
+func (e *Evaluator) Lookup(repository string) *SubscriptionFilter {
+	if f := e.Find(repository); f != nil && !f.Subscription.IsPattern {
+		return f
+	}
+	for _, f := range e.filters {
+		if f.Covers(repository) {
+			return f
+		}
+	}
+	return nil
+}
+
+// Apply returns true if the subscription of the notification's repository
+// lets it through, or the repository has no subscription
+func (e *Evaluator) Apply(n *github.Notification) bool {
+	f := e.Lookup(n.GetRepository().GetFullName())
+	return f == nil || f.Apply(n)
+}
+
+// Description returns a human-readable description of the evaluator
+func (e *Evaluator) Description() string {
+	return fmt.Sprintf("matches the subscription of its repository (%d subscriptions)", len(e.filters))
+}
+
+// NeedsDetails reports whether any subscription matches subject details
+func (e *Evaluator) NeedsDetails() bool {
+	for _, f := range e.filters {
+		if f.NeedsDetails() {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the notifications the subscriptions let through
+func (e *Evaluator) Filter(notifications []*github.Notification) []*github.Notification {
+	var filtered []*github.Notification
+	for _, n := range notifications {
+		if e.Apply(n) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
+// NeedingDetails returns the notifications whose subject details have to be
+// fetched, or fetched again because the subject changed since, before their
+// subscriptions can be evaluated
+func (e *Evaluator) NeedingDetails(notifications []*github.Notification) []*github.Notification {
+	var needing []*github.Notification
+	for _, n := range notifications {
+		f := e.Lookup(n.GetRepository().GetFullName())
+		if f == nil || !f.NeedsDetails() {
+			continue
+		}
+		if enrichment.Stale(n) {
+			needing = append(needing, n)
+		}
+	}
+	return needing
+}
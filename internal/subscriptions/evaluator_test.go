@@ -0,0 +1,137 @@
+package subscriptions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/google/go-github/v60/github"
+)
+
+// testNotification creates a notification with known subject details, or
+// unknown details when details is nil
+func testNotification(store *enrichment.Store, id, repo, subjectType string, details *enrichment.Details) *github.Notification {
+	url := "https://api.github.com/repos/" + repo + "/items/" + id
+	if details != nil {
+		details.FetchedAt = time.Now()
+		store.Set(url, details)
+	}
+	return &github.Notification{
+		ID:         github.String(id),
+		Reason:     github.String("subscribed"),
+		Subject:    &github.NotificationSubject{Type: github.String(subjectType), Title: github.String("Title " + id), URL: github.String(url)},
+		Repository: &github.Repository{FullName: github.String(repo)},
+		UpdatedAt:  &github.Timestamp{Time: time.Now().Add(-time.Hour)},
+	}
+}
+
+func TestEvaluator(t *testing.T) {
+	store := enrichment.NewStore()
+	original := enrichment.Default()
+	enrichment.SetDefault(store)
+	defer enrichment.SetDefault(original)
+
+	// PRs touching docs/** from non-bots on main
+	docs := DefaultSubscriptionConfig()
+	docs.ActivityTypes = []ActivityType{ActivityPRs}
+	docs.BranchFilter = BranchFilter{MainOnly: true}
+	docs.FileFilter = FileFilter{Include: []string{"docs/**"}}
+
+	releases := DefaultSubscriptionConfig()
+	releases.ActivityTypes = []ActivityType{ActivityReleases}
+
+	evaluator, err := NewEvaluator([]RepositorySubscription{
+		{Repository: "org/*", IsPattern: true, Active: true, Config: releases},
+		{Repository: "org/docs", Active: true, Config: docs},
+		{Repository: "org/inactive", Active: false, Config: releases},
+	})
+	if err != nil {
+		t.Fatalf("NewEvaluator() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		notification *github.Notification
+		want         bool
+	}{
+		{"matching pull request", testNotification(store, "1", "org/docs", "PullRequest",
+			&enrichment.Details{Author: "alice", BaseBranch: "main", Files: []string{"README.md", "docs/guide/intro.md"}}), true},
+		{"other branch", testNotification(store, "2", "org/docs", "PullRequest",
+			&enrichment.Details{Author: "alice", BaseBranch: "feature", Files: []string{"docs/a.md"}}), false},
+		{"default branch", testNotification(store, "3", "org/docs", "PullRequest",
+			&enrichment.Details{Author: "alice", BaseBranch: "trunk", DefaultBranch: "trunk", Files: []string{"docs/a.md"}}), true},
+		{"other files", testNotification(store, "4", "org/docs", "PullRequest",
+			&enrichment.Details{Author: "alice", BaseBranch: "main", Files: []string{"main.go"}}), false},
+		{"bot", testNotification(store, "5", "org/docs", "PullRequest",
+			&enrichment.Details{Author: "dependabot[bot]", BaseBranch: "main", Files: []string{"docs/a.md"}}), false},
+		{"unknown details", testNotification(store, "6", "org/docs", "PullRequest", nil), true},
+		{"unsubscribed activity", testNotification(store, "7", "org/docs", "Issue", &enrichment.Details{Author: "alice"}), false},
+		{"pattern", testNotification(store, "8", "org/api", "Release", &enrichment.Details{Author: "alice"}), true},
+		{"pattern activity", testNotification(store, "9", "ORG/api", "Issue", nil), false},
+		{"inactive subscription is covered by the pattern", testNotification(store, "10", "org/inactive", "Issue", nil), false},
+		{"no subscription", testNotification(store, "11", "other/repo", "Issue", nil), true},
+	}
+
+	for _, tt := range tests {
+		if got := evaluator.Apply(tt.notification); got != tt.want {
+			t.Errorf("%s: Apply() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !evaluator.NeedsDetails() {
+		t.Error("NeedsDetails() = false, want true")
+	}
+	needing := evaluator.NeedingDetails([]*github.Notification{tests[0].notification, tests[5].notification, tests[10].notification})
+	if len(needing) != 1 || needing[0].GetID() != "6" {
+		t.Errorf("NeedingDetails() = %d notifications, want only the one without details", len(needing))
+	}
+}
+
+func TestExplain(t *testing.T) {
+	store := enrichment.NewStore()
+	original := enrichment.Default()
+	enrichment.SetDefault(store)
+	defer enrichment.SetDefault(original)
+
+	config := DefaultSubscriptionConfig()
+	config.BranchFilter = BranchFilter{All: true, ExcludePatterns: []string{"release/*"}}
+	config.AuthorFilter = AuthorFilter{Include: []string{"alice"}, Exclude: []string{"mallory"}}
+	config.FileFilter = FileFilter{Extensions: []string{"go"}, Exclude: []string{"*_test.go"}}
+
+	f, err := Compile(RepositorySubscription{Repository: "org/repo", Config: config})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	n := testNotification(store, "1", "org/repo", "PullRequest",
+		&enrichment.Details{Author: "Alice", BaseBranch: "release/1.0", Files: []string{"main_test.go", "cmd/main.go"}})
+	explanation := f.Explain(n)
+	if explanation.Matched {
+		t.Error("Explain() matched a pull request into an excluded branch")
+	}
+
+	want := []Outcome{OutcomeMatch, OutcomeMatch, OutcomeNoMatch, OutcomeMatch, OutcomeMatch, OutcomeNotApplicable}
+	if len(explanation.Checks) != len(want) {
+		t.Fatalf("Explain() = %d checks, want %d: %+v", len(explanation.Checks), len(want), explanation.Checks)
+	}
+	for i, check := range explanation.Checks {
+		if check.Outcome != want[i] {
+			t.Errorf("check %q = %s (%s), want %s", check.Condition, check.Outcome, check.Reason, want[i])
+		}
+	}
+	if reason := explanation.Checks[4].Reason; reason != "changes cmd/main.go" {
+		t.Errorf("file check reason = %q, want the first selected file", reason)
+	}
+
+	// A discussion is checked by its title and reason
+	discussion := testNotification(store, "2", "org/repo", "Discussion", nil)
+	if f.Explain(discussion).Checks[5].Outcome != OutcomeUnknown {
+		t.Error("discussion states cannot be checked from a notification")
+	}
+
+	if _, err := Compile(RepositorySubscription{Repository: "org/repo", Config: SubscriptionConfig{
+		FileFilter: FileFilter{Include: []string{"docs/[a"}},
+	}}); err == nil {
+		t.Error("Compile() accepted an invalid file pattern")
+	}
+}
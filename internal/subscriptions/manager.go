@@ -3,6 +3,7 @@ package subscriptions
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -13,6 +14,10 @@ import (
 type Manager struct {
 	storage Storage
 	client  GitHubClient
+
+	// declared are the subscriptions of a declarative subscriptions file,
+	// which take precedence over stored ones for the same repository
+	declared []RepositorySubscription
 }
 
 // GitHubClient interface for GitHub operations
@@ -31,6 +36,40 @@ func NewManager(storage Storage, client GitHubClient) *Manager {
 	}
 }
 
+// Declare adds the subscriptions of a declarative subscriptions file to the
+// stored ones
+func (m *Manager) Declare(declared []RepositorySubscription) {
+	m.declared = declared
+}
+
+// Merge returns the declared subscriptions followed by the stored ones for
+// repositories and patterns that are not declared
+func Merge(declared, stored []RepositorySubscription) []RepositorySubscription {
+	merged := append([]RepositorySubscription(nil), declared...)
+	for _, sub := range stored {
+		isDeclared := false
+		for _, d := range declared {
+			if strings.EqualFold(d.Repository, sub.Repository) {
+				isDeclared = true
+				break
+			}
+		}
+		if !isDeclared {
+			merged = append(merged, sub)
+		}
+	}
+	return merged
+}
+
+// subscriptions returns the declared and stored subscriptions
+func (m *Manager) subscriptions() ([]RepositorySubscription, error) {
+	stored, err := m.storage.ListSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	return Merge(m.declared, stored), nil
+}
+
 // Subscribe adds a new repository subscription
 func (m *Manager) Subscribe(ctx context.Context, repository string, priority Priority, config SubscriptionConfig) error {
 	// Validate the repository pattern
@@ -84,9 +123,9 @@ func (m *Manager) GetSubscription(repository string) (*RepositorySubscription, e
 	return m.storage.GetSubscription(repository)
 }
 
-// ListSubscriptions returns all subscriptions
+// ListSubscriptions returns all subscriptions, declared ones first
 func (m *Manager) ListSubscriptions() ([]RepositorySubscription, error) {
-	return m.storage.ListSubscriptions()
+	return m.subscriptions()
 }
 
 // UpdateSubscription updates an existing subscription
@@ -119,7 +158,7 @@ func (m *Manager) UpdateSubscription(repository string, updates SubscriptionUpda
 
 // GetStats returns subscription statistics
 func (m *Manager) GetStats() (*SubscriptionStats, error) {
-	subscriptions, err := m.storage.ListSubscriptions()
+	subscriptions, err := m.subscriptions()
 	if err != nil {
 		return nil, err
 	}
@@ -193,7 +232,7 @@ func (m *Manager) ValidateAccess(ctx context.Context) error {
 
 // ExpandPatterns expands wildcard patterns to actual repositories
 func (m *Manager) ExpandPatterns(ctx context.Context) ([]string, error) {
-	subscriptions, err := m.storage.ListSubscriptions()
+	subscriptions, err := m.subscriptions()
 	if err != nil {
 		return nil, err
 	}
@@ -224,11 +263,14 @@ func (m *Manager) validateRepository(repository string) error {
 	// Check for valid pattern
 	if strings.Contains(repository, "*") {
 		parts := strings.Split(repository, "/")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid pattern format, expected 'owner/*'")
+		if len(parts) != 2 || parts[1] == "" {
+			return fmt.Errorf("invalid pattern format, expected 'owner/*' or e.g. 'owner/*-service'")
 		}
-		if parts[1] != "*" {
-			return fmt.Errorf("only 'owner/*' patterns are supported")
+		if strings.ContainsAny(parts[0], "*?[{") {
+			return fmt.Errorf("the owner of a pattern cannot contain wildcards")
+		}
+		if _, err := newRepositoryCondition(repository); err != nil {
+			return err
 		}
 		return nil
 	}
@@ -254,20 +296,28 @@ func (m *Manager) validateAccess(ctx context.Context, repository string) (bool,
 
 // expandPattern expands a wildcard pattern to actual repositories
 func (m *Manager) expandPattern(ctx context.Context, pattern string) ([]string, error) {
-	parts := strings.Split(pattern, "/")
-	if len(parts) != 2 || parts[1] != "*" {
-		return nil, fmt.Errorf("invalid pattern")
+	if err := m.validateRepository(pattern); err != nil {
+		return nil, err
 	}
-
-	org := parts[0]
-	repos, err := m.client.ListOrganizationRepositories(ctx, org)
+	condition, err := newRepositoryCondition(pattern)
 	if err != nil {
 		return nil, err
 	}
+	org := strings.Split(pattern, "/")[0]
+	repos, err := m.client.ListOrganizationRepositories(ctx, org)
+	if err != nil {
+		// The owner may be a user rather than an organization
+		var userErr error
+		if repos, userErr = m.client.ListUserRepositories(ctx, org); userErr != nil {
+			return nil, err
+		}
+	}
 
 	var result []string
 	for _, repo := range repos {
-		result = append(result, repo.GetFullName())
+		if condition.matches(repo.GetFullName()) {
+			result = append(result, repo.GetFullName())
+		}
 	}
 
 	return result, nil
@@ -292,6 +342,21 @@ func (m *Manager) validateSubscription(subscription RepositorySubscription) Vali
 			Message: "at least one activity type must be specified",
 		})
 	}
+	for _, activity := range subscription.Config.ActivityTypes {
+		known := false
+		for _, t := range AllActivityTypes() {
+			if activity == t {
+				known = true
+				break
+			}
+		}
+		if !known {
+			errors = append(errors, ValidationError{
+				Field:   "activity_types",
+				Message: fmt.Sprintf("unknown activity type %q", activity),
+			})
+		}
+	}
 
 	// Validate branch filter
 	if !subscription.Config.BranchFilter.All && len(subscription.Config.BranchFilter.Patterns) == 0 && !subscription.Config.BranchFilter.MainOnly {
@@ -301,6 +366,16 @@ func (m *Manager) validateSubscription(subscription RepositorySubscription) Vali
 		})
 	}
 
+	// Validate webhook URL
+	if webhookURL := subscription.Config.WebhookURL; webhookURL != "" {
+		if u, err := url.Parse(webhookURL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			errors = append(errors, ValidationError{
+				Field:   "webhook_url",
+				Message: "webhook URL must be an http or https URL",
+			})
+		}
+	}
+
 	return ValidationResult{
 		Valid:  len(errors) == 0,
 		Errors: errors,
@@ -14,6 +14,9 @@ import (
 	"golang.org/x/crypto/pbkdf2"
 )
 
+// FileName is the name of the encrypted subscriptions file in the cache directory
+const FileName = "subscriptions.enc"
+
 // Storage interface for subscription persistence
 type Storage interface {
 	AddSubscription(subscription RepositorySubscription) error
@@ -0,0 +1,290 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// WatchClient reads and changes the repositories watched on GitHub
+type WatchClient interface {
+	ListWatchedRepositories(ctx context.Context) ([]*github.Repository, error)
+	GetRepositorySubscription(ctx context.Context, owner, repo string) (*github.Subscription, error)
+	SetRepositorySubscription(ctx context.Context, owner, repo string, subscription *github.Subscription) (*github.Subscription, error)
+	DeleteRepositorySubscription(ctx context.Context, owner, repo string) error
+}
+
+// SyncAction is a change to the watch settings of a repository
+type SyncAction string
+
+const (
+	// SyncWatch starts watching a repository
+	SyncWatch SyncAction = "watch"
+	// SyncUnwatch stops watching a repository
+	SyncUnwatch SyncAction = "unwatch"
+)
+
+// SyncChange is a change that brings the watch settings on GitHub in line
+// with the subscriptions
+type SyncChange struct {
+	// Action is the change to the watch settings
+	Action SyncAction `json:"action"`
+	// Repository is the full name of the repository
+	Repository string `json:"repository"`
+	// Subscription is the repository or pattern of the subscription that
+	// wants the repository watched
+	Subscription string `json:"subscription,omitempty"`
+	// Error is why applying the change failed
+	Error string `json:"error,omitempty"`
+}
+
+// SyncPlan is the difference between the subscriptions and the repositories
+// watched on GitHub
+type SyncPlan struct {
+	// Changes are the changes to make on GitHub, sorted by repository
+	Changes []SyncChange `json:"changes"`
+	// Unchanged is the number of watched repositories that have a subscription
+	Unchanged int `json:"unchanged"`
+	// Ignored are the repositories the plan would change but that are
+	// ignored on GitHub, e.g. muted with gh-notif mute. They are left as
+	// they are, sorted by name.
+	Ignored []string `json:"ignored,omitempty"`
+}
+
+// Count returns the number of changes with an action
+func (p *SyncPlan) Count(action SyncAction) int {
+	count := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// SyncOptions controls how subscriptions are synced with GitHub
+type SyncOptions struct {
+	// KeepUnmanaged keeps watching the repositories that no subscription covers
+	KeepUnmanaged bool
+}
+
+// Plan compares the active subscriptions, with patterns expanded to the
+// repositories they cover, to the repositories watched on GitHub. Watched
+// repositories that no active subscription covers are unwatched, as are
+// repositories whose own subscription is paused. Repositories ignored on
+// GitHub are never changed but listed in the plan's Ignored.
+func (m *Manager) Plan(ctx context.Context, watch WatchClient, opts SyncOptions) (*SyncPlan, error) {
+	list, err := m.subscriptions()
+	if err != nil {
+		return nil, err
+	}
+	// Without subscriptions every watched repository would be unwatched
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no subscriptions to sync, import the watched repositories first")
+	}
+	evaluator, err := NewEvaluator(list)
+	if err != nil {
+		return nil, err
+	}
+
+	paused := make(map[string]bool)
+	for _, sub := range list {
+		if !sub.IsPattern && !sub.Active {
+			paused[strings.ToLower(sub.Repository)] = true
+		}
+	}
+
+	// desired maps the lowercase names of the repositories to watch to
+	// the changes watching them
+	desired := make(map[string]SyncChange)
+	want := func(repository string) {
+		if paused[strings.ToLower(repository)] {
+			return
+		}
+		if f := evaluator.Lookup(repository); f != nil {
+			desired[strings.ToLower(repository)] = SyncChange{Action: SyncWatch, Repository: repository, Subscription: f.Subscription.Repository}
+		}
+	}
+	for _, sub := range list {
+		if sub.Active && !sub.IsPattern {
+			want(sub.Repository)
+		}
+	}
+	if m.client != nil {
+		expanded, err := m.ExpandPatterns(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, repository := range expanded {
+			want(repository)
+		}
+	}
+
+	watched, err := watch.ListWatchedRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SyncPlan{}
+	isWatched := make(map[string]bool, len(watched))
+	for _, repo := range watched {
+		name := repo.GetFullName()
+		isWatched[strings.ToLower(name)] = true
+
+		if _, ok := desired[strings.ToLower(name)]; ok {
+			plan.Unchanged++
+			continue
+		}
+		// Patterns are not always expanded, e.g. for user accounts, so a
+		// repository they cover stays watched
+		if !paused[strings.ToLower(name)] && evaluator.Lookup(name) != nil {
+			plan.Unchanged++
+			continue
+		}
+		if !opts.KeepUnmanaged || paused[strings.ToLower(name)] {
+			plan.Changes = append(plan.Changes, SyncChange{Action: SyncUnwatch, Repository: name})
+		}
+	}
+
+	for repository, change := range desired {
+		if !isWatched[repository] {
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	// Watching or unwatching an ignored repository would drop its ignore
+	changes := plan.Changes[:0]
+	for _, change := range plan.Changes {
+		ignored, err := isIgnored(ctx, watch, change.Repository)
+		if err != nil {
+			return nil, err
+		}
+		if ignored {
+			plan.Ignored = append(plan.Ignored, change.Repository)
+			continue
+		}
+		changes = append(changes, change)
+	}
+	plan.Changes = changes
+
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		return strings.ToLower(plan.Changes[i].Repository) < strings.ToLower(plan.Changes[j].Repository)
+	})
+	sort.Slice(plan.Ignored, func(i, j int) bool {
+		return strings.ToLower(plan.Ignored[i]) < strings.ToLower(plan.Ignored[j])
+	})
+	return plan, nil
+}
+
+// isIgnored reports whether a repository is ignored on GitHub
+func isIgnored(ctx context.Context, watch WatchClient, repository string) (bool, error) {
+	owner, repo, ok := strings.Cut(repository, "/")
+	if !ok {
+		return false, nil
+	}
+	subscription, err := watch.GetRepositorySubscription(ctx, owner, repo)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", repository, err)
+	}
+	return subscription.GetIgnored(), nil
+}
+
+// Apply makes the changes of a plan on GitHub. Changes that fail are
+// recorded in the plan and do not stop the others; the number of applied
+// changes is returned with an error describing the failures.
+func (m *Manager) Apply(ctx context.Context, watch WatchClient, plan *SyncPlan) (int, error) {
+	applied := 0
+	var errs []error
+	for i := range plan.Changes {
+		change := &plan.Changes[i]
+		owner, repo, ok := strings.Cut(change.Repository, "/")
+		if !ok {
+			change.Error = "invalid repository"
+			errs = append(errs, fmt.Errorf("%s %s: %s", change.Action, change.Repository, change.Error))
+			continue
+		}
+
+		var err error
+		switch change.Action {
+		case SyncWatch:
+			_, err = watch.SetRepositorySubscription(ctx, owner, repo, &github.Subscription{Subscribed: github.Bool(true), Ignored: github.Bool(false)})
+		case SyncUnwatch:
+			err = watch.DeleteRepositorySubscription(ctx, owner, repo)
+		default:
+			err = fmt.Errorf("unknown action")
+		}
+		if err != nil {
+			change.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s %s: %w", change.Action, change.Repository, err))
+			continue
+		}
+		applied++
+	}
+	return applied, errors.Join(errs...)
+}
+
+// ImportWatched subscribes to the repositories watched on GitHub that no
+// subscription covers yet, and returns their names
+func (m *Manager) ImportWatched(ctx context.Context, watch WatchClient, priority Priority, config SubscriptionConfig, dryRun bool) ([]string, error) {
+	list, err := m.subscriptions()
+	if err != nil {
+		return nil, err
+	}
+	var existing []*SubscriptionFilter
+	for _, sub := range list {
+		f, err := Compile(sub)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %s: %w", sub.Repository, err)
+		}
+		existing = append(existing, f)
+	}
+	covered := func(repository string) bool {
+		for _, f := range existing {
+			if f.Covers(repository) {
+				return true
+			}
+		}
+		return false
+	}
+
+	watched, err := watch.ListWatchedRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []string
+	for _, repo := range watched {
+		name := repo.GetFullName()
+		if name == "" || covered(name) {
+			continue
+		}
+
+		now := time.Now()
+		subscription := RepositorySubscription{
+			Repository:      name,
+			Priority:        priority,
+			Config:          config,
+			Active:          true,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			LastAccessCheck: now,
+			HasAccess:       true, // Watched repositories are accessible
+		}
+		if result := m.validateSubscription(subscription); !result.Valid {
+			return imported, fmt.Errorf("invalid subscription for %s: %v", name, result.Errors)
+		}
+		if !dryRun {
+			if err := m.storage.AddSubscription(subscription); err != nil {
+				return imported, err
+			}
+		}
+		imported = append(imported, name)
+	}
+	sort.Strings(imported)
+	return imported, nil
+}
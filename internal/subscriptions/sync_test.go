@@ -0,0 +1,198 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// fakeGitHub is an organization listing and the watch settings of a user
+type fakeGitHub struct {
+	orgs    map[string][]string
+	watched map[string]bool
+	ignored map[string]bool
+	failing map[string]bool
+}
+
+func repositories(names []string) []*github.Repository {
+	var repos []*github.Repository
+	for _, name := range names {
+		repos = append(repos, &github.Repository{FullName: github.String(name)})
+	}
+	return repos
+}
+
+func (g *fakeGitHub) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	return &github.Repository{FullName: github.String(owner + "/" + repo)}, nil
+}
+
+func (g *fakeGitHub) ListUserRepositories(ctx context.Context, user string) ([]*github.Repository, error) {
+	return nil, errors.New("not found")
+}
+
+func (g *fakeGitHub) ListOrganizationRepositories(ctx context.Context, org string) ([]*github.Repository, error) {
+	names, ok := g.orgs[org]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return repositories(names), nil
+}
+
+func (g *fakeGitHub) CheckRepositoryAccess(ctx context.Context, owner, repo string) (bool, error) {
+	return true, nil
+}
+
+func (g *fakeGitHub) ListWatchedRepositories(ctx context.Context) ([]*github.Repository, error) {
+	var names []string
+	for name := range g.watched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return repositories(names), nil
+}
+
+func (g *fakeGitHub) GetRepositorySubscription(ctx context.Context, owner, repo string) (*github.Subscription, error) {
+	name := owner + "/" + repo
+	if !g.watched[name] && !g.ignored[name] {
+		return nil, nil
+	}
+	return &github.Subscription{Subscribed: github.Bool(g.watched[name]), Ignored: github.Bool(g.ignored[name])}, nil
+}
+
+func (g *fakeGitHub) SetRepositorySubscription(ctx context.Context, owner, repo string, subscription *github.Subscription) (*github.Subscription, error) {
+	if g.failing[owner+"/"+repo] {
+		return nil, errors.New("forbidden")
+	}
+	g.watched[owner+"/"+repo] = subscription.GetSubscribed()
+	return subscription, nil
+}
+
+func (g *fakeGitHub) DeleteRepositorySubscription(ctx context.Context, owner, repo string) error {
+	if g.failing[owner+"/"+repo] {
+		return errors.New("forbidden")
+	}
+	delete(g.watched, owner+"/"+repo)
+	return nil
+}
+
+func newTestManager(t *testing.T, client GitHubClient, subs ...RepositorySubscription) *Manager {
+	t.Helper()
+	storage, err := NewFileStorage(filepath.Join(t.TempDir(), FileName), "test")
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	for _, sub := range subs {
+		if err := storage.AddSubscription(sub); err != nil {
+			t.Fatalf("AddSubscription() error = %v", err)
+		}
+	}
+	return NewManager(storage, client)
+}
+
+func TestSync(t *testing.T) {
+	gh := &fakeGitHub{
+		orgs:    map[string][]string{"org": {"org/api", "org/web", "org/paused", "org/muted"}},
+		watched: map[string]bool{"org/web": true, "org/paused": true, "other/old": true, "me/dotfiles": true, "other/muted": true},
+		ignored: map[string]bool{"org/muted": true, "other/muted": true},
+		failing: map[string]bool{"other/old": true},
+	}
+	config := DefaultSubscriptionConfig()
+	manager := newTestManager(t, gh,
+		RepositorySubscription{Repository: "org/*", IsPattern: true, Active: true, Config: config},
+		RepositorySubscription{Repository: "org/paused", Active: false, Config: config},
+		RepositorySubscription{Repository: "me/*", IsPattern: true, Active: true, Config: config},
+		RepositorySubscription{Repository: "cli/cli", Active: true, Config: config},
+	)
+
+	plan, err := manager.Plan(context.Background(), gh, SyncOptions{})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	want := []SyncChange{
+		{Action: SyncWatch, Repository: "cli/cli", Subscription: "cli/cli"},
+		{Action: SyncWatch, Repository: "org/api", Subscription: "org/*"},
+		{Action: SyncUnwatch, Repository: "org/paused"},
+		{Action: SyncUnwatch, Repository: "other/old"},
+	}
+	if !reflect.DeepEqual(plan.Changes, want) {
+		t.Errorf("Plan() changes = %+v, want %+v", plan.Changes, want)
+	}
+	// me/* cannot be expanded, but keeps me/dotfiles watched
+	if plan.Unchanged != 2 {
+		t.Errorf("Plan() unchanged = %d, want 2", plan.Unchanged)
+	}
+	// Muted repositories are neither watched nor unwatched
+	if want := []string{"org/muted", "other/muted"}; !reflect.DeepEqual(plan.Ignored, want) {
+		t.Errorf("Plan() ignored = %v, want %v", plan.Ignored, want)
+	}
+
+	kept, err := manager.Plan(context.Background(), gh, SyncOptions{KeepUnmanaged: true})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if kept.Count(SyncUnwatch) != 1 || kept.Changes[2].Repository != "org/paused" {
+		t.Errorf("Plan(KeepUnmanaged) changes = %+v, want only the paused repository unwatched", kept.Changes)
+	}
+
+	applied, err := manager.Apply(context.Background(), gh, plan)
+	if err == nil || !strings.Contains(err.Error(), "other/old") {
+		t.Errorf("Apply() error = %v, want the failed change", err)
+	}
+	if applied != 3 || plan.Changes[3].Error == "" {
+		t.Errorf("Apply() = %d, changes %+v, want 3 applied and the error recorded", applied, plan.Changes)
+	}
+	if !gh.watched["cli/cli"] || !gh.watched["org/api"] || gh.watched["org/paused"] {
+		t.Errorf("watched = %v", gh.watched)
+	}
+	if !gh.ignored["org/muted"] || gh.watched["org/muted"] || !gh.ignored["other/muted"] {
+		t.Errorf("Apply() changed muted repositories: watched %v", gh.watched)
+	}
+
+	empty := newTestManager(t, gh)
+	if _, err := empty.Plan(context.Background(), gh, SyncOptions{}); err == nil {
+		t.Error("Plan() without subscriptions succeeded")
+	}
+}
+
+func TestImportWatched(t *testing.T) {
+	gh := &fakeGitHub{watched: map[string]bool{"org/api": true, "cli/cli": true, "me/dotfiles": true}}
+	manager := newTestManager(t, gh,
+		RepositorySubscription{Repository: "org/*", IsPattern: true, Active: true, Config: DefaultSubscriptionConfig()},
+	)
+
+	dryRun, err := manager.ImportWatched(context.Background(), gh, PriorityLow, DefaultSubscriptionConfig(), true)
+	if err != nil {
+		t.Fatalf("ImportWatched(dry run) error = %v", err)
+	}
+	if list, _ := manager.ListSubscriptions(); len(list) != 1 {
+		t.Errorf("ImportWatched(dry run) added subscriptions: %v", list)
+	}
+
+	imported, err := manager.ImportWatched(context.Background(), gh, PriorityLow, DefaultSubscriptionConfig(), false)
+	if err != nil {
+		t.Fatalf("ImportWatched() error = %v", err)
+	}
+	want := []string{"cli/cli", "me/dotfiles"}
+	if !reflect.DeepEqual(imported, want) || !reflect.DeepEqual(dryRun, want) {
+		t.Errorf("ImportWatched() = %v, dry run %v, want %v", imported, dryRun, want)
+	}
+
+	sub, err := manager.GetSubscription("cli/cli")
+	if err != nil {
+		t.Fatalf("GetSubscription() error = %v", err)
+	}
+	if sub.Priority != PriorityLow || !sub.Active {
+		t.Errorf("imported subscription = %+v", sub)
+	}
+
+	again, err := manager.ImportWatched(context.Background(), gh, PriorityLow, DefaultSubscriptionConfig(), false)
+	if err != nil || len(again) != 0 {
+		t.Errorf("ImportWatched() again = %v, %v, want nothing", again, err)
+	}
+}
@@ -1,7 +1,10 @@
 package subscriptions
 
 import (
+	"fmt"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Priority levels for repository subscriptions
@@ -40,6 +43,24 @@ func ParsePriority(s string) Priority {
 	}
 }
 
+// UnmarshalYAML parses a priority name such as critical
+func (p *Priority) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	if ParsePriority(s).String() != s {
+		return fmt.Errorf("invalid priority %q (must be low, normal or critical)", s)
+	}
+	*p = ParsePriority(s)
+	return nil
+}
+
+// MarshalYAML writes the name of the priority
+func (p Priority) MarshalYAML() (interface{}, error) {
+	return p.String(), nil
+}
+
 // Frequency defines notification frequency
 type Frequency int
 
@@ -76,6 +97,24 @@ func ParseFrequency(s string) Frequency {
 	}
 }
 
+// UnmarshalYAML parses a frequency name such as daily
+func (f *Frequency) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	if ParseFrequency(s).String() != s {
+		return fmt.Errorf("invalid frequency %q (must be real-time, hourly or daily)", s)
+	}
+	*f = ParseFrequency(s)
+	return nil
+}
+
+// MarshalYAML writes the name of the frequency
+func (f Frequency) MarshalYAML() (interface{}, error) {
+	return f.String(), nil
+}
+
 // ActivityType represents different types of repository activities
 type ActivityType string
 
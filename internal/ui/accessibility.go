@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/google/go-github/v60/github"
 )
@@ -53,7 +54,7 @@ func ScreenReaderDescription(notification *github.Notification) string {
 	var sb strings.Builder
 
 	// Add read status
-	if notification.GetUnread() {
+	if readstate.IsUnread(notification) {
 		sb.WriteString("Unread notification. ")
 	} else {
 		sb.WriteString("Read notification. ")
@@ -20,6 +20,9 @@ func RunApp(ctx context.Context, client *githubclient.Client) error {
 		return fmt.Errorf("failed to get notifications: %w", err)
 	}
 
+	// The detail pane shows the subject details once they are fetched
+	go client.FetchNotificationDetails(notifications)
+
 	// Display notifications with enhanced UI
 	return DisplayEnhancedNotifications(notifications)
 }
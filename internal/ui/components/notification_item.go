@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/google/go-github/v60/github"
 )
@@ -44,7 +45,7 @@ func (ni *NotificationItem) renderCompact(style lipgloss.Style) string {
 
 	// Status indicator with enhanced styling
 	var indicator string
-	if n.GetUnread() {
+	if readstate.IsUnread(n) {
 		indicator = ni.styles.UnreadIndicator.Render(ni.symbols.UnreadIndicator + " NEW")
 	} else {
 		indicator = ni.styles.ReadIndicator.Render(ni.symbols.ReadIndicator + " READ")
@@ -159,7 +160,7 @@ func (ni *NotificationItem) renderDetailed(style lipgloss.Style) string {
 
 	// Header line with status and type
 	var statusIcon string
-	if n.GetUnread() {
+	if readstate.IsUnread(n) {
 		statusIcon = ni.styles.BadgeError.Render("● UNREAD")
 	} else {
 		statusIcon = ni.styles.BadgeSuccess.Render("○ READ")
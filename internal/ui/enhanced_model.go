@@ -3,8 +3,12 @@ package ui
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/SharanRP/gh-notif/internal/snooze"
 	"github.com/SharanRP/gh-notif/internal/ui/components"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,6 +20,7 @@ import (
 type EnhancedModel struct {
 	// Data
 	notifications []*github.Notification
+	scorer        *scoring.Scorer
 
 	// Components
 	registry    *components.ComponentRegistry
@@ -260,6 +265,7 @@ func NewEnhancedModel(notifications []*github.Notification) *EnhancedModel {
 
 	model := &EnhancedModel{
 		notifications: notifications,
+		scorer:        scoring.DefaultScorer(),
 		registry:      registry,
 		layout:        layout,
 		virtualList:   virtualList,
@@ -575,6 +581,19 @@ func (m *EnhancedModel) showNotificationDetail(item *components.NotificationItem
 		notification.GetSubject().GetURL(),
 	)
 
+	// The details of the subject are shown once they are fetched
+	if details := renderSubjectDetails(enrichment.Lookup(notification)); details != "" {
+		content += "\n## Details\n"
+		for _, line := range strings.Split(strings.TrimSpace(details), "\n") {
+			content += "- " + line + "\n"
+		}
+	}
+
+	if score := scoreOf(m.scorer, notification); score != nil {
+		plain := func(s string) string { return s }
+		content += fmt.Sprintf("\n## Score: %d/100\n```\n%s```\n", score.Total, scoreBars(score, plain, plain))
+	}
+
 	m.markdown.SetContent(content)
 }
 
@@ -612,7 +631,8 @@ type RefreshCompleteMsg struct{}
 
 // DisplayEnhancedNotifications displays notifications using the enhanced UI
 func DisplayEnhancedNotifications(notifications []*github.Notification) error {
-	model := NewEnhancedModel(notifications)
+	// Snoozed notifications stay hidden until their snooze ends
+	model := NewEnhancedModel(snooze.Default().Filter(notifications))
 
 	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	_, err := p.Run()
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/SharanRP/gh-notif/internal/filter/persistent"
+	"github.com/SharanRP/gh-notif/internal/scoring"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -46,6 +49,7 @@ type Model struct {
 	selected      int
 	filterString  string
 	filteredItems []*github.Notification
+	scorer        *scoring.Scorer
 
 	// UI Components
 	viewport  viewport.Model
@@ -181,6 +185,7 @@ func NewModel(notifications []*github.Notification) Model {
 	m := Model{
 		notifications: notifications,
 		filteredItems: notifications,
+		scorer:        scoring.DefaultScorer(),
 		selected:      0,
 		help:          h,
 		spinner:       s,
@@ -211,6 +216,14 @@ func (m *Model) filterNotifications() {
 		return
 	}
 
+	// Filter expressions such as score:>70 match like in list and watch
+	if strings.Contains(m.filterString, ":") {
+		if expr, err := persistent.NewParser(nil).WithScorer(m.scorer).Parse(m.filterString); err == nil {
+			m.setFilteredItems(filterWith(expr, m.notifications))
+			return
+		}
+	}
+
 	filter := strings.ToLower(m.filterString)
 	var filtered []*github.Notification
 
@@ -229,12 +242,28 @@ func (m *Model) filterNotifications() {
 		}
 	}
 
+	m.setFilteredItems(filtered)
+}
+
+// setFilteredItems shows the filtered notifications, keeping the selection in range
+func (m *Model) setFilteredItems(filtered []*github.Notification) {
 	m.filteredItems = filtered
 	if len(filtered) > 0 && m.selected >= len(filtered) {
 		m.selected = len(filtered) - 1
 	}
 }
 
+// filterWith returns the notifications that match a filter
+func filterWith(f filter.Filter, notifications []*github.Notification) []*github.Notification {
+	var filtered []*github.Notification
+	for _, n := range notifications {
+		if f.Apply(n) {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
+
 // getSelectedNotification returns the currently selected notification
 func (m Model) getSelectedNotification() *github.Notification {
 	if len(m.filteredItems) == 0 || m.selected < 0 || m.selected >= len(m.filteredItems) {
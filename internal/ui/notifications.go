@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/snooze"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -13,6 +14,9 @@ import (
 
 // DisplayNotifications shows the notifications in a terminal UI
 func DisplayNotifications(notifications []*github.Notification) error {
+	// Snoozed notifications stay hidden until their snooze ends
+	notifications = snooze.Default().Filter(notifications)
+
 	if len(notifications) == 0 {
 		fmt.Println("No notifications found.")
 		return nil
@@ -32,6 +36,9 @@ func DisplayNotifications(notifications []*github.Notification) error {
 
 // DisplayNotificationsWithOptions shows notifications with custom options
 func DisplayNotificationsWithOptions(notifications []*github.Notification, options DisplayOptions) error {
+	// Snoozed notifications stay hidden until their snooze ends
+	notifications = snooze.Default().Filter(notifications)
+
 	if len(notifications) == 0 {
 		fmt.Println("No notifications found.")
 		return nil
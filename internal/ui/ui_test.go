@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/scoring"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/google/go-github/v60/github"
 )
@@ -38,6 +40,31 @@ func TestModelCreation(t *testing.T) {
 	}
 }
 
+// TestScoreBars tests the bar chart of the score factors
+func TestScoreBars(t *testing.T) {
+	score := &scoring.NotificationScore{
+		Total: 45,
+		Breakdown: []scoring.FactorScore{
+			{Factor: "age", Raw: 1, Weight: 0.4, Contribution: 40},
+			{Factor: "reason", Raw: 0.5, Weight: 0.1, Contribution: 5},
+		},
+	}
+
+	plain := func(s string) string { return s }
+	lines := strings.Split(strings.TrimSpace(scoreBars(score, plain, plain)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 bars, got %d", len(lines))
+	}
+
+	if !strings.Contains(lines[0], strings.Repeat("█", scoreBarWidth)) || strings.Contains(lines[0], "░") {
+		t.Errorf("Expected a full bar for age, got %q", lines[0])
+	}
+	// The reason weighs a quarter of the age, so its bar is 5 wide and half filled
+	if !strings.Contains(lines[1], "███░░") || !strings.HasSuffix(lines[1], "5.0") {
+		t.Errorf("Expected a half filled short bar for reason, got %q", lines[1])
+	}
+}
+
 // TestModelUpdate tests that the model can be updated
 func TestModelUpdate(t *testing.T) {
 	// Create test notifications
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 
+	"github.com/SharanRP/gh-notif/internal/readstate"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -182,6 +183,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 		}
+		readstate.Default().Clear(msg.id)
 		m.filterNotifications()
 
 	case MarkAllAsReadMsg:
@@ -189,6 +191,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		for i := range m.notifications {
 			m.notifications[i].Unread = github.Bool(false)
 		}
+		readstate.Default().ClearAll()
 		m.filterNotifications()
 
 	case RefreshMsg:
@@ -1,11 +1,17 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/readstate"
+	"github.com/SharanRP/gh-notif/internal/scoring"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/google/go-github/v60/github"
 )
 
 // View renders the UI
@@ -105,7 +111,7 @@ func (m Model) renderCompactView(styles Styles, symbols Symbols) string {
 
 		// Render status indicator
 		var indicator string
-		if n.GetUnread() {
+		if readstate.IsUnread(n) {
 			indicator = styles.UnreadIndicator.Render(symbols.UnreadIndicator)
 		} else {
 			indicator = styles.ReadIndicator.Render(symbols.ReadIndicator)
@@ -181,7 +187,7 @@ func (m Model) renderDetailedView(styles Styles, symbols Symbols) string {
 
 	// Render status
 	var status string
-	if n.GetUnread() {
+	if readstate.IsUnread(n) {
 		status = styles.UnreadIndicator.Render("Unread")
 	} else {
 		status = styles.ReadIndicator.Render("Read")
@@ -196,6 +202,10 @@ func (m Model) renderDetailedView(styles Styles, symbols Symbols) string {
 	url := m.getNotificationURL()
 	sb.WriteString(fmt.Sprintf("URL: %s\n\n", url))
 
+	// Render the details of the subject and the factors of its score
+	sb.WriteString(renderSubjectDetails(enrichment.Lookup(n)))
+	sb.WriteString(renderScoreBreakdown(scoreOf(m.scorer, n), styles))
+
 	// Render actions
 	actions := "Press 'o' to open in browser, 'm' to mark as read"
 	sb.WriteString(styles.DetailFooter.Render(actions))
@@ -225,7 +235,7 @@ func (m Model) renderSplitView(styles Styles, symbols Symbols) string {
 
 		// Render status indicator
 		var indicator string
-		if n.GetUnread() {
+		if readstate.IsUnread(n) {
 			indicator = styles.UnreadIndicator.Render(symbols.UnreadIndicator)
 		} else {
 			indicator = styles.ReadIndicator.Render(symbols.ReadIndicator)
@@ -286,7 +296,7 @@ func (m Model) renderSplitView(styles Styles, symbols Symbols) string {
 
 		// Render status
 		var status string
-		if n.GetUnread() {
+		if readstate.IsUnread(n) {
 			status = styles.UnreadIndicator.Render("Unread")
 		} else {
 			status = styles.ReadIndicator.Render("Read")
@@ -301,6 +311,10 @@ func (m Model) renderSplitView(styles Styles, symbols Symbols) string {
 		url := m.getNotificationURL()
 		rightSb.WriteString(fmt.Sprintf("URL: %s\n\n", url))
 
+		// Render the details of the subject and the factors of its score
+		rightSb.WriteString(renderSubjectDetails(enrichment.Lookup(n)))
+		rightSb.WriteString(renderScoreBreakdown(scoreOf(m.scorer, n), styles))
+
 		rightContent = rightSb.String()
 	}
 
@@ -431,3 +445,91 @@ func formatTimeForView(t time.Time) string {
 		return t.Format("Jan 2")
 	}
 }
+
+// renderSubjectDetails renders the details of a notification's subject, or
+// nothing when they are unknown
+func renderSubjectDetails(details *enrichment.Details) string {
+	if details == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	state := details.State
+	if details.Draft {
+		state += " (draft)"
+	}
+	if state != "" {
+		sb.WriteString(fmt.Sprintf("State: %s\n", state))
+	}
+	if details.Author != "" {
+		sb.WriteString(fmt.Sprintf("Author: %s\n", details.Author))
+	}
+	if len(details.Assignees) > 0 {
+		sb.WriteString(fmt.Sprintf("Assignees: %s\n", strings.Join(details.Assignees, ", ")))
+	}
+	if len(details.Labels) > 0 {
+		sb.WriteString(fmt.Sprintf("Labels: %s\n", strings.Join(details.Labels, ", ")))
+	}
+	if details.ReviewDecision != "" {
+		sb.WriteString(fmt.Sprintf("Review: %s\n", strings.ReplaceAll(details.ReviewDecision, "_", " ")))
+	}
+	if details.CIStatus != "" {
+		sb.WriteString(fmt.Sprintf("CI: %s\n", details.CIStatus))
+	}
+	if details.MergeState != "" {
+		sb.WriteString(fmt.Sprintf("Merge state: %s\n", details.MergeState))
+	}
+	sb.WriteString(fmt.Sprintf("Comments: %d  Reactions: %d\n\n", details.Comments, details.Reactions))
+	return sb.String()
+}
+
+// scoreBarWidth is the width of the bar of the factor with the largest weight
+const scoreBarWidth = 20
+
+// scoreOf scores a notification, returning nil if it cannot be scored
+func scoreOf(scorer *scoring.Scorer, n *github.Notification) *scoring.NotificationScore {
+	if scorer == nil {
+		return nil
+	}
+	scores, err := scorer.Score(context.Background(), []*github.Notification{n})
+	if err != nil {
+		return nil
+	}
+	return scores[n.GetID()]
+}
+
+// scoreBars renders a bar chart of the points each factor adds to a score.
+// A factor's bar is as long as the points it could add at most, and filled up
+// to the points it adds.
+func scoreBars(score *scoring.NotificationScore, filled, empty func(string) string) string {
+	maxWeight := 0.0
+	for _, factor := range score.Breakdown {
+		maxWeight = math.Max(maxWeight, factor.Weight)
+	}
+	if maxWeight <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, factor := range score.Breakdown {
+		full := int(math.Round(factor.Weight / maxWeight * scoreBarWidth))
+		points := int(math.Round(math.Min(factor.Raw, 1) * float64(full)))
+		bar := filled(strings.Repeat("█", points)) + empty(strings.Repeat("░", full-points))
+		sb.WriteString(fmt.Sprintf("%-12s %s%s %5.1f\n", factor.Factor, bar,
+			strings.Repeat(" ", scoreBarWidth-full), factor.Contribution))
+	}
+	return sb.String()
+}
+
+// renderScoreBreakdown renders a score with a bar chart of its factors, or
+// nothing without a score
+func renderScoreBreakdown(score *scoring.NotificationScore, styles Styles) string {
+	if score == nil {
+		return ""
+	}
+
+	bars := scoreBars(score,
+		func(s string) string { return styles.UnreadIndicator.Render(s) },
+		func(s string) string { return styles.ReadIndicator.Render(s) })
+	return fmt.Sprintf("Score: %d/100\n%s\n", score.Total, bars)
+}
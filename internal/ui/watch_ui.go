@@ -245,6 +245,8 @@ func (m WatchModel) View() string {
 				eventType = lipgloss.NewStyle().Foreground(lipgloss.Color("yellow")).Bold(true).Render("UPDATED")
 			case watch.EventRead:
 				eventType = lipgloss.NewStyle().Foreground(lipgloss.Color("blue")).Bold(true).Render("READ")
+			case watch.EventUnsnoozed:
+				eventType = lipgloss.NewStyle().Foreground(lipgloss.Color("magenta")).Bold(true).Render("UNSNOOZED")
 			}
 			s.WriteString(fmt.Sprintf("%s %s - %s\n",
 				eventType,
@@ -0,0 +1,267 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/auth"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/google/go-github/v60/github"
+)
+
+// Urgency is the urgency level of a desktop notification.
+// The values match the freedesktop.org urgency hint.
+type Urgency byte
+
+const (
+	// UrgencyLow is used for low priority notifications
+	UrgencyLow Urgency = 0
+	// UrgencyNormal is used for regular notifications
+	UrgencyNormal Urgency = 1
+	// UrgencyCritical is used for notifications that need attention
+	UrgencyCritical Urgency = 2
+)
+
+// Score thresholds used to derive the urgency of a notification
+const (
+	// CriticalScoreThreshold is the minimum score for critical urgency
+	CriticalScoreThreshold = 75
+	// NormalScoreThreshold is the minimum score for normal urgency
+	NormalScoreThreshold = 40
+)
+
+// String returns the name of the urgency level
+func (u Urgency) String() string {
+	switch u {
+	case UrgencyLow:
+		return "low"
+	case UrgencyCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// UrgencyFromScore derives the urgency of a notification from its score
+func UrgencyFromScore(score *scoring.NotificationScore) Urgency {
+	if score == nil {
+		return UrgencyNormal
+	}
+
+	switch {
+	case score.Total >= CriticalScoreThreshold:
+		return UrgencyCritical
+	case score.Total >= NormalScoreThreshold:
+		return UrgencyNormal
+	default:
+		return UrgencyLow
+	}
+}
+
+// DesktopNotification is a notification shown on the desktop
+type DesktopNotification struct {
+	// Title is the summary line of the notification
+	Title string
+	// Body is the notification text
+	Body string
+	// URL is opened when the notification is clicked
+	URL string
+	// Urgency is the urgency level
+	Urgency Urgency
+	// Count is the number of GitHub notifications this notification stands for
+	Count int
+}
+
+// Notifier shows desktop notifications
+type Notifier interface {
+	// Notify shows a desktop notification
+	Notify(ctx context.Context, n DesktopNotification) error
+	// Close releases the resources held by the notifier
+	Close() error
+}
+
+// NewNotifier creates the desktop notifier selected by the watch options.
+// With the "auto" backend, D-Bus is tried first on Linux and the platform
+// notification command is used otherwise.
+func NewNotifier(options *WatchOptions) (Notifier, error) {
+	openURL := auth.OpenBrowser
+
+	var notifier Notifier
+	switch options.NotifierBackend {
+	case "dbus":
+		dbusNotifier, err := ConnectDBusNotifier(openURL)
+		if err != nil {
+			return nil, err
+		}
+		dbusNotifier.ErrorCallback = options.ErrorCallback
+		notifier = dbusNotifier
+
+	case "command":
+		commandNotifier, err := NewCommandNotifier(options.DesktopNotificationCommand, options.DesktopNotificationArgs)
+		if err != nil {
+			return nil, err
+		}
+		notifier = commandNotifier
+
+	case "", "auto":
+		if runtime.GOOS == "linux" || runtime.GOOS == "freebsd" {
+			if dbusNotifier, err := ConnectDBusNotifier(openURL); err == nil {
+				dbusNotifier.ErrorCallback = options.ErrorCallback
+				notifier = dbusNotifier
+				break
+			}
+		}
+
+		if options.DesktopNotificationCommand == "" {
+			return nil, fmt.Errorf("desktop notifications are not supported on %s without a notification command", runtime.GOOS)
+		}
+		commandNotifier, err := NewCommandNotifier(options.DesktopNotificationCommand, options.DesktopNotificationArgs)
+		if err != nil {
+			return nil, err
+		}
+		notifier = commandNotifier
+
+	default:
+		return nil, fmt.Errorf("unknown notifier backend: %s (must be auto, dbus or command)", options.NotifierBackend)
+	}
+
+	return notifier, nil
+}
+
+// NewDesktopNotification builds a desktop notification for a GitHub notification event
+func NewDesktopNotification(n *github.Notification, eventType EventType, score *scoring.NotificationScore) DesktopNotification {
+	verb := "New"
+	switch eventType {
+	case EventUpdated:
+		verb = "Updated"
+	case EventUnsnoozed:
+		verb = "Unsnoozed"
+	}
+
+	return DesktopNotification{
+		Title:   n.GetRepository().GetFullName(),
+		Body:    fmt.Sprintf("%s %s: %s", verb, n.GetSubject().GetType(), n.GetSubject().GetTitle()),
+		URL:     notificationWebURL(n),
+		Urgency: UrgencyFromScore(score),
+		Count:   1,
+	}
+}
+
+// notificationWebURL returns the web URL of a notification subject
+func notificationWebURL(n *github.Notification) string {
+	if apiURL := n.GetSubject().GetURL(); apiURL != "" {
+		if webURL, err := githubclient.ConvertAPIURLToWebURL(apiURL); err == nil {
+			return webURL
+		}
+	}
+	return n.GetRepository().GetHTMLURL()
+}
+
+// CoalescingNotifier merges bursts of notifications into a single summary
+type CoalescingNotifier struct {
+	// Notifier receives the coalesced notifications
+	Notifier Notifier
+	// Window is how long to wait for more notifications before showing them
+	Window time.Duration
+	// MaxListed is the maximum number of titles listed in a summary
+	MaxListed int
+	// SummaryURL is opened when a summary notification is clicked
+	SummaryURL string
+	// ErrorCallback is called when a delayed notification fails
+	ErrorCallback func(err error)
+
+	mu      sync.Mutex
+	pending []DesktopNotification
+	timer   *time.Timer
+}
+
+// NewCoalescingNotifier creates a notifier that coalesces notifications arriving within window
+func NewCoalescingNotifier(notifier Notifier, window time.Duration) *CoalescingNotifier {
+	return &CoalescingNotifier{
+		Notifier:   notifier,
+		Window:     window,
+		MaxListed:  5,
+		SummaryURL: "https://github.com/notifications",
+	}
+}
+
+// Notify queues a notification until the coalescing window ends
+func (c *CoalescingNotifier) Notify(ctx context.Context, n DesktopNotification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = append(c.pending, n)
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.Window, func() {
+			if err := c.Flush(context.Background()); err != nil && c.ErrorCallback != nil {
+				c.ErrorCallback(err)
+			}
+		})
+	}
+
+	return nil
+}
+
+// Flush shows the pending notifications immediately
+func (c *CoalescingNotifier) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	return c.Notifier.Notify(ctx, c.coalesce(pending))
+}
+
+// Close flushes pending notifications and closes the wrapped notifier
+func (c *CoalescingNotifier) Close() error {
+	flushErr := c.Flush(context.Background())
+	if err := c.Notifier.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// coalesce merges notifications into one, keeping the highest urgency
+func (c *CoalescingNotifier) coalesce(pending []DesktopNotification) DesktopNotification {
+	if len(pending) == 1 {
+		return pending[0]
+	}
+
+	summary := DesktopNotification{
+		URL:     c.SummaryURL,
+		Urgency: UrgencyLow,
+	}
+
+	var lines []string
+	for i, n := range pending {
+		summary.Count += n.Count
+		if n.Urgency > summary.Urgency {
+			summary.Urgency = n.Urgency
+		}
+		if c.MaxListed <= 0 || i < c.MaxListed {
+			lines = append(lines, fmt.Sprintf("%s: %s", n.Title, n.Body))
+		}
+	}
+
+	if hidden := len(pending) - len(lines); hidden > 0 {
+		lines = append(lines, fmt.Sprintf("and %d more", hidden))
+	}
+
+	summary.Title = fmt.Sprintf("%d GitHub notifications", summary.Count)
+	summary.Body = strings.Join(lines, "\n")
+
+	return summary
+}
@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+// CommandNotifier shows notifications by running an external command.
+// Each argument is a Go template executed with the DesktopNotification,
+// for example "{{.Title}}", "{{.Body}}", "{{.URL}}" or "--urgency={{.Urgency}}".
+type CommandNotifier struct {
+	// Command is the program to run
+	Command string
+
+	args []*template.Template
+}
+
+// commandTemplateFuncs are the functions available in command argument templates
+var commandTemplateFuncs = template.FuncMap{
+	"applescript": quoteAppleScript,
+	"powershell":  quotePowerShell,
+}
+
+// NewCommandNotifier creates a notifier that runs command with templated args
+func NewCommandNotifier(command string, args []string) (*CommandNotifier, error) {
+	if command == "" {
+		return nil, fmt.Errorf("notification command not specified")
+	}
+
+	n := &CommandNotifier{Command: command}
+	for i, arg := range args {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Funcs(commandTemplateFuncs).Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification argument %q: %w", arg, err)
+		}
+		n.args = append(n.args, tmpl)
+	}
+
+	return n, nil
+}
+
+// Notify runs the notification command
+func (n *CommandNotifier) Notify(ctx context.Context, notification DesktopNotification) error {
+	args := make([]string, 0, len(n.args))
+	for _, tmpl := range n.args {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, notification); err != nil {
+			return fmt.Errorf("failed to render notification argument: %w", err)
+		}
+		args = append(args, buf.String())
+	}
+
+	output, err := exec.CommandContext(ctx, n.Command, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("notification command failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("notification command failed: %w", err)
+	}
+
+	return nil
+}
+
+// Close does nothing for command notifiers
+func (n *CommandNotifier) Close() error {
+	return nil
+}
+
+// quoteAppleScript quotes a string for use in an AppleScript string literal
+func quoteAppleScript(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// quotePowerShell quotes a string for use in a PowerShell single-quoted string.
+// PowerShell also treats the typographic single quotes as quote characters,
+// so they are doubled as well.
+func quotePowerShell(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '\u2018', '\u2019', '\u201a', '\u201b':
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// windowsToastScript shows a toast notification through the Windows Runtime
+// notification API, attributed to PowerShell so that no app registration is needed
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode({{powershell .Title}})) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode({{powershell .Body}})) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)`
+
+// getDefaultNotificationCommand returns the default notification command for the platform
+func getDefaultNotificationCommand() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "osascript"
+	case "windows":
+		return "powershell"
+	default:
+		return "notify-send"
+	}
+}
+
+// getDefaultNotificationArgs returns the default notification arguments for the platform
+func getDefaultNotificationArgs() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"-e", "display notification {{applescript .Body}} with title {{applescript .Title}}"}
+	case "windows":
+		return []string{"-NoProfile", "-NonInteractive", "-Command", windowsToastScript}
+	default:
+		return []string{"--app-name=gh-notif", "--urgency={{.Urgency}}", "{{.Title}}", "{{.Body}}"}
+	}
+}
@@ -0,0 +1,183 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	// dbusNotificationsName is the well-known bus name of the notification server
+	dbusNotificationsName = "org.freedesktop.Notifications"
+	// dbusNotificationsPath is the object path of the notification server
+	dbusNotificationsPath = dbus.ObjectPath("/org/freedesktop/Notifications")
+	// dbusNotificationsInterface is the interface of the notification server
+	dbusNotificationsInterface = "org.freedesktop.Notifications"
+	// dbusDefaultAction is the action invoked when the notification body is clicked
+	dbusDefaultAction = "default"
+)
+
+// DBusNotifier shows notifications through the freedesktop.org
+// org.freedesktop.Notifications service on the session bus
+type DBusNotifier struct {
+	// AppName is the application name shown by the notification server
+	AppName string
+	// Icon is the icon name or path shown with notifications
+	Icon string
+	// ExpireTimeout is the timeout in milliseconds, -1 for the server default
+	ExpireTimeout int32
+	// OpenURL is called with the notification URL when a notification is clicked
+	OpenURL func(url string) error
+	// ErrorCallback is called when opening a clicked notification fails
+	ErrorCallback func(err error)
+
+	conn    *dbus.Conn
+	obj     dbus.BusObject
+	signals chan *dbus.Signal
+	done    chan struct{}
+
+	mu   sync.Mutex
+	urls map[uint32]string
+}
+
+// NewDBusNotifier creates a notifier that uses the notification service on an
+// already connected bus. The notifier takes ownership of the connection.
+func NewDBusNotifier(conn *dbus.Conn, openURL func(url string) error) (*DBusNotifier, error) {
+	n := &DBusNotifier{
+		AppName:       "gh-notif",
+		ExpireTimeout: -1,
+		OpenURL:       openURL,
+		conn:          conn,
+		obj:           conn.Object(dbusNotificationsName, dbusNotificationsPath),
+		signals:       make(chan *dbus.Signal, 16),
+		done:          make(chan struct{}),
+		urls:          make(map[uint32]string),
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchInterface(dbusNotificationsInterface),
+		dbus.WithMatchObjectPath(dbusNotificationsPath),
+	); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to notification signals: %w", err)
+	}
+
+	conn.Signal(n.signals)
+	go n.handleSignals()
+
+	return n, nil
+}
+
+// ConnectDBusNotifier connects to the session bus and creates a D-Bus notifier
+func ConnectDBusNotifier(openURL func(url string) error) (*DBusNotifier, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+
+	n, err := NewDBusNotifier(conn, openURL)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// Notify shows a notification through the notification server
+func (n *DBusNotifier) Notify(ctx context.Context, notification DesktopNotification) error {
+	var actions []string
+	if notification.URL != "" && n.OpenURL != nil {
+		actions = []string{dbusDefaultAction, "Open"}
+	}
+
+	hints := map[string]dbus.Variant{
+		"urgency": dbus.MakeVariant(byte(notification.Urgency)),
+	}
+
+	var id uint32
+	err := n.obj.CallWithContext(ctx, dbusNotificationsInterface+".Notify", 0,
+		n.AppName,
+		uint32(0),
+		n.Icon,
+		notification.Title,
+		notification.Body,
+		actions,
+		hints,
+		n.ExpireTimeout,
+	).Store(&id)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	if len(actions) > 0 {
+		n.mu.Lock()
+		n.urls[id] = notification.URL
+		n.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Close stops listening for notification signals and closes the connection
+func (n *DBusNotifier) Close() error {
+	select {
+	case <-n.done:
+		return nil
+	default:
+	}
+
+	n.conn.RemoveSignal(n.signals)
+	close(n.done)
+	return n.conn.Close()
+}
+
+// handleSignals opens URLs of clicked notifications and forgets closed ones
+func (n *DBusNotifier) handleSignals() {
+	for {
+		select {
+		case <-n.done:
+			return
+		case signal, ok := <-n.signals:
+			if !ok {
+				return
+			}
+			n.handleSignal(signal)
+		}
+	}
+}
+
+// handleSignal handles a single notification server signal
+func (n *DBusNotifier) handleSignal(signal *dbus.Signal) {
+	if signal.Path != dbusNotificationsPath || len(signal.Body) == 0 {
+		return
+	}
+
+	id, ok := signal.Body[0].(uint32)
+	if !ok {
+		return
+	}
+
+	switch signal.Name {
+	case dbusNotificationsInterface + ".ActionInvoked":
+		if len(signal.Body) < 2 || signal.Body[1] != dbusDefaultAction {
+			return
+		}
+
+		n.mu.Lock()
+		url, ok := n.urls[id]
+		n.mu.Unlock()
+
+		if ok && n.OpenURL != nil {
+			if err := n.OpenURL(url); err != nil && n.ErrorCallback != nil {
+				n.ErrorCallback(fmt.Errorf("failed to open %s: %w", url, err))
+			}
+		}
+
+	case dbusNotificationsInterface + ".NotificationClosed":
+		n.mu.Lock()
+		delete(n.urls, id)
+		n.mu.Unlock()
+	}
+}
@@ -0,0 +1,422 @@
+package watch
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/godbus/dbus/v5"
+	"github.com/google/go-github/v60/github"
+)
+
+// recordingNotifier records the notifications it is asked to show
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []DesktopNotification
+	closed        bool
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n DesktopNotification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, n)
+	return nil
+}
+
+func (r *recordingNotifier) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *recordingNotifier) shown() []DesktopNotification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]DesktopNotification(nil), r.notifications...)
+}
+
+func TestUrgencyFromScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		score *scoring.NotificationScore
+		want  Urgency
+	}{
+		{name: "no score", score: nil, want: UrgencyNormal},
+		{name: "low", score: &scoring.NotificationScore{Total: 10}, want: UrgencyLow},
+		{name: "normal", score: &scoring.NotificationScore{Total: NormalScoreThreshold}, want: UrgencyNormal},
+		{name: "critical", score: &scoring.NotificationScore{Total: 90}, want: UrgencyCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UrgencyFromScore(tt.score); got != tt.want {
+				t.Errorf("UrgencyFromScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDesktopNotification(t *testing.T) {
+	n := &github.Notification{
+		ID: github.String("1"),
+		Subject: &github.NotificationSubject{
+			Title: github.String("Fix the build"),
+			Type:  github.String("PullRequest"),
+			URL:   github.String("https://api.github.com/repos/owner/repo/pulls/7"),
+		},
+		Repository: &github.Repository{FullName: github.String("owner/repo")},
+	}
+
+	got := NewDesktopNotification(n, EventUpdated, &scoring.NotificationScore{Total: 80})
+
+	if got.Title != "owner/repo" || got.Body != "Updated PullRequest: Fix the build" {
+		t.Errorf("unexpected text: %q / %q", got.Title, got.Body)
+	}
+	if got.URL != "https://github.com/owner/repo/pull/7" {
+		t.Errorf("URL = %q", got.URL)
+	}
+	if got.Urgency != UrgencyCritical || got.Count != 1 {
+		t.Errorf("urgency = %v, count = %d", got.Urgency, got.Count)
+	}
+}
+
+func TestCoalescingNotifier(t *testing.T) {
+	recorder := &recordingNotifier{}
+	notifier := NewCoalescingNotifier(recorder, 50*time.Millisecond)
+	notifier.MaxListed = 2
+
+	ctx := context.Background()
+	notifier.Notify(ctx, DesktopNotification{Title: "a/b", Body: "one", Urgency: UrgencyLow, Count: 1})
+	notifier.Notify(ctx, DesktopNotification{Title: "a/b", Body: "two", Urgency: UrgencyCritical, Count: 1})
+	notifier.Notify(ctx, DesktopNotification{Title: "c/d", Body: "three", Urgency: UrgencyNormal, Count: 1})
+
+	if len(recorder.shown()) != 0 {
+		t.Fatalf("notifications shown before the window ended")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.shown()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	shown := recorder.shown()
+	if len(shown) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(shown))
+	}
+
+	summary := shown[0]
+	if summary.Count != 3 || summary.Urgency != UrgencyCritical {
+		t.Errorf("count = %d, urgency = %v", summary.Count, summary.Urgency)
+	}
+	if summary.URL != "https://github.com/notifications" {
+		t.Errorf("URL = %q", summary.URL)
+	}
+	if !strings.Contains(summary.Body, "a/b: two") || !strings.Contains(summary.Body, "and 1 more") {
+		t.Errorf("unexpected body: %q", summary.Body)
+	}
+
+	// A single notification is passed through unchanged when closing
+	notifier.Notify(ctx, DesktopNotification{Title: "e/f", Body: "four", Count: 1})
+	if err := notifier.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	shown = recorder.shown()
+	if len(shown) != 2 || shown[1].Body != "four" {
+		t.Errorf("pending notification was not flushed on close: %+v", shown)
+	}
+	if !recorder.closed {
+		t.Errorf("wrapped notifier was not closed")
+	}
+}
+
+func TestCommandNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows")
+	}
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	notifier, err := NewCommandNotifier("sh", []string{
+		"-c", `printf '%s|%s|%s|%s' "$1" "$2" "$3" "$4" > "$0"`,
+		out, "{{.Title}}", "{{.Body}}", "--urgency={{.Urgency}}", "{{.URL}}",
+	})
+	if err != nil {
+		t.Fatalf("NewCommandNotifier() error = %v", err)
+	}
+
+	err = notifier.Notify(context.Background(), DesktopNotification{
+		Title:   "owner/repo",
+		Body:    "New Issue: it's \"broken\"",
+		URL:     "https://github.com/owner/repo/issues/1",
+		Urgency: UrgencyLow,
+	})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read command output: %v", err)
+	}
+
+	want := `owner/repo|New Issue: it's "broken"|--urgency=low|https://github.com/owner/repo/issues/1`
+	if string(data) != want {
+		t.Errorf("command received %q, want %q", string(data), want)
+	}
+
+	if _, err := NewCommandNotifier("sh", []string{"{{.Missing"}); err == nil {
+		t.Errorf("NewCommandNotifier() with invalid template did not return error")
+	}
+}
+
+func TestQuoteAppleScript(t *testing.T) {
+	if got := quoteAppleScript(`say "hi" \ bye`); got != `"say \"hi\" \\ bye"` {
+		t.Errorf("quoteAppleScript() = %s", got)
+	}
+}
+
+func TestQuotePowerShell(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"hello", "'hello'"},
+		{"it's $HOME", "'it''s $HOME'"},
+		{"it\u2019s", "'it\u2019\u2019s'"},
+	}
+
+	for _, tt := range tests {
+		if got := quotePowerShell(tt.in); got != tt.want {
+			t.Errorf("quotePowerShell(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultNotificationArgsParse(t *testing.T) {
+	if _, err := NewCommandNotifier("true", getDefaultNotificationArgs()); err != nil {
+		t.Fatalf("default notification arguments do not parse: %v", err)
+	}
+	if _, err := NewCommandNotifier("powershell", []string{windowsToastScript}); err != nil {
+		t.Fatalf("windows toast script does not parse: %v", err)
+	}
+}
+
+// notificationServer is a stand-in for org.freedesktop.Notifications
+type notificationServer struct {
+	mu     sync.Mutex
+	nextID uint32
+	calls  []notifyCall
+}
+
+// notifyCall is a recorded Notify call
+type notifyCall struct {
+	summary string
+	body    string
+	actions []string
+	hints   map[string]dbus.Variant
+}
+
+// Notify implements org.freedesktop.Notifications.Notify
+func (s *notificationServer) Notify(appName string, replacesID uint32, icon, summary, body string,
+	actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.calls = append(s.calls, notifyCall{summary: summary, body: body, actions: actions, hints: hints})
+	return s.nextID, nil
+}
+
+// startSessionBus starts a private dbus-daemon and returns its address
+func startSessionBus(t *testing.T) string {
+	t.Helper()
+
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not available")
+	}
+
+	cmd := exec.Command(daemon, "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("failed to get dbus-daemon output: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("failed to start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read dbus-daemon address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+func TestDBusNotifier(t *testing.T) {
+	address := startSessionBus(t)
+
+	// Export the stand-in notification server on the private bus
+	serverConn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("failed to connect server: %v", err)
+	}
+	defer serverConn.Close()
+
+	server := &notificationServer{}
+	if err := serverConn.Export(server, dbusNotificationsPath, dbusNotificationsInterface); err != nil {
+		t.Fatalf("failed to export server: %v", err)
+	}
+	if _, err := serverConn.RequestName(dbusNotificationsName, dbus.NameFlagDoNotQueue); err != nil {
+		t.Fatalf("failed to request name: %v", err)
+	}
+
+	clientConn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("failed to connect client: %v", err)
+	}
+
+	opened := make(chan string, 1)
+	notifier, err := NewDBusNotifier(clientConn, func(url string) error {
+		opened <- url
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("NewDBusNotifier() error = %v", err)
+	}
+	defer notifier.Close()
+
+	err = notifier.Notify(context.Background(), DesktopNotification{
+		Title:   "owner/repo",
+		Body:    "New PullRequest: Fix the build",
+		URL:     "https://github.com/owner/repo/pull/7",
+		Urgency: UrgencyCritical,
+	})
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	server.mu.Lock()
+	calls := append([]notifyCall(nil), server.calls...)
+	server.mu.Unlock()
+
+	if len(calls) != 1 {
+		t.Fatalf("server received %d calls, want 1", len(calls))
+	}
+	if calls[0].summary != "owner/repo" || calls[0].body != "New PullRequest: Fix the build" {
+		t.Errorf("unexpected notification text: %+v", calls[0])
+	}
+	if len(calls[0].actions) != 2 || calls[0].actions[0] != dbusDefaultAction {
+		t.Errorf("unexpected actions: %v", calls[0].actions)
+	}
+	if urgency, ok := calls[0].hints["urgency"].Value().(byte); !ok || urgency != byte(UrgencyCritical) {
+		t.Errorf("unexpected urgency hint: %v", calls[0].hints["urgency"])
+	}
+
+	// Clicking the notification opens its URL
+	if err := serverConn.Emit(dbusNotificationsPath, dbusNotificationsInterface+".ActionInvoked", uint32(1), dbusDefaultAction); err != nil {
+		t.Fatalf("failed to emit ActionInvoked: %v", err)
+	}
+
+	select {
+	case url := <-opened:
+		if url != "https://github.com/owner/repo/pull/7" {
+			t.Errorf("opened %q", url)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the URL to be opened")
+	}
+
+	// Closed notifications are forgotten
+	if err := serverConn.Emit(dbusNotificationsPath, dbusNotificationsInterface+".NotificationClosed", uint32(1), uint32(2)); err != nil {
+		t.Fatalf("failed to emit NotificationClosed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		notifier.mu.Lock()
+		remaining := len(notifier.urls)
+		notifier.mu.Unlock()
+		if remaining == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("closed notification was not forgotten")
+}
+
+func TestWatcherDesktopNotifications(t *testing.T) {
+	mockClient := &MockClient{
+		notifications: []*github.Notification{
+			{
+				ID: github.String("1"),
+				Subject: &github.NotificationSubject{
+					Title: github.String("Review requested"),
+					Type:  github.String("PullRequest"),
+				},
+				Reason:     github.String("review_requested"),
+				Repository: &github.Repository{FullName: github.String("owner/repo")},
+				UpdatedAt:  &github.Timestamp{Time: time.Now()},
+			},
+			{
+				ID: github.String("2"),
+				Subject: &github.NotificationSubject{
+					Title: github.String("Release"),
+					Type:  github.String("Release"),
+				},
+				Reason:     github.String("subscribed"),
+				Repository: &github.Repository{FullName: github.String("owner/repo")},
+				UpdatedAt:  &github.Timestamp{Time: time.Now()},
+			},
+		},
+	}
+
+	recorder := &recordingNotifier{}
+	options := DefaultWatchOptions()
+	options.RefreshInterval = time.Hour
+	options.ShowDesktopNotifications = true
+	options.Notifier = recorder
+	options.CoalesceWindow = time.Hour
+
+	watcher := NewWatcher(mockClient, options)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Failed to start watcher: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		watcher.Mu.RLock()
+		refreshed := watcher.Stats.RefreshCount > 0
+		watcher.Mu.RUnlock()
+		if refreshed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The burst is held back until the watcher stops
+	if len(recorder.shown()) != 0 {
+		t.Fatalf("notifications shown before the coalescing window ended")
+	}
+
+	watcher.Stop()
+
+	shown := recorder.shown()
+	if len(shown) != 1 || shown[0].Count != 2 {
+		t.Fatalf("expected one coalesced notification for 2 events, got %+v", shown)
+	}
+	if !recorder.closed {
+		t.Errorf("notifier was not closed on stop")
+	}
+}
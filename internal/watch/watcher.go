@@ -6,8 +6,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/digest"
+	"github.com/SharanRP/gh-notif/internal/enrichment"
 	"github.com/SharanRP/gh-notif/internal/filter"
 	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/rules"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -31,6 +37,8 @@ const (
 	EventUpdated EventType = "updated"
 	// EventRead is a notification marked as read
 	EventRead EventType = "read"
+	// EventUnsnoozed is a snoozed notification whose snooze ended
+	EventUnsnoozed EventType = "unsnoozed"
 )
 
 // WatchOptions contains options for watching notifications
@@ -49,8 +57,28 @@ type WatchOptions struct {
 	ShowDesktopNotifications bool
 	// DesktopNotificationCommand is the command to use for desktop notifications
 	DesktopNotificationCommand string
-	// DesktopNotificationArgs are the arguments for the desktop notification command
+	// DesktopNotificationArgs are the arguments for the desktop notification command.
+	// Each argument is a template, see CommandNotifier.
 	DesktopNotificationArgs []string
+	// NotifierBackend selects the desktop notifier: "auto", "dbus" or "command"
+	NotifierBackend string
+	// Notifier overrides the desktop notifier selected by NotifierBackend
+	Notifier Notifier
+	// CoalesceWindow merges desktop notifications arriving within this window, 0 disables coalescing
+	CoalesceWindow time.Duration
+	// Scorer scores notifications to derive the desktop notification urgency, nil for the default scorer
+	Scorer *scoring.Scorer
+	// Snoozes hides snoozed notifications, nil for the default snooze store
+	Snoozes *snooze.Store
+	// Rules triages notifications after every refresh, nil to disable rules
+	Rules *rules.Engine
+	// Subscriptions hides notifications their repository's subscription does
+	// not match, nil to show all notifications
+	Subscriptions *subscriptions.Evaluator
+	// Digests batches the notifications of hourly and daily subscriptions
+	// into digests instead of reporting them as events, nil to report all
+	// notifications in real time
+	Digests *digest.Scheduler
 	// EventCallback is called when a notification event occurs
 	EventCallback func(event NotificationEvent)
 	// ErrorCallback is called when an error occurs
@@ -69,23 +97,11 @@ func DefaultWatchOptions() *WatchOptions {
 		ShowDesktopNotifications:   false,
 		DesktopNotificationCommand: getDefaultNotificationCommand(),
 		DesktopNotificationArgs:    getDefaultNotificationArgs(),
+		NotifierBackend:            "auto",
+		CoalesceWindow:             2 * time.Second,
 	}
 }
 
-// getDefaultNotificationCommand returns the default notification command for the platform
-func getDefaultNotificationCommand() string {
-	// This would be platform-specific
-	// For now, return a placeholder
-	return "echo"
-}
-
-// getDefaultNotificationArgs returns the default notification arguments for the platform
-func getDefaultNotificationArgs() []string {
-	// This would be platform-specific
-	// For now, return a placeholder
-	return []string{}
-}
-
 // WatchStats contains statistics about the watch operation
 type WatchStats struct {
 	// StartTime is when the watch started
@@ -102,6 +118,8 @@ type WatchStats struct {
 	UpdatedNotificationCount int
 	// ReadNotificationCount is the number of read notifications
 	ReadNotificationCount int
+	// UnsnoozedNotificationCount is the number of notifications whose snooze ended
+	UnsnoozedNotificationCount int
 	// ErrorCount is the number of errors
 	ErrorCount int
 	// CurrentRefreshInterval is the current refresh interval
@@ -116,6 +134,18 @@ type GitHubClient interface {
 	GetAllNotifications(options githubclient.NotificationOptions) ([]*github.Notification, error)
 }
 
+// PollIntervalClient is implemented by clients that know the minimum poll
+// interval advertised by the server
+type PollIntervalClient interface {
+	PollInterval() time.Duration
+}
+
+// DetailsClient is implemented by clients that fetch the details of
+// notification subjects, which filters and subscriptions may match
+type DetailsClient interface {
+	FetchNotificationDetails(notifications []*github.Notification) error
+}
+
 // Watcher watches for notification changes
 type Watcher struct {
 	// Options are the watch options
@@ -136,6 +166,9 @@ type Watcher struct {
 	Mu sync.RWMutex
 	// Running indicates whether the watcher is running
 	Running bool
+
+	// notifier shows desktop notifications, nil when they are disabled
+	notifier Notifier
 }
 
 // NewWatcher creates a new watcher
@@ -166,6 +199,10 @@ func (w *Watcher) Start() error {
 		return fmt.Errorf("watcher is already running")
 	}
 
+	if w.Options.ShowDesktopNotifications {
+		w.notifier = w.createNotifier()
+	}
+
 	w.Running = true
 	w.Stats.StartTime = time.Now()
 
@@ -183,15 +220,50 @@ func (w *Watcher) Stop() {
 
 	w.CancelFunc()
 	w.Running = false
+
+	w.Mu.Lock()
+	notifier := w.notifier
+	w.notifier = nil
+	w.Mu.Unlock()
+
+	if notifier != nil {
+		if err := notifier.Close(); err != nil && w.Options.ErrorCallback != nil {
+			w.Options.ErrorCallback(err)
+		}
+	}
+}
+
+// createNotifier creates the desktop notifier, or returns nil and reports why it is unavailable
+func (w *Watcher) createNotifier() Notifier {
+	notifier := w.Options.Notifier
+	if notifier == nil {
+		var err error
+		notifier, err = NewNotifier(w.Options)
+		if err != nil {
+			if w.Options.ErrorCallback != nil {
+				w.Options.ErrorCallback(fmt.Errorf("desktop notifications disabled: %w", err))
+			}
+			return nil
+		}
+	}
+
+	if w.Options.CoalesceWindow > 0 {
+		coalescing := NewCoalescingNotifier(notifier, w.Options.CoalesceWindow)
+		coalescing.ErrorCallback = w.Options.ErrorCallback
+		return coalescing
+	}
+
+	return notifier
 }
 
 // watchLoop is the main watch loop
 func (w *Watcher) watchLoop() {
 	// Initial refresh
 	w.refresh()
+	w.applyPollInterval()
 
 	// Create a ticker for refreshing
-	ticker := time.NewTicker(w.Options.RefreshInterval)
+	ticker := time.NewTicker(w.Stats.CurrentRefreshInterval)
 	defer ticker.Stop()
 
 	for {
@@ -235,8 +307,44 @@ func (w *Watcher) refresh() {
 		return
 	}
 
+	// Resurface threads whose snooze ended and hide the ones still snoozed
+	snoozes := w.snoozes()
+	resurfaced, err := snoozes.Resurface(notifications)
+	if err != nil && w.Options.ErrorCallback != nil {
+		w.Options.ErrorCallback(err)
+	}
+	unsnoozed := make(map[string]bool, len(resurfaced))
+	for _, n := range resurfaced {
+		unsnoozed[n.GetID()] = true
+	}
+	notifications = snoozes.Filter(notifications)
+
+	// Hide what the subscriptions do not match before the rules act on it
+	if w.Options.Subscriptions != nil {
+		w.fetchDetails(w.Options.Subscriptions.NeedingDetails(notifications))
+		notifications = w.Options.Subscriptions.Filter(notifications)
+	}
+
+	// Let the rules triage the notifications before looking for changes
+	if w.Options.Rules != nil {
+		report, err := w.Options.Rules.Apply(w.Context, notifications)
+		if err != nil && w.Options.ErrorCallback != nil {
+			w.Options.ErrorCallback(err)
+		}
+		notifications = report.Remaining(notifications)
+	}
+
 	// Filter notifications if a filter is specified
 	if w.Options.Filter != nil {
+		if filter.NeedsDetails(w.Options.Filter) {
+			var stale []*github.Notification
+			for _, n := range notifications {
+				if enrichment.Stale(n) {
+					stale = append(stale, n)
+				}
+			}
+			w.fetchDetails(stale)
+		}
 		var filtered []*github.Notification
 		for _, n := range notifications {
 			if w.Options.Filter.Apply(n) {
@@ -246,11 +354,23 @@ func (w *Watcher) refresh() {
 		notifications = filtered
 	}
 
+	// Queue what goes into digests and deliver the digests that are due
+	if w.Options.Digests != nil {
+		notifications, err = w.Options.Digests.Enqueue(notifications)
+		if err != nil && w.Options.ErrorCallback != nil {
+			w.Options.ErrorCallback(err)
+		}
+		if _, err := w.Options.Digests.Deliver(w.Context, false); err != nil && w.Options.ErrorCallback != nil {
+			w.Options.ErrorCallback(err)
+		}
+	}
+
 	// Check for changes
 	changes := false
 	newNotifications := make([]*github.Notification, 0)
 	updatedNotifications := make([]*github.Notification, 0)
 	readNotifications := make([]*github.Notification, 0)
+	unsnoozedNotifications := make([]*github.Notification, 0)
 
 	// Create a map of new notifications
 	newNotificationMap := make(map[string]*github.Notification)
@@ -262,7 +382,11 @@ func (w *Watcher) refresh() {
 	for _, n := range notifications {
 		id := n.GetID()
 		existing, ok := w.NotificationMap[id]
-		if !ok {
+		if !ok && unsnoozed[id] {
+			// Notification back from a snooze
+			unsnoozedNotifications = append(unsnoozedNotifications, n)
+			changes = true
+		} else if !ok {
 			// New notification
 			newNotifications = append(newNotifications, n)
 			changes = true
@@ -275,7 +399,7 @@ func (w *Watcher) refresh() {
 
 	// Check for read notifications
 	for id, n := range w.NotificationMap {
-		if _, ok := newNotificationMap[id]; !ok {
+		if _, ok := newNotificationMap[id]; !ok && !snoozes.IsSnoozed(n) {
 			// Notification no longer in the list (marked as read)
 			readNotifications = append(readNotifications, n)
 			changes = true
@@ -290,6 +414,7 @@ func (w *Watcher) refresh() {
 	w.Stats.NewNotificationCount += len(newNotifications)
 	w.Stats.UpdatedNotificationCount += len(updatedNotifications)
 	w.Stats.ReadNotificationCount += len(readNotifications)
+	w.Stats.UnsnoozedNotificationCount += len(unsnoozedNotifications)
 
 	// If there were no changes, increment the idle count
 	if !changes {
@@ -304,11 +429,38 @@ func (w *Watcher) refresh() {
 	}
 
 	// Process events
-	w.processEvents(newNotifications, updatedNotifications, readNotifications)
+	w.processEvents(newNotifications, updatedNotifications, readNotifications, unsnoozedNotifications)
+}
+
+// fetchDetails fetches the subject details of notifications if the client
+// can, reporting failures without dropping the notifications
+func (w *Watcher) fetchDetails(notifications []*github.Notification) {
+	client, ok := w.Client.(DetailsClient)
+	if !ok || len(notifications) == 0 {
+		return
+	}
+	if err := client.FetchNotificationDetails(notifications); err != nil && w.Options.ErrorCallback != nil {
+		w.Options.ErrorCallback(err)
+	}
+}
+
+// snoozes returns the snooze store used to hide snoozed notifications
+func (w *Watcher) snoozes() *snooze.Store {
+	if w.Options.Snoozes != nil {
+		return w.Options.Snoozes
+	}
+	return snooze.Default()
 }
 
 // processEvents processes notification events
-func (w *Watcher) processEvents(newNotifications, updatedNotifications, readNotifications []*github.Notification) {
+func (w *Watcher) processEvents(newNotifications, updatedNotifications, readNotifications, unsnoozedNotifications []*github.Notification) {
+	// Score the changed notifications once for the desktop notification urgency
+	var scores map[string]*scoring.NotificationScore
+	if w.notifier != nil && len(newNotifications)+len(updatedNotifications)+len(unsnoozedNotifications) > 0 {
+		changed := append(append([]*github.Notification{}, newNotifications...), updatedNotifications...)
+		scores = w.scoreNotifications(append(changed, unsnoozedNotifications...))
+	}
+
 	// Process new notifications
 	for _, n := range newNotifications {
 		if w.Options.EventCallback != nil {
@@ -320,8 +472,8 @@ func (w *Watcher) processEvents(newNotifications, updatedNotifications, readNoti
 		}
 
 		// Show desktop notification
-		if w.Options.ShowDesktopNotifications {
-			w.showDesktopNotification(n, EventNew)
+		if w.notifier != nil {
+			w.showDesktopNotification(n, EventNew, scores[n.GetID()])
 		}
 	}
 
@@ -336,8 +488,24 @@ func (w *Watcher) processEvents(newNotifications, updatedNotifications, readNoti
 		}
 
 		// Show desktop notification
-		if w.Options.ShowDesktopNotifications {
-			w.showDesktopNotification(n, EventUpdated)
+		if w.notifier != nil {
+			w.showDesktopNotification(n, EventUpdated, scores[n.GetID()])
+		}
+	}
+
+	// Process notifications back from a snooze
+	for _, n := range unsnoozedNotifications {
+		if w.Options.EventCallback != nil {
+			w.Options.EventCallback(NotificationEvent{
+				Type:         EventUnsnoozed,
+				Notification: n,
+				Timestamp:    time.Now(),
+			})
+		}
+
+		// Show desktop notification
+		if w.notifier != nil {
+			w.showDesktopNotification(n, EventUnsnoozed, scores[n.GetID()])
 		}
 	}
 
@@ -353,11 +521,26 @@ func (w *Watcher) processEvents(newNotifications, updatedNotifications, readNoti
 	}
 }
 
+// scoreNotifications scores notifications, returning nil if scoring fails
+func (w *Watcher) scoreNotifications(notifications []*github.Notification) map[string]*scoring.NotificationScore {
+	scorer := w.Options.Scorer
+	if scorer == nil {
+		scorer = scoring.DefaultScorer()
+	}
+
+	scores, err := scorer.Score(w.Context, notifications)
+	if err != nil {
+		return nil
+	}
+	return scores
+}
+
 // showDesktopNotification shows a desktop notification
-func (w *Watcher) showDesktopNotification(n *github.Notification, eventType EventType) {
-	// This would use the platform-specific notification command
-	// For now, just print to stdout
-	fmt.Printf("Notification: %s - %s\n", eventType, n.GetSubject().GetTitle())
+func (w *Watcher) showDesktopNotification(n *github.Notification, eventType EventType, score *scoring.NotificationScore) {
+	notification := NewDesktopNotification(n, eventType, score)
+	if err := w.notifier.Notify(w.Context, notification); err != nil && w.Options.ErrorCallback != nil {
+		w.Options.ErrorCallback(err)
+	}
 }
 
 // updateRefreshInterval updates the refresh interval based on backoff
@@ -377,4 +560,16 @@ func (w *Watcher) updateRefreshInterval() {
 		// If we had changes, reset to the base interval
 		w.Stats.CurrentRefreshInterval = w.Options.RefreshInterval
 	}
+
+	w.applyPollInterval()
+}
+
+// applyPollInterval stretches the refresh interval to the poll interval
+// advertised by the server, so the watcher never polls more often than allowed
+func (w *Watcher) applyPollInterval() {
+	if client, ok := w.Client.(PollIntervalClient); ok {
+		if pollInterval := client.PollInterval(); pollInterval > w.Stats.CurrentRefreshInterval {
+			w.Stats.CurrentRefreshInterval = pollInterval
+		}
+	}
 }
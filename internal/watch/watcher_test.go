@@ -2,11 +2,16 @@ package watch
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/SharanRP/gh-notif/internal/digest"
+	"github.com/SharanRP/gh-notif/internal/enrichment"
 	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
 	"github.com/google/go-github/v60/github"
 )
 
@@ -319,3 +324,239 @@ func TestWatcherEvents(t *testing.T) {
 	// Stop the watcher
 	watcher.Stop()
 }
+
+func TestWatcherSnooze(t *testing.T) {
+	updatedAt := time.Now().Add(-time.Hour)
+	notification := func(id string, updatedAt time.Time) *github.Notification {
+		return &github.Notification{
+			ID:         github.String(id),
+			Subject:    &github.NotificationSubject{Title: github.String("Thread " + id)},
+			Repository: &github.Repository{FullName: github.String("owner/repo")},
+			UpdatedAt:  &github.Timestamp{Time: updatedAt},
+		}
+	}
+
+	snoozes := snooze.NewStore()
+	snoozes.Snooze(notification("2", updatedAt), time.Time{})
+
+	mockClient := &MockClient{
+		notifications: []*github.Notification{notification("1", updatedAt), notification("2", updatedAt)},
+	}
+
+	var events []NotificationEvent
+	options := DefaultWatchOptions()
+	options.Snoozes = snoozes
+	options.EventCallback = func(event NotificationEvent) {
+		events = append(events, event)
+	}
+
+	watcher := NewWatcher(mockClient, options)
+	eventTypes := func() map[string]EventType {
+		types := make(map[string]EventType)
+		for _, event := range events {
+			types[event.Notification.GetID()] = event.Type
+		}
+		events = nil
+		return types
+	}
+
+	// The snoozed thread is hidden
+	watcher.refresh()
+	if got := eventTypes(); len(got) != 1 || got["1"] != EventNew {
+		t.Fatalf("first refresh events = %v, want only 1 new", got)
+	}
+
+	// Snoozing a visible thread hides it without a read event, and new
+	// activity on the other thread ends its snooze
+	snoozes.Snooze(notification("1", updatedAt), time.Now().Add(time.Hour))
+	mockClient.SetNotifications([]*github.Notification{notification("1", updatedAt), notification("2", time.Now())})
+
+	watcher.refresh()
+	if got := eventTypes(); len(got) != 1 || got["2"] != EventUnsnoozed {
+		t.Fatalf("second refresh events = %v, want only 2 unsnoozed", got)
+	}
+	if len(watcher.Notifications) != 1 || watcher.Notifications[0].GetID() != "2" {
+		t.Errorf("watcher shows %d notifications, want only 2", len(watcher.Notifications))
+	}
+	if watcher.Stats.UnsnoozedNotificationCount != 1 || watcher.Stats.ReadNotificationCount != 0 {
+		t.Errorf("unsnoozed = %d, read = %d, want 1 and 0",
+			watcher.Stats.UnsnoozedNotificationCount, watcher.Stats.ReadNotificationCount)
+	}
+}
+
+// pollIntervalMockClient is a mock client that knows the server poll interval
+type pollIntervalMockClient struct {
+	MockClient
+	pollInterval time.Duration
+}
+
+// PollInterval returns the poll interval advertised by the mock server
+func (m *pollIntervalMockClient) PollInterval() time.Duration {
+	return m.pollInterval
+}
+
+func TestWatcherHonorsPollInterval(t *testing.T) {
+	client := &pollIntervalMockClient{pollInterval: time.Minute}
+
+	options := DefaultWatchOptions()
+	options.RefreshInterval = 10 * time.Second
+	options.MaxRefreshInterval = 30 * time.Second
+
+	watcher := NewWatcher(client, options)
+
+	// Changes reset the interval to the base interval, but not below the poll interval
+	watcher.Stats.IdleCount = 0
+	watcher.updateRefreshInterval()
+	if watcher.Stats.CurrentRefreshInterval != time.Minute {
+		t.Errorf("CurrentRefreshInterval = %v, want %v", watcher.Stats.CurrentRefreshInterval, time.Minute)
+	}
+
+	// The poll interval wins over the maximum backoff interval as well
+	watcher.Stats.IdleCount = options.BackoffThreshold
+	watcher.updateRefreshInterval()
+	if watcher.Stats.CurrentRefreshInterval != time.Minute {
+		t.Errorf("CurrentRefreshInterval = %v, want %v", watcher.Stats.CurrentRefreshInterval, time.Minute)
+	}
+
+	// Without a poll interval the configured interval is used
+	client.pollInterval = 0
+	watcher.Stats.IdleCount = 0
+	watcher.updateRefreshInterval()
+	if watcher.Stats.CurrentRefreshInterval != options.RefreshInterval {
+		t.Errorf("CurrentRefreshInterval = %v, want %v", watcher.Stats.CurrentRefreshInterval, options.RefreshInterval)
+	}
+}
+
+// detailsMockClient is a mock client that fetches subject details
+type detailsMockClient struct {
+	MockClient
+	details map[string]*enrichment.Details
+	fetched []string
+}
+
+// FetchNotificationDetails records the details of the mock subjects
+func (m *detailsMockClient) FetchNotificationDetails(notifications []*github.Notification) error {
+	for _, n := range notifications {
+		m.fetched = append(m.fetched, n.GetID())
+		details := *m.details[n.GetID()]
+		details.FetchedAt = time.Now()
+		enrichment.Default().Set(n.GetSubject().GetURL(), &details)
+	}
+	return nil
+}
+
+func TestWatcherSubscriptions(t *testing.T) {
+	originalStore := enrichment.Default()
+	enrichment.SetDefault(enrichment.NewStore())
+	defer enrichment.SetDefault(originalStore)
+
+	pullRequest := func(id, repo string) *github.Notification {
+		return &github.Notification{
+			ID: github.String(id),
+			Subject: &github.NotificationSubject{
+				Type: github.String("PullRequest"),
+				URL:  github.String("https://api.github.com/repos/" + repo + "/pulls/" + id),
+			},
+			Repository: &github.Repository{FullName: github.String(repo)},
+			UpdatedAt:  &github.Timestamp{Time: time.Now().Add(-time.Hour)},
+		}
+	}
+
+	config := subscriptions.DefaultSubscriptionConfig()
+	evaluator, err := subscriptions.NewEvaluator([]subscriptions.RepositorySubscription{
+		{Repository: "owner/repo", Active: true, Config: config},
+	})
+	if err != nil {
+		t.Fatalf("NewEvaluator() error = %v", err)
+	}
+
+	client := &detailsMockClient{details: map[string]*enrichment.Details{
+		"1": {Author: "alice"},
+		"2": {Author: "dependabot[bot]"},
+		"3": {Author: "renovate[bot]"},
+	}}
+	client.SetNotifications([]*github.Notification{
+		pullRequest("1", "owner/repo"), pullRequest("2", "owner/repo"), pullRequest("3", "owner/other"),
+	})
+
+	var events []string
+	options := DefaultWatchOptions()
+	options.Snoozes = snooze.NewStore()
+	options.Subscriptions = evaluator
+	options.EventCallback = func(event NotificationEvent) {
+		events = append(events, event.Notification.GetID())
+	}
+
+	watcher := NewWatcher(client, options)
+	watcher.refresh()
+
+	// The bot's pull request is hidden by the subscription, and the other
+	// repository has no subscription
+	if !reflect.DeepEqual(events, []string{"1", "3"}) {
+		t.Errorf("events = %v, want 1 and 3", events)
+	}
+	if !reflect.DeepEqual(client.fetched, []string{"1", "2"}) {
+		t.Errorf("fetched details of %v, want only the subscribed repository", client.fetched)
+	}
+
+	// Details fetched since the last update are not fetched again
+	watcher.refresh()
+	if len(client.fetched) != 2 {
+		t.Errorf("fetched details of %v again", client.fetched[2:])
+	}
+}
+
+func TestWatcherDigests(t *testing.T) {
+	daily := subscriptions.DefaultSubscriptionConfig()
+	daily.Frequency = subscriptions.FrequencyDaily
+	evaluator, err := subscriptions.NewEvaluator([]subscriptions.RepositorySubscription{
+		{Repository: "owner/digest", Active: true, Config: daily},
+	})
+	if err != nil {
+		t.Fatalf("NewEvaluator() error = %v", err)
+	}
+
+	issue := func(id, repo string) *github.Notification {
+		return &github.Notification{
+			ID:         github.String(id),
+			Subject:    &github.NotificationSubject{Type: github.String("Issue"), Title: github.String("Issue " + id)},
+			Repository: &github.Repository{FullName: github.String(repo)},
+			UpdatedAt:  &github.Timestamp{Time: time.Now().Add(-time.Hour)},
+		}
+	}
+
+	client := &MockClient{}
+	client.SetNotifications([]*github.Notification{issue("1", "owner/digest"), issue("2", "owner/other")})
+
+	var delivered int
+	queue := digest.NewQueue()
+	scheduler := digest.NewScheduler(queue, evaluator, digest.DefaultSchedule(), digest.NewRenderer(""),
+		digest.DeliverFunc(func(ctx context.Context, d *digest.Digest) error {
+			delivered++
+			return nil
+		}))
+
+	var events []string
+	options := DefaultWatchOptions()
+	options.Snoozes = snooze.NewStore()
+	options.Subscriptions = evaluator
+	options.Digests = scheduler
+	options.EventCallback = func(event NotificationEvent) {
+		events = append(events, string(event.Type)+" "+event.Notification.GetID())
+	}
+
+	watcher := NewWatcher(client, options)
+	watcher.refresh()
+	watcher.refresh()
+
+	// The daily subscription's notification waits for its digest
+	if !reflect.DeepEqual(events, []string{"new 2"}) {
+		t.Errorf("events = %v, want only the real-time notification", events)
+	}
+	if b, ok := queue.Batch("owner/digest"); !ok || len(b.Entries) != 1 {
+		t.Errorf("digest batch = %+v, want the daily notification", b)
+	}
+	if delivered != 0 {
+		t.Errorf("delivered %d digests before they are due", delivered)
+	}
+}
@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/filelock"
+)
+
+// DeadLetterFileName is the name of the failed deliveries in the cache directory
+const DeadLetterFileName = "webhooks-failed.json"
+
+// FailedDelivery is an event that could not be delivered to a webhook
+type FailedDelivery struct {
+	// ID identifies the delivery, and is sent in the delivery header
+	ID string `json:"id"`
+	// Webhook is the name of the webhook
+	Webhook string `json:"webhook"`
+	// URL is where the event was posted
+	URL string `json:"url"`
+	// Format is the payload format
+	Format Format `json:"format"`
+	// Event is the undelivered event
+	Event Event `json:"event"`
+	// Attempts is the number of delivery attempts
+	Attempts int `json:"attempts"`
+	// Error is the error of the last attempt
+	Error string `json:"error"`
+	// FailedAt is when the last attempt failed
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// MaxFailedDeliveries is the number of failed deliveries a dead-letter queue
+// keeps; the oldest are dropped beyond it
+const MaxFailedDeliveries = 1000
+
+// DeadLetterQueue keeps the events that could not be delivered so they can
+// be replayed. Secrets are not stored; they are looked up again on replay.
+// The queue file may be shared with other processes, such as a running
+// watch, so changes are made to its current content under its file lock.
+type DeadLetterQueue struct {
+	// path is the queue file, empty for an in-memory queue
+	path string
+
+	mu         sync.Mutex
+	deliveries []FailedDelivery
+}
+
+// NewDeadLetterQueue creates an in-memory dead-letter queue
+func NewDeadLetterQueue() *DeadLetterQueue {
+	return &DeadLetterQueue{}
+}
+
+// OpenDeadLetterQueue loads the dead-letter queue from a file, creating it
+// on the first failure
+func OpenDeadLetterQueue(path string) (*DeadLetterQueue, error) {
+	q := &DeadLetterQueue{path: path}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Add records a failed delivery, replacing an earlier failure of the same
+// delivery. Beyond MaxFailedDeliveries the oldest failures are dropped.
+func (q *DeadLetterQueue) Add(delivery FailedDelivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.update(func() bool {
+		for i, d := range q.deliveries {
+			if d.ID == delivery.ID {
+				q.deliveries[i] = delivery
+				return true
+			}
+		}
+		q.deliveries = append(q.deliveries, delivery)
+
+		if excess := len(q.deliveries) - MaxFailedDeliveries; excess > 0 {
+			sortDeliveries(q.deliveries)
+			q.deliveries = append([]FailedDelivery(nil), q.deliveries[excess:]...)
+		}
+		return true
+	})
+}
+
+// Remove removes a delivery, typically after it was replayed
+func (q *DeadLetterQueue) Remove(id string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	removed := false
+	err := q.update(func() bool {
+		for i, d := range q.deliveries {
+			if d.ID == id {
+				q.deliveries = append(q.deliveries[:i], q.deliveries[i+1:]...)
+				removed = true
+				return true
+			}
+		}
+		return false
+	})
+	return removed, err
+}
+
+// Deliveries returns the failed deliveries, oldest first
+func (q *DeadLetterQueue) Deliveries() []FailedDelivery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Keep the deliveries in memory when the file cannot be read
+	if q.path != "" {
+		_ = q.load()
+	}
+	deliveries := append([]FailedDelivery(nil), q.deliveries...)
+	sortDeliveries(deliveries)
+	return deliveries
+}
+
+// update applies a change to the current content of the queue's file under
+// its file lock, and saves the queue when change reports that it changed
+// something. Callers must hold the lock.
+func (q *DeadLetterQueue) update(change func() bool) error {
+	if q.path == "" {
+		change()
+		return nil
+	}
+
+	lock, err := filelock.Acquire(q.path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := q.load(); err != nil {
+		return err
+	}
+	if !change() {
+		return nil
+	}
+	return q.save()
+}
+
+// load replaces the deliveries with the content of the queue's file, which
+// is replaced atomically. Callers must hold the lock.
+func (q *DeadLetterQueue) load() error {
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		q.deliveries = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read failed webhook deliveries: %w", err)
+	}
+
+	var deliveries []FailedDelivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return fmt.Errorf("failed to parse failed webhook deliveries: %w", err)
+	}
+	q.deliveries = deliveries
+	return nil
+}
+
+// sortDeliveries sorts deliveries oldest first
+func sortDeliveries(deliveries []FailedDelivery) {
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].FailedAt.Before(deliveries[j].FailedAt) })
+}
+
+// save writes the queue to its file. Callers must hold the lock.
+func (q *DeadLetterQueue) save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	deliveries := q.deliveries
+	if deliveries == nil {
+		deliveries = []FailedDelivery{}
+	}
+	data, err := json.MarshalIndent(deliveries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode failed webhook deliveries: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0700); err != nil {
+		return fmt.Errorf("failed to create webhook directory: %w", err)
+	}
+
+	tmpPath := q.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write failed webhook deliveries: %w", err)
+	}
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to replace failed webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// newDeliveryID returns a random delivery ID
+func newDeliveryID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterQueueSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DeadLetterFileName)
+
+	// A running watch and a replay from another shell
+	watch, err := OpenDeadLetterQueue(path)
+	if err != nil {
+		t.Fatalf("OpenDeadLetterQueue() error = %v", err)
+	}
+	if err := watch.Add(FailedDelivery{ID: "replayed", FailedAt: time.Now()}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	shell, err := OpenDeadLetterQueue(path)
+	if err != nil {
+		t.Fatalf("OpenDeadLetterQueue() error = %v", err)
+	}
+	if removed, err := shell.Remove("replayed"); err != nil || !removed {
+		t.Fatalf("Remove() = %v, %v, want the delivery removed", removed, err)
+	}
+
+	// A later failure in the watch does not bring back the replayed delivery
+	if err := watch.Add(FailedDelivery{ID: "new", FailedAt: time.Now()}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if removed, _ := watch.Remove("replayed"); removed {
+		t.Error("Remove() found the delivery replayed by the other shell")
+	}
+
+	deliveries := shell.Deliveries()
+	if len(deliveries) != 1 || deliveries[0].ID != "new" {
+		t.Errorf("Deliveries() = %+v, want only new", deliveries)
+	}
+}
+
+func TestDeadLetterQueueLimit(t *testing.T) {
+	q := NewDeadLetterQueue()
+
+	start := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxFailedDeliveries+2; i++ {
+		if err := q.Add(FailedDelivery{ID: fmt.Sprint(i), FailedAt: start.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	// The oldest failures are dropped
+	deliveries := q.Deliveries()
+	if len(deliveries) != MaxFailedDeliveries || deliveries[0].ID != "2" {
+		t.Errorf("Deliveries() = %d, oldest %s, want %d from 2", len(deliveries), deliveries[0].ID, MaxFailedDeliveries)
+	}
+}
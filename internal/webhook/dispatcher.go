@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	// EventHeader carries the event type
+	EventHeader = "X-GH-Notif-Event"
+	// DeliveryHeader carries the delivery ID, which stays the same on retries and replays
+	DeliveryHeader = "X-GH-Notif-Delivery"
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body
+	// keyed with the webhook secret, when the webhook has one
+	SignatureHeader = "X-GH-Notif-Signature-256"
+)
+
+// Sign returns the signature of a payload as sent in SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether a signature matches a payload
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Endpoint is a webhook events are posted to
+type Endpoint struct {
+	// Name identifies the webhook
+	Name string
+	// URL is where events are posted
+	URL string
+	// Format is the payload format
+	Format Format
+	// Secret signs payloads, empty to send them unsigned
+	Secret string
+}
+
+// RetryPolicy decides how often and how long apart deliveries are attempted
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts before a delivery fails
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Factor multiplies the delay after every retry
+	Factor float64
+}
+
+// DefaultRetryPolicy attempts a delivery 4 times over about 7 seconds
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Factor:         2,
+	}
+}
+
+// backoff returns the delay after a failed attempt, starting at 1
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= p.Factor
+	}
+	if max := float64(p.MaxBackoff); p.MaxBackoff > 0 && delay > max {
+		delay = max
+	}
+	return time.Duration(delay)
+}
+
+// deliveryError is the error of a delivery attempt
+type deliveryError struct {
+	err error
+	// retryable is false when retrying cannot help, such as for a 404
+	retryable bool
+	// retryAfter is the delay asked for by the server
+	retryAfter time.Duration
+}
+
+func (e *deliveryError) Error() string {
+	return e.err.Error()
+}
+
+// Dispatcher posts events to webhooks, retrying failed attempts with
+// exponential backoff and keeping the events it could not deliver in a
+// dead-letter queue
+type Dispatcher struct {
+	// Client sends the requests
+	Client *http.Client
+	// Retry is the retry policy
+	Retry RetryPolicy
+	// DeadLetters keeps the failed deliveries, nil to drop them
+	DeadLetters *DeadLetterQueue
+	// ErrorCallback is called with the errors of deliveries started by Dispatch
+	ErrorCallback func(err error)
+
+	// sleep waits between attempts
+	sleep func(ctx context.Context, d time.Duration) error
+	// now returns the current time
+	now func() time.Time
+
+	wg sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher with the default retry policy
+func NewDispatcher(deadLetters *DeadLetterQueue) *Dispatcher {
+	return &Dispatcher{
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		Retry:       DefaultRetryPolicy(),
+		DeadLetters: deadLetters,
+		sleep:       sleepContext,
+		now:         time.Now,
+	}
+}
+
+// sleepContext waits for a duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Send delivers an event to a webhook, retrying as the policy allows. An
+// event that cannot be delivered is added to the dead-letter queue.
+func (d *Dispatcher) Send(ctx context.Context, endpoint Endpoint, event Event) error {
+	return d.deliver(ctx, FailedDelivery{ID: newDeliveryID(), Webhook: endpoint.Name, URL: endpoint.URL, Format: endpoint.Format, Event: event}, endpoint.Secret)
+}
+
+// Dispatch delivers an event in the background. Use Close to wait for the
+// deliveries in flight.
+func (d *Dispatcher) Dispatch(ctx context.Context, endpoint Endpoint, event Event) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := d.Send(ctx, endpoint, event); err != nil && d.ErrorCallback != nil {
+			d.ErrorCallback(err)
+		}
+	}()
+}
+
+// Close waits for the deliveries started by Dispatch
+func (d *Dispatcher) Close() {
+	d.wg.Wait()
+}
+
+// Replay delivers a failed delivery again with the same delivery ID and
+// removes it from the dead-letter queue once it is delivered
+func (d *Dispatcher) Replay(ctx context.Context, failed FailedDelivery, secret string) error {
+	if err := d.deliver(ctx, failed, secret); err != nil {
+		return err
+	}
+	if d.DeadLetters != nil {
+		if _, err := d.DeadLetters.Remove(failed.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver attempts a delivery and records it in the dead-letter queue when
+// every attempt fails
+func (d *Dispatcher) deliver(ctx context.Context, delivery FailedDelivery, secret string) error {
+	body, err := Render(delivery.Format, delivery.Event)
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := d.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var lastErr *deliveryError
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		delivery.Attempts++
+		lastErr = d.post(ctx, delivery, body, secret)
+		if lastErr == nil {
+			return nil
+		}
+		if !lastErr.retryable || attempt == maxAttempts {
+			break
+		}
+
+		delay := d.Retry.backoff(attempt)
+		if lastErr.retryAfter > delay && (d.Retry.MaxBackoff <= 0 || lastErr.retryAfter <= d.Retry.MaxBackoff) {
+			delay = lastErr.retryAfter
+		}
+		if err := d.sleep(ctx, delay); err != nil {
+			break
+		}
+	}
+
+	err = fmt.Errorf("webhook %s: %w", delivery.Webhook, lastErr)
+	if d.DeadLetters == nil {
+		return err
+	}
+
+	delivery.Error = lastErr.Error()
+	delivery.FailedAt = d.now()
+	if dlqErr := d.DeadLetters.Add(delivery); dlqErr != nil {
+		return fmt.Errorf("%w (%v)", err, dlqErr)
+	}
+	return err
+}
+
+// post makes one delivery attempt
+func (d *Dispatcher) post(ctx context.Context, delivery FailedDelivery, body []byte, secret string) *deliveryError {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return &deliveryError{err: fmt.Errorf("invalid request: %w", err)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gh-notif")
+	req.Header.Set(EventHeader, delivery.Event.Type)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return &deliveryError{err: err, retryable: ctx.Err() == nil}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	derr := &deliveryError{err: fmt.Errorf("server responded %s", resp.Status)}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode >= 500:
+		derr.retryable = true
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			derr.retryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return derr
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDispatcher returns a dispatcher recording its backoff delays instead
+// of sleeping
+func testDispatcher(deadLetters *DeadLetterQueue) (*Dispatcher, *[]time.Duration) {
+	var delays []time.Duration
+	d := NewDispatcher(deadLetters)
+	d.sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+	d.now = func() time.Time { return time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC) }
+	return d, &delays
+}
+
+func TestDispatcherSigns(t *testing.T) {
+	var mu sync.Mutex
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	d, _ := testDispatcher(NewDeadLetterQueue())
+	event := NewEvent("new", testNotification(), "", time.Now())
+	if err := d.Send(context.Background(), Endpoint{Name: "test", URL: server.URL, Secret: "s3cret"}, event); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if headers.Get(EventHeader) != "new" || headers.Get(DeliveryHeader) == "" {
+		t.Errorf("headers = %v, want the event type and a delivery ID", headers)
+	}
+	if !Verify("s3cret", body, headers.Get(SignatureHeader)) {
+		t.Errorf("signature %q does not verify", headers.Get(SignatureHeader))
+	}
+	if Verify("other", body, headers.Get(SignatureHeader)) {
+		t.Error("signature verifies with another secret")
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	var mu sync.Mutex
+	var deliveryIDs []string
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveryIDs = append(deliveryIDs, r.Header.Get(DeliveryHeader))
+		if len(deliveryIDs) == 2 {
+			w.Header().Set("Retry-After", "5")
+		}
+		if len(deliveryIDs) < 3 {
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+
+	deadLetters := NewDeadLetterQueue()
+	d, delays := testDispatcher(deadLetters)
+	event := NewEvent("updated", testNotification(), "", time.Now())
+	if err := d.Send(context.Background(), Endpoint{Name: "test", URL: server.URL}, event); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(deliveryIDs) != 3 || deliveryIDs[0] != deliveryIDs[2] {
+		t.Errorf("delivery IDs = %v, want 3 attempts of the same delivery", deliveryIDs)
+	}
+	if want := []time.Duration{time.Second, 5 * time.Second}; len(*delays) != 2 || (*delays)[0] != want[0] || (*delays)[1] != want[1] {
+		t.Errorf("delays = %v, want %v", *delays, want)
+	}
+	if len(deadLetters.Deliveries()) != 0 {
+		t.Errorf("dead letters = %v, want none", deadLetters.Deliveries())
+	}
+}
+
+func TestDispatcherDeadLetters(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), DeadLetterFileName)
+	deadLetters, err := OpenDeadLetterQueue(path)
+	if err != nil {
+		t.Fatalf("OpenDeadLetterQueue() error = %v", err)
+	}
+	d, _ := testDispatcher(deadLetters)
+
+	endpoint := Endpoint{Name: "test", URL: server.URL, Format: FormatSlack, Secret: "s3cret"}
+	if err := d.Send(context.Background(), endpoint, NewEvent("new", testNotification(), "", time.Now())); err == nil {
+		t.Fatal("Send() to a missing webhook succeeded")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want no retries after a 404", attempts)
+	}
+
+	status = http.StatusInternalServerError
+	d.Dispatch(context.Background(), endpoint, NewEvent("unsnoozed", testNotification(), "", time.Now()))
+	d.Close()
+	if attempts != 1+d.Retry.MaxAttempts {
+		t.Errorf("attempts = %d, want every attempt after a 500", attempts)
+	}
+
+	reopened, err := OpenDeadLetterQueue(path)
+	if err != nil {
+		t.Fatalf("OpenDeadLetterQueue() error = %v", err)
+	}
+	failed := reopened.Deliveries()
+	if len(failed) != 2 {
+		t.Fatalf("dead letters = %v, want 2", failed)
+	}
+	if failed[0].Webhook != "test" || failed[0].Format != FormatSlack || failed[0].Error == "" || failed[1].Attempts != d.Retry.MaxAttempts {
+		t.Errorf("dead letters = %+v", failed)
+	}
+
+	status = http.StatusOK
+	if err := d.Replay(context.Background(), failed[0], "s3cret"); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if got := deadLetters.Deliveries(); len(got) != 1 || got[0].ID != failed[1].ID {
+		t.Errorf("dead letters after replay = %v, want the other delivery", got)
+	}
+}
@@ -0,0 +1,210 @@
+// Package webhook posts notifications and watch events to outbound webhooks
+// as signed JSON, formatted for Slack, Discord, Microsoft Teams or generic
+// receivers
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/google/go-github/v60/github"
+)
+
+// Format is the payload format of a webhook
+type Format string
+
+const (
+	// FormatGeneric posts the event as is
+	FormatGeneric Format = "generic"
+	// FormatSlack posts a Slack incoming webhook message
+	FormatSlack Format = "slack"
+	// FormatDiscord posts a Discord webhook message with an embed
+	FormatDiscord Format = "discord"
+	// FormatTeams posts a Microsoft Teams message card
+	FormatTeams Format = "teams"
+)
+
+// DetectFormat returns the payload format expected by the host of a webhook
+// URL, and the generic format for unknown hosts
+func DetectFormat(rawURL string) Format {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return FormatGeneric
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "hooks.slack.com":
+		return FormatSlack
+	case (host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com")) &&
+		strings.HasPrefix(u.Path, "/api/webhooks/"):
+		return FormatDiscord
+	case strings.HasSuffix(host, ".webhook.office.com") || strings.HasSuffix(host, ".logic.azure.com"):
+		return FormatTeams
+	default:
+		return FormatGeneric
+	}
+}
+
+// Notification is the notification an event is about
+type Notification struct {
+	// ID is the ID of the notification thread
+	ID string `json:"id"`
+	// Repository is the full name of the repository
+	Repository string `json:"repository"`
+	// Title is the title of the subject
+	Title string `json:"title"`
+	// Type is the type of the subject, e.g. PullRequest
+	Type string `json:"type"`
+	// Reason is why the notification was received, e.g. review_requested
+	Reason string `json:"reason"`
+	// URL is the web URL of the subject
+	URL string `json:"url,omitempty"`
+	// UpdatedAt is when the thread was last updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Event is what is posted to webhooks
+type Event struct {
+	// Type is the watch event type: new, updated, read or unsnoozed
+	Type string `json:"event"`
+	// Subscription is the repository or pattern of the subscription that
+	// matched the notification, if any
+	Subscription string `json:"subscription,omitempty"`
+	// Notification is the notification the event is about
+	Notification Notification `json:"notification"`
+	// Timestamp is when the event occurred
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewEvent creates an event about a notification
+func NewEvent(eventType string, n *github.Notification, subscription string, timestamp time.Time) Event {
+	return Event{
+		Type:         eventType,
+		Subscription: subscription,
+		Notification: Notification{
+			ID:         n.GetID(),
+			Repository: n.GetRepository().GetFullName(),
+			Title:      n.GetSubject().GetTitle(),
+			Type:       n.GetSubject().GetType(),
+			Reason:     n.GetReason(),
+			URL:        webURL(n),
+			UpdatedAt:  n.GetUpdatedAt().Time,
+		},
+		Timestamp: timestamp,
+	}
+}
+
+// webURL returns the web URL of a notification's subject, or of its repository
+func webURL(n *github.Notification) string {
+	if apiURL := n.GetSubject().GetURL(); apiURL != "" {
+		if u, err := githubclient.ConvertAPIURLToWebURL(apiURL); err == nil {
+			return u
+		}
+	}
+	return n.GetRepository().GetHTMLURL()
+}
+
+// summary returns a one-line summary of an event
+func (e Event) summary() string {
+	verb := "New"
+	switch e.Type {
+	case "updated":
+		verb = "Updated"
+	case "read":
+		verb = "Read"
+	case "unsnoozed":
+		verb = "Unsnoozed"
+	case "test":
+		verb = "Test"
+	}
+	return fmt.Sprintf("%s %s in %s: %s", verb, e.Notification.Type, e.Notification.Repository, e.Notification.Title)
+}
+
+// details returns the reason and subscription of an event
+func (e Event) details() string {
+	details := "Reason: " + strings.ReplaceAll(e.Notification.Reason, "_", " ")
+	if e.Subscription != "" {
+		details += " · Subscription: " + e.Subscription
+	}
+	return details
+}
+
+// Render renders an event in a payload format
+func Render(format Format, e Event) ([]byte, error) {
+	var payload interface{}
+	switch format {
+	case FormatGeneric, "":
+		payload = e
+
+	case FormatSlack:
+		title := e.summary()
+		if e.Notification.URL != "" {
+			title = fmt.Sprintf("<%s|%s>", e.Notification.URL, slackEscape(title))
+		} else {
+			title = slackEscape(title)
+		}
+		payload = map[string]interface{}{
+			"text": e.summary(),
+			"blocks": []map[string]interface{}{
+				{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": "*" + title + "*"}},
+				{"type": "context", "elements": []map[string]string{{"type": "mrkdwn", "text": slackEscape(e.details())}}},
+			},
+		}
+
+	case FormatDiscord:
+		embed := map[string]interface{}{
+			"title":       truncate(e.summary(), 256),
+			"description": e.details(),
+			"timestamp":   e.Timestamp.UTC().Format(time.RFC3339),
+			"footer":      map[string]string{"text": e.Notification.Repository},
+		}
+		if e.Notification.URL != "" {
+			embed["url"] = e.Notification.URL
+		}
+		payload = map[string]interface{}{
+			"username": "gh-notif",
+			"embeds":   []interface{}{embed},
+		}
+
+	case FormatTeams:
+		card := map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    e.summary(),
+			"themeColor": "24292F",
+			"title":      e.summary(),
+			"text":       e.details(),
+		}
+		if e.Notification.URL != "" {
+			card["potentialAction"] = []interface{}{map[string]interface{}{
+				"@type":   "OpenUri",
+				"name":    "Open on GitHub",
+				"targets": []map[string]string{{"os": "default", "uri": e.Notification.URL}},
+			}}
+		}
+		payload = card
+
+	default:
+		return nil, fmt.Errorf("unknown webhook format: %s", format)
+	}
+
+	return json.Marshal(payload)
+}
+
+// slackEscape escapes the characters Slack treats as control sequences
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// truncate shortens a string to a number of runes
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
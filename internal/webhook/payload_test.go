@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func testNotification() *github.Notification {
+	return &github.Notification{
+		ID:     github.String("1"),
+		Reason: github.String("review_requested"),
+		Subject: &github.NotificationSubject{
+			Type:  github.String("PullRequest"),
+			Title: github.String("Fix <script> & friends"),
+			URL:   github.String("https://api.github.com/repos/org/api/pulls/42"),
+		},
+		Repository: &github.Repository{
+			FullName: github.String("org/api"),
+			HTMLURL:  github.String("https://github.com/org/api"),
+		},
+		UpdatedAt: &github.Timestamp{Time: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)},
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Format
+	}{
+		{"https://hooks.slack.com/services/T000/B000/XXXX", FormatSlack},
+		{"https://discord.com/api/webhooks/1/abc", FormatDiscord},
+		{"https://ptb.discord.com/api/webhooks/1/abc", FormatDiscord},
+		{"https://discord.com/channels/1", FormatGeneric},
+		{"https://contoso.webhook.office.com/webhookb2/abc", FormatTeams},
+		{"https://example.com/hook", FormatGeneric},
+		{"::", FormatGeneric},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat(tt.url); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	event := NewEvent("new", testNotification(), "org/*", time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC))
+	if event.Notification.URL != "https://github.com/org/api/pull/42" {
+		t.Fatalf("NewEvent() URL = %q, want the web URL of the pull request", event.Notification.URL)
+	}
+
+	decode := func(format Format) map[string]interface{} {
+		t.Helper()
+		data, err := Render(format, event)
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", format, err)
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			t.Fatalf("Render(%s) is not JSON: %v", format, err)
+		}
+		return payload
+	}
+
+	generic := decode(FormatGeneric)
+	if generic["event"] != "new" || generic["subscription"] != "org/*" {
+		t.Errorf("generic payload = %v, want the event", generic)
+	}
+	if n := generic["notification"].(map[string]interface{}); n["repository"] != "org/api" || n["reason"] != "review_requested" {
+		t.Errorf("generic notification = %v", n)
+	}
+
+	slack := decode(FormatSlack)
+	if slack["text"] != "New PullRequest in org/api: Fix <script> & friends" {
+		t.Errorf("slack text = %v", slack["text"])
+	}
+	section := slack["blocks"].([]interface{})[0].(map[string]interface{})["text"].(map[string]interface{})["text"].(string)
+	if !strings.Contains(section, "<https://github.com/org/api/pull/42|") || !strings.Contains(section, "&lt;script&gt; &amp; friends") {
+		t.Errorf("slack section = %q, want an escaped link", section)
+	}
+
+	discord := decode(FormatDiscord)
+	embed := discord["embeds"].([]interface{})[0].(map[string]interface{})
+	if embed["url"] != "https://github.com/org/api/pull/42" || !strings.Contains(embed["description"].(string), "Subscription: org/*") {
+		t.Errorf("discord embed = %v", embed)
+	}
+
+	teams := decode(FormatTeams)
+	if teams["@type"] != "MessageCard" || teams["potentialAction"] == nil {
+		t.Errorf("teams card = %v", teams)
+	}
+
+	if _, err := Render("irc", event); err == nil {
+		t.Error("Render() with an unknown format succeeded")
+	}
+}
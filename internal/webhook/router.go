@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/google/go-github/v60/github"
+)
+
+// DefaultEvents are the events webhooks receive unless configured otherwise
+var DefaultEvents = []string{"new", "updated", "unsnoozed"}
+
+// Route is a webhook with the events and notifications it receives
+type Route struct {
+	// Endpoint is the webhook
+	Endpoint Endpoint
+	// Events are the event types posted, empty for DefaultEvents
+	Events []string
+	// Filter selects the notifications posted, nil for all
+	Filter filter.Filter
+}
+
+// accepts reports whether the route receives an event about a notification
+func (r Route) accepts(eventType string, n *github.Notification) bool {
+	events := r.Events
+	if len(events) == 0 {
+		events = DefaultEvents
+	}
+	if !containsString(events, eventType) {
+		return false
+	}
+	return r.Filter == nil || r.Filter.Apply(n)
+}
+
+// Router posts events to the configured webhooks and to the webhooks of the
+// subscriptions matching their notifications
+type Router struct {
+	// Dispatcher delivers the events
+	Dispatcher *Dispatcher
+	// Routes are the configured webhooks
+	Routes []Route
+	// Subscriptions finds the subscription of a notification, nil for none
+	Subscriptions *subscriptions.Evaluator
+	// DefaultSecret signs the payloads of subscription webhooks that are
+	// not configured webhooks
+	DefaultSecret string
+}
+
+// Route posts an event about a notification to every webhook receiving it,
+// once per URL, and returns the number of webhooks it is posted to.
+// Subscription webhooks receive DefaultEvents of the notifications their
+// subscription matches.
+func (r *Router) Route(ctx context.Context, eventType string, n *github.Notification, timestamp time.Time) int {
+	var subscription *subscriptions.SubscriptionFilter
+	if r.Subscriptions != nil {
+		subscription = r.Subscriptions.Lookup(n.GetRepository().GetFullName())
+	}
+
+	event := NewEvent(eventType, n, "", timestamp)
+	if subscription != nil {
+		event.Subscription = subscription.Subscription.Repository
+	}
+
+	posted := make(map[string]bool)
+	for _, route := range r.Routes {
+		if !posted[route.Endpoint.URL] && route.accepts(eventType, n) {
+			posted[route.Endpoint.URL] = true
+			r.Dispatcher.Dispatch(ctx, route.Endpoint, event)
+		}
+	}
+
+	if subscription != nil && subscription.Subscription.Config.WebhookURL != "" && containsString(DefaultEvents, eventType) {
+		webhookURL := subscription.Subscription.Config.WebhookURL
+		if !posted[webhookURL] && subscription.Apply(n) {
+			posted[webhookURL] = true
+			r.Dispatcher.Dispatch(ctx, r.endpoint(webhookURL), event)
+		}
+	}
+
+	return len(posted)
+}
+
+// endpoint returns the configured webhook with a URL, or a webhook in the
+// format detected from the URL
+func (r *Router) endpoint(webhookURL string) Endpoint {
+	for _, route := range r.Routes {
+		if route.Endpoint.URL == webhookURL {
+			return route.Endpoint
+		}
+	}
+
+	name := webhookURL
+	if u, err := url.Parse(webhookURL); err == nil && u.Host != "" {
+		name = u.Host
+	}
+	return Endpoint{Name: name, URL: webhookURL, Format: DetectFormat(webhookURL), Secret: r.DefaultSecret}
+}
+
+// containsString reports whether values contain a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
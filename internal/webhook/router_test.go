@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+)
+
+func TestRouter(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		json.NewDecoder(r.Body).Decode(&event)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r.URL.Path+" "+event.Type+" "+event.Subscription)
+	}))
+	defer server.Close()
+
+	prs := subscriptions.DefaultSubscriptionConfig()
+	prs.ActivityTypes = []subscriptions.ActivityType{subscriptions.ActivityPRs}
+	prs.WebhookURL = server.URL + "/team"
+	issues := subscriptions.DefaultSubscriptionConfig()
+	issues.ActivityTypes = []subscriptions.ActivityType{subscriptions.ActivityIssues}
+	issues.WebhookURL = server.URL + "/issues"
+	evaluator, err := subscriptions.NewEvaluator([]subscriptions.RepositorySubscription{
+		{Repository: "org/*", IsPattern: true, Active: true, Config: prs},
+		{Repository: "org/docs", Active: true, Config: issues},
+	})
+	if err != nil {
+		t.Fatalf("NewEvaluator() error = %v", err)
+	}
+
+	d, _ := testDispatcher(nil)
+	router := &Router{
+		Dispatcher: d,
+		Routes: []Route{
+			{Endpoint: Endpoint{Name: "reviews", URL: server.URL + "/reviews"}, Filter: &filter.ReasonFilter{Reason: "review_requested"}},
+			{Endpoint: Endpoint{Name: "reads", URL: server.URL + "/reads"}, Events: []string{"read"}},
+		},
+		Subscriptions: evaluator,
+	}
+
+	route := func(eventType, repo string) int {
+		n := testNotification()
+		n.Repository.FullName = &repo
+		return router.Route(context.Background(), eventType, n, time.Now())
+	}
+
+	if got := route("new", "org/api"); got != 2 {
+		t.Errorf("Route(new, org/api) = %d, want the review and subscription webhooks", got)
+	}
+	if got := route("read", "org/api"); got != 1 {
+		t.Errorf("Route(read, org/api) = %d, want the read webhook only", got)
+	}
+	if got := route("updated", "org/docs"); got != 1 {
+		t.Errorf("Route(updated, org/docs) = %d, want the review webhook only", got)
+	}
+	d.Close()
+
+	sort.Strings(received)
+	want := []string{
+		"/reads read org/*",
+		"/reviews new org/*",
+		"/reviews updated org/docs",
+		"/team new org/*",
+	}
+	if len(received) != len(want) {
+		t.Fatalf("received = %q, want %q", received, want)
+	}
+	for i := range want {
+		if received[i] != want[i] {
+			t.Errorf("received[%d] = %q, want %q", i, received[i], want[i])
+		}
+	}
+}
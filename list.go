@@ -0,0 +1,538 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/enrichment"
+	"github.com/SharanRP/gh-notif/internal/filter"
+	"github.com/SharanRP/gh-notif/internal/filter/persistent"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/grouping"
+	"github.com/SharanRP/gh-notif/internal/output"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/google/go-github/v60/github"
+	"github.com/spf13/cobra"
+)
+
+// listOptions holds the flags of the list command
+type listOptions struct {
+	all           bool
+	participating bool
+	repo          string
+	org           string
+	filter        string
+	sort          string
+	groupBy       string
+	secondaryBy   string
+	maxGroups     int
+	limit         int
+	format        string
+	template      string
+	fields        []string
+	outputFile    string
+	noColor       bool
+	noRules       bool
+	noSubs        bool
+	details       bool
+
+	// scopeSet is true when --all or --participating was given, so the
+	// configured default filter does not apply
+	scopeSet bool
+}
+
+var listOpts = &listOptions{}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List GitHub notifications",
+	Long: `List GitHub notifications with filtering, sorting and grouping options.
+
+Filters use the same expression syntax as saved filter presets, for example
+"repo:owner/repo AND is:unread" or "@my-prs".
+
+Sort fields are repository, type, title, updated, status, reason and score,
+optionally suffixed with :asc or :desc and separated by commas.
+
+With --details the state, author, labels, review decision and CI status of
+each subject are fetched and included in the output. They are fetched
+automatically for filters such as "state:open" or "ci:failure" and for
+fields such as state, author, assignees, labels, review, ci, comments and
+draft.`,
+	Example: `  gh-notif list
+  gh-notif list --all --limit 20
+  gh-notif list --filter "repo:owner/repo AND type:PullRequest"
+  gh-notif list --sort score --group-by repository
+  gh-notif list --group-by owner --max-groups 5
+  gh-notif list --filter "type:PullRequest AND review:approved AND ci:success"
+  gh-notif list --details --fields id,repository,title,state,ci
+  gh-notif list --format json --output notifications.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listOpts.scopeSet = scopeFlagsChanged(cmd)
+		return runList(cmd.Context(), listOpts, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	flags := listCmd.Flags()
+	flags.BoolVarP(&listOpts.all, "all", "a", false, "include read notifications")
+	flags.BoolVar(&listOpts.participating, "participating", false, "only show notifications you are participating in")
+	flags.StringVarP(&listOpts.repo, "repo", "r", "", "only show notifications for a repository (owner/repo)")
+	flags.StringVarP(&listOpts.org, "org", "o", "", "only show notifications for an organization")
+	flags.StringVarP(&listOpts.filter, "filter", "f", "", "filter expression or @preset")
+	flags.StringVarP(&listOpts.sort, "sort", "s", "updated:desc", "sort fields, e.g. score or repository:asc,updated:desc")
+	flags.StringVarP(&listOpts.groupBy, "group-by", "g", "", "group by repository, owner, type, reason, thread, time, score or smart")
+	flags.StringVar(&listOpts.secondaryBy, "secondary-by", "", "secondary grouping used together with --group-by")
+	flags.IntVar(&listOpts.maxGroups, "max-groups", 10, "maximum number of groups to show with --group-by, the rest are merged into \"Other\"")
+	flags.IntVarP(&listOpts.limit, "limit", "l", 0, "maximum number of notifications to show (0 for no limit)")
+	flags.StringVar(&listOpts.format, "format", "text", "output format: text, json, csv or template")
+	flags.StringVar(&listOpts.template, "template", "", "Go template used with --format template")
+	flags.StringSliceVar(&listOpts.fields, "fields", nil, "fields to show in text and csv output")
+	flags.StringVar(&listOpts.outputFile, "output", "", "write output to a file instead of stdout")
+	flags.BoolVar(&listOpts.noColor, "no-color", false, "disable color output")
+	flags.BoolVar(&listOpts.noRules, "no-rules", false, "do not apply the configured triage rules")
+	flags.BoolVar(&listOpts.noSubs, "no-subscriptions", false, "show notifications the repository subscriptions do not match")
+	flags.BoolVar(&listOpts.details, "details", false, "fetch subject details such as state, author, labels and CI status, also used to score activity and involvement")
+
+	rootCmd.AddCommand(listCmd)
+}
+
+// runList fetches, filters, sorts, groups and prints notifications
+func runList(ctx context.Context, opts *listOptions, w io.Writer) error {
+	formatter, err := newFormatter(opts, w)
+	if err != nil {
+		return err
+	}
+
+	// Check the filter before fetching anything
+	filterExpr, err := parseFilterExpression(opts.filter)
+	if err != nil {
+		return err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	notifications, err := fetchNotifications(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// A merged inbox shows where each notification came from
+	if inboxHosts() != nil && len(opts.fields) == 0 {
+		formatter.WithFields(append([]string{"host"}, formatter.Fields...))
+	}
+
+	if opts.details || output.NeedsDetails(formatter.Fields) {
+		fetchNotificationDetails(ctx, notifications)
+	}
+
+	notifications, err = applyFilterExpression(ctx, filterExpr, notifications)
+	if err != nil {
+		return err
+	}
+
+	notifications, err = sortNotifications(ctx, opts.sort, notifications)
+	if err != nil {
+		return err
+	}
+
+	if opts.limit > 0 && len(notifications) > opts.limit {
+		notifications = notifications[:opts.limit]
+	}
+
+	// JSON output explains the score of each notification
+	if formatter.OutputFormat == output.FormatJSON {
+		scores, err := scoreNotifications(ctx, notifications)
+		if err != nil {
+			return err
+		}
+		formatter.WithScores(scores)
+	}
+
+	if opts.outputFile != "" {
+		file, err := os.Create(opts.outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		formatter.Writer = file
+	}
+
+	if opts.groupBy == "" {
+		return formatter.Format(notifications)
+	}
+
+	groupOptions := grouping.DefaultGroupOptions()
+	groupOptions.PrimaryGrouping = grouping.GroupType(opts.groupBy)
+	groupOptions.SecondaryGrouping = grouping.GroupType(opts.secondaryBy)
+	groupOptions.MinGroupSize = 1
+	if opts.maxGroups > 0 {
+		groupOptions.MaxGroups = opts.maxGroups
+	}
+
+	groups, err := grouping.NewGrouper(groupOptions).Group(ctx, notifications)
+	if err != nil {
+		return fmt.Errorf("failed to group notifications: %w", err)
+	}
+
+	return formatter.FormatGroups(groups)
+}
+
+// newFormatter creates an output formatter from the list options
+func newFormatter(opts *listOptions, w io.Writer) (*output.Formatter, error) {
+	formatter := output.NewFormatter(w).
+		WithNoColor(opts.noColor || opts.outputFile != "").
+		WithFields(opts.fields)
+
+	switch output.Format(opts.format) {
+	case output.FormatText, output.FormatJSON, output.FormatCSV:
+		formatter.WithFormat(output.Format(opts.format))
+	case output.FormatTemplate:
+		if opts.template == "" {
+			return nil, fmt.Errorf("--template is required with --format template")
+		}
+		formatter.WithTemplate(opts.template)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s (must be text, json, csv or template)", opts.format)
+	}
+
+	return formatter, nil
+}
+
+// fetchNotifications fetches notifications from GitHub using the list options
+func fetchNotifications(ctx context.Context, opts *listOptions) ([]*github.Notification, error) {
+	all, participating := notificationScope(opts)
+
+	notificationOpts := githubclient.NotificationOptions{
+		All:           all,
+		Participating: participating,
+		RepoName:      opts.repo,
+		OrgName:       opts.org,
+		UseCache:      true,
+		CacheTTL:      time.Minute,
+	}
+
+	notifications, err := fetchGitHubNotifications(ctx, notificationOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Snoozed notifications stay hidden until their snooze ends
+	notifications = snooze.Default().Filter(notifications)
+
+	// Repository subscriptions hide what they do not match
+	if !opts.noSubs {
+		notifications = applySubscriptions(ctx, notifications)
+	}
+
+	if !opts.noRules {
+		remaining := applyRules(ctx, notifications)
+		// Notifications handled by a rule are still shown when listing read ones
+		if !all {
+			notifications = remaining
+		}
+	}
+
+	return notifications, nil
+}
+
+// fetchGitHubNotifications fetches notifications from the merged inbox, or
+// from the selected host when there is none
+var fetchGitHubNotifications = func(ctx context.Context, opts githubclient.NotificationOptions) ([]*github.Notification, error) {
+	var notifications []*github.Notification
+	if hosts := inboxHosts(); hosts != nil {
+		inbox := githubclient.FetchInbox(ctx, hosts, opts)
+		var errs []error
+		for _, host := range hosts {
+			if err := inbox.Errors[host]; err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", host, err))
+			}
+		}
+		if len(errs) == len(hosts) {
+			return nil, fmt.Errorf("failed to fetch notifications: %w", errors.Join(errs...))
+		}
+		// The inbox is still useful when some hosts cannot be reached
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: failed to fetch notifications from %v\n", err)
+		}
+		notifications = inbox.Notifications
+	} else {
+		client, err := githubclient.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+
+		if opts.All {
+			notifications, err = client.GetAllNotifications(opts)
+		} else {
+			notifications, err = client.GetUnreadNotifications(opts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+		}
+	}
+
+	return notifications, nil
+}
+
+// notificationScope returns whether to fetch read notifications and only
+// participating ones. The configured default filter applies unless --all or
+// --participating was given.
+func notificationScope(opts *listOptions) (all, participating bool) {
+	all, participating = opts.all, opts.participating
+	if opts.scopeSet || configManager == nil || configManager.GetConfig() == nil {
+		return all, participating
+	}
+
+	switch configManager.GetConfig().Notifications.DefaultFilter {
+	case "all":
+		all = true
+	case "participating":
+		participating = true
+	}
+	return all, participating
+}
+
+// scopeFlagsChanged reports whether --all or --participating was given
+func scopeFlagsChanged(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("all") || cmd.Flags().Changed("participating")
+}
+
+// inboxHosts returns the hosts whose notifications are merged into one inbox,
+// or nil when only the selected host is used
+func inboxHosts() []string {
+	if config.Host() != "" || configManager == nil || configManager.GetConfig() == nil {
+		return nil
+	}
+
+	hosts := configManager.GetConfig().HostNames()
+	if len(hosts) < 2 {
+		return nil
+	}
+	return hosts
+}
+
+// fetchNotificationDetails fetches the subject details of the notifications
+// that do not have them yet, from the host each notification came from.
+// Notifications whose details cannot be fetched are shown without them.
+var fetchNotificationDetails = func(ctx context.Context, notifications []*github.Notification) {
+	byHost := make(map[string][]*github.Notification)
+	for _, n := range notifications {
+		if enrichment.Lookup(n) == nil {
+			host := ""
+			if inboxHosts() != nil {
+				host = githubclient.NotificationHost(n)
+			}
+			byHost[host] = append(byHost[host], n)
+		}
+	}
+
+	for host, hostNotifications := range byHost {
+		client, err := githubclient.NewClient(ctx, githubclient.WithHost(host))
+		if err == nil {
+			err = client.FetchNotificationDetails(hostNotifications)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to fetch some notification details: %v\n", err)
+		}
+	}
+}
+
+// newFilterParser creates a filter expression parser backed by the preset store
+func newFilterParser() (*persistent.Parser, error) {
+	if configManager == nil {
+		return persistent.NewParser(nil).WithCurrentUser(authenticatedUser), nil
+	}
+
+	store, err := persistent.NewFilterStore(configManager)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load filter presets: %w", err)
+	}
+
+	return persistent.NewParser(store).WithCurrentUser(authenticatedUser), nil
+}
+
+// authenticatedUser returns the login of the authenticated user of the
+// default host, which @me stands for in filter expressions
+var authenticatedUser = func() (string, error) {
+	client, err := githubclient.NewClient(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	return client.CurrentUser()
+}
+
+// parseFilterExpression parses a filter expression, returning nil for an
+// empty expression
+func parseFilterExpression(expr string) (filter.Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	parser, err := newFilterParser()
+	if err != nil {
+		return nil, err
+	}
+
+	filterExpr, err := parser.Parse(expr)
+	if err != nil {
+		return nil, withExitCode(exitUsage, fmt.Errorf("invalid filter %q: %w", expr, err))
+	}
+	return filterExpr, nil
+}
+
+// applyFilterExpression filters notifications with a parsed filter expression
+func applyFilterExpression(ctx context.Context, filterExpr filter.Filter, notifications []*github.Notification) ([]*github.Notification, error) {
+	if filterExpr == nil {
+		return notifications, nil
+	}
+
+	if filter.NeedsDetails(filterExpr) {
+		fetchNotificationDetails(ctx, notifications)
+	}
+	if filter.NeedsScores(filterExpr) {
+		notificationScorer(ctx, notifications)
+	}
+
+	filtered, err := filter.NewEngine().WithFilter(filterExpr).Filter(ctx, notifications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply filter: %w", err)
+	}
+
+	return filtered, nil
+}
+
+// sortNotifications sorts notifications by a comma-separated list of fields
+func sortNotifications(ctx context.Context, spec string, notifications []*github.Notification) ([]*github.Notification, error) {
+	if strings.TrimSpace(spec) == "" || len(notifications) == 0 {
+		return notifications, nil
+	}
+
+	var criteria []filter.SortCriterion
+	for _, part := range strings.Split(spec, ",") {
+		name, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.ToLower(name)
+
+		direction := filter.Ascending
+		switch strings.ToLower(dir) {
+		case "", "asc":
+			// Newest and highest first unless asked otherwise
+			if dir == "" && (name == "updated" || name == "time" || name == "score") {
+				direction = filter.Descending
+			}
+		case "desc":
+			direction = filter.Descending
+		default:
+			return nil, fmt.Errorf("invalid sort direction %q (must be asc or desc)", dir)
+		}
+
+		// Score is not a notification field, so it has to be computed first
+		if name == "score" {
+			if len(criteria) > 0 {
+				return nil, fmt.Errorf("score must be the first sort field")
+			}
+			return sortByScore(ctx, notifications, direction)
+		}
+
+		field, ok := sortFields[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field %q", name)
+		}
+		criteria = append(criteria, filter.NewSortCriterion(field, direction))
+	}
+
+	return filter.NewSorter().WithCriteria(criteria...).Sort(notifications), nil
+}
+
+// sortFields maps sort field names to filter sort fields
+var sortFields = map[string]filter.SortField{
+	"repository": filter.SortByRepository,
+	"repo":       filter.SortByRepository,
+	"type":       filter.SortByType,
+	"title":      filter.SortByTitle,
+	"updated":    filter.SortByTime,
+	"time":       filter.SortByTime,
+	"status":     filter.SortByStatus,
+	"reason":     filter.SortByReason,
+}
+
+// currentUsername returns the login of the authenticated user of the default
+// host, or an empty string if it cannot be found
+var currentUsername = func(ctx context.Context) string {
+	client, err := githubclient.NewClient(ctx)
+	if err != nil {
+		return ""
+	}
+	login, err := client.CurrentUser()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return login
+}
+
+// notificationScorer returns the shared scorer, set up for the current user
+// when the details of some notification subjects are known
+func notificationScorer(ctx context.Context, notifications []*github.Notification) *scoring.Scorer {
+	scorer := scoring.DefaultScorer()
+	if scorer.Username != "" {
+		return scorer
+	}
+	for _, n := range notifications {
+		// Involvement in a subject can only be scored from its details
+		if enrichment.Lookup(n) != nil {
+			scorer.WithUsername(currentUsername(ctx))
+			break
+		}
+	}
+	return scorer
+}
+
+// scoreNotifications scores notifications for the current user
+func scoreNotifications(ctx context.Context, notifications []*github.Notification) (map[string]*scoring.NotificationScore, error) {
+	scores, err := notificationScorer(ctx, notifications).Score(ctx, notifications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score notifications: %w", err)
+	}
+	return scores, nil
+}
+
+// sortByScore sorts notifications by their priority score
+func sortByScore(ctx context.Context, notifications []*github.Notification, direction filter.SortDirection) ([]*github.Notification, error) {
+	scores, err := scoreNotifications(ctx, notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	// Notifications missing from the scores are scored one by one
+	totals := make(map[*github.Notification]int, len(notifications))
+	for _, n := range notifications {
+		score := scores[n.GetID()]
+		if score == nil {
+			score = notificationScorer(ctx, notifications).ScoreOf(n)
+		}
+		totals[n] = score.Total
+	}
+
+	sorted := make([]*github.Notification, len(notifications))
+	copy(sorted, notifications)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := totals[sorted[i]], totals[sorted[j]]
+		if direction == filter.Descending {
+			return a > b
+		}
+		return a < b
+	})
+
+	return sorted, nil
+}
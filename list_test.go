@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/google/go-github/v60/github"
+)
+
+// testNotification creates a notification updated age ago
+func testNotification(id, repo, subjectType, reason string, age time.Duration) *github.Notification {
+	return &github.Notification{
+		ID:     github.String(id),
+		Unread: github.Bool(true),
+		Reason: github.String(reason),
+		Subject: &github.NotificationSubject{
+			Title: github.String("Notification " + id),
+			Type:  github.String(subjectType),
+		},
+		Repository: &github.Repository{
+			FullName: github.String(repo),
+		},
+		UpdatedAt: &github.Timestamp{Time: time.Now().Add(-age)},
+	}
+}
+
+// useTestNotifications makes the list pipeline fetch the given notifications
+// instead of calling GitHub
+func useTestNotifications(t *testing.T, notifications ...*github.Notification) {
+	t.Helper()
+	previous := fetchGitHubNotifications
+	fetchGitHubNotifications = func(ctx context.Context, opts githubclient.NotificationOptions) ([]*github.Notification, error) {
+		return notifications, nil
+	}
+	t.Cleanup(func() { fetchGitHubNotifications = previous })
+}
+
+// testListOptions returns list options that skip the rules and subscriptions
+func testListOptions() *listOptions {
+	return &listOptions{sort: "updated:desc", format: "text", noColor: true, noRules: true, noSubs: true}
+}
+
+// listedNotification is a notification in the JSON output of list
+type listedNotification struct {
+	ID         string `json:"id"`
+	Repository string `json:"repository"`
+	Score      *int   `json:"score"`
+}
+
+// runListJSON runs list with JSON output and decodes it
+func runListJSON(t *testing.T, opts *listOptions) []listedNotification {
+	t.Helper()
+	opts.format = "json"
+
+	var buf bytes.Buffer
+	if err := runList(context.Background(), opts, &buf); err != nil {
+		t.Fatalf("runList() error = %v", err)
+	}
+
+	var listed []listedNotification
+	if err := json.Unmarshal(buf.Bytes(), &listed); err != nil {
+		t.Fatalf("failed to decode list output %q: %v", buf.String(), err)
+	}
+	return listed
+}
+
+// listedIDs returns the IDs of listed notifications in order
+func listedIDs(listed []listedNotification) string {
+	ids := make([]string, 0, len(listed))
+	for _, n := range listed {
+		ids = append(ids, n.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+// useTestConfig loads a configuration with the given YAML content for the
+// duration of the test
+func useTestConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_NOTIF_CONFIG", path)
+
+	cm := config.NewConfigManager()
+	if err := cm.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	previous := configManager
+	configManager = cm
+	t.Cleanup(func() { configManager = previous })
+}
+
+// useTestScorer gives the test a scorer of its own
+func useTestScorer(t *testing.T) {
+	t.Helper()
+	scoring.SetDefaultScorer(scoring.NewScorer(scoring.DefaultScoreFactors()))
+	t.Cleanup(func() { scoring.SetDefaultScorer(nil) })
+}
+
+func TestSortByScoreManyNotifications(t *testing.T) {
+	useTestScorer(t)
+
+	// Enough notifications to be scored concurrently
+	reasons := []string{"review_requested", "mention", "subscribed", "ci_activity"}
+	notifications := make([]*github.Notification, 500)
+	for i := range notifications {
+		notifications[i] = testNotification(fmt.Sprint(i), "owner/repo", "PullRequest", reasons[i%len(reasons)], time.Duration(i)*time.Hour)
+	}
+
+	for _, spec := range []string{"score", "score:asc"} {
+		sorted, err := sortNotifications(context.Background(), spec, notifications)
+		if err != nil {
+			t.Fatalf("sortNotifications(%q) error = %v", spec, err)
+		}
+		if len(sorted) != len(notifications) {
+			t.Fatalf("sortNotifications(%q) returned %d notifications, want %d", spec, len(sorted), len(notifications))
+		}
+
+		scorer := scoring.DefaultScorer()
+		for i := 1; i < len(sorted); i++ {
+			prev, cur := scorer.ScoreOf(sorted[i-1]).Total, scorer.ScoreOf(sorted[i]).Total
+			if (spec == "score" && prev < cur) || (spec == "score:asc" && prev > cur) {
+				t.Fatalf("sortNotifications(%q) is out of order at %d: %d then %d", spec, i, prev, cur)
+			}
+		}
+	}
+}
+
+func TestNotificationScope(t *testing.T) {
+	tests := []struct {
+		name              string
+		defaultFilter     string
+		opts              listOptions
+		wantAll           bool
+		wantParticipating bool
+	}{
+		{name: "unread default", defaultFilter: "unread"},
+		{name: "all default", defaultFilter: "all", wantAll: true},
+		{name: "participating default", defaultFilter: "participating", wantParticipating: true},
+		{name: "all flag", defaultFilter: "unread", opts: listOptions{all: true, scopeSet: true}, wantAll: true},
+		{name: "flags narrow the all default", defaultFilter: "all", opts: listOptions{scopeSet: true}},
+		{name: "participating flag wins over all default", defaultFilter: "all", opts: listOptions{participating: true, scopeSet: true}, wantParticipating: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestConfig(t, "notifications:\n  default_filter: "+tt.defaultFilter+"\n")
+
+			all, participating := notificationScope(&tt.opts)
+			if all != tt.wantAll || participating != tt.wantParticipating {
+				t.Errorf("notificationScope() = %v, %v, want %v, %v", all, participating, tt.wantAll, tt.wantParticipating)
+			}
+		})
+	}
+}
+
+func TestRunListSort(t *testing.T) {
+	useTestScorer(t)
+	useTestNotifications(t,
+		testNotification("1", "owner/b", "Issue", "subscribed", time.Hour),
+		testNotification("2", "owner/a", "PullRequest", "review_requested", 3*time.Hour),
+		testNotification("3", "owner/c", "Issue", "subscribed", 48*time.Hour),
+	)
+
+	tests := []struct {
+		sort    string
+		want    string
+		wantErr bool
+	}{
+		{sort: "updated", want: "1,2,3"},
+		{sort: "updated:asc", want: "3,2,1"},
+		{sort: "repository", want: "2,1,3"},
+		{sort: "repository:desc", want: "3,1,2"},
+		{sort: "type,updated:asc", want: "3,1,2"},
+		{sort: "score", want: "2,1,3"},
+		{sort: "score:asc", want: "3,1,2"},
+		{sort: "updated,score", wantErr: true},
+		{sort: "size", wantErr: true},
+		{sort: "updated:up", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			opts := testListOptions()
+			opts.sort = tt.sort
+
+			if tt.wantErr {
+				if err := runList(context.Background(), opts, &bytes.Buffer{}); err == nil {
+					t.Errorf("runList() with --sort %q succeeded", tt.sort)
+				}
+				return
+			}
+
+			if got := listedIDs(runListJSON(t, opts)); got != tt.want {
+				t.Errorf("runList() with --sort %q listed %s, want %s", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunListScoreSortManyNotifications(t *testing.T) {
+	useTestScorer(t)
+
+	reasons := []string{"review_requested", "mention", "subscribed", "ci_activity"}
+	notifications := make([]*github.Notification, 150)
+	for i := range notifications {
+		notifications[i] = testNotification(fmt.Sprint(i), "owner/repo", "Issue", reasons[i%len(reasons)], time.Duration(i)*time.Hour)
+	}
+	useTestNotifications(t, notifications...)
+
+	opts := testListOptions()
+	opts.sort = "score"
+	listed := runListJSON(t, opts)
+	if len(listed) != len(notifications) {
+		t.Fatalf("runList() listed %d notifications, want %d", len(listed), len(notifications))
+	}
+	for i, n := range listed {
+		if n.Score == nil {
+			t.Fatalf("notification %s has no score", n.ID)
+		}
+		if i > 0 && *listed[i-1].Score < *n.Score {
+			t.Fatalf("notifications are out of score order at %d: %d then %d", i, *listed[i-1].Score, *n.Score)
+		}
+	}
+}
+
+func TestRunListFilter(t *testing.T) {
+	useTestNotifications(t,
+		testNotification("1", "owner/a", "Issue", "subscribed", 3*time.Hour),
+		testNotification("2", "owner/a", "PullRequest", "review_requested", 2*time.Hour),
+		testNotification("3", "owner/b", "PullRequest", "mention", time.Hour),
+	)
+
+	tests := []struct {
+		filter  string
+		limit   int
+		want    string
+		wantErr bool
+	}{
+		{filter: "", want: "3,2,1"},
+		{filter: "repo:owner/a", want: "2,1"},
+		{filter: "type:PullRequest", want: "3,2"},
+		{filter: "repo:owner/a AND type:PullRequest", want: "2"},
+		{filter: "reason:mention OR reason:subscribed", want: "3,1"},
+		{filter: "type:PullRequest", limit: 1, want: "3"},
+		{filter: "repo:owner/c", want: ""},
+		{filter: "repo:owner/a AND (", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			opts := testListOptions()
+			opts.filter = tt.filter
+			opts.limit = tt.limit
+
+			if tt.wantErr {
+				if err := runList(context.Background(), opts, &bytes.Buffer{}); err == nil {
+					t.Errorf("runList() with --filter %q succeeded", tt.filter)
+				}
+				return
+			}
+
+			if got := listedIDs(runListJSON(t, opts)); got != tt.want {
+				t.Errorf("runList() with --filter %q listed %s, want %s", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunListFormat(t *testing.T) {
+	useTestScorer(t)
+	useTestNotifications(t,
+		testNotification("1", "owner/a", "Issue", "subscribed", 2*time.Hour),
+		testNotification("2", "owner/b", "PullRequest", "mention", time.Hour),
+	)
+
+	tests := []struct {
+		name     string
+		format   string
+		template string
+		fields   []string
+		want     string
+		wantErr  bool
+	}{
+		{name: "text", format: "text", want: "Notification 2"},
+		{name: "csv", format: "csv", fields: []string{"id", "repository"}, want: "2,owner/b\n1,owner/a\n"},
+		{name: "template", format: "template", template: "{{range .}}{{.GetID}};{{end}}", want: "2;1;"},
+		{name: "template without template", format: "template", wantErr: true},
+		{name: "unknown", format: "yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testListOptions()
+			opts.format = tt.format
+			opts.template = tt.template
+			opts.fields = tt.fields
+
+			var buf bytes.Buffer
+			err := runList(context.Background(), opts, &buf)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("runList() with --format %s succeeded", tt.format)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("runList() error = %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("runList() output %q does not contain %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	// JSON output explains the score of each notification
+	for _, n := range runListJSON(t, testListOptions()) {
+		if n.Score == nil {
+			t.Errorf("notification %s has no score in the JSON output", n.ID)
+		}
+	}
+}
+
+func TestRunListGroups(t *testing.T) {
+	useTestNotifications(t,
+		testNotification("1", "owner/a", "Issue", "subscribed", 3*time.Hour),
+		testNotification("2", "owner/a", "PullRequest", "mention", 2*time.Hour),
+		testNotification("3", "owner/b", "Issue", "mention", time.Hour),
+		testNotification("4", "owner/c", "Issue", "mention", time.Hour),
+	)
+
+	opts := testListOptions()
+	opts.groupBy = "repository"
+	opts.maxGroups = 1
+
+	var buf bytes.Buffer
+	if err := runList(context.Background(), opts, &buf); err != nil {
+		t.Fatalf("runList() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "owner/a (2") {
+		t.Errorf("expected a group for owner/a, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Other (2") {
+		t.Errorf("expected the other repositories to be merged into one group, got:\n%s", out)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/SharanRP/gh-notif/internal/auth"
 	"github.com/SharanRP/gh-notif/internal/config"
 	"github.com/SharanRP/gh-notif/internal/version"
 	"github.com/spf13/cobra"
@@ -18,26 +19,44 @@ var (
 )
 
 var (
-	cfgFile string
-	rootCmd = &cobra.Command{
+	cfgFile       string
+	profileName   string
+	hostName      string
+	configManager *config.ConfigManager
+	rootCmd       = &cobra.Command{
 		Use:   "gh-notif",
 		Short: "A high-performance GitHub notification manager",
 		Long: `gh-notif is a CLI tool for managing GitHub notifications in the terminal.
 It allows you to view, filter, and interact with your GitHub notifications efficiently.`,
+		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if cmd.Parent() != nil && cmd.Parent().Name() == "config" {
-				return nil
+			// Flags parsed fine, so further errors are not usage errors
+			cmd.SilenceUsage = true
+
+			// Select the config file and profile before anything loads them
+			config.SetConfigFile(cfgFile)
+			if err := config.SetProfile(profileName); err != nil {
+				return withExitCode(exitUsage, err)
+			}
+			config.SetHost(hostName)
+			if cfgFile != "" || config.Profile() != "" || config.Host() != "" {
+				auth.Reload()
 			}
 
-			configManager := config.NewConfigManager()
-			if cfgFile != "" {
-				// TODO: Set custom config file
+			if cmd.Parent() != nil && cmd.Parent().Name() == "config" {
+				return nil
 			}
 
+			configManager = config.NewConfigManager()
 			if err := configManager.Load(); err != nil {
 				return fmt.Errorf("failed to load configuration: %w", err)
 			}
 
+			// Action history, read state and snoozes are kept in the profile's cache directory
+			if err := openLocalState(configManager.GetConfig()); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+			}
+
 			return nil
 		},
 	}
@@ -49,14 +68,17 @@ func main() {
 	version.Date = dateString
 	version.BuiltBy = builtByString
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		printError(os.Stderr, err)
+		os.Exit(exitCode(cmd, err))
 	}
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gh-notif.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $GH_NOTIF_CONFIG or $HOME/.gh-notif.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "named profile with its own config, token and cache (default is $GH_NOTIF_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&hostName, "host", "", "GitHub Enterprise Server host to use instead of all configured hosts (default is $GH_NOTIF_HOST)")
 
 	versionCmd := &cobra.Command{
 		Use:   "version",
@@ -70,16 +92,4 @@ func init() {
 		},
 	}
 	rootCmd.AddCommand(versionCmd)
-
-	listCmd := &cobra.Command{
-		Use:   "list",
-		Short: "List GitHub notifications",
-		Long:  `List GitHub notifications with filtering options.`,
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("gh-notif list command")
-			fmt.Println("This is a placeholder implementation.")
-			fmt.Println("Run 'gh-notif version' to see version information.")
-		},
-	}
-	rootCmd.AddCommand(listCmd)
 }
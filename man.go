@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/SharanRP/gh-notif/internal/docs"
+	"github.com/spf13/cobra"
+)
+
+var manOutputDir string
+
+var manCmd = &cobra.Command{
+	Use:    "man",
+	Short:  "Generate man pages",
+	Long:   `Generate man pages for gh-notif and all of its subcommands.`,
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := docs.GenerateManPagesForCommand(rootCmd, manOutputDir); err != nil {
+			return fmt.Errorf("failed to generate man pages: %w", err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Man pages written to %s\n", manOutputDir)
+		return nil
+	},
+}
+
+func init() {
+	manCmd.Flags().StringVar(&manOutputDir, "output-dir", "docs/man", "directory to write man pages to")
+
+	rootCmd.AddCommand(manCmd)
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/rules"
+	"github.com/google/go-github/v60/github"
+	"github.com/spf13/cobra"
+)
+
+// newRulesEngine creates the rule engine for the configured rules, or
+// returns nil when no rules are configured
+func newRulesEngine() (*rules.Engine, error) {
+	if configManager == nil || configManager.GetConfig() == nil || len(configManager.GetConfig().Rules) == 0 {
+		return nil, nil
+	}
+	cfg := configManager.GetConfig()
+
+	parser, err := newFilterParser()
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := rules.NewEngine(cfg.Rules, parser)
+	if err != nil {
+		return nil, err
+	}
+
+	audit, err := rules.OpenAuditLog(filepath.Join(cacheDirectory(cfg), rules.AuditFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.WithAuditLog(audit), nil
+}
+
+// applyRules lets the configured rules triage freshly fetched notifications
+// and returns the notifications they left in place. Rule problems are
+// reported as warnings so they never prevent listing notifications.
+func applyRules(ctx context.Context, notifications []*github.Notification) []*github.Notification {
+	engine, err := newRulesEngine()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: rules not applied: %v\n", err)
+		return notifications
+	}
+	if engine == nil {
+		return notifications
+	}
+
+	report, err := engine.Apply(ctx, notifications)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if report == nil {
+		return notifications
+	}
+
+	if failures := report.FailureCount(); failures > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d of %d rule actions failed, see \"gh-notif rules log\"\n", failures, len(report.Results))
+	}
+
+	return report.Remaining(notifications)
+}
+
+// jsonRuleResult is the JSON representation of a rule result
+type jsonRuleResult struct {
+	Rule           string `json:"rule"`
+	Action         string `json:"action"`
+	NotificationID string `json:"notification_id"`
+	Repository     string `json:"repository,omitempty"`
+	Title          string `json:"title,omitempty"`
+	Success        bool   `json:"success"`
+	DryRun         bool   `json:"dry_run,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// printRuleReport prints what the rules did or would do
+func printRuleReport(w io.Writer, format string, report *rules.Report) error {
+	if format == "json" {
+		results := make([]jsonRuleResult, 0, len(report.Results))
+		for _, r := range report.Results {
+			jr := jsonRuleResult{
+				Rule:           r.Rule.Name,
+				Action:         r.Rule.Action,
+				NotificationID: r.Notification.GetID(),
+				Repository:     r.Notification.GetRepository().GetFullName(),
+				Title:          r.Notification.GetSubject().GetTitle(),
+				Success:        r.Success,
+				DryRun:         report.DryRun,
+			}
+			if r.Error != nil {
+				jr.Error = r.Error.Error()
+			}
+			results = append(results, jr)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	if len(report.Results) == 0 {
+		fmt.Fprintln(w, "No notifications matched the rules")
+		return nil
+	}
+
+	for _, r := range report.Results {
+		target := fmt.Sprintf("%s %q (%s)", r.Notification.GetID(),
+			r.Notification.GetSubject().GetTitle(), r.Notification.GetRepository().GetFullName())
+
+		switch {
+		case report.DryRun:
+			fmt.Fprintf(w, "Would %s %s [rule %s]\n", r.Rule.Action, target, r.Rule.Name)
+		case r.Success:
+			fmt.Fprintf(w, "✓ %s %s [rule %s]\n", r.Rule.Action, target, r.Rule.Name)
+		default:
+			fmt.Fprintf(w, "✗ %s %s [rule %s]: %v\n", r.Rule.Action, target, r.Rule.Name, r.Error)
+		}
+	}
+	return nil
+}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage notification triage rules",
+	Long: `Triage rules act on notifications automatically after every fetch and
+while watching. Rules are configured in the rules section of the config file:
+
+  rules:
+    - name: releases
+      when: "repo:my-org/* AND type:Release"
+      then: archive
+    - name: old-ci
+      when: "reason:ci_activity"
+      older_than: 1d
+      then: read
+    - name: later
+      when: "repo:my-org/roadmap"
+      then: snooze
+      until: "tomorrow 9am"
+
+"when" is a filter expression as used by --filter, "then" is one of read,
+archive, unsubscribe, mute or snooze. Rules only act on unread
+notifications, and each notification is handled by the first rule that
+matches it. Every action is recorded in the audit log shown by
+"gh-notif rules log" and in the action history, so it can be undone.`,
+}
+
+var rulesListFormat string
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured rules",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(rulesListFormat); err != nil {
+			return err
+		}
+
+		engine, err := newRulesEngine()
+		if err != nil {
+			return err
+		}
+		var compiled []*rules.Rule
+		if engine != nil {
+			if compiled, err = engine.Rules(); err != nil {
+				return err
+			}
+		}
+
+		w := cmd.OutOrStdout()
+		if rulesListFormat == "json" {
+			type jsonRule struct {
+				Name      string     `json:"name"`
+				When      string     `json:"when"`
+				OlderThan string     `json:"older_than,omitempty"`
+				Then      string     `json:"then"`
+				Until     *time.Time `json:"until,omitempty"`
+			}
+			out := make([]jsonRule, 0, len(compiled))
+			for _, rule := range compiled {
+				jr := jsonRule{Name: rule.Name, When: rule.Expression, Then: rule.Action}
+				if rule.OlderThan > 0 {
+					jr.OlderThan = rule.OlderThan.String()
+				}
+				if !rule.Until.IsZero() {
+					until := rule.Until
+					jr.Until = &until
+				}
+				out = append(out, jr)
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(out)
+		}
+
+		if len(compiled) == 0 {
+			fmt.Fprintln(w, "No rules configured")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tTHEN\tWHEN\tOLDER THAN")
+		for _, rule := range compiled {
+			olderThan := ""
+			if rule.OlderThan > 0 {
+				olderThan = rule.OlderThan.String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rule.Name, rule.Action, rule.Expression, olderThan)
+		}
+		return tw.Flush()
+	},
+}
+
+var rulesRunFlags actionFlags
+
+var rulesRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Apply the rules to the current notifications",
+	Long: `Fetch unread notifications and apply the rules to them now. Use --dry-run
+to see what the rules would do without changing anything.`,
+	Example: `  gh-notif rules run --dry-run
+  gh-notif rules run --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := rulesRunFlags.validate(); err != nil {
+			return err
+		}
+
+		engine, err := newRulesEngine()
+		if err != nil {
+			return err
+		}
+		if engine == nil {
+			return fmt.Errorf("no rules configured")
+		}
+
+		ctx := commandContext(cmd)
+		client, err := githubclient.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+		notifications, err := client.GetUnreadNotifications(githubclient.NotificationOptions{All: true})
+		if err != nil {
+			return fmt.Errorf("failed to fetch notifications: %w", err)
+		}
+
+		var report *rules.Report
+		if rulesRunFlags.dryRun {
+			report, err = engine.DryRun(notifications)
+		} else {
+			report, err = engine.Apply(ctx, notifications)
+		}
+		if report == nil {
+			return err
+		}
+
+		if err := printRuleReport(cmd.OutOrStdout(), rulesRunFlags.format, report); err != nil {
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if failures := report.FailureCount(); failures > 0 {
+			err := fmt.Errorf("%d of %d rule actions failed", failures, len(report.Results))
+			if failures < len(report.Results) {
+				return withExitCode(exitPartial, err)
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+var (
+	rulesLogFormat string
+	rulesLogLimit  int
+)
+
+var rulesLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show what the rules did",
+	Long:  `Show the audit log of the actions performed by rules, most recent first.`,
+	Example: `  gh-notif rules log
+  gh-notif rules log --limit 100 --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(rulesLogFormat); err != nil {
+			return err
+		}
+		if rulesLogLimit < 0 {
+			return withExitCode(exitUsage, fmt.Errorf("--limit must not be negative"))
+		}
+
+		audit, err := rules.OpenAuditLog(filepath.Join(cacheDirectory(configManager.GetConfig()), rules.AuditFileName))
+		if err != nil {
+			return err
+		}
+		entries, err := audit.Entries(rulesLogLimit)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if rulesLogFormat == "json" {
+			if entries == nil {
+				entries = []rules.AuditEntry{}
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(entries)
+		}
+
+		if len(entries) == 0 {
+			fmt.Fprintln(w, "No rule actions recorded")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "WHEN\tRULE\tACTION\tNOTIFICATION\tREPOSITORY\tTITLE\tSTATUS")
+		for _, entry := range entries {
+			status := "ok"
+			if !entry.Success {
+				status = "failed: " + entry.Error
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				entry.Time.Local().Format("2006-01-02 15:04"),
+				entry.Rule, entry.Action, entry.NotificationID, entry.Repository, entry.Title, status)
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	rulesListCmd.Flags().StringVar(&rulesListFormat, "format", "text", "output format: text or json")
+	rulesRunFlags.register(rulesRunCmd)
+	rulesLogCmd.Flags().IntVarP(&rulesLogLimit, "limit", "l", 20, "maximum number of entries to show, 0 for all")
+	rulesLogCmd.Flags().StringVar(&rulesLogFormat, "format", "text", "output format: text or json")
+
+	rulesCmd.AddCommand(rulesListCmd, rulesRunCmd, rulesLogCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
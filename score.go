@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/SharanRP/gh-notif/internal/actions"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/google/go-github/v60/github"
+	"github.com/spf13/cobra"
+)
+
+// jsonScoreExplanation is the JSON representation of an explained score
+type jsonScoreExplanation struct {
+	ID             string                `json:"id"`
+	Repository     string                `json:"repository"`
+	Title          string                `json:"title"`
+	Score          int                   `json:"score"`
+	ScoreBreakdown []scoring.FactorScore `json:"score_breakdown"`
+}
+
+// printScoreExplanation prints how each factor contributed to a score
+func printScoreExplanation(w io.Writer, format string, n *github.Notification, score *scoring.NotificationScore) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(jsonScoreExplanation{
+			ID:             n.GetID(),
+			Repository:     n.GetRepository().GetFullName(),
+			Title:          n.GetSubject().GetTitle(),
+			Score:          score.Total,
+			ScoreBreakdown: score.Breakdown,
+		})
+	}
+
+	fmt.Fprintf(w, "%s (%s)\n", n.GetSubject().GetTitle(), n.GetRepository().GetFullName())
+	fmt.Fprintf(w, "Score: %d/100\n\n", score.Total)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FACTOR\tRAW\tWEIGHT\tPOINTS\tDETAIL")
+	for _, factor := range score.Breakdown {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%.1f\t%s\n", factor.Factor, factor.Raw, factor.Weight, factor.Contribution, factor.Detail)
+	}
+	return tw.Flush()
+}
+
+// jsonWeightChanges is the JSON representation of trained or reset weights
+type jsonWeightChanges struct {
+	Engaged   int                    `json:"engaged,omitempty"`
+	Dismissed int                    `json:"dismissed,omitempty"`
+	Changes   []scoring.WeightChange `json:"changes"`
+}
+
+// printWeightChanges prints how training or resetting changed the weights
+func printWeightChanges(w io.Writer, format string, learned *scoring.LearnedWeights, changes []scoring.WeightChange) error {
+	if format == "json" {
+		out := jsonWeightChanges{Changes: changes}
+		if learned != nil {
+			out.Engaged, out.Dismissed = learned.Engaged, learned.Dismissed
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	}
+
+	if learned != nil {
+		fmt.Fprintf(w, "Learned from %d read and %d dismissed notifications\n\n", learned.Engaged, learned.Dismissed)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FACTOR\tBEFORE\tAFTER\tCHANGE")
+	for _, change := range changes {
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%+.3f\n", change.Factor, change.Before, change.After, change.After-change.Before)
+	}
+	return tw.Flush()
+}
+
+var scoreCmd = &cobra.Command{
+	Use:   "score",
+	Short: "Explain and learn notification scores",
+	Long: `Explain how notifications are scored, and learn the weights of the factors
+from your actions.
+
+Each factor has a raw value between 0 and 1, which is multiplied by its
+weight. The points of all factors add up to the score, capped at 100.`,
+}
+
+var scoreExplainFormat string
+
+var scoreExplainCmd = &cobra.Command{
+	Use:   "explain <notification-id>",
+	Short: "Show the factors behind a notification's score",
+	Long: `Show the raw value, weight and contribution of each factor to the score of
+a notification. The details of its subject are fetched to score activity and
+involvement.`,
+	Example: `  gh-notif score explain 123456789
+  gh-notif score explain 123456789 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scoreExplainFormat != "text" && scoreExplainFormat != "json" {
+			return withExitCode(exitUsage, fmt.Errorf("unsupported format: %s (must be text or json)", scoreExplainFormat))
+		}
+
+		ctx := commandContext(cmd)
+		client, err := githubclient.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+
+		notification, _, err := client.GetThread(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get notification: %w", err)
+		}
+
+		notifications := []*github.Notification{notification}
+		fetchNotificationDetails(ctx, notifications)
+
+		scores, err := scoreNotifications(ctx, notifications)
+		if err != nil {
+			return err
+		}
+
+		return printScoreExplanation(cmd.OutOrStdout(), scoreExplainFormat, notification, scores[notification.GetID()])
+	},
+}
+
+var scoreTrainFormat string
+
+var scoreTrainCmd = &cobra.Command{
+	Use:   "train",
+	Short: "Learn score weights from the action history",
+	Long: `Learn the weights of the score factors from the action history.
+
+Notifications you read within a day of their update count as important;
+notifications you archive or unsubscribe from while unread count as
+unimportant. Undone actions and actions taken by rules are ignored, and muted
+repositories score lowest.
+The weights are learned with logistic regression starting from the defaults,
+scaled so scores stay between 0 and 100, and stored in the cache directory.
+
+Only actions recorded together with the state of their notification are
+learned from, so actions recorded by older versions are skipped.`,
+	Example: `  gh-notif score train
+  gh-notif score train --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scoreTrainFormat != "text" && scoreTrainFormat != "json" {
+			return withExitCode(exitUsage, fmt.Errorf("unsupported format: %s (must be text or json)", scoreTrainFormat))
+		}
+
+		data := actions.TrainingData(actions.GetActionHistory().Entries(0))
+		learned, err := scoring.Train(data, scoring.DefaultScoreFactors())
+		if err != nil {
+			return fmt.Errorf("failed to learn score weights: %w", err)
+		}
+
+		model := scoring.DefaultModel()
+		before := model.Factors()
+		if err := model.Set(learned); err != nil {
+			return err
+		}
+
+		return printWeightChanges(cmd.OutOrStdout(), scoreTrainFormat, learned, scoring.CompareWeights(before, model.Factors()))
+	},
+}
+
+var scoreResetFormat string
+
+var scoreResetCmd = &cobra.Command{
+	Use:     "reset",
+	Short:   "Forget the learned score weights",
+	Long:    `Forget the learned score weights and go back to the default weights.`,
+	Example: `  gh-notif score reset`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scoreResetFormat != "text" && scoreResetFormat != "json" {
+			return withExitCode(exitUsage, fmt.Errorf("unsupported format: %s (must be text or json)", scoreResetFormat))
+		}
+
+		model := scoring.DefaultModel()
+		before := model.Factors()
+		if err := model.Reset(); err != nil {
+			return err
+		}
+
+		return printWeightChanges(cmd.OutOrStdout(), scoreResetFormat, nil, scoring.CompareWeights(before, model.Factors()))
+	},
+}
+
+func init() {
+	scoreExplainCmd.Flags().StringVar(&scoreExplainFormat, "format", "text", "output format: text or json")
+	scoreTrainCmd.Flags().StringVar(&scoreTrainFormat, "format", "text", "output format: text or json")
+	scoreResetCmd.Flags().StringVar(&scoreResetFormat, "format", "text", "output format: text or json")
+
+	scoreCmd.AddCommand(scoreExplainCmd, scoreTrainCmd, scoreResetCmd)
+	rootCmd.AddCommand(scoreCmd)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/SharanRP/gh-notif/internal/search"
+	"github.com/google/go-github/v60/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchOpts          = &listOptions{}
+	searchRegex         bool
+	searchCaseSensitive bool
+	searchFields        []string
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search GitHub notifications",
+	Long: `Search notifications by title, repository, type and reason.
+
+Results are ordered by relevance. Use --regex to treat the query as a
+regular expression and --filter to narrow the notifications searched.`,
+	Example: `  gh-notif search "bug fix"
+  gh-notif search "bug fix" --filter "repo:owner/repo is:unread"
+  gh-notif search "fix.*bug" --regex --case-sensitive
+  gh-notif search release --format json --limit 5`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := commandContext(cmd)
+
+		formatter, err := newFormatter(searchOpts, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+
+		filterExpr, err := parseFilterExpression(searchOpts.filter)
+		if err != nil {
+			return err
+		}
+
+		searchOpts.scopeSet = scopeFlagsChanged(cmd)
+		notifications, err := fetchNotifications(ctx, searchOpts)
+		if err != nil {
+			return err
+		}
+
+		notifications, err = applyFilterExpression(ctx, filterExpr, notifications)
+		if err != nil {
+			return err
+		}
+
+		options := search.DefaultSearchOptions()
+		options.UseRegex = searchRegex
+		options.CaseSensitive = searchCaseSensitive
+		options.HighlightMatches = false
+		if len(searchFields) > 0 {
+			options.Fields = searchFields
+		}
+		if searchOpts.limit > 0 {
+			options.MaxResults = searchOpts.limit
+		}
+
+		results, err := search.NewSearcher(options).Search(ctx, notifications, args[0])
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+
+		matched := make([]*github.Notification, 0, len(results))
+		for _, result := range results {
+			matched = append(matched, result.Notification)
+		}
+		if searchOpts.limit > 0 && len(matched) > searchOpts.limit {
+			matched = matched[:searchOpts.limit]
+		}
+
+		return formatter.Format(matched)
+	},
+}
+
+func init() {
+	flags := searchCmd.Flags()
+	flags.BoolVar(&searchRegex, "regex", false, "treat the query as a regular expression")
+	flags.BoolVar(&searchCaseSensitive, "case-sensitive", false, "match case exactly")
+	flags.StringSliceVar(&searchFields, "fields", nil, "fields to search: title, repository, type, reason")
+	flags.BoolVarP(&searchOpts.all, "all", "a", false, "include read notifications")
+	flags.StringVarP(&searchOpts.repo, "repo", "r", "", "only search notifications for a repository (owner/repo)")
+	flags.StringVarP(&searchOpts.org, "org", "o", "", "only search notifications for an organization")
+	flags.StringVarP(&searchOpts.filter, "filter", "f", "", "filter expression or @preset")
+	flags.IntVarP(&searchOpts.limit, "limit", "l", 0, "maximum number of results to show (0 for no limit)")
+	flags.StringVar(&searchOpts.format, "format", "text", "output format: text, json, csv or template")
+	flags.StringVar(&searchOpts.template, "template", "", "Go template used with --format template")
+	flags.BoolVar(&searchOpts.noColor, "no-color", false, "disable color output")
+	flags.BoolVar(&searchOpts.noRules, "no-rules", false, "do not apply the configured triage rules")
+
+	rootCmd.AddCommand(searchCmd)
+}
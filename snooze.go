@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/actions"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+	"github.com/spf13/cobra"
+)
+
+// jsonSnooze is the JSON representation of a snoozed notification
+type jsonSnooze struct {
+	ID         string     `json:"id"`
+	Repository string     `json:"repository,omitempty"`
+	Title      string     `json:"title,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	SnoozedAt  time.Time  `json:"snoozed_at"`
+}
+
+// printSnoozes prints snoozed notifications as a table or JSON
+func printSnoozes(w io.Writer, format string, entries []snooze.Entry) error {
+	if format == "json" {
+		out := make([]jsonSnooze, 0, len(entries))
+		for _, entry := range entries {
+			js := jsonSnooze{
+				ID:         entry.ThreadID,
+				Repository: entry.Repository,
+				Title:      entry.Title,
+				SnoozedAt:  entry.SnoozedAt,
+			}
+			if !entry.Until.IsZero() {
+				until := entry.Until
+				js.Until = &until
+			}
+			out = append(out, js)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No snoozed notifications")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUNTIL\tREPOSITORY\tTITLE")
+	for _, entry := range entries {
+		until := snooze.NextActivity
+		if !entry.Until.IsZero() {
+			until = entry.Until.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.ThreadID, until, entry.Repository, entry.Title)
+	}
+	return tw.Flush()
+}
+
+var (
+	snoozeFlags actionFlags
+	snoozeUntil string
+)
+
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze [notification-id...]",
+	Short: "Hide notifications until later",
+	Long: `Hide notifications until a time or until their thread has new activity.
+
+Snoozed notifications are left out of list, search, the terminal UI and
+watch. A snooze ends when its time passes or the thread is updated, whichever
+comes first; watch then reports the notification as unsnoozed. Snoozing is
+local and does not change the notification on GitHub.
+
+--until accepts a duration (2h, 3d, 1w), "tomorrow 9am", "today 5pm", a
+weekday ("monday 10:30"), a time of day, a date (2024-06-01 18:00) or
+next-activity. Without notification IDs, the snoozed notifications are listed.`,
+	Example: `  gh-notif snooze 123456789 --until 2h
+  gh-notif snooze 123456789 --until "tomorrow 9am"
+  gh-notif snooze 123456789 987654321 --until next-activity
+  gh-notif snooze`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			if err := snoozeFlags.validate(); err != nil {
+				return err
+			}
+			return printSnoozes(cmd.OutOrStdout(), snoozeFlags.format, snooze.Default().Entries())
+		}
+
+		until, err := snooze.ParseUntil(snoozeUntil, time.Now())
+		if err != nil {
+			return withExitCode(exitUsage, err)
+		}
+
+		untilValue := snooze.NextActivity
+		if !until.IsZero() {
+			untilValue = until.Format(time.RFC3339)
+		}
+
+		return runBatchAction(cmd, &snoozeFlags, actions.Action{
+			Type:     actions.ActionSnooze,
+			Metadata: map[string]interface{}{"until": untilValue},
+		}, args, false,
+			func(ctx context.Context, ids []string, opts *actions.BatchOptions) (*actions.BatchResult, error) {
+				return actions.SnoozeMultipleNotifications(ctx, ids, until, opts)
+			})
+	},
+}
+
+var unsnoozeFlags actionFlags
+
+var unsnoozeCmd = &cobra.Command{
+	Use:     "unsnooze <notification-id...>",
+	Short:   "End the snooze of notifications",
+	Long:    `End the snooze of one or more notifications so they are shown again.`,
+	Example: `  gh-notif unsnooze 123456789`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatchAction(cmd, &unsnoozeFlags, actions.Action{Type: actions.ActionUnsnooze}, args, false, actions.UnsnoozeMultipleNotifications)
+	},
+}
+
+func init() {
+	snoozeCmd.Flags().StringVarP(&snoozeUntil, "until", "u", snooze.NextActivity, "when the snooze ends: a duration, tomorrow 9am, a weekday, a date or next-activity")
+	snoozeFlags.register(snoozeCmd)
+	unsnoozeFlags.register(unsnoozeCmd)
+
+	rootCmd.AddCommand(snoozeCmd, unsnoozeCmd)
+}
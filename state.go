@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/actions"
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/readstate"
+	"github.com/SharanRP/gh-notif/internal/scoring"
+	"github.com/SharanRP/gh-notif/internal/snooze"
+)
+
+// openLocalState loads the state kept in the cache directory of the active
+// profile: the action history journal, the read state overlay, the snoozed
+// notifications and the learned score weights
+func openLocalState(cfg *config.Config) error {
+	cacheDir := cacheDirectory(cfg)
+
+	var errs []error
+
+	history, err := actions.OpenActionHistory(filepath.Join(cacheDir, actions.HistoryFileName), actions.HistoryRetention{
+		MaxEntries: cfg.Advanced.HistoryMaxEntries,
+		MaxAge:     time.Duration(cfg.Advanced.HistoryMaxAge) * 24 * time.Hour,
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to open action history: %w", err))
+	} else {
+		actions.SetActionHistory(history)
+	}
+
+	overlay, err := readstate.Open(filepath.Join(cacheDir, readstate.FileName))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to open read state: %w", err))
+	} else {
+		readstate.SetDefault(overlay)
+	}
+
+	snoozes, err := snooze.Open(filepath.Join(cacheDir, snooze.FileName))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to open snoozed notifications: %w", err))
+	} else {
+		snooze.SetDefault(snoozes)
+	}
+
+	model, err := scoring.OpenModel(filepath.Join(cacheDir, scoring.ModelFileName))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to open learned score weights: %w", err))
+	} else {
+		scoring.SetDefaultModel(model)
+	}
+
+	return errors.Join(errs...)
+}
+
+// cacheDirectory returns the cache directory of the active profile
+func cacheDirectory(cfg *config.Config) string {
+	if cfg == nil || cfg.Advanced.CacheDir == "" {
+		return config.DefaultConfig().Advanced.CacheDir
+	}
+	return cfg.Advanced.CacheDir
+}
@@ -0,0 +1,762 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/SharanRP/gh-notif/internal/auth"
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/digest"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/google/go-github/v60/github"
+	"github.com/spf13/cobra"
+)
+
+// subscriptionsPath returns the path of the subscriptions file of the active profile
+func subscriptionsPath() string {
+	return filepath.Join(cacheDirectory(configManager.GetConfig()), subscriptions.FileName)
+}
+
+// openSubscriptionStorage opens the subscriptions file, which is encrypted
+// with the key that also encrypts the cache
+func openSubscriptionStorage() (*subscriptions.FileStorage, error) {
+	key, err := auth.CacheKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions key: %w", err)
+	}
+	return subscriptions.NewFileStorage(subscriptionsPath(), hex.EncodeToString(key[:]))
+}
+
+// loadDeclaredSubscriptions reads the declarative subscriptions file, if one
+// is configured, reporting its issues as warnings
+func loadDeclaredSubscriptions(w io.Writer) ([]subscriptions.RepositorySubscription, error) {
+	path := configManager.GetConfig().Advanced.DeclaredSubscriptionsFile()
+	if path == "" {
+		return nil, nil
+	}
+
+	declared, issues, err := subscriptions.NewManager(nil, nil).LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(w, "Warning: %s\n", issue)
+	}
+	return declared, nil
+}
+
+// listSubscriptions returns the declared subscriptions followed by the
+// stored ones for other repositories
+func listSubscriptions(w io.Writer) ([]subscriptions.RepositorySubscription, error) {
+	declared, err := loadDeclaredSubscriptions(w)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []subscriptions.RepositorySubscription
+	if _, err := os.Stat(subscriptionsPath()); err == nil {
+		storage, err := openSubscriptionStorage()
+		if err != nil {
+			return nil, err
+		}
+		if stored, err = storage.ListSubscriptions(); err != nil {
+			return nil, err
+		}
+	}
+	return subscriptions.Merge(declared, stored), nil
+}
+
+// newSubscriptionEvaluator compiles the active subscriptions, or returns nil
+// when there are none
+func newSubscriptionEvaluator() (*subscriptions.Evaluator, error) {
+	list, err := listSubscriptions(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := subscriptions.NewEvaluator(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(evaluator.Filters()) == 0 {
+		return nil, nil
+	}
+	return evaluator, nil
+}
+
+// applySubscriptions hides the notifications that the subscriptions of their
+// repositories do not match. Subscription problems are reported as warnings
+// so they never prevent listing notifications.
+func applySubscriptions(ctx context.Context, notifications []*github.Notification) []*github.Notification {
+	evaluator, err := newSubscriptionEvaluator()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: subscriptions not applied: %v\n", err)
+		return notifications
+	}
+	if evaluator == nil {
+		return notifications
+	}
+
+	if needing := evaluator.NeedingDetails(notifications); len(needing) > 0 {
+		fetchNotificationDetails(ctx, needing)
+	}
+	return evaluator.Filter(notifications)
+}
+
+// printSubscriptionExplanation prints why a subscription matches a notification or not
+func printSubscriptionExplanation(w io.Writer, format string, explanation *subscriptions.Explanation) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explanation)
+	}
+
+	fmt.Fprintf(w, "Notification %s %q (%s)\n", explanation.NotificationID, explanation.Title, explanation.Repository)
+	if explanation.Matched {
+		fmt.Fprintf(w, "Matches subscription %s\n\n", explanation.Subscription)
+	} else {
+		fmt.Fprintf(w, "Does not match subscription %s\n\n", explanation.Subscription)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, check := range explanation.Checks {
+		mark := "✓"
+		switch check.Outcome {
+		case subscriptions.OutcomeNoMatch:
+			mark = "✗"
+		case subscriptions.OutcomeUnknown:
+			mark = "?"
+		case subscriptions.OutcomeNotApplicable:
+			mark = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", mark, check.Condition, check.Reason)
+	}
+	return tw.Flush()
+}
+
+var subscriptionsCmd = &cobra.Command{
+	Use:   "subscriptions",
+	Short: "Manage repository subscriptions",
+	Long: `Repository subscriptions decide which notifications of a repository are
+shown by list and watch. A subscription for owner/repo or a pattern such as
+owner/* matches notifications by activity type and, from the details of their
+subjects, by the base branch, author and changed files of pull requests.
+
+Notifications of repositories without a subscription are always shown. A
+subscription for a repository takes precedence over patterns. Conditions
+that cannot be checked, for example because the details of a subject could
+not be fetched, do not hide a notification.
+
+Subscriptions with an hourly or daily frequency are not reported by watch as
+their notifications arrive; they are collected into digests, see
+"gh-notif digest".
+
+Use --no-subscriptions with list and watch to show every notification.
+
+"subscriptions plan" and "subscriptions apply" sync the repositories watched
+on GitHub with the subscriptions, and "subscriptions import-from-github"
+creates subscriptions for the repositories already watched.
+
+Subscriptions can also be declared in a plaintext YAML file set by
+advanced.subscriptions_file, which can be shared and reviewed. Declared
+subscriptions take precedence over stored ones for the same repository; see
+"subscriptions lint".`,
+}
+
+var subscriptionsListFormat string
+
+var subscriptionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List repository subscriptions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(subscriptionsListFormat); err != nil {
+			return err
+		}
+
+		list, err := listSubscriptions(cmd.ErrOrStderr())
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if subscriptionsListFormat == "json" {
+			if list == nil {
+				list = []subscriptions.RepositorySubscription{}
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(list)
+		}
+
+		if len(list) == 0 {
+			fmt.Fprintln(w, "No subscriptions")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "REPOSITORY\tPRIORITY\tFREQUENCY\tACTIVE\tSOURCE\tCONDITIONS")
+		for _, sub := range list {
+			var conditions string
+			if compiled, err := subscriptions.Compile(sub); err != nil {
+				conditions = "invalid: " + err.Error()
+			} else {
+				// The repository is already in the first column
+				var descriptions []string
+				for _, c := range compiled.Conditions[1:] {
+					descriptions = append(descriptions, c.Description())
+				}
+				conditions = strings.Join(descriptions, "; ")
+			}
+			source := "stored"
+			if file, ok := sub.Metadata["source"].(string); ok {
+				source = filepath.Base(file)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\t%s\n", sub.Repository, sub.Priority, sub.Config.Frequency, sub.Active, source, conditions)
+		}
+		return tw.Flush()
+	},
+}
+
+// subscriptionsAddOptions holds the flags of the subscriptions add command
+type subscriptionsAddOptions struct {
+	types          []string
+	priority       string
+	frequency      string
+	branches       []string
+	excludeBranch  []string
+	mainOnly       bool
+	authors        []string
+	excludeAuthors []string
+	includeBots    bool
+	paths          []string
+	excludePaths   []string
+	template       string
+	webhook        string
+}
+
+var subscriptionsAddOpts = &subscriptionsAddOptions{}
+
+var subscriptionsAddCmd = &cobra.Command{
+	Use:   "add <owner/repo|owner/*>",
+	Short: "Subscribe to a repository or all repositories of an owner",
+	Long: `Add or replace the subscription of a repository or owner/* pattern.
+
+Without flags the subscription matches pull requests, issues, discussions
+and releases on any branch by authors other than bots.`,
+	Example: `  gh-notif subscriptions add owner/repo
+  gh-notif subscriptions add owner/docs --type prs --main-only --path "docs/**"
+  gh-notif subscriptions add "my-org/*" --type releases --include-bots
+  gh-notif subscriptions add owner/repo --frequency daily --template @digest.tmpl
+  gh-notif subscriptions add my-org/api --type prs --webhook https://hooks.slack.com/services/T000/B000/XXXX`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := subscriptionsAddOpts
+		if opts.webhook != "" {
+			if err := config.ValidateWebhookURL(opts.webhook); err != nil {
+				return withExitCode(exitUsage, fmt.Errorf("invalid webhook: %w", err))
+			}
+		}
+		config := subscriptions.DefaultSubscriptionConfig()
+
+		if len(opts.types) > 0 {
+			config.ActivityTypes = nil
+			for _, t := range opts.types {
+				activity, err := parseActivityType(t)
+				if err != nil {
+					return withExitCode(exitUsage, err)
+				}
+				config.ActivityTypes = append(config.ActivityTypes, activity)
+			}
+		}
+		if opts.frequency != "" {
+			if subscriptions.ParseFrequency(opts.frequency).String() != opts.frequency {
+				return withExitCode(exitUsage, fmt.Errorf("invalid frequency %q (must be real-time, hourly or daily)", opts.frequency))
+			}
+			config.Frequency = subscriptions.ParseFrequency(opts.frequency)
+		}
+		if subscriptions.ParsePriority(opts.priority).String() != opts.priority {
+			return withExitCode(exitUsage, fmt.Errorf("invalid priority %q (must be low, normal or critical)", opts.priority))
+		}
+
+		if len(opts.branches) > 0 || opts.mainOnly {
+			config.BranchFilter.All = false
+			config.BranchFilter.MainOnly = opts.mainOnly
+			config.BranchFilter.Patterns = opts.branches
+		}
+		config.BranchFilter.ExcludePatterns = opts.excludeBranch
+
+		if len(opts.authors) > 0 {
+			config.AuthorFilter.All = false
+			config.AuthorFilter.Include = opts.authors
+		}
+		config.AuthorFilter.Exclude = opts.excludeAuthors
+		config.AuthorFilter.ExcludeBots = !opts.includeBots
+
+		if len(opts.paths) > 0 {
+			config.FileFilter.Include = opts.paths
+		}
+		config.FileFilter.Exclude = opts.excludePaths
+
+		if opts.template != "" {
+			text, err := readTemplateArg(opts.template)
+			if err != nil {
+				return withExitCode(exitUsage, err)
+			}
+			if _, err := digest.ParseTemplate(text); err != nil {
+				return withExitCode(exitUsage, err)
+			}
+			config.Template = text
+		}
+
+		config.WebhookURL = opts.webhook
+
+		ctx := commandContext(cmd)
+		client, err := subscriptions.NewGitHubClient(ctx)
+		if err != nil {
+			return err
+		}
+		storage, err := openSubscriptionStorage()
+		if err != nil {
+			return err
+		}
+
+		manager := subscriptions.NewManager(storage, client)
+		if err := manager.Subscribe(ctx, args[0], subscriptions.ParsePriority(opts.priority), config); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Subscribed to %s\n", args[0])
+		return nil
+	},
+}
+
+// parseActivityType parses an activity type, accepting "pulls" for pull requests
+func parseActivityType(s string) (subscriptions.ActivityType, error) {
+	if s == "pulls" {
+		return subscriptions.ActivityPRs, nil
+	}
+	for _, t := range subscriptions.AllActivityTypes() {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("invalid activity type %q", s)
+}
+
+// readTemplateArg returns a template given on the command line, reading it
+// from a file when it starts with @
+func readTemplateArg(arg string) (string, error) {
+	if !strings.HasPrefix(arg, "@") {
+		return arg, nil
+	}
+	data, err := os.ReadFile(strings.TrimPrefix(arg, "@"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+	return string(data), nil
+}
+
+var subscriptionsRemoveCmd = &cobra.Command{
+	Use:     "remove <owner/repo|owner/*>",
+	Aliases: []string{"rm"},
+	Short:   "Remove the subscription of a repository or pattern",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		storage, err := openSubscriptionStorage()
+		if err != nil {
+			return err
+		}
+		if _, err := storage.GetSubscription(args[0]); err != nil {
+			declared, _ := loadDeclaredSubscriptions(io.Discard)
+			for _, sub := range declared {
+				if strings.EqualFold(sub.Repository, args[0]) {
+					return fmt.Errorf("subscription %s is declared in %s, remove it there", args[0], sub.Metadata["source"])
+				}
+			}
+		}
+		if err := subscriptions.NewManager(storage, nil).Unsubscribe(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Removed subscription %s\n", args[0])
+		return nil
+	},
+}
+
+var subscriptionsTestFormat string
+
+var subscriptionsTestCmd = &cobra.Command{
+	Use:   "test <owner/repo|owner/*> <notification-id>",
+	Short: "Explain whether a subscription matches a notification",
+	Long: `Fetch a notification and the details of its subject, then check every
+condition of a subscription against it. The subscription is the one for the
+given repository or pattern, or else the one that covers the repository.
+
+Exits with status 1 when the subscription does not match.`,
+	Example: `  gh-notif subscriptions test owner/repo 123456789
+  gh-notif subscriptions test "my-org/*" 123456789 --format json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(subscriptionsTestFormat); err != nil {
+			return err
+		}
+
+		evaluator, err := newSubscriptionEvaluator()
+		if err != nil {
+			return err
+		}
+		var subscription *subscriptions.SubscriptionFilter
+		if evaluator != nil {
+			if subscription = evaluator.Find(args[0]); subscription == nil {
+				subscription = evaluator.Lookup(args[0])
+			}
+		}
+		if subscription == nil {
+			return fmt.Errorf("no active subscription for %s", args[0])
+		}
+
+		ctx := commandContext(cmd)
+		client, err := githubclient.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+		notification, _, err := client.GetThread(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to fetch notification %s: %w", args[1], err)
+		}
+		if subscription.NeedsDetails() {
+			if err := client.FetchNotificationDetails([]*github.Notification{notification}); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+			}
+		}
+
+		explanation := subscription.Explain(notification)
+		if err := printSubscriptionExplanation(cmd.OutOrStdout(), subscriptionsTestFormat, explanation); err != nil {
+			return err
+		}
+		if !explanation.Matched {
+			return fmt.Errorf("subscription %s does not match notification %s", explanation.Subscription, explanation.NotificationID)
+		}
+		return nil
+	},
+}
+
+// newSubscriptionSync opens the subscriptions and the GitHub client that
+// syncs them with the repositories watched on GitHub
+func newSubscriptionSync(ctx context.Context) (*subscriptions.Manager, *subscriptions.GitHubClientImpl, error) {
+	client, err := subscriptions.NewGitHubClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	storage, err := openSubscriptionStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+	declared, err := loadDeclaredSubscriptions(os.Stderr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manager := subscriptions.NewManager(storage, client)
+	manager.Declare(declared)
+	return manager, client, nil
+}
+
+// printSyncPlan prints the changes of a sync plan
+func printSyncPlan(w io.Writer, format string, plan *subscriptions.SyncPlan) error {
+	if format == "json" {
+		if plan.Changes == nil {
+			plan.Changes = []subscriptions.SyncChange{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plan)
+	}
+
+	if len(plan.Changes) == 0 {
+		fmt.Fprintf(w, "Watch settings match the subscriptions (%d watched repositories)\n", plan.Unchanged)
+		printIgnoredRepositories(w, plan.Ignored)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, change := range plan.Changes {
+		mark, reason := "-", "no active subscription"
+		if change.Action == subscriptions.SyncWatch {
+			mark, reason = "+", "subscription "+change.Subscription
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\n", mark, change.Action, change.Repository, reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d to watch, %d to unwatch, %d unchanged\n",
+		plan.Count(subscriptions.SyncWatch), plan.Count(subscriptions.SyncUnwatch), plan.Unchanged)
+	printIgnoredRepositories(w, plan.Ignored)
+	return nil
+}
+
+// printIgnoredRepositories prints the repositories a sync leaves alone
+// because they are ignored on GitHub
+func printIgnoredRepositories(w io.Writer, ignored []string) {
+	if len(ignored) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%d ignored on GitHub and left unchanged: %s\n", len(ignored), strings.Join(ignored, ", "))
+}
+
+// confirm asks a yes or no question on the command's input
+func confirm(cmd *cobra.Command, question string) (bool, error) {
+	fmt.Fprintf(cmd.ErrOrStderr(), "%s [y/N] ", question)
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && answer == "" {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+var (
+	subscriptionsSyncKeepUnmanaged bool
+	subscriptionsPlanFormat        string
+	subscriptionsApplyYes          bool
+)
+
+var subscriptionsPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show how the watch settings on GitHub differ from the subscriptions",
+	Long: `Compare the active subscriptions, with owner/* patterns expanded to the
+repositories of the owner, to the repositories watched on GitHub.
+
+Repositories a subscription covers are to be watched. Watched repositories
+that no active subscription covers, or whose own subscription is paused, are
+to be unwatched unless --keep-unmanaged is given. Repositories ignored on
+GitHub, e.g. with "gh-notif mute", are listed but never changed. Nothing is
+changed; use "subscriptions apply" to make the changes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(subscriptionsPlanFormat); err != nil {
+			return err
+		}
+
+		ctx := commandContext(cmd)
+		manager, client, err := newSubscriptionSync(ctx)
+		if err != nil {
+			return err
+		}
+		plan, err := manager.Plan(ctx, client, subscriptions.SyncOptions{KeepUnmanaged: subscriptionsSyncKeepUnmanaged})
+		if err != nil {
+			return err
+		}
+		return printSyncPlan(cmd.OutOrStdout(), subscriptionsPlanFormat, plan)
+	},
+}
+
+var subscriptionsApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Watch and unwatch repositories on GitHub to match the subscriptions",
+	Long: `Show the plan of "subscriptions plan" and, once confirmed, watch and
+unwatch repositories on GitHub accordingly. Changes that fail do not stop
+the others.`,
+	Example: `  gh-notif subscriptions apply
+  gh-notif subscriptions apply --keep-unmanaged --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := commandContext(cmd)
+		manager, client, err := newSubscriptionSync(ctx)
+		if err != nil {
+			return err
+		}
+		plan, err := manager.Plan(ctx, client, subscriptions.SyncOptions{KeepUnmanaged: subscriptionsSyncKeepUnmanaged})
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if err := printSyncPlan(w, "text", plan); err != nil {
+			return err
+		}
+		if len(plan.Changes) == 0 {
+			return nil
+		}
+
+		if !subscriptionsApplyYes {
+			ok, err := confirm(cmd, fmt.Sprintf("Apply %d changes on GitHub?", len(plan.Changes)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(w, "No changes applied")
+				return nil
+			}
+		}
+
+		applied, err := manager.Apply(ctx, client, plan)
+		for _, change := range plan.Changes {
+			if change.Error != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to %s %s: %s\n", change.Action, change.Repository, change.Error)
+			}
+		}
+		fmt.Fprintf(w, "✓ Applied %d of %d changes\n", applied, len(plan.Changes))
+		if err != nil {
+			err = fmt.Errorf("%d of %d changes failed", len(plan.Changes)-applied, len(plan.Changes))
+			if applied > 0 {
+				return withExitCode(exitPartial, err)
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+var (
+	subscriptionsImportPriority string
+	subscriptionsImportDryRun   bool
+)
+
+var subscriptionsImportCmd = &cobra.Command{
+	Use:   "import-from-github",
+	Short: "Subscribe to the repositories watched on GitHub",
+	Long: `Add a subscription with the default settings for every repository watched
+on GitHub that no subscription covers yet. Existing subscriptions are kept.`,
+	Example: `  gh-notif subscriptions import-from-github --dry-run
+  gh-notif subscriptions import-from-github --priority low`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if subscriptions.ParsePriority(subscriptionsImportPriority).String() != subscriptionsImportPriority {
+			return withExitCode(exitUsage, fmt.Errorf("invalid priority %q (must be low, normal or critical)", subscriptionsImportPriority))
+		}
+
+		ctx := commandContext(cmd)
+		manager, client, err := newSubscriptionSync(ctx)
+		if err != nil {
+			return err
+		}
+		imported, err := manager.ImportWatched(ctx, client, subscriptions.ParsePriority(subscriptionsImportPriority),
+			subscriptions.DefaultSubscriptionConfig(), subscriptionsImportDryRun)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		for _, repository := range imported {
+			fmt.Fprintln(w, repository)
+		}
+		if subscriptionsImportDryRun {
+			fmt.Fprintf(w, "Would subscribe to %d watched repositories\n", len(imported))
+			return nil
+		}
+		fmt.Fprintf(w, "✓ Subscribed to %d watched repositories\n", len(imported))
+		return nil
+	},
+}
+
+var subscriptionsLintFormat string
+
+var subscriptionsLintCmd = &cobra.Command{
+	Use:   "lint [file]",
+	Short: "Check a declarative subscriptions file",
+	Long: `Check a declarative subscriptions file and the files it includes: unknown
+keys and settings, unknown templates, invalid repositories and patterns, and
+subscriptions that would be rejected by "subscriptions add".
+
+Without an argument the file configured by advanced.subscriptions_file is
+checked. Exits with status 1 when there are problems.`,
+	Example: `  gh-notif subscriptions lint
+  gh-notif subscriptions lint team/subscriptions.yaml --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(subscriptionsLintFormat); err != nil {
+			return err
+		}
+
+		path := configManager.GetConfig().Advanced.DeclaredSubscriptionsFile()
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			return withExitCode(exitUsage, fmt.Errorf("no subscriptions file given and advanced.subscriptions_file is not set"))
+		}
+
+		declared, issues, err := subscriptions.NewManager(nil, nil).LoadFile(path)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		if subscriptionsLintFormat == "json" {
+			if issues == nil {
+				issues = []subscriptions.Issue{}
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(issues); err != nil {
+				return err
+			}
+		} else if len(issues) == 0 {
+			fmt.Fprintf(w, "✓ %d subscriptions in %s are valid\n", len(declared), path)
+		} else {
+			for _, issue := range issues {
+				fmt.Fprintln(w, issue)
+			}
+		}
+
+		if len(issues) > 0 {
+			return fmt.Errorf("%d problems in %s", len(issues), path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	subscriptionsListCmd.Flags().StringVar(&subscriptionsListFormat, "format", "text", "output format: text or json")
+
+	flags := subscriptionsAddCmd.Flags()
+	flags.StringSliceVar(&subscriptionsAddOpts.types, "type", nil, "activity types: prs, issues, discussions, releases, commits, security, branches or wiki")
+	flags.StringVar(&subscriptionsAddOpts.priority, "priority", "normal", "priority: low, normal or critical")
+	flags.StringVar(&subscriptionsAddOpts.frequency, "frequency", "", "notification frequency: real-time, hourly or daily")
+	flags.StringSliceVar(&subscriptionsAddOpts.branches, "branch", nil, "only pull requests into branches matching these patterns")
+	flags.StringSliceVar(&subscriptionsAddOpts.excludeBranch, "exclude-branch", nil, "hide pull requests into branches matching these patterns")
+	flags.BoolVar(&subscriptionsAddOpts.mainOnly, "main-only", false, "only pull requests into the default branch")
+	flags.StringSliceVar(&subscriptionsAddOpts.authors, "author", nil, "only subjects by these authors")
+	flags.StringSliceVar(&subscriptionsAddOpts.excludeAuthors, "exclude-author", nil, "hide subjects by these authors")
+	flags.BoolVar(&subscriptionsAddOpts.includeBots, "include-bots", false, "also show subjects by bots")
+	flags.StringSliceVar(&subscriptionsAddOpts.paths, "path", nil, "only pull requests and commits changing files matching these patterns, e.g. docs/**")
+	flags.StringSliceVar(&subscriptionsAddOpts.excludePaths, "exclude-path", nil, "ignore changed files matching these patterns")
+	flags.StringVar(&subscriptionsAddOpts.template, "template", "", "template of hourly and daily digests, or @file to read it from a file")
+	flags.StringVar(&subscriptionsAddOpts.webhook, "webhook", "", "webhook URL watch posts the matching notifications to")
+
+	subscriptionsTestCmd.Flags().StringVar(&subscriptionsTestFormat, "format", "text", "output format: text or json")
+
+	subscriptionsPlanCmd.Flags().StringVar(&subscriptionsPlanFormat, "format", "text", "output format: text or json")
+	for _, c := range []*cobra.Command{subscriptionsPlanCmd, subscriptionsApplyCmd} {
+		c.Flags().BoolVar(&subscriptionsSyncKeepUnmanaged, "keep-unmanaged", false, "keep watching repositories without a subscription")
+	}
+	subscriptionsApplyCmd.Flags().BoolVarP(&subscriptionsApplyYes, "yes", "y", false, "apply the changes without asking")
+
+	subscriptionsLintCmd.Flags().StringVar(&subscriptionsLintFormat, "format", "text", "output format: text or json")
+
+	subscriptionsImportCmd.Flags().StringVar(&subscriptionsImportPriority, "priority", "normal", "priority of the new subscriptions: low, normal or critical")
+	subscriptionsImportCmd.Flags().BoolVar(&subscriptionsImportDryRun, "dry-run", false, "list the repositories without subscribing to them")
+
+	subscriptionsCmd.AddCommand(subscriptionsListCmd, subscriptionsAddCmd, subscriptionsRemoveCmd, subscriptionsTestCmd,
+		subscriptionsPlanCmd, subscriptionsApplyCmd, subscriptionsImportCmd, subscriptionsLintCmd)
+	rootCmd.AddCommand(subscriptionsCmd)
+}
@@ -448,9 +448,15 @@ func testPathTraversal(t *testing.T, binaryPath string) {
 		"C:\\Windows\\System32\\config\\SAM",
 	}
 
+	// Run from deep enough in a temporary directory that relative attempts
+	// stay inside it and cannot leave files in the source tree
+	workDir := filepath.Join(t.TempDir(), "a", "b", "c")
+	require.NoError(t, os.MkdirAll(workDir, 0700))
+
 	for _, attempt := range traversalAttempts {
 		// Test in config file parameter
 		cmd := exec.CommandContext(ctx, binaryPath, "config", "list", "--config", attempt)
+		cmd.Dir = workDir
 		output, err := cmd.CombinedOutput()
 
 		// Should fail safely
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/digest"
+	githubclient "github.com/SharanRP/gh-notif/internal/github"
+	"github.com/SharanRP/gh-notif/internal/rules"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/SharanRP/gh-notif/internal/watch"
+	"github.com/SharanRP/gh-notif/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchFilter      string
+	watchInterval    time.Duration
+	watchMaxInterval time.Duration
+	watchDesktop     bool
+	watchNotifier    string
+	watchNotifyCmd   string
+	watchNotifyArgs  []string
+	watchCoalesce    time.Duration
+	watchFormat      string
+	watchNoRules     bool
+	watchNoSubs      bool
+	watchNoWebhooks  bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch for new GitHub notifications",
+	Long: `Watch for notification changes and print an event for every new, updated
+or read notification until interrupted.
+
+The refresh interval backs off while nothing changes and resets as soon as
+new activity arrives.
+
+Events are also posted to the configured webhooks and to the webhooks of
+the subscriptions matching their notifications, see "gh-notif webhooks".`,
+	Example: `  gh-notif watch
+  gh-notif watch --filter "repo:owner/repo is:unread"
+  gh-notif watch --interval 1m --desktop-notification
+  gh-notif watch --desktop-notification --notifier command \
+    --notify-command notify-send --notify-arg "{{.Title}}" --notify-arg "{{.Body}}"
+  gh-notif watch --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if watchFormat != "text" && watchFormat != "json" {
+			return fmt.Errorf("unsupported format: %s (must be text or json)", watchFormat)
+		}
+		if watchInterval <= 0 {
+			return withExitCode(exitUsage, fmt.Errorf("--interval must be positive"))
+		}
+
+		ctx, stop := signal.NotifyContext(commandContext(cmd), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		filterExpr, err := parseFilterExpression(watchFilter)
+		if err != nil {
+			return err
+		}
+
+		var rulesEngine *rules.Engine
+		if !watchNoRules {
+			if rulesEngine, err = newRulesEngine(); err != nil {
+				return err
+			}
+		}
+
+		var evaluator *subscriptions.Evaluator
+		if !watchNoSubs {
+			if evaluator, err = newSubscriptionEvaluator(); err != nil {
+				return err
+			}
+		}
+
+		w := cmd.OutOrStdout()
+		var mu sync.Mutex
+
+		var scheduler *digest.Scheduler
+		if hasDigestSubscriptions(evaluator) {
+			var stdout digest.Deliverer = digest.NewWriterDelivery(w)
+			if watchFormat == "json" {
+				stdout = digest.DeliverFunc(func(ctx context.Context, d *digest.Digest) error {
+					mu.Lock()
+					defer mu.Unlock()
+					return printWatchDigest(w, d)
+				})
+			}
+			if scheduler, err = newDigestScheduler(evaluator, stdout); err != nil {
+				return err
+			}
+		}
+
+		var router *webhook.Router
+		if !watchNoWebhooks {
+			if router, err = newWebhookRouter(evaluator); err != nil {
+				return err
+			}
+		}
+		if router != nil {
+			router.Dispatcher.ErrorCallback = func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error posting to webhook: %v\n", err)
+			}
+			defer router.Dispatcher.Close()
+		}
+
+		client, err := githubclient.NewClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create GitHub client: %w", err)
+		}
+
+		options := watch.DefaultWatchOptions()
+		options.RefreshInterval = watchInterval
+		if watchMaxInterval > watchInterval {
+			options.MaxRefreshInterval = watchMaxInterval
+		} else {
+			options.MaxRefreshInterval = watchInterval
+		}
+		options.Filter = filterExpr
+		options.Rules = rulesEngine
+		options.Subscriptions = evaluator
+		options.Digests = scheduler
+		options.ShowDesktopNotifications = watchDesktop
+		options.NotifierBackend = watchNotifier
+		options.CoalesceWindow = watchCoalesce
+		if watchNotifyCmd != "" {
+			options.DesktopNotificationCommand = watchNotifyCmd
+			options.DesktopNotificationArgs = watchNotifyArgs
+		}
+		options.EventCallback = func(event watch.NotificationEvent) {
+			if router != nil {
+				router.Route(ctx, string(event.Type), event.Notification, event.Timestamp)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			printWatchEvent(w, watchFormat, event)
+		}
+		options.ErrorCallback = func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(cmd.ErrOrStderr(), "Error refreshing notifications: %v\n", err)
+		}
+
+		watcher := watch.NewWatcher(client, options)
+		if err := watcher.Start(); err != nil {
+			return fmt.Errorf("failed to start watcher: %w", err)
+		}
+		defer watcher.Stop()
+
+		if watchFormat == "text" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Watching notifications every %s. Press Ctrl+C to stop.\n", watchInterval)
+		}
+
+		<-ctx.Done()
+		return nil
+	},
+}
+
+// jsonWatchEvent is the JSON representation of a watch event
+type jsonWatchEvent struct {
+	Type       string    `json:"type"`
+	ID         string    `json:"id"`
+	Repository string    `json:"repository"`
+	Title      string    `json:"title"`
+	Kind       string    `json:"kind"`
+	Reason     string    `json:"reason"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// printWatchEvent prints a single watch event
+func printWatchEvent(w io.Writer, format string, event watch.NotificationEvent) {
+	n := event.Notification
+	if format == "json" {
+		json.NewEncoder(w).Encode(jsonWatchEvent{
+			Type:       string(event.Type),
+			ID:         n.GetID(),
+			Repository: n.GetRepository().GetFullName(),
+			Title:      n.GetSubject().GetTitle(),
+			Kind:       n.GetSubject().GetType(),
+			Reason:     n.GetReason(),
+			Timestamp:  event.Timestamp,
+		})
+		return
+	}
+
+	fmt.Fprintf(w, "[%s] %-7s %s %s: %s\n",
+		event.Timestamp.Format("15:04:05"), event.Type, n.GetID(),
+		n.GetRepository().GetFullName(), n.GetSubject().GetTitle())
+}
+
+// jsonWatchDigest is the JSON representation of a digest delivered by watch
+type jsonWatchDigest struct {
+	Type         string    `json:"type"`
+	Subscription string    `json:"subscription"`
+	Frequency    string    `json:"frequency"`
+	Count        int       `json:"count"`
+	Subject      string    `json:"subject"`
+	Body         string    `json:"body"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// printWatchDigest prints a digest delivered to stdout in the JSON event stream
+func printWatchDigest(w io.Writer, d *digest.Digest) error {
+	return json.NewEncoder(w).Encode(jsonWatchDigest{
+		Type:         "digest",
+		Subscription: d.Subscription,
+		Frequency:    d.Frequency.String(),
+		Count:        d.Count,
+		Subject:      d.Subject,
+		Body:         d.Body,
+		Timestamp:    d.Until,
+	})
+}
+
+func init() {
+	flags := watchCmd.Flags()
+	flags.StringVarP(&watchFilter, "filter", "f", "", "filter expression or @preset")
+	flags.DurationVarP(&watchInterval, "interval", "i", 30*time.Second, "refresh interval")
+	flags.DurationVar(&watchMaxInterval, "max-interval", 5*time.Minute, "maximum refresh interval while idle")
+	flags.BoolVar(&watchDesktop, "desktop-notification", false, "show desktop notifications for new activity")
+	flags.StringVar(&watchNotifier, "notifier", "auto", "desktop notification backend: auto, dbus or command")
+	flags.StringVar(&watchNotifyCmd, "notify-command", "", "command used by the command notification backend")
+	flags.StringArrayVar(&watchNotifyArgs, "notify-arg", nil, "templated argument for --notify-command, e.g. \"{{.Title}}\" (repeatable)")
+	flags.DurationVar(&watchCoalesce, "coalesce", 2*time.Second, "merge desktop notifications arriving within this window (0 to disable)")
+	flags.StringVar(&watchFormat, "format", "text", "output format: text or json")
+	flags.BoolVar(&watchNoRules, "no-rules", false, "do not apply the configured triage rules")
+	flags.BoolVar(&watchNoSubs, "no-subscriptions", false, "show notifications the repository subscriptions do not match")
+	flags.BoolVar(&watchNoWebhooks, "no-webhooks", false, "do not post events to webhooks")
+
+	rootCmd.AddCommand(watchCmd)
+}
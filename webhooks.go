@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SharanRP/gh-notif/internal/config"
+	"github.com/SharanRP/gh-notif/internal/subscriptions"
+	"github.com/SharanRP/gh-notif/internal/webhook"
+	"github.com/google/go-github/v60/github"
+	"github.com/spf13/cobra"
+)
+
+// openDeadLetterQueue opens the failed webhook deliveries of the active profile
+func openDeadLetterQueue() (*webhook.DeadLetterQueue, error) {
+	return webhook.OpenDeadLetterQueue(filepath.Join(cacheDirectory(configManager.GetConfig()), webhook.DeadLetterFileName))
+}
+
+// webhookEndpoint returns the endpoint of a configured webhook
+func webhookEndpoint(w config.WebhookConfig) webhook.Endpoint {
+	format := webhook.Format(w.Format)
+	if format == "" {
+		format = webhook.DetectFormat(w.URL)
+	}
+	return webhook.Endpoint{Name: w.DisplayName(), URL: w.URL, Format: format, Secret: w.SigningSecret()}
+}
+
+// configuredWebhooks returns the webhooks of the active profile
+func configuredWebhooks() []config.WebhookConfig {
+	if configManager == nil || configManager.GetConfig() == nil {
+		return nil
+	}
+	return configManager.GetConfig().Webhooks
+}
+
+// newWebhookRouter creates the router posting watch events to the
+// configured webhooks and the webhooks of subscriptions, or returns nil when
+// there are none
+func newWebhookRouter(evaluator *subscriptions.Evaluator) (*webhook.Router, error) {
+	router := &webhook.Router{Subscriptions: evaluator, DefaultSecret: os.Getenv(config.WebhookSecretEnv)}
+	for _, w := range configuredWebhooks() {
+		filterExpr, err := parseFilterExpression(w.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", w.DisplayName(), err)
+		}
+		router.Routes = append(router.Routes, webhook.Route{Endpoint: webhookEndpoint(w), Events: w.Events, Filter: filterExpr})
+	}
+
+	subscribed := false
+	if evaluator != nil {
+		for _, f := range evaluator.Filters() {
+			if f.Subscription.Config.WebhookURL != "" {
+				subscribed = true
+				break
+			}
+		}
+	}
+	if len(router.Routes) == 0 && !subscribed {
+		return nil, nil
+	}
+
+	deadLetters, err := openDeadLetterQueue()
+	if err != nil {
+		return nil, err
+	}
+	router.Dispatcher = webhook.NewDispatcher(deadLetters)
+	return router, nil
+}
+
+// replaySecret returns the secret signing a replayed delivery: the secret of
+// the configured webhook it was posted to, or the default secret
+func replaySecret(delivery webhook.FailedDelivery) string {
+	for _, w := range configuredWebhooks() {
+		if w.URL == delivery.URL {
+			return w.SigningSecret()
+		}
+	}
+	return os.Getenv(config.WebhookSecretEnv)
+}
+
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Inspect and replay outbound webhook deliveries",
+	Long: `watch posts notification events to the webhooks configured in the
+webhooks section of the configuration, and the notifications a subscription
+matches to the subscription's webhook_url. Payloads are formatted for Slack,
+Discord, Microsoft Teams or generic receivers, detected from the URL unless
+a webhook sets its format.
+
+Every request carries the event type in X-GH-Notif-Event, a delivery ID in
+X-GH-Notif-Delivery and, when the webhook has a secret, the hex HMAC-SHA256
+of the body in X-GH-Notif-Signature-256 as "sha256=<signature>". Webhooks
+without a secret use GH_NOTIF_WEBHOOK_SECRET.
+
+Failed attempts are retried with exponential backoff. Deliveries that still
+fail are kept so they can be listed and replayed.`,
+}
+
+var webhooksFailedFormat string
+
+var webhooksFailedCmd = &cobra.Command{
+	Use:   "failed",
+	Short: "List the webhook deliveries that failed",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateHistoryFormat(webhooksFailedFormat); err != nil {
+			return err
+		}
+
+		deadLetters, err := openDeadLetterQueue()
+		if err != nil {
+			return err
+		}
+		deliveries := deadLetters.Deliveries()
+
+		w := cmd.OutOrStdout()
+		if webhooksFailedFormat == "json" {
+			if deliveries == nil {
+				deliveries = []webhook.FailedDelivery{}
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(deliveries)
+		}
+
+		if len(deliveries) == 0 {
+			fmt.Fprintln(w, "No failed webhook deliveries")
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tWEBHOOK\tEVENT\tNOTIFICATION\tATTEMPTS\tFAILED\tERROR")
+		for _, d := range deliveries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s %s\t%d\t%s\t%s\n", d.ID, d.Webhook, d.Event.Type,
+				d.Event.Notification.Repository, d.Event.Notification.Title, d.Attempts,
+				d.FailedAt.Local().Format("2006-01-02 15:04"), d.Error)
+		}
+		return tw.Flush()
+	},
+}
+
+var webhooksReplayCmd = &cobra.Command{
+	Use:   "replay [delivery-id...]",
+	Short: "Deliver failed webhook deliveries again",
+	Long: `Deliver the given failed deliveries, or all of them, again with their
+original delivery IDs. Delivered ones are removed from the failed
+deliveries; the others stay with their new error.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deadLetters, err := openDeadLetterQueue()
+		if err != nil {
+			return err
+		}
+
+		var deliveries []webhook.FailedDelivery
+		if len(args) == 0 {
+			deliveries = deadLetters.Deliveries()
+		} else {
+			for _, id := range args {
+				found := false
+				for _, d := range deadLetters.Deliveries() {
+					if d.ID == id {
+						deliveries = append(deliveries, d)
+						found = true
+						break
+					}
+				}
+				if !found {
+					return withExitCode(exitUsage, fmt.Errorf("no failed delivery %s", id))
+				}
+			}
+		}
+		if len(deliveries) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No failed webhook deliveries")
+			return nil
+		}
+
+		ctx := commandContext(cmd)
+		dispatcher := webhook.NewDispatcher(deadLetters)
+		replayed := 0
+		for _, d := range deliveries {
+			if err := dispatcher.Replay(ctx, d, replaySecret(d)); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: delivery %s failed again: %v\n", d.ID, err)
+				continue
+			}
+			replayed++
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Replayed %d of %d deliveries\n", replayed, len(deliveries))
+		if replayed < len(deliveries) {
+			if replayed == 0 {
+				return fmt.Errorf("no delivery could be replayed")
+			}
+			return withExitCode(exitPartial, fmt.Errorf("%d deliveries failed again", len(deliveries)-replayed))
+		}
+		return nil
+	},
+}
+
+var webhooksDropCmd = &cobra.Command{
+	Use:   "drop <delivery-id...>",
+	Short: "Discard failed webhook deliveries",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deadLetters, err := openDeadLetterQueue()
+		if err != nil {
+			return err
+		}
+		for _, id := range args {
+			removed, err := deadLetters.Remove(id)
+			if err != nil {
+				return err
+			}
+			if !removed {
+				return withExitCode(exitUsage, fmt.Errorf("no failed delivery %s", id))
+			}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Dropped %d deliveries\n", len(args))
+		return nil
+	},
+}
+
+var webhooksTestCmd = &cobra.Command{
+	Use:   "test <name|url>",
+	Short: "Post a test event to a webhook",
+	Long: `Post a test event about a made-up notification to a configured webhook,
+or to any URL in the format detected from it. A test that fails is kept with
+the failed deliveries.`,
+	Example: `  gh-notif webhooks test reviews
+  gh-notif webhooks test https://hooks.slack.com/services/T000/B000/XXXX`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var endpoint webhook.Endpoint
+		if w, ok := configManager.GetConfig().Webhook(args[0]); ok {
+			endpoint = webhookEndpoint(w)
+		} else {
+			if err := config.ValidateWebhookURL(args[0]); err != nil {
+				return withExitCode(exitUsage, fmt.Errorf("no webhook named %s and %w", args[0], err))
+			}
+			endpoint = webhookEndpoint(config.WebhookConfig{URL: args[0]})
+		}
+
+		deadLetters, err := openDeadLetterQueue()
+		if err != nil {
+			return err
+		}
+
+		n := &github.Notification{
+			ID:     github.String("0"),
+			Reason: github.String("review_requested"),
+			Subject: &github.NotificationSubject{
+				Type:  github.String("PullRequest"),
+				Title: github.String("Test notification from gh-notif"),
+			},
+			Repository: &github.Repository{
+				FullName: github.String("octocat/hello-world"),
+				HTMLURL:  github.String("https://github.com/octocat/hello-world"),
+			},
+			UpdatedAt: &github.Timestamp{Time: time.Now()},
+		}
+		event := webhook.NewEvent("test", n, "", time.Now())
+		if err := webhook.NewDispatcher(deadLetters).Send(commandContext(cmd), endpoint, event); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Posted a test event to %s (%s)\n", endpoint.Name, endpoint.Format)
+		return nil
+	},
+}
+
+func init() {
+	webhooksFailedCmd.Flags().StringVar(&webhooksFailedFormat, "format", "text", "output format: text or json")
+
+	webhooksCmd.AddCommand(webhooksFailedCmd, webhooksReplayCmd, webhooksDropCmd, webhooksTestCmd)
+	rootCmd.AddCommand(webhooksCmd)
+}